@@ -135,3 +135,37 @@ func (path DerivationPath) String() string {
 	}
 	return result
 }
+
+// Wanchain accounts hold two secp256k1 keys: the account key, whose address
+// identifies the account, and the view key, which together with the public
+// account key forms the WAddress used for privacy transactions. The bn256 key
+// used by PoS validators is not derived separately, it is computed from the
+// view key (see posconfig.GenerateD3byKey2).
+//
+// Both secp256k1 keys are derived from the same BIP-44 path, the account key
+// on the external chain and the view key on a dedicated chain:
+//
+//   account key  m / 44' / 5718350' / account' / 0 / address_index
+//   view key     m / 44' / 5718350' / account' / 1 / address_index
+//
+// This keeps the account key (and thus the address) compatible with plain
+// BIP-44 wallets, while the full key triple can be recovered from one seed.
+const (
+	AccountKeyChain = 0 // BIP-44 change component of the account key
+	ViewKeyChain    = 1 // BIP-44 change component of the view key
+)
+
+// WanKeyPaths returns the derivation paths of the account key and of the view
+// key of the Wanchain account at the given BIP-44 path.
+func WanKeyPaths(path DerivationPath) (DerivationPath, DerivationPath, error) {
+	if len(path) != 5 {
+		return nil, nil, fmt.Errorf("derivation path %v is not a BIP-44 path", path)
+	}
+	if path[3] != AccountKeyChain {
+		return nil, nil, fmt.Errorf("derivation path %v is not on the account key chain", path)
+	}
+	viewPath := make(DerivationPath, len(path))
+	copy(viewPath, path)
+	viewPath[3] = ViewKeyChain
+	return path, viewPath, nil
+}
@@ -77,3 +77,23 @@ func TestHDPathParsing(t *testing.T) {
 		}
 	}
 }
+
+// Tests that the view key path of a Wanchain account is derived correctly.
+func TestWanKeyPaths(t *testing.T) {
+	account, view, err := WanKeyPaths(DefaultBaseDerivationPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if account.String() != "m/44'/5718350'/0'/0/0" || view.String() != "m/44'/5718350'/0'/1/0" {
+		t.Errorf("key paths mismatch: have %v and %v", account, view)
+	}
+	if DefaultBaseDerivationPath[3] != AccountKeyChain {
+		t.Errorf("base derivation path modified: %v", DefaultBaseDerivationPath)
+	}
+	for _, path := range []string{"m/44'/5718350'/0'/0", "m/44'/5718350'/0'/1/0"} {
+		parsed, _ := ParseDerivationPath(path)
+		if _, _, err := WanKeyPaths(parsed); err == nil {
+			t.Errorf("%s: no error for invalid path", path)
+		}
+	}
+}
@@ -0,0 +1,145 @@
+// Copyright 2018 Wanchain Foundation Ltd
+// This file is part of the go-wanchain library.
+//
+// The go-wanchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-wanchain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-wanchain library. If not, see <http://www.gnu.org/licenses/>.
+
+package keystore
+
+import (
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/binary"
+	"errors"
+	"math/big"
+	"strings"
+
+	"github.com/tyler-smith/go-bip39"
+	"github.com/wanchain/go-wanchain/accounts"
+	"github.com/wanchain/go-wanchain/common/math"
+	"github.com/wanchain/go-wanchain/crypto"
+)
+
+// mnemonicEntropyBits is the entropy of generated mnemonics, giving 24 words.
+const mnemonicEntropyBits = 256
+
+var (
+	ErrInvalidMnemonic = errors.New("invalid mnemonic")
+	errInvalidHDKey    = errors.New("derived key is invalid, try the next index")
+)
+
+// hdKey is an extended private key as defined by BIP-32.
+type hdKey struct {
+	key       *big.Int
+	chainCode []byte
+}
+
+// NewMnemonic generates a random BIP-39 mnemonic.
+func NewMnemonic() (string, error) {
+	entropy, err := bip39.NewEntropy(mnemonicEntropyBits)
+	if err != nil {
+		return "", err
+	}
+	return bip39.NewMnemonic(entropy)
+}
+
+// DeriveKeysFromMnemonic derives the account key and the view key of the
+// Wanchain account at the given BIP-44 path from a BIP-39 mnemonic and its
+// optional passphrase, following the scheme of accounts.WanKeyPaths.
+func DeriveKeysFromMnemonic(mnemonic, passphrase string, path accounts.DerivationPath) (*ecdsa.PrivateKey, *ecdsa.PrivateKey, error) {
+	mnemonic = strings.Join(strings.Fields(mnemonic), " ")
+	if !bip39.IsMnemonicValid(mnemonic) {
+		return nil, nil, ErrInvalidMnemonic
+	}
+	seed := bip39.NewSeed(mnemonic, passphrase)
+	return DeriveKeysFromSeed(seed, path)
+}
+
+// DeriveKeysFromSeed derives the account key and the view key of the Wanchain
+// account at the given BIP-44 path from a BIP-32 seed.
+func DeriveKeysFromSeed(seed []byte, path accounts.DerivationPath) (*ecdsa.PrivateKey, *ecdsa.PrivateKey, error) {
+	accountPath, viewPath, err := accounts.WanKeyPaths(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	priv1, err := deriveHDKey(seed, accountPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	priv2, err := deriveHDKey(seed, viewPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	return priv1, priv2, nil
+}
+
+// deriveHDKey derives the private key at the given path from a BIP-32 seed.
+func deriveHDKey(seed []byte, path accounts.DerivationPath) (*ecdsa.PrivateKey, error) {
+	mac := hmac.New(sha512.New, []byte("Bitcoin seed"))
+	mac.Write(seed)
+	sum := mac.Sum(nil)
+
+	key := &hdKey{key: new(big.Int).SetBytes(sum[:32]), chainCode: sum[32:]}
+	if key.key.Sign() == 0 || key.key.Cmp(crypto.S256().Params().N) >= 0 {
+		return nil, errInvalidHDKey
+	}
+	for _, index := range path {
+		var err error
+		if key, err = key.child(index); err != nil {
+			return nil, err
+		}
+	}
+	return crypto.ToECDSA(math.PaddedBigBytes(key.key, 32))
+}
+
+// child derives the private child key of the given index.
+func (k *hdKey) child(index uint32) (*hdKey, error) {
+	var data []byte
+	if index >= 0x80000000 {
+		data = append([]byte{0}, math.PaddedBigBytes(k.key, 32)...)
+	} else {
+		curve := crypto.S256()
+		x, y := curve.ScalarBaseMult(math.PaddedBigBytes(k.key, 32))
+		data = ECDSAPKCompression(&ecdsa.PublicKey{Curve: curve, X: x, Y: y})
+	}
+	data = append(data, 0, 0, 0, 0)
+	binary.BigEndian.PutUint32(data[len(data)-4:], index)
+
+	mac := hmac.New(sha512.New, k.chainCode)
+	mac.Write(data)
+	sum := mac.Sum(nil)
+
+	n := crypto.S256().Params().N
+	il := new(big.Int).SetBytes(sum[:32])
+	if il.Cmp(n) >= 0 {
+		return nil, errInvalidHDKey
+	}
+	child := new(big.Int).Add(il, k.key)
+	child.Mod(child, n)
+	if child.Sign() == 0 {
+		return nil, errInvalidHDKey
+	}
+	return &hdKey{key: child, chainCode: sum[32:]}, nil
+}
+
+// ImportMnemonic derives the keys of the account at the given BIP-44 path from
+// a BIP-39 mnemonic and stores them into the key directory, encrypting them
+// with passphrase.
+func (ks *KeyStore) ImportMnemonic(mnemonic, mnemonicPassphrase string, path accounts.DerivationPath, passphrase string) (accounts.Account, error) {
+	priv1, priv2, err := DeriveKeysFromMnemonic(mnemonic, mnemonicPassphrase, path)
+	if err != nil {
+		return accounts.Account{}, err
+	}
+	return ks.ImportECDSA(priv1, priv2, passphrase)
+}
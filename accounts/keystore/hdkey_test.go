@@ -0,0 +1,85 @@
+package keystore
+
+import (
+	"encoding/hex"
+	"os"
+	"testing"
+
+	"github.com/wanchain/go-wanchain/accounts"
+	"github.com/wanchain/go-wanchain/crypto"
+)
+
+// Tests the BIP-32 private key derivation against the first test vector of the
+// specification.
+func TestHDKeyDerivation(t *testing.T) {
+	seed, _ := hex.DecodeString("000102030405060708090a0b0c0d0e0f")
+	tests := []struct {
+		path string
+		key  string
+	}{
+		{"m/0'", "edb2e14f9ee77d26dd93b4ecede8d16ed408ce149b6cd80b0715a2d911a0afea"},
+		{"m/0'/1", "3c6cb8d0f6a264c91ea8b5030fadaa8e538b020f0a387421a12de9319dc93368"},
+		{"m/0'/1/2'", "cbce0d719ecf7431d88e6a89fa1483e02e35092af60c042b1df2ff59fa424dca"},
+		{"m/0'/1/2'/2", "0f479245fb19a38a1954c5c7c0ebab2f9bdfd96a17563ef28a6a4b1a2a764ef4"},
+		{"m/0'/1/2'/2/1000000000", "471b76e389e528d6de6d816857e012c5455051cad6660850e58372a6c3e6e7c8"},
+	}
+	for _, tt := range tests {
+		path, err := accounts.ParseDerivationPath(tt.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		key, err := deriveHDKey(seed, path)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.path, err)
+		}
+		if have := hex.EncodeToString(crypto.FromECDSA(key)); have != tt.key {
+			t.Errorf("%s: key mismatch: have %s, want %s", tt.path, have, tt.key)
+		}
+	}
+}
+
+func TestImportMnemonic(t *testing.T) {
+	dir, ks := tmpKeyStore(t, true)
+	defer os.RemoveAll(dir)
+
+	mnemonic, err := NewMnemonic()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ks.ImportMnemonic("abandon abandon", "", accounts.DefaultBaseDerivationPath, "foo"); err != ErrInvalidMnemonic {
+		t.Fatalf("invalid mnemonic: have %v, want %v", err, ErrInvalidMnemonic)
+	}
+	account, err := ks.ImportMnemonic(mnemonic, "", accounts.DefaultBaseDerivationPath, "foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := ks.GetKey(account, "foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Recovering from the same mnemonic must give the same key pair
+	priv1, priv2, err := DeriveKeysFromMnemonic(" "+mnemonic+"\n", "", accounts.DefaultBaseDerivationPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if priv1.D.Cmp(key.PrivateKey.D) != 0 || priv2.D.Cmp(key.PrivateKey2.D) != 0 {
+		t.Fatal("recovered keys mismatch")
+	}
+	if key.WAddress != *GenerateWaddressFromPK(&priv1.PublicKey, &priv2.PublicKey) {
+		t.Fatal("wan address mismatch")
+	}
+	// The passphrase and the path select other accounts
+	other1, _, _ := DeriveKeysFromMnemonic(mnemonic, "bar", accounts.DefaultBaseDerivationPath)
+	if other1.D.Cmp(priv1.D) == 0 {
+		t.Error("mnemonic passphrase ignored")
+	}
+	path, _ := accounts.ParseDerivationPath("m/44'/5718350'/0'/0/1")
+	next1, next2, _ := DeriveKeysFromMnemonic(mnemonic, "", path)
+	if next1.D.Cmp(priv1.D) == 0 || next2.D.Cmp(priv2.D) == 0 || next1.D.Cmp(next2.D) == 0 {
+		t.Error("derivation path ignored")
+	}
+	if _, err := ks.ImportMnemonic(mnemonic, "", accounts.DefaultBaseDerivationPath, "foo"); err == nil {
+		t.Error("imported the same account twice")
+	}
+}
@@ -37,6 +37,7 @@ import (
 	"github.com/wanchain/go-wanchain/common"
 	"github.com/wanchain/go-wanchain/common/math"
 	"github.com/wanchain/go-wanchain/crypto"
+	"github.com/wanchain/go-wanchain/kms"
 )
 
 const (
@@ -62,12 +63,21 @@ type keyPair struct {
 	D1 string `json:"privateKey1"`
 }
 
+// AwsKmsInfo are the credentials of the AWS Key Management Service.
+//
+// Deprecated: use kms.AWSProvider.
 type AwsKmsInfo struct {
 	AKID      string
 	SecretKey string
 	Region    string
 }
 
+// Provider returns the AWS provider of the credentials, encrypting with the
+// key keyID.
+func (info *AwsKmsInfo) Provider(keyID string) *kms.AWSProvider {
+	return &kms.AWSProvider{AKID: info.AKID, SecretKey: info.SecretKey, Region: info.Region, KeyID: keyID}
+}
+
 type keyStore interface {
 	// Loads and decrypts the key from disk
 	GetKey(addr common.Address, filename string, auth string) (*Key, error)
@@ -307,6 +307,46 @@ func (ks *KeyStore) ComputeOTAPPKeys(a accounts.Account, AX, AY, BX, BY string)
 	return []string{pub1X, pub1Y, priv1D, priv2D}, err
 }
 
+// CheckOTAOwnership tells if the OTA given by its WAddress was sent to the
+// account. For an owned OTA the key image is returned as well, it is recorded
+// on chain once the OTA is refunded.
+func (ks *KeyStore) CheckOTAOwnership(a accounts.Account, otaWAddr []byte) (bool, []byte, error) {
+	A1, S1, err := GeneratePKPairFromWAddress(otaWAddr)
+	if err != nil {
+		return false, nil, err
+	}
+
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+
+	unlockedKey, found := ks.unlocked[a.Address]
+	if !found {
+		return false, nil, ErrLocked
+	}
+	if unlockedKey.PrivateKey2 == nil {
+		return false, nil, nil
+	}
+	if !crypto.CompareA1(unlockedKey.PrivateKey2.D.Bytes(), &unlockedKey.PrivateKey.PublicKey, S1, A1) {
+		return false, nil, nil
+	}
+
+	otaPriv, _, err := crypto.GenerateOneTimePrivateKey2528(unlockedKey.PrivateKey, unlockedKey.PrivateKey2, A1, S1)
+	if err != nil {
+		return false, nil, err
+	}
+	keyImage := crypto.GenerateKeyImage(otaPriv.D.Bytes(), A1)
+	return true, crypto.FromECDSAPub(keyImage), nil
+}
+
+// IsUnlocked tells if the account of the given address is unlocked.
+func (ks *KeyStore) IsUnlocked(addr common.Address) bool {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+
+	_, found := ks.unlocked[addr]
+	return found
+}
+
 // SignHashWithPassphrase signs hash if the private key matching the given address
 // can be decrypted with the given passphrase. The produced signature is in the
 // [R || S || V] format where V is 0 or 1.
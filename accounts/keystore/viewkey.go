@@ -0,0 +1,166 @@
+// Copyright 2018 Wanchain Foundation Ltd
+// This file is part of the go-wanchain library.
+//
+// The go-wanchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-wanchain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-wanchain library. If not, see <http://www.gnu.org/licenses/>.
+
+package keystore
+
+import (
+	"crypto/ecdsa"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/pborman/uuid"
+	"github.com/wanchain/go-wanchain/accounts"
+	"github.com/wanchain/go-wanchain/common"
+	"github.com/wanchain/go-wanchain/crypto"
+)
+
+const (
+	viewKeyType    = "viewkey"
+	viewKeyVersion = 3
+)
+
+var (
+	ErrNoViewKey      = errors.New("account has no second private key")
+	ErrInvalidViewKey = errors.New("invalid view key")
+)
+
+// ViewKey is the watch-only part of an account: the public key of the first
+// key pair and the private key of the second one. It is enough to tell which
+// OTAs were sent to the account, but not to spend them.
+type ViewKey struct {
+	Id          uuid.UUID
+	Address     common.Address
+	PublicKey   *ecdsa.PublicKey  // public key A of the first key pair
+	PrivateKey2 *ecdsa.PrivateKey // private key b of the second key pair
+	WAddress    common.WAddress
+}
+
+type encryptedViewKeyJSON struct {
+	Address   string     `json:"address"`
+	PublicKey string     `json:"publickey"`
+	Crypto2   cryptoJSON `json:"crypto2"`
+	Id        string     `json:"id"`
+	Type      string     `json:"type"`
+	Version   int        `json:"version"`
+	WAddress  string     `json:"waddress"`
+}
+
+// NewViewKey extracts the view key of a full key.
+func NewViewKey(key *Key) (*ViewKey, error) {
+	if key.PrivateKey2 == nil {
+		return nil, ErrNoViewKey
+	}
+	return &ViewKey{
+		Id:          uuid.NewRandom(),
+		Address:     key.Address,
+		PublicKey:   &key.PrivateKey.PublicKey,
+		PrivateKey2: key.PrivateKey2,
+		WAddress:    key.WAddress,
+	}, nil
+}
+
+// CheckOTAOwnership tells if the OTA given by its WAddress was sent to the
+// account of the view key.
+func (vk *ViewKey) CheckOTAOwnership(otaWAddr []byte) (bool, error) {
+	A1, S1, err := GeneratePKPairFromWAddress(otaWAddr)
+	if err != nil {
+		return false, err
+	}
+	return crypto.CompareA1(vk.PrivateKey2.D.Bytes(), vk.PublicKey, S1, A1), nil
+}
+
+// EncryptViewKey encrypts a view key using the specified scrypt parameters into
+// a json blob that can be decrypted later on.
+func EncryptViewKey(vk *ViewKey, auth string, scryptN, scryptP int) ([]byte, error) {
+	crypto2, err := EncryptOnePrivateKey(vk.PrivateKey2, auth, scryptN, scryptP)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(&encryptedViewKeyJSON{
+		Address:   hex.EncodeToString(vk.Address[:]),
+		PublicKey: hex.EncodeToString(crypto.FromECDSAPub(vk.PublicKey)),
+		Crypto2:   *crypto2,
+		Id:        vk.Id.String(),
+		Type:      viewKeyType,
+		Version:   viewKeyVersion,
+		WAddress:  hex.EncodeToString(vk.WAddress[:]),
+	})
+}
+
+// DecryptViewKey decrypts a view key from a json blob, checking that its parts
+// belong to the same account.
+func DecryptViewKey(vkjson []byte, auth string) (*ViewKey, error) {
+	var k encryptedViewKeyJSON
+	if err := json.Unmarshal(vkjson, &k); err != nil {
+		return nil, err
+	}
+	if k.Type != viewKeyType || k.Version != viewKeyVersion {
+		return nil, fmt.Errorf("unsupported view key: type %q, version %d", k.Type, k.Version)
+	}
+	pubBytes, err := hex.DecodeString(k.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+	pub := crypto.ToECDSAPub(pubBytes)
+	if pub == nil || pub.X == nil {
+		return nil, ErrInvalidViewKey
+	}
+	keyBytes, err := decryptKeyV3Item(k.Crypto2, auth)
+	if err != nil {
+		return nil, err
+	}
+	priv2, err := crypto.ToECDSA(keyBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	vk := &ViewKey{
+		Id:          uuid.Parse(k.Id),
+		Address:     crypto.PubkeyToAddress(*pub),
+		PublicKey:   pub,
+		PrivateKey2: priv2,
+		WAddress:    *GenerateWaddressFromPK(pub, &priv2.PublicKey),
+	}
+	if hex.EncodeToString(vk.Address[:]) != k.Address || hex.EncodeToString(vk.WAddress[:]) != k.WAddress {
+		return nil, ErrInvalidViewKey
+	}
+	return vk, nil
+}
+
+// ExportViewKey exports the view key of an account as a JSON blob, encrypted
+// with newPassphrase. The exported key can be imported into a watch-only
+// keystore to track the OTAs of the account without being able to spend them.
+func (ks *KeyStore) ExportViewKey(a accounts.Account, passphrase, newPassphrase string) ([]byte, error) {
+	_, key, err := ks.getDecryptedKey(a, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	defer zeroKey(key.PrivateKey)
+
+	vk, err := NewViewKey(key)
+	if err != nil {
+		return nil, err
+	}
+	var N, P int
+	if store, ok := ks.storage.(*keyStorePassphrase); ok {
+		N, P = store.scryptN, store.scryptP
+	} else {
+		N, P = StandardScryptN, StandardScryptP
+	}
+	return EncryptViewKey(vk, newPassphrase, N, P)
+}
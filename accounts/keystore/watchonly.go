@@ -0,0 +1,359 @@
+// Copyright 2018 Wanchain Foundation Ltd
+// This file is part of the go-wanchain library.
+//
+// The go-wanchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-wanchain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-wanchain library. If not, see <http://www.gnu.org/licenses/>.
+
+package keystore
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"sync"
+
+	ethereum "github.com/wanchain/go-wanchain"
+	"github.com/wanchain/go-wanchain/accounts"
+	"github.com/wanchain/go-wanchain/common"
+	"github.com/wanchain/go-wanchain/core/types"
+	"github.com/wanchain/go-wanchain/event"
+	"github.com/wanchain/go-wanchain/log"
+)
+
+// WatchOnlyKeyStoreType is the reflect type of a watch-only keystore backend.
+var WatchOnlyKeyStoreType = reflect.TypeOf(&WatchOnlyKeyStore{})
+
+// WatchOnlyScheme is the protocol scheme prefixing account and wallet URLs.
+var WatchOnlyScheme = "watchonly"
+
+// ErrWatchOnly is returned for any signing or spending request on a watch-only
+// account, which only holds the view key.
+var ErrWatchOnly = errors.New("watch-only account can't sign or spend")
+
+// WatchOnlyKeyStore manages the view keys of accounts whose spending keys are
+// kept elsewhere. Once a view key is unlocked the OTAs sent to the account can
+// be detected, but the account can't sign transactions, generate ring
+// signatures or refund OTAs.
+type WatchOnlyKeyStore struct {
+	dir              string
+	scryptN, scryptP int
+
+	mu       sync.RWMutex
+	accounts []accounts.Account // sorted by URL
+	unlocked map[common.Address]*ViewKey
+	wallets  []accounts.Wallet
+
+	updateFeed  event.Feed
+	updateScope event.SubscriptionScope
+}
+
+// NewWatchOnlyKeyStore creates a watch-only keystore for the view keys stored
+// in the given directory.
+func NewWatchOnlyKeyStore(dir string, scryptN, scryptP int) *WatchOnlyKeyStore {
+	dir, _ = filepath.Abs(dir)
+	ks := &WatchOnlyKeyStore{
+		dir:      dir,
+		scryptN:  scryptN,
+		scryptP:  scryptP,
+		unlocked: make(map[common.Address]*ViewKey),
+	}
+	ks.load()
+	return ks
+}
+
+// load reads the addresses of the view key files in the key directory.
+func (ks *WatchOnlyKeyStore) load() {
+	files, err := ioutil.ReadDir(ks.dir)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Warn("Failed to read watch-only key directory", "dir", ks.dir, "err", err)
+		}
+		return
+	}
+	for _, fi := range files {
+		path := filepath.Join(ks.dir, fi.Name())
+		if fi.IsDir() || skipKeyFile(fi) {
+			continue
+		}
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			log.Warn("Failed to read view key file", "path", path, "err", err)
+			continue
+		}
+		var key struct {
+			Address string `json:"address"`
+		}
+		if err := json.Unmarshal(data, &key); err != nil || !common.IsHexAddress(key.Address) {
+			log.Debug("Skipping invalid view key file", "path", path)
+			continue
+		}
+		ks.add(accounts.Account{
+			Address: common.HexToAddress(key.Address),
+			URL:     accounts.URL{Scheme: WatchOnlyScheme, Path: path},
+		})
+	}
+}
+
+// add inserts an account into the sorted account list and wraps it into a
+// wallet. The caller must hold the lock or own the keystore exclusively.
+func (ks *WatchOnlyKeyStore) add(account accounts.Account) accounts.Wallet {
+	i := sort.Search(len(ks.accounts), func(i int) bool { return ks.accounts[i].URL.Cmp(account.URL) >= 0 })
+	wallet := &watchOnlyWallet{account: account, keystore: ks}
+
+	ks.accounts = append(ks.accounts, accounts.Account{})
+	copy(ks.accounts[i+1:], ks.accounts[i:])
+	ks.accounts[i] = account
+
+	ks.wallets = append(ks.wallets, nil)
+	copy(ks.wallets[i+1:], ks.wallets[i:])
+	ks.wallets[i] = wallet
+	return wallet
+}
+
+// find returns the account of the given address.
+func (ks *WatchOnlyKeyStore) find(addr common.Address) (accounts.Account, bool) {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+
+	for _, account := range ks.accounts {
+		if account.Address == addr {
+			return account, true
+		}
+	}
+	return accounts.Account{}, false
+}
+
+// Wallets implements accounts.Backend, returning a wallet for each view key.
+func (ks *WatchOnlyKeyStore) Wallets() []accounts.Wallet {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+
+	cpy := make([]accounts.Wallet, len(ks.wallets))
+	copy(cpy, ks.wallets)
+	return cpy
+}
+
+// Subscribe implements accounts.Backend, creating an async subscription to
+// receive notifications on the addition of watch-only wallets.
+func (ks *WatchOnlyKeyStore) Subscribe(sink chan<- accounts.WalletEvent) event.Subscription {
+	return ks.updateScope.Track(ks.updateFeed.Subscribe(sink))
+}
+
+// Accounts returns the accounts of all the view keys in the directory.
+func (ks *WatchOnlyKeyStore) Accounts() []accounts.Account {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+
+	cpy := make([]accounts.Account, len(ks.accounts))
+	copy(cpy, ks.accounts)
+	return cpy
+}
+
+// Import stores the given encrypted view key into the key directory,
+// encrypting it with newPassphrase.
+func (ks *WatchOnlyKeyStore) Import(vkjson []byte, passphrase, newPassphrase string) (accounts.Account, error) {
+	vk, err := DecryptViewKey(vkjson, passphrase)
+	if err != nil {
+		return accounts.Account{}, err
+	}
+	if _, ok := ks.find(vk.Address); ok {
+		return accounts.Account{}, errors.New("view key already exists")
+	}
+	data, err := EncryptViewKey(vk, newPassphrase, ks.scryptN, ks.scryptP)
+	if err != nil {
+		return accounts.Account{}, err
+	}
+	path := filepath.Join(ks.dir, keyFileName(vk.Address))
+	if err := writeKeyFile(path, data); err != nil {
+		return accounts.Account{}, err
+	}
+	account := accounts.Account{Address: vk.Address, URL: accounts.URL{Scheme: WatchOnlyScheme, Path: path}}
+
+	ks.mu.Lock()
+	wallet := ks.add(account)
+	ks.mu.Unlock()
+
+	ks.updateFeed.Send(accounts.WalletEvent{Wallet: wallet, Kind: accounts.WalletArrived})
+	return account, nil
+}
+
+// Unlock decrypts the view key of an account, enabling OTA ownership checks
+// until the key is locked again.
+func (ks *WatchOnlyKeyStore) Unlock(addr common.Address, passphrase string) error {
+	account, ok := ks.find(addr)
+	if !ok {
+		return ErrNoMatch
+	}
+	data, err := ioutil.ReadFile(account.URL.Path)
+	if err != nil {
+		return err
+	}
+	vk, err := DecryptViewKey(data, passphrase)
+	if err != nil {
+		return ErrDecrypt
+	}
+	if vk.Address != addr {
+		return ErrInvalidViewKey
+	}
+	ks.mu.Lock()
+	ks.unlocked[addr] = vk
+	ks.mu.Unlock()
+	return nil
+}
+
+// Lock removes the view key of an account from memory.
+func (ks *WatchOnlyKeyStore) Lock(addr common.Address) error {
+	ks.mu.Lock()
+	if vk, ok := ks.unlocked[addr]; ok {
+		zeroKey(vk.PrivateKey2)
+		delete(ks.unlocked, addr)
+	}
+	ks.mu.Unlock()
+	return nil
+}
+
+// IsUnlocked tells if the view key of the given address is unlocked.
+func (ks *WatchOnlyKeyStore) IsUnlocked(addr common.Address) bool {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+
+	_, ok := ks.unlocked[addr]
+	return ok
+}
+
+// CheckOTAOwnership tells if the OTA given by its WAddress was sent to the
+// account. Computing the key image needs the spending key, so no key image is
+// ever returned and the spent status of watched OTAs stays unknown.
+func (ks *WatchOnlyKeyStore) CheckOTAOwnership(a accounts.Account, otaWAddr []byte) (bool, []byte, error) {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+
+	vk, found := ks.unlocked[a.Address]
+	if !found {
+		return false, nil, ErrLocked
+	}
+	owned, err := vk.CheckOTAOwnership(otaWAddr)
+	return owned, nil, err
+}
+
+// GetWanAddress returns the wanchain address of a watched account.
+func (ks *WatchOnlyKeyStore) GetWanAddress(a accounts.Account) (common.WAddress, error) {
+	account, ok := ks.find(a.Address)
+	if !ok {
+		return common.WAddress{}, ErrNoMatch
+	}
+	data, err := ioutil.ReadFile(account.URL.Path)
+	if err != nil {
+		return common.WAddress{}, err
+	}
+	var key struct {
+		WAddress string `json:"waddress"`
+	}
+	if err := json.Unmarshal(data, &key); err != nil {
+		return common.WAddress{}, err
+	}
+	raw := common.FromHex(key.WAddress)
+	if len(raw) != common.WAddressLength {
+		return common.WAddress{}, ErrWAddressInvalid
+	}
+	var waddr common.WAddress
+	copy(waddr[:], raw)
+	return waddr, nil
+}
+
+// watchOnlyWallet implements accounts.Wallet for a single view key. Only the
+// read-only operations are supported, everything that signs fails with
+// ErrWatchOnly.
+type watchOnlyWallet struct {
+	account  accounts.Account
+	keystore *WatchOnlyKeyStore
+}
+
+// URL implements accounts.Wallet, returning the URL of the view key file.
+func (w *watchOnlyWallet) URL() accounts.URL {
+	return w.account.URL
+}
+
+// Status implements accounts.Wallet, returning whether the view key is unlocked.
+func (w *watchOnlyWallet) Status() (string, error) {
+	if w.keystore.IsUnlocked(w.account.Address) {
+		return "Unlocked (watch-only)", nil
+	}
+	return "Locked (watch-only)", nil
+}
+
+// Open implements accounts.Wallet, but is a noop for watch-only wallets.
+func (w *watchOnlyWallet) Open(passphrase string) error { return nil }
+
+// Close implements accounts.Wallet, but is a noop for watch-only wallets.
+func (w *watchOnlyWallet) Close() error { return nil }
+
+// Accounts implements accounts.Wallet, returning the single watched account.
+func (w *watchOnlyWallet) Accounts() []accounts.Account {
+	return []accounts.Account{w.account}
+}
+
+// Contains implements accounts.Wallet, returning whether a particular account is
+// or is not wrapped by this wallet instance.
+func (w *watchOnlyWallet) Contains(account accounts.Account) bool {
+	return account.Address == w.account.Address && (account.URL == (accounts.URL{}) || account.URL == w.account.URL)
+}
+
+// Derive implements accounts.Wallet, but watch-only accounts can't be derived.
+func (w *watchOnlyWallet) Derive(path accounts.DerivationPath, pin bool) (accounts.Account, error) {
+	return accounts.Account{}, accounts.ErrNotSupported
+}
+
+// SelfDerive implements accounts.Wallet, but is a noop for watch-only wallets.
+func (w *watchOnlyWallet) SelfDerive(base accounts.DerivationPath, chain ethereum.ChainStateReader) {}
+
+// SignHash implements accounts.Wallet, always failing with ErrWatchOnly.
+func (w *watchOnlyWallet) SignHash(account accounts.Account, hash []byte) ([]byte, error) {
+	return nil, ErrWatchOnly
+}
+
+// SignTx implements accounts.Wallet, always failing with ErrWatchOnly.
+func (w *watchOnlyWallet) SignTx(account accounts.Account, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	return nil, ErrWatchOnly
+}
+
+// SignHashWithPassphrase implements accounts.Wallet, always failing with ErrWatchOnly.
+func (w *watchOnlyWallet) SignHashWithPassphrase(account accounts.Account, passphrase string, hash []byte) ([]byte, error) {
+	return nil, ErrWatchOnly
+}
+
+// SignTxWithPassphrase implements accounts.Wallet, always failing with ErrWatchOnly.
+func (w *watchOnlyWallet) SignTxWithPassphrase(account accounts.Account, passphrase string, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	return nil, ErrWatchOnly
+}
+
+// ComputeOTAPPKeys implements accounts.Wallet, always failing with ErrWatchOnly.
+// The OTA private keys it derives are what ring signatures and refunds are
+// made with, so refusing them keeps watched OTAs from being spent.
+func (w *watchOnlyWallet) ComputeOTAPPKeys(account accounts.Account, AX, AY, BX, BY string) ([]string, error) {
+	return nil, ErrWatchOnly
+}
+
+// GetWanAddress implements accounts.Wallet, returning the wanchain address of
+// the watched account.
+func (w *watchOnlyWallet) GetWanAddress(account accounts.Account) (common.WAddress, error) {
+	if !w.Contains(account) {
+		return common.WAddress{}, accounts.ErrUnknownAccount
+	}
+	return w.keystore.GetWanAddress(w.account)
+}
@@ -0,0 +1,121 @@
+package keystore
+
+import (
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/wanchain/go-wanchain/accounts"
+	"github.com/wanchain/go-wanchain/common/hexutil"
+	"github.com/wanchain/go-wanchain/core/types"
+)
+
+func TestWatchOnlyKeyStore(t *testing.T) {
+	dir, ks := tmpKeyStore(t, true)
+	defer os.RemoveAll(dir)
+
+	owner, err := ks.NewAccount("foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := ks.NewAccount("foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ks.ExportViewKey(owner, "bar", "view"); err != ErrDecrypt {
+		t.Fatalf("export with wrong passphrase: have %v, want %v", err, ErrDecrypt)
+	}
+	vkjson, err := ks.ExportViewKey(owner, "foo", "view")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Import the view key and make sure it survives a reload
+	wdir := filepath.Join(dir, "watchonly")
+	wks := NewWatchOnlyKeyStore(wdir, veryLightScryptN, veryLightScryptP)
+	if _, err := wks.Import(vkjson, "wrong", "watch"); err == nil {
+		t.Fatal("imported view key with wrong passphrase")
+	}
+	account, err := wks.Import(vkjson, "view", "watch")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if account.Address != owner.Address {
+		t.Fatalf("imported address mismatch: have %x, want %x", account.Address, owner.Address)
+	}
+	if _, err := wks.Import(vkjson, "view", "watch"); err == nil {
+		t.Fatal("imported view key twice")
+	}
+	wks = NewWatchOnlyKeyStore(wdir, veryLightScryptN, veryLightScryptP)
+	if accs := wks.Accounts(); len(accs) != 1 || accs[0] != account {
+		t.Fatalf("reloaded accounts mismatch: %v", accs)
+	}
+	if len(ks.Accounts()) != 2 {
+		t.Fatalf("view key picked up by the keystore: %v", ks.Accounts())
+	}
+	waddr, err := wks.GetWanAddress(account)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, _ := ks.GetWanAddress(owner)
+	if waddr != want {
+		t.Fatalf("wan address mismatch: have %x, want %x", waddr, want)
+	}
+
+	// Ownership checks need the view key unlocked
+	ownedStr, err := genOTA(hexutil.Encode(want[:]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherWAddr, _ := ks.GetWanAddress(other)
+	foreignStr, err := genOTA(hexutil.Encode(otherWAddr[:]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	owned, foreign := hexutil.MustDecode(ownedStr), hexutil.MustDecode(foreignStr)
+
+	if _, _, err := wks.CheckOTAOwnership(account, owned); err != ErrLocked {
+		t.Fatalf("ownership check on locked key: have %v, want %v", err, ErrLocked)
+	}
+	if err := wks.Unlock(account.Address, "view"); err != ErrDecrypt {
+		t.Fatalf("unlock with wrong passphrase: have %v, want %v", err, ErrDecrypt)
+	}
+	if err := wks.Unlock(account.Address, "watch"); err != nil {
+		t.Fatal(err)
+	}
+	if ok, keyImage, err := wks.CheckOTAOwnership(account, owned); err != nil || !ok || keyImage != nil {
+		t.Fatalf("owned OTA check: have %v, %x, %v", ok, keyImage, err)
+	}
+	if ok, _, err := wks.CheckOTAOwnership(account, foreign); err != nil || ok {
+		t.Fatalf("foreign OTA check: have %v, %v", ok, err)
+	}
+
+	// Nothing can be signed or spent through the watch-only wallet
+	wallets := wks.Wallets()
+	if len(wallets) != 1 || !wallets[0].Contains(account) {
+		t.Fatalf("wallets mismatch: %v", wallets)
+	}
+	wallet := wallets[0]
+	if _, err := wallet.SignHash(account, make([]byte, 32)); err != ErrWatchOnly {
+		t.Errorf("SignHash: have %v, want %v", err, ErrWatchOnly)
+	}
+	tx := types.NewTransaction(0, account.Address, big.NewInt(1), big.NewInt(21000), big.NewInt(1), nil)
+	if _, err := wallet.SignTx(account, tx, big.NewInt(1)); err != ErrWatchOnly {
+		t.Errorf("SignTx: have %v, want %v", err, ErrWatchOnly)
+	}
+	if _, err := wallet.SignTxWithPassphrase(account, "watch", tx, big.NewInt(1)); err != ErrWatchOnly {
+		t.Errorf("SignTxWithPassphrase: have %v, want %v", err, ErrWatchOnly)
+	}
+	if _, err := wallet.ComputeOTAPPKeys(account, "", "", "", ""); err != ErrWatchOnly {
+		t.Errorf("ComputeOTAPPKeys: have %v, want %v", err, ErrWatchOnly)
+	}
+	if _, err := wallet.Derive(accounts.DefaultBaseDerivationPath, false); err != accounts.ErrNotSupported {
+		t.Errorf("Derive: have %v, want %v", err, accounts.ErrNotSupported)
+	}
+
+	wks.Lock(account.Address)
+	if wks.IsUnlocked(account.Address) {
+		t.Fatal("view key still unlocked")
+	}
+}
@@ -0,0 +1,48 @@
+// Copyright 2018 Wanchain Foundation Ltd
+// This file is part of the go-wanchain library.
+//
+// The go-wanchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-wanchain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-wanchain library. If not, see <http://www.gnu.org/licenses/>.
+
+package remotesigner
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+)
+
+// authScheme prefixes the token in the Authorization header of the requests
+// sent to signers served over HTTP.
+const authScheme = "Bearer "
+
+// authHeader returns the header fields authenticating a client with token.
+func authHeader(token string) http.Header {
+	header := make(http.Header)
+	header.Set("Authorization", authScheme+token)
+	return header
+}
+
+// NewTokenHandler wraps the HTTP handler of a signer, refusing the requests
+// which don't carry token, as sent by a backend created with
+// NewBackendWithToken.
+func NewTokenHandler(token string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth := r.Header.Get("Authorization")
+		if !strings.HasPrefix(auth, authScheme) || subtle.ConstantTimeCompare([]byte(auth[len(authScheme):]), []byte(token)) != 1 {
+			http.Error(w, "invalid signer token", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
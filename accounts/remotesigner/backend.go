@@ -0,0 +1,191 @@
+// Copyright 2018 Wanchain Foundation Ltd
+// This file is part of the go-wanchain library.
+//
+// The go-wanchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-wanchain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-wanchain library. If not, see <http://www.gnu.org/licenses/>.
+
+package remotesigner
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/wanchain/go-wanchain/accounts"
+	"github.com/wanchain/go-wanchain/common"
+	"github.com/wanchain/go-wanchain/event"
+	"github.com/wanchain/go-wanchain/log"
+	"github.com/wanchain/go-wanchain/rpc"
+)
+
+// Scheme is the protocol scheme prefixing account and wallet URLs.
+const Scheme = "remote"
+
+// BackendType is the reflect type of a remote signer backend.
+var BackendType = reflect.TypeOf(&Backend{})
+
+// refreshCycle is the time between two refreshes of the account list of the
+// signer while someone is subscribed to wallet events.
+const refreshCycle = 3 * time.Second
+
+// callTimeout bounds every request sent to the signer.
+const callTimeout = 10 * time.Second
+
+// Backend is an accounts.Backend exposing the accounts of a remote signer, one
+// wallet per account.
+type Backend struct {
+	endpoint string
+	client   *rpc.Client
+
+	wallets     []*wallet               // wallets of the signer accounts, sorted by URL
+	refreshErr  error                   // error of the last account list refresh
+	updateFeed  event.Feed              // event feed to notify wallet additions/removals
+	updateScope event.SubscriptionScope // subscription scope tracking current live listeners
+	updating    bool                    // whether the event notification loop is running
+
+	stateLock sync.RWMutex
+}
+
+// NewBackend connects to the signer listening on endpoint, which is either an
+// HTTP URL or the path of an IPC socket.
+func NewBackend(endpoint string) (*Backend, error) {
+	return NewBackendWithToken(endpoint, "")
+}
+
+// NewBackendWithToken connects to the signer listening on endpoint like
+// NewBackend, authenticating with token if the signer is served over HTTP.
+func NewBackendWithToken(endpoint string, token string) (*Backend, error) {
+	var (
+		client *rpc.Client
+		err    error
+	)
+	if token != "" && (strings.HasPrefix(endpoint, "http://") || strings.HasPrefix(endpoint, "https://")) {
+		client, err = rpc.DialHTTPWithHeader(endpoint, authHeader(token))
+	} else {
+		client, err = rpc.Dial(endpoint)
+	}
+	if err != nil {
+		return nil, err
+	}
+	b := &Backend{endpoint: endpoint, client: client}
+	b.refreshWallets()
+	return b, nil
+}
+
+// Wallets implements accounts.Backend, returning a wallet for each account of
+// the signer.
+func (b *Backend) Wallets() []accounts.Wallet {
+	b.stateLock.RLock()
+	defer b.stateLock.RUnlock()
+
+	cpy := make([]accounts.Wallet, len(b.wallets))
+	for i, w := range b.wallets {
+		cpy[i] = w
+	}
+	return cpy
+}
+
+// Subscribe implements accounts.Backend, creating an async subscription to
+// receive notifications on the addition or removal of signer accounts.
+func (b *Backend) Subscribe(sink chan<- accounts.WalletEvent) event.Subscription {
+	b.stateLock.Lock()
+	defer b.stateLock.Unlock()
+
+	sub := b.updateScope.Track(b.updateFeed.Subscribe(sink))
+	if !b.updating {
+		b.updating = true
+		go b.updater()
+	}
+	return sub
+}
+
+// updater refreshes the account list periodically until all subscribers left.
+func (b *Backend) updater() {
+	for {
+		time.Sleep(refreshCycle)
+		b.refreshWallets()
+
+		b.stateLock.Lock()
+		if b.updateScope.Count() == 0 {
+			b.updating = false
+			b.stateLock.Unlock()
+			return
+		}
+		b.stateLock.Unlock()
+	}
+}
+
+// refreshWallets retrieves the accounts of the signer and updates the wallets.
+// If the signer can't be reached the current wallets are kept, their requests
+// will fail until it is back.
+func (b *Backend) refreshWallets() {
+	var infos []AccountInfo
+	err := b.call(&infos, "accounts")
+
+	b.stateLock.Lock()
+	b.refreshErr = err
+	if err != nil {
+		b.stateLock.Unlock()
+		log.Warn("Failed to list remote signer accounts", "endpoint", b.endpoint, "err", err)
+		return
+	}
+	current := make(map[common.Address]*wallet, len(b.wallets))
+	for _, w := range b.wallets {
+		current[w.account.Address] = w
+	}
+	var (
+		wallets = make([]*wallet, 0, len(infos))
+		events  []accounts.WalletEvent
+	)
+	for _, info := range infos {
+		if w, ok := current[info.Address]; ok {
+			delete(current, info.Address)
+			wallets = append(wallets, w)
+			continue
+		}
+		w, err := newWallet(b, info)
+		if err != nil {
+			log.Warn("Invalid remote signer account", "address", info.Address, "err", err)
+			continue
+		}
+		wallets = append(wallets, w)
+		events = append(events, accounts.WalletEvent{Wallet: w, Kind: accounts.WalletArrived})
+	}
+	for _, w := range current {
+		events = append(events, accounts.WalletEvent{Wallet: w, Kind: accounts.WalletDropped})
+	}
+	sort.Slice(wallets, func(i, j int) bool { return wallets[i].account.URL.Cmp(wallets[j].account.URL) < 0 })
+	b.wallets = wallets
+	b.stateLock.Unlock()
+
+	for _, event := range events {
+		b.updateFeed.Send(event)
+	}
+}
+
+// status returns the error of the last account list refresh.
+func (b *Backend) status() error {
+	b.stateLock.RLock()
+	defer b.stateLock.RUnlock()
+	return b.refreshErr
+}
+
+// call invokes a method of the signer namespace.
+func (b *Backend) call(result interface{}, method string, args ...interface{}) error {
+	ctx, cancel := context.WithTimeout(context.Background(), callTimeout)
+	defer cancel()
+	return b.client.CallContext(ctx, result, Namespace+"_"+method, args...)
+}
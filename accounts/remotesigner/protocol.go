@@ -0,0 +1,115 @@
+// Copyright 2018 Wanchain Foundation Ltd
+// This file is part of the go-wanchain library.
+//
+// The go-wanchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-wanchain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-wanchain library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package remotesigner implements an account backend whose keys are held by an
+// external signer daemon, reached over HTTP or IPC.
+//
+// The signer serves the JSON-RPC methods below in the "signer" namespace. Keys
+// are identified by their account address and never leave the signer:
+//
+//	signer_accounts()                                    -> []AccountInfo
+//	signer_signHash(address, hash)                       -> signature
+//	signer_signTx(address, rlpTx, chainId)               -> signed rlpTx
+//	signer_generateSMA(address, pieces)                  -> sma
+//	signer_slotLeaderProof(address, SlotLeaderProofArgs) -> SlotLeaderProof
+//	signer_bn256SignShare(address, ens, m)               -> signature share
+//
+// secp256k1 points are encoded uncompressed and bn256 points in their
+// marshalled form. A reference signer is available in pos/possigner.
+package remotesigner
+
+import (
+	"crypto/ecdsa"
+	"errors"
+
+	"github.com/wanchain/go-wanchain/common"
+	"github.com/wanchain/go-wanchain/common/hexutil"
+	"github.com/wanchain/go-wanchain/crypto"
+	bn256 "github.com/wanchain/go-wanchain/crypto/bn256/cloudflare"
+)
+
+// Namespace is the RPC namespace served by remote signers.
+const Namespace = "signer"
+
+var ErrInvalidPoint = errors.New("invalid curve point")
+
+// AccountInfo describes an account held by a remote signer.
+type AccountInfo struct {
+	Address        common.Address `json:"address"`
+	PublicKey      hexutil.Bytes  `json:"publicKey"`      // uncompressed secp256k1 public key
+	WAddress       hexutil.Bytes  `json:"waddress"`       // wanchain address of the account
+	Bn256PublicKey hexutil.Bytes  `json:"bn256PublicKey"` // random beacon public key
+}
+
+// SlotLeaderProofArgs are the inputs of a slot leader proof.
+type SlotLeaderProofArgs struct {
+	SMA          []hexutil.Bytes `json:"sma"`
+	EpochLeaders []hexutil.Bytes `json:"epochLeaders"`
+	RB           hexutil.Bytes   `json:"rb"`
+	SlotID       hexutil.Uint64  `json:"slotId"`
+	EpochID      hexutil.Uint64  `json:"epochId"`
+}
+
+// SlotLeaderProof is the proof of being the leader of a slot.
+type SlotLeaderProof struct {
+	ProofMeg []hexutil.Bytes `json:"proofMeg"`
+	Proof    []*hexutil.Big  `json:"proof"`
+}
+
+// EncodePublicKeys encodes secp256k1 points for the signer protocol.
+func EncodePublicKeys(pks []*ecdsa.PublicKey) []hexutil.Bytes {
+	enc := make([]hexutil.Bytes, len(pks))
+	for i, pk := range pks {
+		enc[i] = crypto.FromECDSAPub(pk)
+	}
+	return enc
+}
+
+// DecodePublicKeys decodes secp256k1 points of the signer protocol, checking
+// that they are on the curve.
+func DecodePublicKeys(enc []hexutil.Bytes) ([]*ecdsa.PublicKey, error) {
+	pks := make([]*ecdsa.PublicKey, len(enc))
+	for i, b := range enc {
+		pk := crypto.ToECDSAPub(b)
+		if pk == nil {
+			return nil, ErrInvalidPoint
+		}
+		pks[i] = pk
+	}
+	return pks, nil
+}
+
+// EncodeG1s encodes bn256 points for the signer protocol.
+func EncodeG1s(points []*bn256.G1) []hexutil.Bytes {
+	enc := make([]hexutil.Bytes, len(points))
+	for i, p := range points {
+		enc[i] = p.Marshal()
+	}
+	return enc
+}
+
+// DecodeG1s decodes bn256 points of the signer protocol.
+func DecodeG1s(enc []hexutil.Bytes) ([]*bn256.G1, error) {
+	points := make([]*bn256.G1, len(enc))
+	for i, b := range enc {
+		p := new(bn256.G1)
+		if _, err := p.Unmarshal(b); err != nil {
+			return nil, ErrInvalidPoint
+		}
+		points[i] = p
+	}
+	return points, nil
+}
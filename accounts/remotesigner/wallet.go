@@ -0,0 +1,267 @@
+// Copyright 2018 Wanchain Foundation Ltd
+// This file is part of the go-wanchain library.
+//
+// The go-wanchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-wanchain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-wanchain library. If not, see <http://www.gnu.org/licenses/>.
+
+package remotesigner
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"math/big"
+
+	ethereum "github.com/wanchain/go-wanchain"
+	"github.com/wanchain/go-wanchain/accounts"
+	"github.com/wanchain/go-wanchain/accounts/keystore"
+	"github.com/wanchain/go-wanchain/common"
+	"github.com/wanchain/go-wanchain/common/hexutil"
+	"github.com/wanchain/go-wanchain/core/types"
+	"github.com/wanchain/go-wanchain/crypto"
+	bn256 "github.com/wanchain/go-wanchain/crypto/bn256/cloudflare"
+	"github.com/wanchain/go-wanchain/rlp"
+)
+
+var (
+	ErrInvalidAccount = errors.New("signer account keys don't match its address")
+	ErrBadSignature   = errors.New("signer returned a signature of another key or message")
+)
+
+// wallet implements accounts.Wallet for a single account of a remote signer.
+// It also implements posconfig.MinerSigner, so the account can mine without
+// its keys being loaded into the node.
+type wallet struct {
+	backend   *Backend
+	account   accounts.Account
+	publicKey *ecdsa.PublicKey
+	waddress  common.WAddress
+	bn256PK   *bn256.G1
+}
+
+// newWallet creates the wallet of a signer account, checking that the keys
+// reported by the signer belong to the account.
+func newWallet(b *Backend, info AccountInfo) (*wallet, error) {
+	pks, err := DecodePublicKeys([]hexutil.Bytes{info.PublicKey})
+	if err != nil {
+		return nil, err
+	}
+	if crypto.PubkeyToAddress(*pks[0]) != info.Address || len(info.WAddress) != common.WAddressLength {
+		return nil, ErrInvalidAccount
+	}
+	if !bytes.Equal(info.WAddress[:33], keystore.ECDSAPKCompression(pks[0])) {
+		return nil, ErrInvalidAccount
+	}
+	g1s, err := DecodeG1s([]hexutil.Bytes{info.Bn256PublicKey})
+	if err != nil {
+		return nil, err
+	}
+	w := &wallet{
+		backend: b,
+		account: accounts.Account{
+			Address: info.Address,
+			URL:     accounts.URL{Scheme: Scheme, Path: info.Address.Hex()},
+		},
+		publicKey: pks[0],
+		bn256PK:   g1s[0],
+	}
+	copy(w.waddress[:], info.WAddress)
+	return w, nil
+}
+
+// URL implements accounts.Wallet, returning the URL of the account.
+func (w *wallet) URL() accounts.URL {
+	return w.account.URL
+}
+
+// Status implements accounts.Wallet, returning whether the signer could be
+// reached the last time its accounts were listed.
+func (w *wallet) Status() (string, error) {
+	if err := w.backend.status(); err != nil {
+		return "Offline", err
+	}
+	return fmt.Sprintf("Online (%s)", w.backend.endpoint), nil
+}
+
+// Open implements accounts.Wallet, but is a noop for remote signer wallets,
+// accounts are unlocked on the signer.
+func (w *wallet) Open(passphrase string) error { return nil }
+
+// Close implements accounts.Wallet, but is a noop for remote signer wallets.
+func (w *wallet) Close() error { return nil }
+
+// Accounts implements accounts.Wallet, returning the single signer account.
+func (w *wallet) Accounts() []accounts.Account {
+	return []accounts.Account{w.account}
+}
+
+// Contains implements accounts.Wallet, returning whether a particular account is
+// or is not wrapped by this wallet instance.
+func (w *wallet) Contains(account accounts.Account) bool {
+	return account.Address == w.account.Address && (account.URL == (accounts.URL{}) || account.URL == w.account.URL)
+}
+
+// Derive implements accounts.Wallet, but signer accounts can't be derived.
+func (w *wallet) Derive(path accounts.DerivationPath, pin bool) (accounts.Account, error) {
+	return accounts.Account{}, accounts.ErrNotSupported
+}
+
+// SelfDerive implements accounts.Wallet, but is a noop for remote signer wallets.
+func (w *wallet) SelfDerive(base accounts.DerivationPath, chain ethereum.ChainStateReader) {}
+
+// SignHash implements accounts.Wallet, having the signer sign the hash with the
+// account key. The signature is checked against the account public key.
+func (w *wallet) SignHash(account accounts.Account, hash []byte) ([]byte, error) {
+	if !w.Contains(account) {
+		return nil, accounts.ErrUnknownAccount
+	}
+	var sig hexutil.Bytes
+	if err := w.backend.call(&sig, "signHash", w.account.Address, hexutil.Bytes(hash)); err != nil {
+		return nil, err
+	}
+	pub, err := crypto.Ecrecover(hash, sig)
+	if err != nil || !bytes.Equal(pub, crypto.FromECDSAPub(w.publicKey)) {
+		return nil, ErrBadSignature
+	}
+	return sig, nil
+}
+
+// SignTx implements accounts.Wallet, having the signer sign the transaction
+// with the account key. The signed transaction is checked to be the requested
+// one, sent from the account.
+func (w *wallet) SignTx(account accounts.Account, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	if !w.Contains(account) {
+		return nil, accounts.ErrUnknownAccount
+	}
+	txRlp, err := rlp.EncodeToBytes(tx)
+	if err != nil {
+		return nil, err
+	}
+	var signedRlp hexutil.Bytes
+	if err := w.backend.call(&signedRlp, "signTx", w.account.Address, hexutil.Bytes(txRlp), (*hexutil.Big)(chainID)); err != nil {
+		return nil, err
+	}
+	signed := new(types.Transaction)
+	if err := rlp.DecodeBytes(signedRlp, signed); err != nil {
+		return nil, err
+	}
+	var signer types.Signer = types.HomesteadSigner{}
+	if chainID != nil {
+		signer = types.NewEIP155Signer(chainID)
+	}
+	if signer.Hash(signed) != signer.Hash(tx) {
+		return nil, ErrBadSignature
+	}
+	if from, err := types.Sender(signer, signed); err != nil || from != w.account.Address {
+		return nil, ErrBadSignature
+	}
+	return signed, nil
+}
+
+// SignHashWithPassphrase implements accounts.Wallet, ignoring the passphrase as
+// the account is unlocked on the signer.
+func (w *wallet) SignHashWithPassphrase(account accounts.Account, passphrase string, hash []byte) ([]byte, error) {
+	return w.SignHash(account, hash)
+}
+
+// SignTxWithPassphrase implements accounts.Wallet, ignoring the passphrase as
+// the account is unlocked on the signer.
+func (w *wallet) SignTxWithPassphrase(account accounts.Account, passphrase string, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	return w.SignTx(account, tx, chainID)
+}
+
+// GetWanAddress implements accounts.Wallet, returning the wanchain address
+// reported by the signer.
+func (w *wallet) GetWanAddress(account accounts.Account) (common.WAddress, error) {
+	if !w.Contains(account) {
+		return common.WAddress{}, accounts.ErrUnknownAccount
+	}
+	return w.waddress, nil
+}
+
+// ComputeOTAPPKeys implements accounts.Wallet, but OTA keys are not supported by
+// the signer protocol.
+func (w *wallet) ComputeOTAPPKeys(account accounts.Account, AX, AY, BX, BY string) ([]string, error) {
+	return nil, accounts.ErrNotSupported
+}
+
+// Address returns the address of the signer account.
+func (w *wallet) Address() common.Address {
+	return w.account.Address
+}
+
+// PublicKey returns the secp256k1 public key of the signer account.
+func (w *wallet) PublicKey() *ecdsa.PublicKey {
+	return w.publicKey
+}
+
+// Bn256PublicKey returns the random beacon public key of the signer account.
+func (w *wallet) Bn256PublicKey() *bn256.G1 {
+	return w.bn256PK
+}
+
+// GenerateSMA has the signer multiply the security pieces by the inverse of the
+// account key.
+func (w *wallet) GenerateSMA(pieces []*ecdsa.PublicKey) ([]*ecdsa.PublicKey, error) {
+	var sma []hexutil.Bytes
+	if err := w.backend.call(&sma, "generateSMA", w.account.Address, EncodePublicKeys(pieces)); err != nil {
+		return nil, err
+	}
+	if len(sma) != len(pieces) {
+		return nil, fmt.Errorf("signer returned %d SMA pieces, want %d", len(sma), len(pieces))
+	}
+	return DecodePublicKeys(sma)
+}
+
+// SlotLeaderProof has the signer prove that the account is the leader of the
+// given slot.
+func (w *wallet) SlotLeaderProof(sma, epochLeaders []*ecdsa.PublicKey, rb []byte, slotID, epochID uint64) ([]*ecdsa.PublicKey, []*big.Int, error) {
+	args := SlotLeaderProofArgs{
+		SMA:          EncodePublicKeys(sma),
+		EpochLeaders: EncodePublicKeys(epochLeaders),
+		RB:           rb,
+		SlotID:       hexutil.Uint64(slotID),
+		EpochID:      hexutil.Uint64(epochID),
+	}
+	var res SlotLeaderProof
+	if err := w.backend.call(&res, "slotLeaderProof", w.account.Address, args); err != nil {
+		return nil, nil, err
+	}
+	proofMeg, err := DecodePublicKeys(res.ProofMeg)
+	if err != nil {
+		return nil, nil, err
+	}
+	proof := make([]*big.Int, len(res.Proof))
+	for i, p := range res.Proof {
+		if p == nil {
+			return nil, nil, errors.New("signer returned an empty proof")
+		}
+		proof[i] = p.ToInt()
+	}
+	return proofMeg, proof, nil
+}
+
+// Bn256SignShare has the signer compute the random beacon signature share of
+// the account.
+func (w *wallet) Bn256SignShare(ens []*bn256.G1, m *big.Int) (*bn256.G1, error) {
+	var share hexutil.Bytes
+	if err := w.backend.call(&share, "bn256SignShare", w.account.Address, EncodeG1s(ens), (*hexutil.Big)(m)); err != nil {
+		return nil, err
+	}
+	g1s, err := DecodeG1s([]hexutil.Bytes{share})
+	if err != nil {
+		return nil, err
+	}
+	return g1s[0], nil
+}
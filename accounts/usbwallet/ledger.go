@@ -29,9 +29,11 @@ import (
 	"math/big"
 
 	"github.com/wanchain/go-wanchain/accounts"
+	"github.com/wanchain/go-wanchain/accounts/keystore"
 	"github.com/wanchain/go-wanchain/common"
 	"github.com/wanchain/go-wanchain/common/hexutil"
 	"github.com/wanchain/go-wanchain/core/types"
+	"github.com/wanchain/go-wanchain/crypto"
 	"github.com/wanchain/go-wanchain/log"
 	"github.com/wanchain/go-wanchain/rlp"
 )
@@ -113,7 +115,7 @@ func (w *ledgerDriver) offline() bool {
 func (w *ledgerDriver) Open(device io.ReadWriter, passphrase string) error {
 	w.device, w.failure = device, nil
 
-	_, err := w.ledgerDerive(accounts.DefaultBaseDerivationPath)
+	_, _, err := w.ledgerDerive(accounts.DefaultBaseDerivationPath)
 	if err != nil {
 		// Ethereum app is not running or in browser mode, nothing more to do, return
 		if err == errLedgerReplyInvalidHeader {
@@ -148,7 +150,22 @@ func (w *ledgerDriver) Heartbeat() error {
 // Derive implements usbwallet.driver, sending a derivation request to the Ledger
 // and returning the Ethereum address located on that derivation path.
 func (w *ledgerDriver) Derive(path accounts.DerivationPath) (common.Address, error) {
-	return w.ledgerDerive(path)
+	address, _, err := w.ledgerDerive(path)
+	return address, err
+}
+
+// DerivePublicKey implements usbwallet.driver, sending a derivation request to
+// the Ledger and returning the compressed public key located on that path.
+func (w *ledgerDriver) DerivePublicKey(path accounts.DerivationPath) ([]byte, error) {
+	_, pubkey, err := w.ledgerDerive(path)
+	if err != nil {
+		return nil, err
+	}
+	pub := crypto.ToECDSAPub(pubkey)
+	if pub == nil || pub.X == nil {
+		return nil, errors.New("reply has invalid public key")
+	}
+	return keystore.ECDSAPKCompression(pub), nil
 }
 
 // SignTx implements usbwallet.driver, sending the transaction to the Ledger and
@@ -233,7 +250,7 @@ func (w *ledgerDriver) ledgerVersion() ([3]byte, error) {
 //   Ethereum address length | 1 byte
 //   Ethereum address        | 40 bytes hex ascii
 //   Chain code if requested | 32 bytes
-func (w *ledgerDriver) ledgerDerive(derivationPath []uint32) (common.Address, error) {
+func (w *ledgerDriver) ledgerDerive(derivationPath []uint32) (common.Address, []byte, error) {
 	// Flatten the derivation path into the Ledger request
 	path := make([]byte, 1+4*len(derivationPath))
 	path[0] = byte(len(derivationPath))
@@ -243,24 +260,25 @@ func (w *ledgerDriver) ledgerDerive(derivationPath []uint32) (common.Address, er
 	// Send the request and wait for the response
 	reply, err := w.ledgerExchange(ledgerOpRetrieveAddress, ledgerP1DirectlyFetchAddress, ledgerP2DiscardAddressChainCode, path)
 	if err != nil {
-		return common.Address{}, err
+		return common.Address{}, nil, err
 	}
-	// Discard the public key, we don't need that for now
+	// Extract the public key, needed for the wanchain address
 	if len(reply) < 1 || len(reply) < 1+int(reply[0]) {
-		return common.Address{}, errors.New("reply lacks public key entry")
+		return common.Address{}, nil, errors.New("reply lacks public key entry")
 	}
+	pubkey := common.CopyBytes(reply[1 : 1+int(reply[0])])
 	reply = reply[1+int(reply[0]):]
 
 	// Extract the Ethereum hex address string
 	if len(reply) < 1 || len(reply) < 1+int(reply[0]) {
-		return common.Address{}, errors.New("reply lacks address entry")
+		return common.Address{}, nil, errors.New("reply lacks address entry")
 	}
 	hexstr := reply[1 : 1+int(reply[0])]
 
 	// Decode the hex sting into an Ethereum address and return
 	var address common.Address
 	hex.Decode(address[:], hexstr)
-	return address, nil
+	return address, pubkey, nil
 }
 
 // ledgerSign sends the transaction to the Ledger wallet, and waits for the user
@@ -150,6 +150,19 @@ func (w *trezorDriver) Derive(path accounts.DerivationPath) (common.Address, err
 	return w.trezorDerive(path)
 }
 
+// DerivePublicKey implements usbwallet.driver, sending a public key request to
+// the Trezor and returning the compressed public key located on that path.
+func (w *trezorDriver) DerivePublicKey(path accounts.DerivationPath) ([]byte, error) {
+	pubkey := new(trezor.PublicKey)
+	if _, err := w.trezorExchange(&trezor.GetPublicKey{AddressN: path}, pubkey); err != nil {
+		return nil, err
+	}
+	if node := pubkey.GetNode(); node == nil || len(node.GetPublicKey()) != 33 {
+		return nil, errors.New("reply lacks public key")
+	}
+	return pubkey.GetNode().GetPublicKey(), nil
+}
+
 // SignTx implements usbwallet.driver, sending the transaction to the Trezor and
 // waiting for the user to confirm or deny the transaction.
 func (w *trezorDriver) SignTx(path accounts.DerivationPath, tx *types.Transaction, chainID *big.Int) (common.Address, *types.Transaction, error) {
@@ -64,6 +64,10 @@ type driver interface {
 	// address located on that path.
 	Derive(path accounts.DerivationPath) (common.Address, error)
 
+	// DerivePublicKey sends a derivation request to the USB device and returns
+	// the compressed public key located on that path.
+	DerivePublicKey(path accounts.DerivationPath) ([]byte, error)
+
 	// SignTx sends the transaction to the USB device and waits for the user to confirm
 	// or deny the transaction.
 	SignTx(path accounts.DerivationPath, tx *types.Transaction, chainID *big.Int) (common.Address, *types.Transaction, error)
@@ -562,9 +566,41 @@ func (w *wallet) SignTxWithPassphrase(account accounts.Account, passphrase strin
 	return w.SignTx(account, tx, chainID)
 }
 
-// TODO: TBI
+// GetWanAddress implements accounts.Wallet, deriving the public keys of the
+// account key and of the view key of a pinned account (see accounts.WanKeyPaths)
+// and combining them into the wanchain address.
 func (w *wallet) GetWanAddress(account accounts.Account) (common.WAddress, error) {
-	return common.WAddress{}, nil
+	w.stateLock.RLock() // Comms have own mutex, this is for the state fields
+	defer w.stateLock.RUnlock()
+
+	// If the wallet is closed, abort
+	if w.device == nil {
+		return common.WAddress{}, accounts.ErrWalletClosed
+	}
+	// Make sure the requested account is contained within
+	path, ok := w.paths[account.Address]
+	if !ok {
+		return common.WAddress{}, accounts.ErrUnknownAccount
+	}
+	accountPath, viewPath, err := accounts.WanKeyPaths(path)
+	if err != nil {
+		return common.WAddress{}, err
+	}
+	<-w.commsLock
+	defer func() { w.commsLock <- struct{}{} }()
+
+	pubA, err := w.driver.DerivePublicKey(accountPath)
+	if err != nil {
+		return common.WAddress{}, err
+	}
+	pubB, err := w.driver.DerivePublicKey(viewPath)
+	if err != nil {
+		return common.WAddress{}, err
+	}
+	var waddr common.WAddress
+	copy(waddr[:33], pubA)
+	copy(waddr[33:], pubB)
+	return waddr, nil
 }
 
 // TODO: TBI
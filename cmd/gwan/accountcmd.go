@@ -18,7 +18,7 @@ package main
 
 import (
 	"fmt"
-	"github.com/wanchain/go-wanchain/awskms"
+	"github.com/wanchain/go-wanchain/kms"
 	"github.com/wanchain/go-wanchain/common"
 	"github.com/wanchain/go-wanchain/crypto"
 	"github.com/wanchain/go-wanchain/pos/posconfig"
@@ -35,6 +35,16 @@ import (
 )
 
 var (
+	mnemonicFlag = cli.BoolFlag{
+		Name:  "mnemonic",
+		Usage: "Derive the account keys from a BIP39 mnemonic",
+	}
+	hdPathFlag = cli.StringFlag{
+		Name:  "hdpath",
+		Usage: "BIP44 derivation path of the account derived from the mnemonic",
+		Value: accounts.DefaultBaseDerivationPath.String(),
+	}
+
 	walletCommand = cli.Command{
 		Name:      "wallet",
 		Usage:     "Manage Ethereum presale wallets",
@@ -115,12 +125,20 @@ Print a short summary of all accounts`,
 					utils.KeyStoreDirFlag,
 					utils.PasswordFileFlag,
 					utils.LightKDFFlag,
+					mnemonicFlag,
+					hdPathFlag,
 				},
 				Description: `
     geth account new
 
 Creates a new account and prints the address.
 
+With --mnemonic the account keys are derived from a newly generated BIP39
+mnemonic, which is printed along with the address. The account key, the view
+key and the PoS bn256 key can all be recovered from the mnemonic later on with
+'gwan account import --mnemonic'. The --hdpath flag selects the BIP44 path of
+the account (default m/44'/5718350'/0'/0/0).
+
 The account is saved in encrypted format, you are prompted for a passphrase.
 
 You must remember this passphrase to unlock your account in the future.
@@ -169,6 +187,8 @@ changing your password is only possible interactively.
 					utils.KeyStoreDirFlag,
 					utils.PasswordFileFlag,
 					utils.LightKDFFlag,
+					mnemonicFlag,
+					hdPathFlag,
 				},
 				ArgsUsage: "<keyFile>",
 				Description: `
@@ -179,6 +199,11 @@ Prints the address.
 
 The keyfile is assumed to be a json file which contains an unencrypted private key pair in hexencoded string format.
 
+    gwan account import --mnemonic [--hdpath <path>] [<mnemonicfile>]
+
+Recovers the account derived from a BIP39 mnemonic, read from <mnemonicfile>
+or prompted for if no file is given.
+
 The account is saved in encrypted format, you are prompted for a passphrase.
 
 You must remember this passphrase to unlock your account in the future.
@@ -206,36 +231,51 @@ Print public key of an address`,
 			},
 			{
 				Name:      "encrypt",
-				Usage:     "Encrypt an existing account with AWS KMS",
+				Usage:     "Encrypt an existing account with a key management service",
 				Action:    utils.MigrateFlags(accountEncrypt),
 				ArgsUsage: "<address>",
 				Flags: []cli.Flag{
 					utils.DataDirFlag,
 					utils.KeyStoreDirFlag,
+					utils.KMSProviderFlag,
+					utils.KMSKeyIDFlag,
+					utils.KMSRegionFlag,
+					utils.KMSKeyFileFlag,
+					utils.KMSVaultAddrFlag,
+					utils.KMSVaultMountFlag,
 				},
 				Description: `
     gwan account encrypt <address>
 
 Encrypt an existing account.
 
-The account will be encrypted by AWS KMS, and ciphertext will be saved into new file named as "<original-name>-cipher"
+The account will be encrypted by the key encryption provider selected with --kms.provider:
+AWS KMS ("aws", default), a local master key file ("local") or a HashiCorp Vault
+transit key ("vault"). The ciphertext will be saved into new file named as "<original-name>-cipher"
 `,
 			},
 			{
 				Name:      "decrypt",
-				Usage:     "Decrypt an existing AWS KMS encrypted account",
+				Usage:     "Decrypt an existing KMS encrypted account",
 				Action:    utils.MigrateFlags(accountDecrypt),
 				ArgsUsage: "<address>",
 				Flags: []cli.Flag{
 					utils.DataDirFlag,
 					utils.KeyStoreDirFlag,
+					utils.KMSProviderFlag,
+					utils.KMSKeyIDFlag,
+					utils.KMSRegionFlag,
+					utils.KMSKeyFileFlag,
+					utils.KMSVaultAddrFlag,
+					utils.KMSVaultMountFlag,
 				},
 				Description: `
     gwan account decrypt <address>
 
 Decrypt an existing account.
 
-The account will be decrypted by AWS KMS, and plaintext will be saved into new file named as "<original-name>"
+The account will be decrypted by the key encryption provider selected with --kms.provider,
+and plaintext will be saved into new file named as "<original-name>"
 `,
 			},
 		},
@@ -283,7 +323,9 @@ func unlockAccount(ctx *cli.Context, ks *keystore.KeyStore, address string, i in
 	return accounts.Account{}, ""
 }
 
-func unlockAccountFromAwsKmsFile(ctx *cli.Context, ks *keystore.KeyStore, address string, i int, passwords []string) (accounts.Account, string) {
+// unlockAccountFromKMSFile decrypts the KMS encrypted keystore file of an
+// account with the configured key encryption provider, then unlocks the key.
+func unlockAccountFromKMSFile(ctx *cli.Context, ks *keystore.KeyStore, address string, i int, passwords []string) (accounts.Account, string) {
 	account, err := utils.MakeAddress(ks, address)
 	if err != nil {
 		utils.Fatalf("Could not list accounts: %v", err)
@@ -294,30 +336,29 @@ func unlockAccountFromAwsKmsFile(ctx *cli.Context, ks *keystore.KeyStore, addres
 		utils.Fatalf("Could not find the account: %v", err)
 	}
 
-	var trials int
+	cfg := makeKMSConfig(ctx)
 	var keyjson []byte
-	for ; trials < 3; trials++ {
-		prompt := fmt.Sprintf("AWS KMS decrypting account %s | Attempt %d/%d", address, trials+1, 3)
-		keyNames := [3]string{"aKID", "secretKey", "region"}
-		kmsInfo, err := getAwsKmsSecretInfo(prompt, keyNames[:])
+	for trials := 0; trials < 3; trials++ {
+		fmt.Printf("KMS decrypting account %s | Attempt %d/%d\n", address, trials+1, 3)
+		provider, err := kms.NewProvider(&cfg, promptKMSSecret, false)
 		if err != nil {
-			utils.Fatalf("Failed to read input: %v", err)
+			utils.Fatalf("Failed to create key encryption provider: %v", err)
 		}
 
-		keyjson, err = awskms.DecryptFileToBuffer(a.URL.Path, kmsInfo[0], kmsInfo[1], kmsInfo[2])
+		keyjson, err = kms.DecryptFileToBuffer(provider, a.URL.Path)
 		if err != nil {
-			fmt.Println("invalid AWS KMS info, decrypt keystore file fail: ", err)
+			fmt.Println("invalid KMS info, decrypt keystore file fail: ", err)
 			continue
 		}
 
 		break
 	}
 
-	if trials == 3 || len(keyjson) == 0 {
-		utils.Fatalf("AWS KMS decrypt failed")
+	if len(keyjson) == 0 {
+		utils.Fatalf("KMS decrypt failed")
 	}
 
-	fmt.Println("AWS KMS decrypt successful")
+	fmt.Println("KMS decrypt successful")
 	for trials := 0; trials < 3; trials++ {
 		prompt := fmt.Sprintf("Unlocking account %s | Attempt %d/%d", address, trials+1, 3)
 		password := getPassPhrase(prompt, false, i, passwords)
@@ -399,15 +440,38 @@ func ambiguousAddrRecovery(ks *keystore.KeyStore, err *keystore.AmbiguousAddrErr
 
 // accountCreate creates a new account into the keystore defined by the CLI flags.
 func accountCreate(ctx *cli.Context) error {
+	var (
+		mnemonic string
+		path     accounts.DerivationPath
+		err      error
+	)
+	if ctx.Bool(mnemonicFlag.Name) {
+		if path, err = accounts.ParseDerivationPath(ctx.String(hdPathFlag.Name)); err != nil {
+			utils.Fatalf("Invalid derivation path: %v", err)
+		}
+		if mnemonic, err = keystore.NewMnemonic(); err != nil {
+			utils.Fatalf("Failed to generate mnemonic: %v", err)
+		}
+	}
 	stack, _ := makeConfigNode(ctx)
 	password := getPassPhrase("Your new account is locked with a password. Please give a password. Do not forget this password.", true, 0, utils.MakePasswordList(ctx))
 
 	ks := stack.AccountManager().Backends(keystore.KeyStoreType)[0].(*keystore.KeyStore)
-	account, err := ks.NewAccount(password)
+	var account accounts.Account
+	if mnemonic != "" {
+		account, err = ks.ImportMnemonic(mnemonic, "", path, password)
+	} else {
+		account, err = ks.NewAccount(password)
+	}
 	if err != nil {
 		utils.Fatalf("Failed to create account: %v", err)
 	}
 	fmt.Printf("Address: {%s}\n", account.Address.Hex()[2:])
+	if mnemonic != "" {
+		fmt.Printf("Path: %s\n", path)
+		fmt.Printf("Mnemonic: %s\n", mnemonic)
+		fmt.Println("Write the mnemonic down and keep it safe, anyone knowing it controls the account.")
+	}
 	return nil
 }
 
@@ -453,6 +517,9 @@ func importWallet(ctx *cli.Context) error {
 }
 
 func accountImport(ctx *cli.Context) error {
+	if ctx.Bool(mnemonicFlag.Name) {
+		return accountImportMnemonic(ctx)
+	}
 	keyfile := ctx.Args().First()
 	if len(keyfile) == 0 {
 		utils.Fatalf("keyfile must be given as argument")
@@ -473,6 +540,35 @@ func accountImport(ctx *cli.Context) error {
 	return nil
 }
 
+// accountImportMnemonic recovers the account derived from a BIP39 mnemonic into
+// the keystore defined by the CLI flags.
+func accountImportMnemonic(ctx *cli.Context) error {
+	path, err := accounts.ParseDerivationPath(ctx.String(hdPathFlag.Name))
+	if err != nil {
+		utils.Fatalf("Invalid derivation path: %v", err)
+	}
+	var mnemonic string
+	if file := ctx.Args().First(); file != "" {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			utils.Fatalf("Failed to read the mnemonic: %v", err)
+		}
+		mnemonic = string(data)
+	} else if mnemonic, err = console.Stdin.PromptPassword("Mnemonic: "); err != nil {
+		utils.Fatalf("Failed to read the mnemonic: %v", err)
+	}
+	stack, _ := makeConfigNode(ctx)
+	passphrase := getPassPhrase("Your new account is locked with a password. Please give a password. Do not forget this password.", true, 0, utils.MakePasswordList(ctx))
+
+	ks := stack.AccountManager().Backends(keystore.KeyStoreType)[0].(*keystore.KeyStore)
+	acct, err := ks.ImportMnemonic(mnemonic, "", path, passphrase)
+	if err != nil {
+		utils.Fatalf("Could not create the account: %v", err)
+	}
+	fmt.Printf("Address: {%s}\n", acct.Address.Hex()[2:])
+	return nil
+}
+
 func showPublicKey(ctx *cli.Context) error {
 	addrstr := ctx.Args().Get(0)
 	passwd := ctx.Args().Get(1)
@@ -510,21 +606,20 @@ func showPublicKey(ctx *cli.Context) error {
 	return nil
 }
 
-// accountEncrypt encrypt an account using AWS KMS,
+// accountEncrypt encrypt an account using the configured key encryption provider,
 // and save ciphertext into new file named as "<original-name>-cipher"
 func accountEncrypt(ctx *cli.Context) error {
 	if len(ctx.Args()) == 0 {
 		utils.Fatalf("No accounts specified to encrypt")
 	}
 
-	keyNames := [4]string{"aKID", "secretKey", "region", "keyId"}
-	keyVals, err := getAwsKmsSecretInfo("", keyNames[:])
+	stack, cfg := makeConfigNode(ctx)
+	provider, err := kms.NewProvider(&cfg.KMS, promptKMSSecret, true)
 	if err != nil {
 		return err
 	}
 
 	fmt.Println("begin encrypting...")
-	stack, _ := makeConfigNode(ctx)
 	ks := stack.AccountManager().Backends(keystore.KeyStoreType)[0].(*keystore.KeyStore)
 	for _, addr := range ctx.Args() {
 		exceptAddr := common.HexToAddress(addr)
@@ -535,7 +630,7 @@ func accountEncrypt(ctx *cli.Context) error {
 		}
 
 		desFile := fa.URL.Path + keystore.AwsKMSCiphertextFileExt
-		err = awskms.EncryptFile(fa.URL.Path, desFile, keyVals[0], keyVals[1], keyVals[2], keyVals[3])
+		err = kms.EncryptFile(provider, fa.URL.Path, desFile)
 		if err != nil {
 			return err
 		}
@@ -546,21 +641,20 @@ func accountEncrypt(ctx *cli.Context) error {
 	return nil
 }
 
-// accountDecrypt decrypt an account using AWS KMS,
-// and save ciphertext into new file named as "<original-name>-plain"
+// accountDecrypt decrypt an account using the configured key encryption provider,
+// and save plaintext into new file named as "<original-name>"
 func accountDecrypt(ctx *cli.Context) error {
 	if len(ctx.Args()) == 0 {
 		utils.Fatalf("No accounts specified to decrypt")
 	}
 
-	keyNames := [3]string{"aKID", "secretKey", "region"}
-	keyVals, err := getAwsKmsSecretInfo("", keyNames[:])
+	stack, cfg := makeConfigNode(ctx)
+	provider, err := kms.NewProvider(&cfg.KMS, promptKMSSecret, false)
 	if err != nil {
 		return err
 	}
 
 	fmt.Println("begin decrypting...")
-	stack, _ := makeConfigNode(ctx)
 	ks := stack.AccountManager().Backends(keystore.KeyStoreType)[0].(*keystore.KeyStore)
 	for _, addr := range ctx.Args() {
 		exceptAddr := common.HexToAddress(addr)
@@ -578,7 +672,7 @@ func accountDecrypt(ctx *cli.Context) error {
 			desFile = fa.URL.Path + "-plain"
 		}
 
-		err = awskms.DecryptFile(fa.URL.Path, desFile, keyVals[0], keyVals[1], keyVals[2])
+		err = kms.DecryptFile(provider, fa.URL.Path, desFile)
 		if err != nil {
 			return err
 		}
@@ -589,18 +683,8 @@ func accountDecrypt(ctx *cli.Context) error {
 	return nil
 }
 
-func getAwsKmsSecretInfo(notice string, items []string) ([]string, error) {
-	inputs := make([]string, len(items))
-	fmt.Println(notice)
-	for i, name := range items {
-		input, err := console.Stdin.PromptPassword(name + ": ")
-		if err != nil {
-			return nil, err
-		}
-
-		inputs[i] = input
-	}
-
-	return inputs, nil
+// promptKMSSecret asks the user for a setting or secret of the key encryption
+// provider.
+func promptKMSSecret(name string) (string, error) {
+	return console.Stdin.PromptPassword(name + ": ")
 }
-
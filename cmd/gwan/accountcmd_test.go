@@ -18,6 +18,7 @@ package main
 
 import (
 	"io/ioutil"
+	"os"
 	"path/filepath"
 	"runtime"
 	"strings"
@@ -90,6 +91,25 @@ Fatal: Passphrases do not match
 `)
 }
 
+func TestAccountImportMnemonic(t *testing.T) {
+	datadir := tmpdir(t)
+	defer os.RemoveAll(datadir)
+	mnemonicfile := filepath.Join(datadir, "mnemonic")
+	mnemonic := "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about\n"
+	if err := ioutil.WriteFile(mnemonicfile, []byte(mnemonic), 0600); err != nil {
+		t.Fatal(err)
+	}
+	geth := runGeth(t, "account", "import", "--datadir", datadir, "--lightkdf", "--mnemonic", mnemonicfile)
+	defer geth.ExpectExit()
+	geth.Expect(`
+Your new account is locked with a password. Please give a password. Do not forget this password.
+!! Unsupported terminal, password will be echoed.
+Passphrase: {{.InputLine "foobar"}}
+Repeat passphrase: {{.InputLine "foobar"}}
+Address: {e5524b1E073bA7dAF47954f8692044d7cB24E0DD}
+`)
+}
+
 func TestAccountUpdate(t *testing.T) {
 	datadir := tmpDatadirWithKeystore(t)
 	geth := runGeth(t, "account", "update",
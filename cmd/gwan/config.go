@@ -32,6 +32,7 @@ import (
 	"github.com/wanchain/go-wanchain/cmd/utils"
 	"github.com/wanchain/go-wanchain/contracts/release"
 	"github.com/wanchain/go-wanchain/eth"
+	"github.com/wanchain/go-wanchain/kms"
 	"github.com/wanchain/go-wanchain/node"
 	"github.com/wanchain/go-wanchain/params"
 	"github.com/wanchain/go-wanchain/pos/posconfig"
@@ -85,6 +86,7 @@ type gethConfig struct {
 	Shh      whisper.Config
 	Node     node.Config
 	Ethstats ethstatsConfig
+	KMS      kms.Config
 }
 
 func loadConfig(file string, cfg *gethConfig) error {
@@ -120,6 +122,7 @@ func makeConfigNode(ctx *cli.Context) (*node.Node, gethConfig) {
 		Eth:  eth.DefaultConfig,
 		Shh:  whisper.DefaultConfig,
 		Node: defaultNodeConfig(),
+		KMS:  kms.DefaultConfig,
 	}
 
 	// Load config file.
@@ -131,6 +134,7 @@ func makeConfigNode(ctx *cli.Context) (*node.Node, gethConfig) {
 
 	// Apply flags.
 	utils.SetNodeConfig(ctx, &cfg.Node)
+	utils.SetKMSConfig(ctx, &cfg.KMS)
 	stack, err := node.New(&cfg.Node)
 	if err != nil {
 		utils.Fatalf("Failed to create the protocol stack: %v", err)
@@ -145,10 +149,24 @@ func makeConfigNode(ctx *cli.Context) (*node.Node, gethConfig) {
 	//Init wanpos private db
 	posdb.DbInitAll(cfg.Node.DataDir)
 	posconfig.Init(&cfg.Node, cfg.Eth.NetworkId)
+	posconfig.Cfg().EpochRetention = ctx.GlobalUint64(utils.PosRetentionFlag.Name)
 
 	return stack, cfg
 }
 
+// makeKMSConfig loads the key encryption provider settings from the config file
+// and the command line flags.
+func makeKMSConfig(ctx *cli.Context) kms.Config {
+	cfg := gethConfig{KMS: kms.DefaultConfig}
+	if file := ctx.GlobalString(configFileFlag.Name); file != "" {
+		if err := loadConfig(file, &cfg); err != nil {
+			utils.Fatalf("%v", err)
+		}
+	}
+	utils.SetKMSConfig(ctx, &cfg.KMS)
+	return cfg.KMS
+}
+
 // enableWhisper returns true in case one of the whisper flags is set.
 func enableWhisper(ctx *cli.Context) bool {
 	for _, flag := range whisperFlags {
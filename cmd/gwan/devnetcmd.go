@@ -0,0 +1,100 @@
+// Copyright 2015 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+
+	"github.com/wanchain/go-wanchain/cmd/utils"
+	"github.com/wanchain/go-wanchain/log"
+	"github.com/wanchain/go-wanchain/pos/devnet"
+	"github.com/wanchain/go-wanchain/pos/staking"
+	"gopkg.in/urfave/cli.v1"
+)
+
+var (
+	devnetValidatorsFlag = cli.IntFlag{
+		Name:  "validators",
+		Usage: "Number of validators",
+		Value: devnet.DefaultConfig.Validators,
+	}
+	devnetStakeFlag = cli.StringFlag{
+		Name:  "stake",
+		Usage: "Genesis stake of each validator in wan (default 400000)",
+	}
+	devnetDirFlag = cli.StringFlag{
+		Name:  "dir",
+		Usage: "Directory of the validator nodes (default a temporary one, removed on exit)",
+	}
+
+	devnetCommand = cli.Command{
+		Action:    utils.MigrateFlags(runDevnet),
+		Name:      "devnet",
+		Usage:     "Run a local PoS network of validators",
+		ArgsUsage: " ",
+		Category:  "MISCELLANEOUS COMMANDS",
+		Flags: []cli.Flag{
+			devnetValidatorsFlag,
+			devnetStakeFlag,
+			devnetDirFlag,
+		},
+		Description: `
+The devnet command boots a PoS network of validators on the local host, each
+one running in its own process, until it is interrupted. The validators are
+generated and staked in the genesis block. The first one replaces the plutodev
+white list, leading the epochs until the stakers are selected.
+
+Build gwan with the devnet tag for slots of 2 seconds and epochs of 144 slots.
+
+Attach to the printed IPC endpoints to follow the network.`,
+	}
+)
+
+// runDevnet runs a devnet until interrupted.
+func runDevnet(ctx *cli.Context) error {
+	config := devnet.DefaultConfig
+	config.Validators = ctx.Int(devnetValidatorsFlag.Name)
+	config.BaseDir = ctx.String(devnetDirFlag.Name)
+	if s := ctx.String(devnetStakeFlag.Name); s != "" {
+		stake, err := staking.ParseAmount(s)
+		if err != nil {
+			utils.Fatalf("Invalid stake: %v", err)
+		}
+		config.Stake = stake
+	}
+
+	n, err := devnet.New(config)
+	if err != nil {
+		utils.Fatalf("Failed to create devnet: %v", err)
+	}
+	defer n.Stop()
+	if err := n.Start(); err != nil {
+		return err
+	}
+	for i := 0; i < n.Len(); i++ {
+		fmt.Printf("%s: validator %x, IPC %s\n", n.Name(i), n.Validators[i].Address, n.IPCEndpoint(i))
+	}
+
+	sigc := make(chan os.Signal, 1)
+	signal.Notify(sigc, os.Interrupt)
+	defer signal.Stop(sigc)
+	<-sigc
+	log.Info("Got interrupt, shutting down devnet...")
+	return nil
+}
@@ -0,0 +1,107 @@
+// Copyright 2015 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/syndtr/goleveldb/leveldb/util"
+	"github.com/wanchain/go-wanchain/cmd/utils"
+	"github.com/wanchain/go-wanchain/common"
+	"github.com/wanchain/go-wanchain/core"
+	"github.com/wanchain/go-wanchain/ethdb"
+	"github.com/wanchain/go-wanchain/log"
+	"gopkg.in/urfave/cli.v1"
+)
+
+// freezerMigrateBatch is the number of blocks frozen at once by the migration.
+const freezerMigrateBatch = 100000
+
+var freezerCommand = cli.Command{
+	Name:     "freezer",
+	Usage:    "Manage the freezer of the old blocks",
+	Category: "BLOCKCHAIN COMMANDS",
+	Subcommands: []cli.Command{
+		{
+			Name:   "migrate",
+			Usage:  "Move the old blocks of an existing chain database to the freezer",
+			Action: utils.MigrateFlags(migrateFreezer),
+			Flags: []cli.Flag{
+				utils.DataDirFlag,
+				utils.CacheFlag,
+				utils.FreezerThresholdFlag,
+			},
+			Description: `
+    gwan freezer migrate [--freezer.threshold N]
+
+Moves the headers, bodies, receipts and total difficulties of the canonical
+blocks, but the latest N ones, from the LevelDB chain database to the freezer,
+append-only flat files in the ancient directory of the chain database. LevelDB
+is compacted afterwards to reclaim the disk space.
+
+A running node moves the blocks both stable and buried under N blocks to the
+freezer in the background. This command moves the blocks of a datadir filled
+before at once. The node must be stopped, and the pos stability of the blocks
+is not checked: N must stay above the depth of any reorganisation.`,
+		},
+	},
+}
+
+func migrateFreezer(ctx *cli.Context) error {
+	stack, _ := makeConfigNode(ctx)
+	chainDb := utils.MakeChainDatabase(ctx, stack)
+	defer chainDb.Close()
+
+	db, ok := chainDb.(*ethdb.LDBDatabase)
+	if !ok || db.Freezer() == nil {
+		utils.Fatalf("The chain database has no freezer")
+	}
+	hash := core.GetHeadBlockHash(db)
+	head := core.GetHeader(db, hash, core.GetBlockNumber(db, hash))
+	if head == nil {
+		utils.Fatalf("The chain database has no head block")
+	}
+	threshold := ctx.GlobalUint64(utils.FreezerThresholdFlag.Name)
+	if head.Number.Uint64() < threshold {
+		fmt.Printf("The chain has only %d blocks, none is moved\n", head.Number.Uint64()+1)
+		return nil
+	}
+	limit := head.Number.Uint64() - threshold
+
+	start, moved := time.Now(), uint64(0)
+	for {
+		frozen, err := core.FreezeBlocks(db, limit, freezerMigrateBatch)
+		moved += frozen
+		if err != nil {
+			utils.Fatalf("Moving the blocks failed: %v", err)
+		}
+		if frozen == 0 {
+			break
+		}
+		log.Info("Moved blocks to the freezer", "frozen", db.Ancients(), "limit", limit, "elapsed", common.PrettyDuration(time.Since(start)))
+	}
+	fmt.Printf("Moved %d blocks to the freezer in %v, %d blocks frozen\n", moved, time.Since(start), db.Ancients())
+
+	start = time.Now()
+	fmt.Println("Compacting entire database...")
+	if err := db.LDB().CompactRange(util.Range{}); err != nil {
+		utils.Fatalf("Compaction failed: %v", err)
+	}
+	fmt.Printf("Compaction done in %v.\n", time.Since(start))
+	return nil
+}
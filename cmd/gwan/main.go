@@ -67,6 +67,8 @@ var (
 		utils.DataDirFlag,
 		utils.KeyStoreDirFlag,
 		utils.NoUSBFlag,
+		utils.RemoteSignerFlag,
+		utils.RemoteSignerTokenFlag,
 		utils.EthashCacheDirFlag,
 		utils.EthashCachesInMemoryFlag,
 		utils.EthashCachesOnDiskFlag,
@@ -92,6 +94,7 @@ var (
 		utils.LightKDFFlag,
 		utils.CacheFlag,
 		utils.TrieCacheGenFlag,
+		utils.FreezerThresholdFlag,
 		utils.ListenPortFlag,
 		utils.MaxPeersFlag,
 		utils.MaxPendingPeersFlag,
@@ -109,6 +112,7 @@ var (
 		utils.DevModeFlag,
 		utils.TestnetFlag,
 		utils.FirstPos,
+		utils.PosRetentionFlag,
 		utils.DevInternalFlag,
 
 		utils.PlutoFlag,
@@ -130,6 +134,18 @@ var (
 		configFileFlag,
 
 		utils.AwsKmsFlag,
+		utils.KMSProviderFlag,
+		utils.KMSKeyIDFlag,
+		utils.KMSRegionFlag,
+		utils.KMSKeyFileFlag,
+		utils.KMSVaultAddrFlag,
+		utils.KMSVaultMountFlag,
+		utils.OTAScanFlag,
+		utils.StakingIndexFlag,
+		utils.ValidatorHealthFlag,
+		utils.ValidatorHealthMissedSlotsFlag,
+		utils.ValidatorHealthWebhookFlag,
+		utils.ValidatorHealthExecFlag,
 	}
 
 	rpcFlags = []cli.Flag{
@@ -181,6 +197,16 @@ func init() {
 		accountCommand,
 		walletCommand,
 		transactionCommand,
+		// See stakingcmd.go:
+		stakingCommand,
+		// See poscmd.go:
+		posCommand,
+		// See snapshotcmd.go:
+		snapshotCommand,
+		// See freezercmd.go:
+		freezerCommand,
+		// See devnetcmd.go:
+		devnetCommand,
 		// See consolecmd.go:
 		consoleCommand,
 		attachCommand,
@@ -273,7 +299,7 @@ func startNode(ctx *cli.Context, stack *node.Node) {
 	for i, account := range unlocks {
 		if trimmed := strings.TrimSpace(account); trimmed != "" {
 			if ctx.IsSet(utils.AwsKmsFlag.Name) {
-				unlockAccountFromAwsKmsFile(ctx, ks, trimmed, i, passwords)
+				unlockAccountFromKMSFile(ctx, ks, trimmed, i, passwords)
 			} else {
 				unlockAccount(ctx, ks, trimmed, i, passwords)
 			}
@@ -0,0 +1,282 @@
+// Copyright 2015 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/wanchain/go-wanchain/accounts/keystore"
+	"github.com/wanchain/go-wanchain/cmd/utils"
+	"github.com/wanchain/go-wanchain/common"
+	"github.com/wanchain/go-wanchain/core"
+	"github.com/wanchain/go-wanchain/core/state"
+	"github.com/wanchain/go-wanchain/core/vm"
+	"github.com/wanchain/go-wanchain/log"
+	"github.com/wanchain/go-wanchain/node"
+	"github.com/wanchain/go-wanchain/pos/epochLeader"
+	"github.com/wanchain/go-wanchain/pos/incentive"
+	"github.com/wanchain/go-wanchain/pos/posconfig"
+	"github.com/wanchain/go-wanchain/pos/posdb"
+	"github.com/wanchain/go-wanchain/pos/possigner"
+	"github.com/wanchain/go-wanchain/pos/slotleader"
+	"github.com/wanchain/go-wanchain/pos/util"
+	"gopkg.in/urfave/cli.v1"
+)
+
+var (
+	posFromEpochFlag = cli.Uint64Flag{
+		Name:  "from-epoch",
+		Usage: "First epoch to process (default: the first epoch with selected leaders)",
+	}
+
+	posCommand = cli.Command{
+		Name:     "pos",
+		Usage:    "Maintain the pos databases of the node",
+		Category: "BLOCKCHAIN COMMANDS",
+		Subcommands: []cli.Command{
+			{
+				Name:   "rebuild-incentive",
+				Usage:  "Regenerate the incentive history from the chain",
+				Action: utils.MigrateFlags(rebuildIncentive),
+				Flags: []cli.Flag{
+					utils.DataDirFlag,
+					utils.CacheFlag,
+				},
+				Description: `
+    gwan pos rebuild-incentive
+
+The incentive history served by pos_getEpochIncentivePayDetail and the other
+incentive RPCs is recorded as the blocks are imported. This command recomputes
+it from the canonical chain, replacing the records of every epoch. It needs the
+states of the blocks which paid the incentives and of their parents, which
+gwan snapshot prune-state keeps, and must be run with the node stopped.
+
+A fast sync imports the blocks before its pivot without executing them, so
+their incentives are not recorded and the node warns at startup until this
+command succeeds on a chain database holding their states.`,
+			},
+			{
+				Name:   "rebuild-db",
+				Usage:  "Regenerate the pos databases from the chain",
+				Action: utils.MigrateFlags(rebuildPosDb),
+				Flags: []cli.Flag{
+					utils.DataDirFlag,
+					utils.CacheFlag,
+					utils.UnlockedAccountFlag,
+					utils.PasswordFileFlag,
+					posFromEpochFlag,
+				},
+				Description: `
+    gwan pos rebuild-db [--from-epoch N] [--unlock validator]
+
+The epoch leaders, random proposers and staker snapshots of each epoch are
+selected from the chain and kept in the pos databases of the node. This command
+selects them again from the canonical blocks and states, replacing the stored
+ones from epoch N on, along with the stake-out records of the epochs. With
+--unlock, the security messages the validator generated as an epoch leader are
+rebuilt too.
+
+It needs the states of the last blocks of the epochs and of the blocks running
+the stake-outs and their parents, which gwan snapshot prune-state keeps, and
+must be run with the node stopped.`,
+			},
+			{
+				Name:   "verify-db",
+				Usage:  "Check the pos databases against the chain",
+				Action: utils.MigrateFlags(verifyPosDb),
+				Flags: []cli.Flag{
+					utils.DataDirFlag,
+					utils.CacheFlag,
+					utils.UnlockedAccountFlag,
+					utils.PasswordFileFlag,
+					posFromEpochFlag,
+				},
+				Description: `
+    gwan pos verify-db [--from-epoch N] [--unlock validator]
+
+Selects the leaders of each epoch from epoch N on again from the chain, like
+rebuild-db, and reports where the pos databases differ. With --unlock, the
+security messages of the validator are checked too. It exits with an error if
+any difference is found, run rebuild-db to repair the databases.`,
+			},
+		},
+	}
+)
+
+func rebuildIncentive(ctx *cli.Context) error {
+	stack, _ := makeConfigNode(ctx)
+	chain, chainDb := utils.MakeChain(ctx, stack)
+	defer chainDb.Close()
+
+	epocher := posSetup(chain)
+	if epocher == nil {
+		epocher = epochLeader.NewEpocher(chain)
+	}
+	incentive.Init(epocher.GetEpochProbability, epocher.SetEpochIncentive, epocher.GetRBProposerGroup)
+
+	stakers := func(epochID uint64, feeRateState *state.StateDB) (incentive.GetStakerInfoFn, error) {
+		// The leaders are kept in the local pos database, select them again if
+		// it lost them.
+		if !epocher.IsGenerateELSuc(epochID) || !epocher.IsGenerateRBPSuc(epochID) {
+			if err := epocher.SelectLeadersLoop(epochID); err != nil {
+				return nil, err
+			}
+		}
+		return func(epochID uint64, addr common.Address) (*vm.ValidatorInfo, error) {
+			return epocher.GetEpochProbabilityAt(epochID, addr, feeRateState)
+		}, nil
+	}
+
+	start := time.Now()
+	count, err := incentive.Rebuild(chain, chainDb, stakers)
+	if err != nil {
+		utils.Fatalf("Incentive rebuild failed after %d epochs: %v", count, err)
+	}
+	fmt.Printf("Rebuilt the incentive of %d epochs in %v\n", count, time.Since(start))
+	return nil
+}
+
+// posSetup sets the pos modules up over chain, the node being stopped. It
+// returns the epocher of the chain, or nil if the chain has no pos block.
+func posSetup(chain *core.BlockChain) *epochLeader.Epocher {
+	posconfig.Pow2PosUpgradeBlockNumber = chain.Config().PosFirstBlock.Uint64()
+	h := chain.GetHeaderByNumber(posconfig.Pow2PosUpgradeBlockNumber)
+	if h == nil {
+		return nil
+	}
+	posconfig.FirstEpochId, _ = util.CalEpSlbyTd(h.Difficulty.Uint64())
+	return epochLeader.NewEpocher(chain)
+}
+
+// posdbEpochs sets the pos modules up over chain, and returns the epochs whose
+// leaders are selected from it: the ones from --from-epoch on, up to the next
+// epoch if its leaders are already selected.
+func posdbEpochs(ctx *cli.Context, chain *core.BlockChain) (epocher *epochLeader.Epocher, first, last, head uint64) {
+	if epocher = posSetup(chain); epocher == nil {
+		utils.Fatalf("The chain has no pos block")
+	}
+
+	head, slot := util.GetEpochSlotIDFromDifficulty(chain.CurrentHeader().Difficulty)
+	first = posconfig.FirstEpochId + 2
+	if from := ctx.Uint64(posFromEpochFlag.Name); from > first {
+		first = from
+	}
+	last = head
+	if slot >= 2*posconfig.K+1 {
+		last++
+	}
+	if first > last {
+		utils.Fatalf("No leaders selected from epoch %d on, the chain is at epoch %d", first, head)
+	}
+	return epocher, first, last, head
+}
+
+// posdbSigner unlocks the validator of --unlock, whose security messages are
+// processed. It returns nil if no validator is given.
+func posdbSigner(ctx *cli.Context, stack *node.Node) posconfig.MinerSigner {
+	addr := strings.TrimSpace(ctx.String(utils.UnlockedAccountFlag.Name))
+	if addr == "" {
+		return nil
+	}
+	ks := stack.AccountManager().Backends(keystore.KeyStoreType)[0].(*keystore.KeyStore)
+	account, _ := unlockAccount(ctx, ks, addr, 0, utils.MakePasswordList(ctx))
+	wallet, err := stack.AccountManager().Find(account)
+	if err != nil {
+		utils.Fatalf("Could not find the validator wallet: %v", err)
+	}
+	signer, err := possigner.FromWallet(wallet, account.Address)
+	if err != nil {
+		utils.Fatalf("Could not use the validator key: %v", err)
+	}
+	return signer
+}
+
+func rebuildPosDb(ctx *cli.Context) error {
+	stack, _ := makeConfigNode(ctx)
+	chain, chainDb := utils.MakeChain(ctx, stack)
+	defer chainDb.Close()
+
+	epocher, first, last, head := posdbEpochs(ctx, chain)
+	signer := posdbSigner(ctx, stack)
+
+	start := time.Now()
+	msgs, stakeOuts := 0, 0
+	for epochID := first; epochID <= last; epochID++ {
+		if err := epocher.RebuildSelection(epochID); err != nil {
+			utils.Fatalf("Leader selection of epoch %d failed: %v", epochID, err)
+		}
+		stored, err := epocher.RebuildStakeOut(epochID)
+		if err != nil {
+			utils.Fatalf("Stake-out of epoch %d failed: %v", epochID, err)
+		}
+		if stored {
+			stakeOuts++
+		}
+		// The security message of the current epoch may not be generated yet
+		if signer != nil && epochID < head {
+			stored, err := slotleader.RebuildSecurityMsg(chain, epocher, epochID, signer)
+			if err != nil {
+				utils.Fatalf("Security message of epoch %d failed: %v", epochID, err)
+			}
+			if stored {
+				msgs++
+			}
+		}
+		log.Info("Rebuilt pos data of epoch", "epochID", epochID)
+	}
+	fmt.Printf("Rebuilt the pos data of epochs %d to %d, with %d stake-outs and %d security messages, in %v\n", first, last, stakeOuts, msgs, time.Since(start))
+	return nil
+}
+
+func verifyPosDb(ctx *cli.Context) error {
+	stack, _ := makeConfigNode(ctx)
+	chain, chainDb := utils.MakeChain(ctx, stack)
+	defer chainDb.Close()
+
+	epocher, first, last, head := posdbEpochs(ctx, chain)
+	signer := posdbSigner(ctx, stack)
+
+	count := 0
+	for epochID := first; epochID <= last; epochID++ {
+		diffs, err := epocher.VerifySelection(epochID)
+		if err != nil {
+			utils.Fatalf("Leader selection of epoch %d failed: %v", epochID, err)
+		}
+		if signer != nil && epochID < head {
+			want, err := slotleader.SecurityMsgOf(chain, epocher, epochID, signer)
+			if err != nil {
+				utils.Fatalf("Security message of epoch %d failed: %v", epochID, err)
+			}
+			have, _ := posdb.GetDb().Get(epochID+1, slotleader.SecurityMsg)
+			if want != nil && !bytes.Equal(have, want) {
+				diffs = append(diffs, "security message differs")
+			}
+		}
+		for _, diff := range diffs {
+			fmt.Printf("Epoch %d: %s\n", epochID, diff)
+		}
+		count += len(diffs)
+	}
+	if count > 0 {
+		utils.Fatalf("Found %d differences in the pos databases, run gwan pos rebuild-db to repair them", count)
+	}
+	fmt.Printf("The pos data of epochs %d to %d matches the chain\n", first, last)
+	return nil
+}
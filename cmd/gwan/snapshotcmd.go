@@ -0,0 +1,181 @@
+// Copyright 2015 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/wanchain/go-wanchain/cmd/utils"
+	"github.com/wanchain/go-wanchain/common"
+	"github.com/wanchain/go-wanchain/core"
+	"github.com/wanchain/go-wanchain/core/state/pruner"
+	"github.com/wanchain/go-wanchain/core/vm"
+	"github.com/wanchain/go-wanchain/ethdb"
+	"github.com/wanchain/go-wanchain/pos/incentive"
+	"github.com/wanchain/go-wanchain/pos/posconfig"
+	"github.com/wanchain/go-wanchain/pos/util"
+	"gopkg.in/urfave/cli.v1"
+)
+
+var (
+	pruneKeepBlocksFlag = cli.Uint64Flag{
+		Name:  "keep-blocks",
+		Usage: "Number of recent blocks whose state is kept",
+		Value: 128,
+	}
+
+	snapshotCommand = cli.Command{
+		Name:     "snapshot",
+		Usage:    "Maintain the state database of the node",
+		Category: "BLOCKCHAIN COMMANDS",
+		Subcommands: []cli.Command{
+			{
+				Name:   "prune-state",
+				Usage:  "Delete the historical states no longer needed",
+				Action: utils.MigrateFlags(pruneState),
+				Flags: []cli.Flag{
+					utils.DataDirFlag,
+					utils.CacheFlag,
+					pruneKeepBlocksFlag,
+				},
+				Description: `
+    gwan snapshot prune-state [--keep-blocks N]
+
+Deletes from the chain database the states of the blocks, but the ones of the
+latest N blocks, of the genesis block and of the last block of every epoch.
+The pos modules select the leaders and compute the incentives of an epoch from
+the states of the last blocks of the epochs before. The states of the blocks
+which ran the incentive and the stake-out of every epoch, and of their parents,
+are kept too for gwan pos rebuild-incentive and rebuild-db.
+
+The rebuild commands compute the incentives and stake-outs again from those
+states only, and fail naming the epoch when the state of a block which ran
+them is missing.
+
+The storage of the privacy contracts only grows, the head state keeps all the
+OTAs. It is checked to be complete before and after pruning.
+
+The node must be stopped. The historical states can't be served, nor traced,
+once pruned.`,
+			},
+		},
+	}
+)
+
+func pruneState(ctx *cli.Context) error {
+	stack, _ := makeConfigNode(ctx)
+	chain, chainDb := utils.MakeChain(ctx, stack)
+	defer chainDb.Close()
+
+	db, ok := chainDb.(*ethdb.LDBDatabase)
+	if !ok {
+		utils.Fatalf("The chain database can't be pruned")
+	}
+	keep := ctx.Uint64(pruneKeepBlocksFlag.Name)
+	if keep == 0 {
+		keep = 1
+	}
+
+	// The state of the head comes first, it must be complete
+	head := chain.CurrentBlock()
+	roots := []common.Hash{head.Root()}
+	seen := map[common.Hash]bool{head.Root(): true}
+	retain := func(number uint64) {
+		if header := chain.GetHeaderByNumber(number); header != nil && !seen[header.Root] {
+			roots = append(roots, header.Root)
+			seen[header.Root] = true
+		}
+	}
+	for i := uint64(1); i < keep && i <= head.NumberU64(); i++ {
+		retain(head.NumberU64() - i)
+	}
+	retain(0)
+	if epocher := posSetup(chain); epocher != nil {
+		// The leaders of the first pos epochs are selected from the last pow block
+		if posconfig.Pow2PosUpgradeBlockNumber > 0 {
+			retain(posconfig.Pow2PosUpgradeBlockNumber - 1)
+		}
+		headEpoch, _ := util.GetEpochSlotIDFromDifficulty(head.Difficulty())
+		for epochID := posconfig.FirstEpochId; epochID <= headEpoch; epochID++ {
+			retain(epocher.GetEpochLastBlkNumber(epochID))
+		}
+		for _, number := range epochRunBlocks(chain, head.NumberU64()) {
+			retain(number - 1)
+			retain(number)
+		}
+	}
+
+	otaAddrs := vm.OTAStorageAddrs()
+	if err := pruner.CheckStorage(db, head.Root(), otaAddrs); err != nil {
+		utils.Fatalf("The OTA storage of the head state is incomplete, not pruning: %v", err)
+	}
+	chain.Stop()
+
+	start := time.Now()
+	stats, err := pruner.Prune(db, roots)
+	if err != nil {
+		utils.Fatalf("State pruning failed: %v", err)
+	}
+	if err := pruner.CheckStorage(db, head.Root(), otaAddrs); err != nil {
+		utils.Fatalf("The OTA storage of the head state is incomplete after pruning: %v", err)
+	}
+	for _, root := range stats.Missing {
+		fmt.Printf("State %x was already incomplete\n", root)
+	}
+	fmt.Printf("Kept %d states, deleted %d entries (%v) in %v\n", len(roots), stats.Deleted, stats.Size, time.Since(start))
+	return nil
+}
+
+// epochRunBlocks returns the numbers of the blocks which ran the incentive or
+// the stake-out of an epoch, whose states and the ones of their parents the
+// rebuild commands read. The blocks whose state is already pruned are skipped.
+func epochRunBlocks(chain *core.BlockChain, head uint64) []uint64 {
+	var (
+		numbers         []uint64
+		epoch           uint64
+		paid, stakedOut bool
+	)
+	for number := util.FirstPosBlockNumber() + 1; number <= head; number++ {
+		header := chain.GetHeaderByNumber(number)
+		if header == nil {
+			break
+		}
+		epochID, slotID := util.GetEpochSlotIDFromDifficulty(header.Difficulty)
+		if epochID != epoch {
+			epoch, paid, stakedOut = epochID, false, false
+		}
+		if (paid && stakedOut) || !incentive.RunsAt(epochID, slotID) {
+			continue
+		}
+		stateDb, err := chain.StateAt(header.Root)
+		if err != nil {
+			continue
+		}
+		ran := false
+		if !paid && incentive.IsPaid(stateDb, epochID-posconfig.IncentiveDelayEpochs) {
+			paid, ran = true, true
+		}
+		if !stakedOut && vm.StakeoutIsFinished(stateDb, epochID) {
+			stakedOut, ran = true, true
+		}
+		if ran {
+			numbers = append(numbers, number)
+		}
+	}
+	return numbers
+}
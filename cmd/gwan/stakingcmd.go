@@ -0,0 +1,441 @@
+// Copyright 2015 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"strings"
+
+	"github.com/wanchain/go-wanchain/accounts"
+	"github.com/wanchain/go-wanchain/accounts/keystore"
+	"github.com/wanchain/go-wanchain/cmd/utils"
+	"github.com/wanchain/go-wanchain/common"
+	"github.com/wanchain/go-wanchain/common/hexutil"
+	"github.com/wanchain/go-wanchain/core/types"
+	"github.com/wanchain/go-wanchain/ethclient"
+	"github.com/wanchain/go-wanchain/params"
+	"github.com/wanchain/go-wanchain/pos/posapi"
+	posclient "github.com/wanchain/go-wanchain/pos/posapi/client"
+	"github.com/wanchain/go-wanchain/pos/staking"
+	"github.com/wanchain/go-wanchain/rlp"
+	"github.com/wanchain/go-wanchain/rpc"
+	"gopkg.in/urfave/cli.v1"
+)
+
+var (
+	stakingAttachFlag = cli.StringFlag{
+		Name:  "attach",
+		Usage: "API endpoint of a node to read the nonce, gas price and validator state from (default offline)",
+	}
+	stakingFromFlag = cli.StringFlag{
+		Name:  "from",
+		Usage: "Account sending and signing the transaction",
+	}
+	stakingValidatorFlag = cli.StringFlag{
+		Name:  "validator",
+		Usage: "Address of the validator",
+	}
+	stakingAmountFlag = cli.StringFlag{
+		Name:  "amount",
+		Usage: "Amount in wan, e.g. 10000.5",
+	}
+	stakingLockEpochsFlag = cli.Uint64Flag{
+		Name:  "lockepochs",
+		Usage: "Lock epochs of the stake",
+	}
+	stakingFeeRateFlag = cli.Uint64Flag{
+		Name:  "feerate",
+		Usage: "Fee rate charged to delegators in 1/10000, 10000 to refuse delegation",
+	}
+	stakingMaxFeeRateFlag = cli.Uint64Flag{
+		Name:  "maxfeerate",
+		Usage: "Maximum fee rate the validator may set later",
+	}
+	stakingRenewalFlag = cli.BoolFlag{
+		Name:  "renewal",
+		Usage: "Renew the partnership at the end of the lock epochs",
+	}
+	stakingSecPkFlag = cli.StringFlag{
+		Name:  "secpk",
+		Usage: "Hex secp256k1 public key of the validator (key1 of 'gwan account pubkeys')",
+	}
+	stakingBn256PkFlag = cli.StringFlag{
+		Name:  "bn256pk",
+		Usage: "Hex bn256 public key of the validator (key3 of 'gwan account pubkeys')",
+	}
+	stakingNonceFlag = cli.Uint64Flag{
+		Name:  "nonce",
+		Usage: "Nonce of the transaction (required offline)",
+	}
+	stakingGasLimitFlag = cli.Uint64Flag{
+		Name:  "gaslimit",
+		Usage: "Gas limit of the transaction",
+		Value: staking.DefaultGasLimit,
+	}
+	stakingChainIDFlag = cli.Uint64Flag{
+		Name:  "chainid",
+		Usage: "Chain id to sign for (default from the network flags)",
+	}
+	stakingOutFlag = cli.StringFlag{
+		Name:  "out",
+		Usage: "File to write the transaction to instead of the standard output",
+	}
+	stakingUnsignedFlag = cli.BoolFlag{
+		Name:  "unsigned",
+		Usage: "Output the unsigned transaction, e.g. to sign it on another machine",
+	}
+	stakingSendFlag = cli.BoolFlag{
+		Name:  "send",
+		Usage: "Send the signed transaction to the attached node",
+	}
+
+	stakingTxFlags = []cli.Flag{
+		utils.DataDirFlag,
+		utils.KeyStoreDirFlag,
+		utils.PasswordFileFlag,
+		utils.TestnetFlag,
+		utils.PlutoFlag,
+		utils.DevInternalFlag,
+		utils.GasPriceFlag,
+		stakingAttachFlag,
+		stakingFromFlag,
+		stakingNonceFlag,
+		stakingGasLimitFlag,
+		stakingChainIDFlag,
+		stakingOutFlag,
+		stakingUnsignedFlag,
+		stakingSendFlag,
+	}
+
+	stakingCommand = cli.Command{
+		Name:     "staking",
+		Usage:    "Build, validate, sign and send pos staking transactions",
+		Category: "STAKING COMMANDS",
+		Description: `
+The staking commands build the calls of the pos staking contract. Each call is
+validated with the rules of the transaction pool, and against the validator state
+when a node is attached with --attach.
+
+The transaction is then signed with the --from account of the keystore and
+printed as hex, written to --out or sent with --send. Without --attach nothing
+leaves the machine: pass --nonce and --gasprice, sign on an air-gapped box and
+send the output with 'gwan staking send' from another one. --unsigned outputs the
+unsigned transaction instead, to be signed with 'gwan staking sign'.`,
+		Subcommands: []cli.Command{
+			stakingSubcommand("stakein", "Register a validator", "--secpk <hex> --bn256pk <hex> --lockepochs <n> --feerate <n> --amount <wan>",
+				stakingSecPkFlag, stakingBn256PkFlag, stakingLockEpochsFlag, stakingFeeRateFlag, stakingAmountFlag),
+			stakingSubcommand("stakeregister", "Register a validator with a maximum fee rate", "--secpk <hex> --bn256pk <hex> --lockepochs <n> --feerate <n> --maxfeerate <n> --amount <wan>",
+				stakingSecPkFlag, stakingBn256PkFlag, stakingLockEpochsFlag, stakingFeeRateFlag, stakingMaxFeeRateFlag, stakingAmountFlag),
+			stakingSubcommand("stakeappend", "Add to the stake of a validator", "--validator <address> --amount <wan>",
+				stakingValidatorFlag, stakingAmountFlag),
+			stakingSubcommand("stakeupdate", "Set the lock epochs of the next staking period, 0 to quit", "--validator <address> --lockepochs <n>",
+				stakingValidatorFlag, stakingLockEpochsFlag),
+			stakingSubcommand("stakeupdatefeerate", "Change the fee rate of a validator", "--validator <address> --feerate <n>",
+				stakingValidatorFlag, stakingFeeRateFlag),
+			stakingSubcommand("partnerin", "Join the partners of a validator", "--validator <address> --amount <wan> [--renewal]",
+				stakingValidatorFlag, stakingAmountFlag, stakingRenewalFlag),
+			stakingSubcommand("delegatein", "Delegate to a validator", "--validator <address> --amount <wan>",
+				stakingValidatorFlag, stakingAmountFlag),
+			stakingSubcommand("delegateout", "Withdraw the delegation to a validator", "--validator <address>",
+				stakingValidatorFlag),
+			{
+				Name:      "sign",
+				Usage:     "Sign an unsigned staking transaction",
+				ArgsUsage: "<file>",
+				Action:    utils.MigrateFlags(stakingSign),
+				Flags:     stakingTxFlags,
+				Description: `
+    gwan staking sign --from <address> <file>
+
+Signs the unsigned transaction of a staking command run with --unsigned.`,
+			},
+			{
+				Name:      "send",
+				Usage:     "Validate and send a signed staking transaction",
+				ArgsUsage: "<file>",
+				Action:    utils.MigrateFlags(stakingSend),
+				Flags:     []cli.Flag{stakingAttachFlag},
+				Description: `
+    gwan staking send --attach <endpoint> <file>
+
+Sends the hex encoded signed transaction of a staking command to the attached node.`,
+			},
+		},
+	}
+)
+
+// stakingSubcommand creates the command building the staking call of name.
+func stakingSubcommand(name, usage, args string, flags ...cli.Flag) cli.Command {
+	return cli.Command{
+		Name:      name,
+		Usage:     usage,
+		ArgsUsage: " ",
+		Action: utils.MigrateFlags(func(ctx *cli.Context) error {
+			call, err := makeStakingCall(ctx, name)
+			if err != nil {
+				utils.Fatalf("Invalid %s call: %v", name, err)
+			}
+			return stakingTx(ctx, call, nil)
+		}),
+		Flags: append(flags, stakingTxFlags...),
+		Description: fmt.Sprintf(`
+    gwan staking %s %s --from <address>`, name, args),
+	}
+}
+
+// makeStakingCall builds and validates the staking call of a subcommand.
+func makeStakingCall(ctx *cli.Context, name string) (*staking.Call, error) {
+	var (
+		validator common.Address
+		amount    *big.Int
+		err       error
+	)
+	if ctx.IsSet(stakingValidatorFlag.Name) {
+		if !common.IsHexAddress(ctx.String(stakingValidatorFlag.Name)) {
+			return nil, fmt.Errorf("invalid validator address %q", ctx.String(stakingValidatorFlag.Name))
+		}
+		validator = common.HexToAddress(ctx.String(stakingValidatorFlag.Name))
+	} else if name != "stakein" && name != "stakeregister" {
+		return nil, fmt.Errorf("--%s is required", stakingValidatorFlag.Name)
+	}
+	if ctx.IsSet(stakingAmountFlag.Name) {
+		if amount, err = staking.ParseAmount(ctx.String(stakingAmountFlag.Name)); err != nil {
+			return nil, err
+		}
+	}
+	lockEpochs := ctx.Uint64(stakingLockEpochsFlag.Name)
+	feeRate := ctx.Uint64(stakingFeeRateFlag.Name)
+
+	switch name {
+	case "stakein", "stakeregister":
+		secPk, err := hexutil.Decode(ctx.String(stakingSecPkFlag.Name))
+		if err != nil {
+			return nil, fmt.Errorf("invalid --%s: %v", stakingSecPkFlag.Name, err)
+		}
+		bn256Pk, err := hexutil.Decode(ctx.String(stakingBn256PkFlag.Name))
+		if err != nil {
+			return nil, fmt.Errorf("invalid --%s: %v", stakingBn256PkFlag.Name, err)
+		}
+		if name == "stakein" {
+			return staking.StakeIn(secPk, bn256Pk, lockEpochs, feeRate, amount)
+		}
+		return staking.StakeRegister(secPk, bn256Pk, lockEpochs, feeRate, ctx.Uint64(stakingMaxFeeRateFlag.Name), amount)
+	case "stakeappend":
+		return staking.StakeAppend(validator, amount)
+	case "stakeupdate":
+		return staking.StakeUpdate(validator, lockEpochs)
+	case "stakeupdatefeerate":
+		return staking.StakeUpdateFeeRate(validator, feeRate)
+	case "partnerin":
+		return staking.PartnerIn(validator, ctx.Bool(stakingRenewalFlag.Name), amount)
+	case "delegatein":
+		return staking.DelegateIn(validator, amount)
+	case "delegateout":
+		return staking.DelegateOut(validator)
+	}
+	return nil, staking.ErrUnknownMethod
+}
+
+// stakingTx completes the transaction of a staking call, signs and outputs it.
+// tx is the unsigned transaction when signing one built earlier.
+func stakingTx(ctx *cli.Context, call *staking.Call, tx *types.Transaction) error {
+	if !ctx.IsSet(stakingFromFlag.Name) {
+		utils.Fatalf("--%s is required", stakingFromFlag.Name)
+	}
+	from := common.HexToAddress(ctx.String(stakingFromFlag.Name))
+
+	var client *ethclient.Client
+	if endpoint := ctx.String(stakingAttachFlag.Name); endpoint != "" {
+		rpcClient, err := dialRPC(endpoint)
+		if err != nil {
+			utils.Fatalf("Failed to attach to %s: %v", endpoint, err)
+		}
+		defer rpcClient.Close()
+		client = ethclient.NewClient(rpcClient)
+		if err := checkStaker(rpcClient, from, call); err != nil {
+			utils.Fatalf("Invalid %s call: %v", call.Method, err)
+		}
+	} else if ctx.Bool(stakingSendFlag.Name) {
+		utils.Fatalf("--%s needs a node to --%s to", stakingSendFlag.Name, stakingAttachFlag.Name)
+	}
+
+	if tx == nil {
+		var nonce uint64
+		switch {
+		case ctx.IsSet(stakingNonceFlag.Name):
+			nonce = ctx.Uint64(stakingNonceFlag.Name)
+		case client != nil:
+			var err error
+			if nonce, err = client.PendingNonceAt(context.Background(), from); err != nil {
+				utils.Fatalf("Failed to get the nonce: %v", err)
+			}
+		default:
+			utils.Fatalf("--%s is required offline", stakingNonceFlag.Name)
+		}
+		var gasPrice *big.Int
+		switch {
+		case ctx.GlobalIsSet(utils.GasPriceFlag.Name):
+			gasPrice = utils.GlobalBig(ctx, utils.GasPriceFlag.Name)
+		case client != nil:
+			var err error
+			if gasPrice, err = client.SuggestGasPrice(context.Background()); err != nil {
+				utils.Fatalf("Failed to get the gas price: %v", err)
+			}
+		default:
+			utils.Fatalf("--%s is required offline", utils.GasPriceFlag.Name)
+		}
+		tx = call.Tx(nonce, new(big.Int).SetUint64(ctx.Uint64(stakingGasLimitFlag.Name)), gasPrice)
+	}
+
+	if ctx.Bool(stakingUnsignedFlag.Name) {
+		return writeStakingTx(ctx, tx)
+	}
+
+	chainID := new(big.Int).SetUint64(ctx.Uint64(stakingChainIDFlag.Name))
+	if !ctx.IsSet(stakingChainIDFlag.Name) {
+		chainID = params.WanchainChainConfig.ChainId
+		if genesis := utils.MakeGenesis(ctx); genesis != nil {
+			chainID = genesis.Config.ChainId
+		}
+	}
+	stack, _ := makeConfigNode(ctx)
+	ks := stack.AccountManager().Backends(keystore.KeyStoreType)[0].(*keystore.KeyStore)
+	account, err := ks.Find(accounts.Account{Address: from})
+	if err != nil {
+		utils.Fatalf("Could not find the account %x: %v", from, err)
+	}
+	fmt.Fprintf(os.Stderr, "Signing %s to %x, value %s, nonce %d, gas price %v\n", call.Method, call.Validator(), staking.FormatAmount(tx.Value()), tx.Nonce(), tx.GasPrice())
+	password := getPassPhrase("Please enter the password of the account", false, 0, utils.MakePasswordList(ctx))
+	signed, err := ks.SignTxWithPassphrase(account, password, tx, chainID)
+	if err != nil {
+		utils.Fatalf("Failed to sign the transaction: %v", err)
+	}
+
+	if ctx.Bool(stakingSendFlag.Name) {
+		if err := client.SendTransaction(context.Background(), signed); err != nil {
+			utils.Fatalf("Failed to send the transaction: %v", err)
+		}
+		fmt.Println("Sent transaction", signed.Hash().Hex())
+		return nil
+	}
+	return writeStakingTx(ctx, signed)
+}
+
+// checkStaker checks a staking call against the current state of its validator.
+func checkStaker(rpcClient *rpc.Client, from common.Address, call *staking.Call) error {
+	bgctx := context.Background()
+	client := posclient.NewClient(rpcClient)
+	epochID, err := client.GetEpochID(bgctx)
+	if err != nil {
+		return err
+	}
+	head, err := ethclient.NewClient(rpcClient).HeaderByNumber(bgctx, nil)
+	if err != nil {
+		return err
+	}
+	stakers, err := client.GetStakerInfo(bgctx, head.Number.Uint64())
+	if err != nil {
+		return err
+	}
+	var staker *posapi.StakerJson
+	target := call.Validator()
+	for _, s := range stakers {
+		if s.Address == target {
+			staker = s
+		}
+	}
+	return call.CheckStaker(from, staker, epochID)
+}
+
+// writeStakingTx outputs the hex RLP encoding of a transaction.
+func writeStakingTx(ctx *cli.Context, tx *types.Transaction) error {
+	raw, err := rlp.EncodeToBytes(tx)
+	if err != nil {
+		return err
+	}
+	out := hexutil.Encode(raw)
+	if file := ctx.String(stakingOutFlag.Name); file != "" {
+		return ioutil.WriteFile(file, []byte(out+"\n"), 0600)
+	}
+	fmt.Println(out)
+	return nil
+}
+
+// readStakingTx reads a staking transaction written by writeStakingTx and
+// validates its call.
+func readStakingTx(ctx *cli.Context) (*types.Transaction, *staking.Call) {
+	if len(ctx.Args()) != 1 {
+		utils.Fatalf("This command requires an argument.")
+	}
+	data, err := ioutil.ReadFile(ctx.Args().First())
+	if err != nil {
+		utils.Fatalf("Failed to read the transaction: %v", err)
+	}
+	raw, err := hexutil.Decode(strings.TrimSpace(string(data)))
+	if err != nil {
+		utils.Fatalf("Invalid transaction: %v", err)
+	}
+	tx := new(types.Transaction)
+	if err := rlp.DecodeBytes(raw, tx); err != nil {
+		utils.Fatalf("Invalid transaction: %v", err)
+	}
+	call, err := staking.Decode(tx)
+	if err == nil {
+		err = call.Validate()
+	}
+	if err != nil {
+		utils.Fatalf("Invalid staking transaction: %v", err)
+	}
+	return tx, call
+}
+
+// stakingSign signs an unsigned staking transaction.
+func stakingSign(ctx *cli.Context) error {
+	tx, call := readStakingTx(ctx)
+	return stakingTx(ctx, call, tx)
+}
+
+// stakingSend validates and sends a signed staking transaction.
+func stakingSend(ctx *cli.Context) error {
+	tx, call := readStakingTx(ctx)
+
+	endpoint := ctx.String(stakingAttachFlag.Name)
+	if endpoint == "" {
+		utils.Fatalf("--%s is required", stakingAttachFlag.Name)
+	}
+	rpcClient, err := dialRPC(endpoint)
+	if err != nil {
+		utils.Fatalf("Failed to attach to %s: %v", endpoint, err)
+	}
+	defer rpcClient.Close()
+	from, err := types.Sender(types.NewEIP155Signer(tx.ChainId()), tx)
+	if err != nil {
+		utils.Fatalf("Invalid signature: %v", err)
+	}
+	if err := checkStaker(rpcClient, from, call); err != nil {
+		utils.Fatalf("Invalid %s call: %v", call.Method, err)
+	}
+	if err := ethclient.NewClient(rpcClient).SendTransaction(context.Background(), tx); err != nil {
+		utils.Fatalf("Failed to send the transaction: %v", err)
+	}
+	fmt.Println("Sent transaction", tx.Hash().Hex())
+	return nil
+}
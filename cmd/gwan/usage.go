@@ -70,9 +70,12 @@ var AppHelpFlagGroups = []flagGroup{
 			utils.DataDirFlag,
 			utils.KeyStoreDirFlag,
 			utils.NoUSBFlag,
+			utils.RemoteSignerFlag,
+			utils.RemoteSignerTokenFlag,
 			utils.NetworkIdFlag,
 			utils.TestnetFlag,
 			utils.FirstPos,
+			utils.PosRetentionFlag,
 			utils.DevInternalFlag,
 			utils.PlutoFlag,
 			utils.PlutoDevFlag,
@@ -116,6 +119,7 @@ var AppHelpFlagGroups = []flagGroup{
 		Flags: []cli.Flag{
 			utils.CacheFlag,
 			utils.TrieCacheGenFlag,
+			utils.FreezerThresholdFlag,
 		},
 	},
 	{
@@ -124,6 +128,18 @@ var AppHelpFlagGroups = []flagGroup{
 			utils.UnlockedAccountFlag,
 			utils.PasswordFileFlag,
 			utils.AwsKmsFlag,
+			utils.KMSProviderFlag,
+			utils.KMSKeyIDFlag,
+			utils.KMSRegionFlag,
+			utils.KMSKeyFileFlag,
+			utils.KMSVaultAddrFlag,
+			utils.KMSVaultMountFlag,
+			utils.OTAScanFlag,
+			utils.StakingIndexFlag,
+			utils.ValidatorHealthFlag,
+			utils.ValidatorHealthMissedSlotsFlag,
+			utils.ValidatorHealthWebhookFlag,
+			utils.ValidatorHealthExecFlag,
 		},
 	},
 	{
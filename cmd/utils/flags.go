@@ -43,6 +43,7 @@ import (
 	"github.com/wanchain/go-wanchain/eth/downloader"
 	"github.com/wanchain/go-wanchain/eth/gasprice"
 	"github.com/wanchain/go-wanchain/ethdb"
+	"github.com/wanchain/go-wanchain/kms"
 	"github.com/wanchain/go-wanchain/ethstats"
 	"github.com/wanchain/go-wanchain/les"
 	"github.com/wanchain/go-wanchain/log"
@@ -54,6 +55,7 @@ import (
 	"github.com/wanchain/go-wanchain/p2p/nat"
 	"github.com/wanchain/go-wanchain/p2p/netutil"
 	"github.com/wanchain/go-wanchain/params"
+	"github.com/wanchain/go-wanchain/pos/validatorhealth"
 	whisper "github.com/wanchain/go-wanchain/whisper/whisperv5"
 	cli "gopkg.in/urfave/cli.v1"
 )
@@ -125,6 +127,14 @@ var (
 		Name:  "nousb",
 		Usage: "Disables monitoring for and managing USB hardware wallets",
 	}
+	RemoteSignerFlag = cli.StringFlag{
+		Name:  "signer",
+		Usage: "HTTP URL or IPC path of an external signer holding account and validator keys",
+	}
+	RemoteSignerTokenFlag = cli.StringFlag{
+		Name:  "signertoken",
+		Usage: "File containing the token authenticating the node with an external signer served over HTTP",
+	}
 	NetworkIdFlag = cli.Uint64Flag{
 		Name:  "networkid",
 		Usage: "Network identifier (integer, 1=Frontier, 2=Morden (disused), 3=Ropsten, 4=Rinkeby, 6=Pluto)",
@@ -134,6 +144,10 @@ var (
 		Name:  "firstPos",
 		Usage: "firstPos",
 	}
+	PosRetentionFlag = cli.Uint64Flag{
+		Name:  "pos.retention",
+		Usage: "Number of past epochs whose leaders, random beacon and staker data the pos databases keep (0 = all)",
+	}
 	TestnetFlag = cli.BoolFlag{
 		Name:  "testnet",
 		Usage: "Wan test network: pre-configured proof-of-work test network",
@@ -304,6 +318,11 @@ var (
 		Usage: "Number of trie node generations to keep in memory",
 		Value: int(state.MaxTrieCacheGen),
 	}
+	FreezerThresholdFlag = cli.Uint64Flag{
+		Name:  "freezer.threshold",
+		Usage: "Number of recent blocks kept in the key-value store, older stable blocks being moved to the freezer",
+		Value: eth.DefaultConfig.FreezerThreshold,
+	}
 	// Miner settings
 	MiningEnabledFlag = cli.BoolFlag{
 		Name:  "mine",
@@ -344,6 +363,31 @@ var (
 		Usage: "Password file to use for non-interactive password input",
 		Value: "",
 	}
+	OTAScanFlag = cli.BoolFlag{
+		Name:  "otascan",
+		Usage: "Index the OTAs received by the unlocked accounts in the background",
+	}
+	StakingIndexFlag = cli.BoolFlag{
+		Name:  "stakingindex",
+		Usage: "Index the staking history of the accounts in the background (pos_getStakingHistory)",
+	}
+	ValidatorHealthFlag = cli.StringFlag{
+		Name:  "validatorhealth",
+		Usage: "Comma separated list of validator addresses whose duties are monitored (pos_getValidatorHealth)",
+	}
+	ValidatorHealthMissedSlotsFlag = cli.Uint64Flag{
+		Name:  "validatorhealth.missedslots",
+		Usage: "Number of slots missed by a validator in an epoch which raises an alert",
+		Value: validatorhealth.DefaultConfig.MissedSlots,
+	}
+	ValidatorHealthWebhookFlag = cli.StringFlag{
+		Name:  "validatorhealth.webhook",
+		Usage: "URL the validator health alerts are posted to as JSON",
+	}
+	ValidatorHealthExecFlag = cli.StringFlag{
+		Name:  "validatorhealth.exec",
+		Usage: "Command run on each validator health alert, with the alert as JSON on its standard input",
+	}
 
 	VMEnableDebugFlag = cli.BoolFlag{
 		Name:  "vmdebug",
@@ -548,7 +592,33 @@ var (
 	}
 	AwsKmsFlag = cli.BoolFlag{
 		Name:  "kms",
-		Usage: "Enable AWS KMS encrypted keystore file",
+		Usage: "Enable KMS encrypted keystore file",
+	}
+	KMSProviderFlag = cli.StringFlag{
+		Name:  "kms.provider",
+		Usage: `Key encryption provider of the KMS encrypted keystore files ("aws", "local" or "vault")`,
+		Value: kms.DefaultConfig.Provider,
+	}
+	KMSKeyIDFlag = cli.StringFlag{
+		Name:  "kms.keyid",
+		Usage: "AWS KMS key ID or Vault transit key name",
+	}
+	KMSRegionFlag = cli.StringFlag{
+		Name:  "kms.region",
+		Usage: "Region of the AWS KMS key",
+	}
+	KMSKeyFileFlag = cli.StringFlag{
+		Name:  "kms.keyfile",
+		Usage: "Master key file of the local provider",
+	}
+	KMSVaultAddrFlag = cli.StringFlag{
+		Name:  "kms.vault.addr",
+		Usage: "Address of the Vault server, the token is read from VAULT_TOKEN or prompted for",
+	}
+	KMSVaultMountFlag = cli.StringFlag{
+		Name:  "kms.vault.mount",
+		Usage: "Mount path of the Vault transit secrets engine",
+		Value: kms.DefaultConfig.VaultMount,
 	}
 )
 
@@ -790,6 +860,28 @@ func MakeAddress(ks *keystore.KeyStore, account string) (accounts.Account, error
 
 // setEtherbase retrieves the etherbase either from the directly specified
 // command line flags or from the keystore if CLI indexed.
+// setValidatorHealth applies the validator health monitor flags to the config.
+func setValidatorHealth(ctx *cli.Context, cfg *validatorhealth.Config) {
+	if ctx.GlobalIsSet(ValidatorHealthFlag.Name) {
+		cfg.Addresses = nil
+		for _, addr := range splitAndTrim(ctx.GlobalString(ValidatorHealthFlag.Name)) {
+			if !common.IsHexAddress(addr) {
+				Fatalf("Invalid validator address %q", addr)
+			}
+			cfg.Addresses = append(cfg.Addresses, common.HexToAddress(addr))
+		}
+	}
+	if ctx.GlobalIsSet(ValidatorHealthMissedSlotsFlag.Name) {
+		cfg.MissedSlots = ctx.GlobalUint64(ValidatorHealthMissedSlotsFlag.Name)
+	}
+	if ctx.GlobalIsSet(ValidatorHealthWebhookFlag.Name) {
+		cfg.Webhook = ctx.GlobalString(ValidatorHealthWebhookFlag.Name)
+	}
+	if ctx.GlobalIsSet(ValidatorHealthExecFlag.Name) {
+		cfg.Exec = ctx.GlobalString(ValidatorHealthExecFlag.Name)
+	}
+}
+
 func setEtherbase(ctx *cli.Context, ks *keystore.KeyStore, cfg *eth.Config) {
 	if ctx.GlobalIsSet(EtherbaseFlag.Name) {
 		account, err := MakeAddress(ks, ctx.GlobalString(EtherbaseFlag.Name))
@@ -904,6 +996,38 @@ func SetNodeConfig(ctx *cli.Context, cfg *node.Config) {
 	if ctx.GlobalIsSet(NoUSBFlag.Name) {
 		cfg.NoUSB = ctx.GlobalBool(NoUSBFlag.Name)
 	}
+	if ctx.GlobalIsSet(RemoteSignerFlag.Name) {
+		cfg.RemoteSigner = ctx.GlobalString(RemoteSignerFlag.Name)
+	}
+	if ctx.GlobalIsSet(RemoteSignerTokenFlag.Name) {
+		token, err := ioutil.ReadFile(ctx.GlobalString(RemoteSignerTokenFlag.Name))
+		if err != nil {
+			Fatalf("Failed to read signer token file: %v", err)
+		}
+		cfg.RemoteSignerToken = strings.TrimSpace(string(token))
+	}
+}
+
+// SetKMSConfig applies the key encryption provider flags to the config.
+func SetKMSConfig(ctx *cli.Context, cfg *kms.Config) {
+	if ctx.GlobalIsSet(KMSProviderFlag.Name) {
+		cfg.Provider = ctx.GlobalString(KMSProviderFlag.Name)
+	}
+	if ctx.GlobalIsSet(KMSKeyIDFlag.Name) {
+		cfg.KeyID = ctx.GlobalString(KMSKeyIDFlag.Name)
+	}
+	if ctx.GlobalIsSet(KMSRegionFlag.Name) {
+		cfg.AWSRegion = ctx.GlobalString(KMSRegionFlag.Name)
+	}
+	if ctx.GlobalIsSet(KMSKeyFileFlag.Name) {
+		cfg.KeyFile = ctx.GlobalString(KMSKeyFileFlag.Name)
+	}
+	if ctx.GlobalIsSet(KMSVaultAddrFlag.Name) {
+		cfg.VaultAddr = ctx.GlobalString(KMSVaultAddrFlag.Name)
+	}
+	if ctx.GlobalIsSet(KMSVaultMountFlag.Name) {
+		cfg.VaultMount = ctx.GlobalString(KMSVaultMountFlag.Name)
+	}
 }
 
 func setGPO(ctx *cli.Context, cfg *gasprice.Config) {
@@ -1027,6 +1151,9 @@ func SetEthConfig(ctx *cli.Context, stack *node.Node, cfg *eth.Config) {
 	if ctx.GlobalIsSet(CacheFlag.Name) {
 		cfg.DatabaseCache = ctx.GlobalInt(CacheFlag.Name)
 	}
+	if ctx.GlobalIsSet(FreezerThresholdFlag.Name) {
+		cfg.FreezerThreshold = ctx.GlobalUint64(FreezerThresholdFlag.Name)
+	}
 	cfg.DatabaseHandles = makeDatabaseHandles()
 
 	if ctx.GlobalIsSet(MinerThreadsFlag.Name) {
@@ -1044,6 +1171,13 @@ func SetEthConfig(ctx *cli.Context, stack *node.Node, cfg *eth.Config) {
 		cfg.GasPrice = GlobalBig(ctx, GasPriceFlag.Name)
 	}
 
+	if ctx.GlobalIsSet(OTAScanFlag.Name) {
+		cfg.OTAScan = ctx.GlobalBool(OTAScanFlag.Name)
+	}
+	if ctx.GlobalIsSet(StakingIndexFlag.Name) {
+		cfg.StakingIndex = ctx.GlobalBool(StakingIndexFlag.Name)
+	}
+	setValidatorHealth(ctx, &cfg.ValidatorHealth)
 	if ctx.GlobalIsSet(VMEnableDebugFlag.Name) {
 		// TODO(fjl): force-enable this in --dev mode
 		cfg.EnablePreimageRecording = ctx.GlobalBool(VMEnableDebugFlag.Name)
@@ -1162,7 +1296,7 @@ func MakeChainDatabase(ctx *cli.Context, stack *node.Node) ethdb.Database {
 	if ctx.GlobalBool(LightModeFlag.Name) {
 		name = "lightchaindata"
 	}
-	chainDb, err := stack.OpenDatabase(name, cache, handles)
+	chainDb, err := stack.OpenDatabaseWithFreezer(name, cache, handles, "")
 	if err != nil {
 		Fatalf("Could not open database: %v", err)
 	}
@@ -0,0 +1,217 @@
+// Copyright 2018 Wanchain Foundation Ltd
+// This file is part of go-wanchain.
+//
+// go-wanchain is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-wanchain is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-wanchain. If not, see <http://www.gnu.org/licenses/>.
+
+// wansigner is a reference remote signer. It decrypts keystore accounts and
+// serves the signer protocol of accounts/remotesigner over IPC and/or HTTP, so
+// that gwan can validate with --signer without holding the keys.
+//
+// The signer listens on IPC only by default. HTTP is served on loopback
+// addresses, or on public ones with -httppublic and a -httptoken that gwan
+// sends with --signertoken, over TLS if -httptlscert and -httptlskey are set.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+
+	"github.com/wanchain/go-wanchain/accounts"
+	"github.com/wanchain/go-wanchain/accounts/keystore"
+	"github.com/wanchain/go-wanchain/accounts/remotesigner"
+	"github.com/wanchain/go-wanchain/cmd/utils"
+	"github.com/wanchain/go-wanchain/common"
+	"github.com/wanchain/go-wanchain/console"
+	"github.com/wanchain/go-wanchain/log"
+	"github.com/wanchain/go-wanchain/pos/possigner"
+	"github.com/wanchain/go-wanchain/rpc"
+)
+
+func main() {
+	var (
+		keydir      = flag.String("keystore", "", "directory of the keystore holding the accounts")
+		unlock      = flag.String("unlock", "", "comma separated addresses of the accounts to serve (default all)")
+		password    = flag.String("password", "", "password file, one line per unlocked account")
+		ipcPath     = flag.String("ipcpath", "wansigner.ipc", "path of the IPC socket to listen on, empty to disable")
+		httpAddr    = flag.String("http", "", "HTTP listen address, e.g. 127.0.0.1:18550")
+		httpCors    = flag.String("httpcors", "", "comma separated origins to accept cross origin HTTP requests from")
+		httpVhosts  = flag.String("httpvhosts", "localhost", "comma separated virtual hostnames to accept HTTP requests for (* for any)")
+		httpPublic  = flag.Bool("httppublic", false, "allow a non-loopback HTTP listen address, requires -httptoken")
+		httpToken   = flag.String("httptoken", "", "file containing the token HTTP clients must send")
+		httpTLSCert = flag.String("httptlscert", "", "TLS certificate file to serve HTTPS with")
+		httpTLSKey  = flag.String("httptlskey", "", "TLS key file to serve HTTPS with")
+		verbosity   = flag.Int("verbosity", int(log.LvlInfo), "log verbosity (0-9)")
+	)
+	flag.Parse()
+
+	glogger := log.NewGlogHandler(log.StreamHandler(os.Stderr, log.TerminalFormat(false)))
+	glogger.Verbosity(log.Lvl(*verbosity))
+	log.Root().SetHandler(glogger)
+
+	switch {
+	case *keydir == "":
+		utils.Fatalf("Use -keystore to specify the keystore directory")
+	case *ipcPath == "" && *httpAddr == "":
+		utils.Fatalf("Use -ipcpath and/or -http to specify where to listen")
+	case (*httpTLSCert == "") != (*httpTLSKey == ""):
+		utils.Fatalf("Use both -httptlscert and -httptlskey to serve HTTPS")
+	}
+	var token string
+	if *httpToken != "" {
+		text, err := ioutil.ReadFile(*httpToken)
+		if err != nil {
+			utils.Fatalf("Failed to read token file: %v", err)
+		}
+		if token = strings.TrimSpace(string(text)); token == "" {
+			utils.Fatalf("Empty token file %s", *httpToken)
+		}
+	}
+	if *httpAddr != "" && !isLoopback(*httpAddr) {
+		if !*httpPublic || token == "" {
+			utils.Fatalf("Refusing to serve the signer on non-loopback address %s without -httppublic and -httptoken", *httpAddr)
+		}
+		if *httpTLSCert == "" {
+			log.Warn("Serving the signer publicly without TLS, the token is sent in the clear")
+		}
+	}
+	ks := keystore.NewKeyStore(*keydir, keystore.StandardScryptN, keystore.StandardScryptP)
+
+	var accs []accounts.Account
+	if *unlock == "" {
+		accs = ks.Accounts()
+	} else {
+		for _, addr := range strings.Split(*unlock, ",") {
+			addr = strings.TrimSpace(addr)
+			if !common.IsHexAddress(addr) {
+				utils.Fatalf("Invalid account address %q", addr)
+			}
+			acc, err := ks.Find(accounts.Account{Address: common.HexToAddress(addr)})
+			if err != nil {
+				utils.Fatalf("Account %s: %v", addr, err)
+			}
+			accs = append(accs, acc)
+		}
+	}
+	if len(accs) == 0 {
+		utils.Fatalf("No account to serve")
+	}
+	var passwords []string
+	if *password != "" {
+		text, err := ioutil.ReadFile(*password)
+		if err != nil {
+			utils.Fatalf("Failed to read password file: %v", err)
+		}
+		for _, line := range strings.Split(string(text), "\n") {
+			passwords = append(passwords, strings.TrimRight(line, "\r"))
+		}
+	}
+
+	api := possigner.NewSignerAPI()
+	for i, acc := range accs {
+		key, err := ks.GetKey(acc, getPassPhrase(acc, i, passwords))
+		if err != nil {
+			utils.Fatalf("Failed to unlock account %x: %v", acc.Address, err)
+		}
+		api.Add(key)
+		log.Info("Serving account", "address", acc.Address)
+	}
+
+	srv := rpc.NewServer()
+	if err := srv.RegisterName(remotesigner.Namespace, api); err != nil {
+		utils.Fatalf("Failed to register signer service: %v", err)
+	}
+	if *ipcPath != "" {
+		listener, err := rpc.CreateIPCListener(*ipcPath)
+		if err != nil {
+			utils.Fatalf("Failed to listen on %s: %v", *ipcPath, err)
+		}
+		go srv.ServeListener(listener)
+		log.Info("IPC endpoint opened", "path", *ipcPath)
+	}
+	if *httpAddr != "" {
+		listener, err := net.Listen("tcp", *httpAddr)
+		if err != nil {
+			utils.Fatalf("Failed to listen on %s: %v", *httpAddr, err)
+		}
+		handler := rpc.NewVirtualHostHandler(splitAndTrim(*httpVhosts), rpc.NewHTTPServer(splitAndTrim(*httpCors), srv).Handler)
+		if token != "" {
+			handler = remotesigner.NewTokenHandler(token, handler)
+		}
+		server := &http.Server{Handler: handler}
+		scheme := "http"
+		if *httpTLSCert != "" {
+			scheme = "https"
+			go server.ServeTLS(listener, *httpTLSCert, *httpTLSKey)
+		} else {
+			go server.Serve(listener)
+		}
+		log.Info("HTTP endpoint opened", "url", fmt.Sprintf("%s://%s", scheme, listener.Addr()), "cors", *httpCors, "vhosts", *httpVhosts)
+	}
+
+	sigc := make(chan os.Signal, 1)
+	signal.Notify(sigc, os.Interrupt)
+	<-sigc
+	log.Info("Shutting down")
+	srv.Stop()
+	if *ipcPath != "" {
+		os.Remove(*ipcPath)
+	}
+}
+
+// getPassPhrase returns the password of the i-th account, from the password
+// file if given or from the terminal otherwise.
+func getPassPhrase(acc accounts.Account, i int, passwords []string) string {
+	if len(passwords) > 0 {
+		if i < len(passwords) {
+			return passwords[i]
+		}
+		return passwords[len(passwords)-1]
+	}
+	password, err := console.Stdin.PromptPassword(fmt.Sprintf("Passphrase of %x: ", acc.Address))
+	if err != nil {
+		utils.Fatalf("Failed to read passphrase: %v", err)
+	}
+	return password
+}
+
+// isLoopback reports whether the HTTP listen address addr only accepts local
+// connections.
+func isLoopback(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return false
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// splitAndTrim splits a comma separated list, dropping the empty entries.
+func splitAndTrim(input string) []string {
+	var result []string
+	for _, r := range strings.Split(input, ",") {
+		if r = strings.TrimSpace(r); r != "" {
+			result = append(result, r)
+		}
+	}
+	return result
+}
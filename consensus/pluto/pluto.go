@@ -31,7 +31,6 @@ import (
 
 	lru "github.com/hashicorp/golang-lru"
 	"github.com/wanchain/go-wanchain/accounts"
-	"github.com/wanchain/go-wanchain/accounts/keystore"
 	"github.com/wanchain/go-wanchain/common"
 	"github.com/wanchain/go-wanchain/common/hexutil"
 	"github.com/wanchain/go-wanchain/consensus"
@@ -213,6 +212,12 @@ func ecrecover(header *types.Header, sigcache *lru.ARCCache) (common.Address, er
 	return signer, nil
 }
 
+// SealSigner extracts the account address that sealed a pos header. It is used
+// by header verifiers that run without the engine, such as the light client.
+func SealSigner(header *types.Header, sigcache *lru.ARCCache) (common.Address, error) {
+	return ecrecover(header, sigcache)
+}
+
 // Pluto is the proof-of-authority consensus engine proposed to support the
 // Ethereum testnet following the Ropsten attacks.
 type Pluto struct {
@@ -228,7 +233,7 @@ type Pluto struct {
 	signFn SignerFn       // Signer function to authorize hashes with
 	lock   sync.RWMutex   // Protects the signer fields
 
-	key *keystore.Key // Unlocked key
+	key posconfig.MinerSigner // Signer of the slot leader proofs
 }
 
 // New creates a Pluto proof-of-authority consensus engine with the initial
@@ -761,10 +766,12 @@ func (c *Pluto) Prepare(chain consensus.ChainReader, header *types.Header, minin
 // rewards given, and returns the final block.
 func (c *Pluto) Finalize(chain consensus.ChainReader, header *types.Header, state *state.StateDB, txs []*types.Transaction, uncles []*types.Header, receipts []*types.Receipt) (*types.Block, error) {
 	epochID, slotID := util.GetEpochSlotIDFromDifficulty(header.Difficulty)
-	if posconfig.FirstEpochId != 0 && epochID > posconfig.FirstEpochId+2 && epochID >= posconfig.IncentiveDelayEpochs && slotID > posconfig.IncentiveStartStage {
+	var payment *incentive.EpochPayment
+	if incentive.RunsAt(epochID, slotID) {
 		log.Debug("--------Incentive Start--------", "number", header.Number.String(), "epochID", epochID)
 		snap := state.Snapshot()
-		if !incentive.Run(chain, state, epochID-posconfig.IncentiveDelayEpochs) {
+		var ok bool
+		if payment, ok = incentive.Run(chain, state, epochID-posconfig.IncentiveDelayEpochs); !ok {
 			log.SyslogAlert("********Incentive Failed********", "number", header.Number.String(), "epochID", epochID)
 			state.RevertToSnapshot(snap)
 		} else {
@@ -788,6 +795,9 @@ func (c *Pluto) Finalize(chain consensus.ChainReader, header *types.Header, stat
 	// No block rewards in PoA, so the state remains as is and uncles are dropped
 	state.Finalise(true)
 	header.Root = state.IntermediateRoot(true /*chain.Config().IsEIP158(header.Number)*/)
+	if payment != nil {
+		incentive.KeepPayment(header.Root, epochID-posconfig.IncentiveDelayEpochs, payment)
+	}
 
 	header.UncleHash = types.CalcUncleHash(nil)
 
@@ -795,9 +805,9 @@ func (c *Pluto) Finalize(chain consensus.ChainReader, header *types.Header, stat
 	return types.NewBlock(header, txs, nil, receipts), nil
 }
 
-// Authorize injects a private key into the consensus engine to mint new blocks
-// with.
-func (c *Pluto) Authorize(signer common.Address, signFn SignerFn, key *keystore.Key) {
+// Authorize injects a signing function and the miner signer into the consensus
+// engine to mint new blocks with.
+func (c *Pluto) Authorize(signer common.Address, signFn SignerFn, key posconfig.MinerSigner) {
 	c.lock.Lock()
 	defer c.lock.Unlock()
 
@@ -841,7 +851,7 @@ func (c *Pluto) Seal(chain consensus.ChainReader, block *types.Block, stop <-cha
 	if epochSlotId <= lastEpochSlotId {
 		return nil, nil
 	}
-	localPublicKey := hex.EncodeToString(crypto.FromECDSAPub(&c.key.PrivateKey.PublicKey))
+	localPublicKey := hex.EncodeToString(crypto.FromECDSAPub(key.PublicKey()))
 	leaderPub, err := slotleader.GetSlotLeaderSelection().GetSlotLeader(epochId, slotId)
 	if err != nil {
 		return nil, err
@@ -871,7 +881,7 @@ func (c *Pluto) Seal(chain consensus.ChainReader, block *types.Block, stop <-cha
 	header.Coinbase = signer
 
 	s := slotleader.GetSlotLeaderSelection()
-	buf, err := s.PackSlotProof(epochId, slotId, key.PrivateKey)
+	buf, err := s.PackSlotProof(epochId, slotId, key)
 	if err != nil {
 		log.Warn("PackSlotProof failed in Seal", "epochID", epochId, "slotID", slotId, "error", err.Error())
 		return nil, err
@@ -891,7 +901,7 @@ func (c *Pluto) Seal(chain consensus.ChainReader, block *types.Block, stop <-cha
 
 	log.Debug("signature", "hex", hex.EncodeToString(sighash))
 	log.Debug("sigHash(header)", "Bytes", hex.EncodeToString(sigHash(header).Bytes()))
-	log.Debug("Packed slotleader proof info success", "epochID", epochId, "slotID", slotId, "len", len(header.Extra), "pk", hex.EncodeToString(crypto.FromECDSAPub(&key.PrivateKey.PublicKey)))
+	log.Debug("Packed slotleader proof info success", "epochID", epochId, "slotID", slotId, "len", len(header.Extra), "pk", localPublicKey)
 
 	err = c.verifySeal(nil, header, nil, false)
 	if err != nil {
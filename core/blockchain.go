@@ -43,6 +43,8 @@ import (
 	"github.com/wanchain/go-wanchain/log"
 	"github.com/wanchain/go-wanchain/metrics"
 	"github.com/wanchain/go-wanchain/params"
+	"github.com/wanchain/go-wanchain/pos/equivocation"
+	"github.com/wanchain/go-wanchain/pos/incentive"
 	"github.com/wanchain/go-wanchain/pos/posconfig"
 	posUtil "github.com/wanchain/go-wanchain/pos/util"
 	"github.com/wanchain/go-wanchain/rlp"
@@ -128,6 +130,8 @@ type BlockChain struct {
 
 	badBlocks *lru.Cache // Bad block cache
 
+	equivocations *equivocation.Detector // Detector of slot leaders signing two blocks of a slot
+
 	CurrentEpochId int64
 
 	slotValidator Validator
@@ -336,6 +340,13 @@ func (bc *BlockChain) SetHead(head uint64) error {
 	bc.hc.SetHead(head, delFn)
 	currentHeader := bc.hc.CurrentHeader()
 
+	// Drop the frozen blocks above the new head
+	if db, ok := bc.chainDb.(*ethdb.LDBDatabase); ok && db.Freezer() != nil {
+		if err := db.Freezer().TruncateAncients(currentHeader.Number.Uint64() + 1); err != nil {
+			log.Crit("Failed to rewind the freezer", "err", err)
+		}
+	}
+
 	// Clear out any stale content from the caches
 	bc.bodyCache.Purge()
 	bc.bodyRLPCache.Purge()
@@ -609,7 +620,7 @@ func (bc *BlockChain) HasBlock(hash common.Hash, number uint64) bool {
 		return true
 	}
 	ok, _ := bc.chainDb.Has(blockBodyKey(hash, number))
-	return ok
+	return ok || isAncient(bc.chainDb, hash, number)
 }
 
 // HasBlockAndState checks if a block and associated state trie is fully present
@@ -784,6 +795,10 @@ func SetReceiptsData(config *params.ChainConfig, block *types.Block, receipts ty
 
 // InsertReceiptChain attempts to complete an already existing header chain with
 // transaction and receipt data.
+//
+// The blocks are not executed, so the incentives paid by pos blocks are not
+// recorded: the gap is noted in the incentive history, which has to be rebuilt
+// with gwan pos rebuild-incentive.
 func (bc *BlockChain) InsertReceiptChain(blockChain types.Blocks, receiptChain []types.Receipts) (int, error) {
 	bc.wg.Add(1)
 	defer bc.wg.Done()
@@ -868,6 +883,9 @@ func (bc *BlockChain) InsertReceiptChain(blockChain types.Blocks, receiptChain [
 		"number", head.Number(),
 		"hash", head.Hash(),
 		"ignored", stats.ignored)
+	if stats.processed > 0 && bc.config.PosFirstBlock != nil && bc.config.IsPosBlockNumber(head.Number()) {
+		incentive.MarkHistoryGap(bc.chainDb, head.NumberU64())
+	}
 	return 0, nil
 }
 
@@ -1087,6 +1105,8 @@ func (bc *BlockChain) WriteBlockAndState(block *types.Block, receipts []*types.R
 	if err := WriteBlockReceipts(batch, block.Hash(), block.NumberU64(), receipts); err != nil {
 		return NonStatTy, err
 	}
+	// Record the incentive the block paid, if any
+	incentive.WriteHistory(bc.chainDb, batch, block.Header())
 
 	/// If the total difficulty is higher than our known, add it to the canonical chain
 	/// Second clause in the if statement reduces the vulnerability to selfish mining.
@@ -1129,8 +1149,10 @@ func (bc *BlockChain) WriteBlockAndState(block *types.Block, receipts []*types.R
 	if status == CanonStatTy {
 
 		bc.insert(block)
+		incentive.UpdateHistoryHead(bc.chainDb, block.Hash())
 		if bc.config.IsPosActive {
 			posUtil.UpdateEpochBlock(block)
+			posdb.CollectGarbage(epid)
 			
 			flatSlotId := epid*posconfig.SlotCount + slotId
 			bc.cqCache.Add(flatSlotId, block.Number().Uint64())
@@ -1393,6 +1415,9 @@ func (bc *BlockChain) insertChain(chain types.Blocks) (int, []interface{}, []*ty
 			bc.reportBlock(block, nil, err)
 			return i, events, coalescedLogs, err
 		}
+		if bc.equivocations != nil {
+			bc.equivocations.Observe(block.Header())
+		}
 		// TODO: verify pos header proof
 		// Create a new statedb using the parent block and report an
 		// error if it fails.
@@ -1607,11 +1632,14 @@ func (bc *BlockChain) reorg(oldBlock, newBlock *types.Block) error {
 	}
 
 	var addedTxs types.Transactions
+	for _, block := range newChain {
+		addedTxs = append(addedTxs, block.Transactions()...)
+	}
+	// calculate the difference between deleted and added transactions
+	diff := types.TxDifference(deletedTxs, addedTxs)
+
 	// insert blocks. Order does not matter. Last block will be written in ImportChain itself which creates the new head properly
 	newChainLen := len(newChain)
-	epochId, slotid := posUtil.CalEpSlbyTd(newChain[newChainLen-1].Header().Difficulty.Uint64())
-	bc.updateReOrg(epochId, slotid, uint64(len(oldChain)))
-	go bc.reorgFeed.Send(ReorgEvent{epochId, slotid, uint64(len(oldChain))})
 
 	//if reorg length is bigger than k,do not let reorg happen
 	if posconfig.FirstEpochId != 0 && uint(newChainLen) > posconfig.Cfg().K {
@@ -1632,11 +1660,23 @@ func (bc *BlockChain) reorg(oldBlock, newBlock *types.Block) error {
 		if err := WriteTxLookupEntries(bc.chainDb, block); err != nil {
 			return err
 		}
-		addedTxs = append(addedTxs, block.Transactions()...)
 	}
 
-	// calculate the difference between deleted and added transactions
-	diff := types.TxDifference(deletedTxs, addedTxs)
+	// Move the incentive history back to the new chain
+	dropped, added := make([]common.Hash, len(oldChain)), make([]common.Hash, len(newChain))
+	for i, block := range oldChain {
+		dropped[i] = block.Hash()
+	}
+	for i, block := range newChain {
+		added[len(newChain)-1-i] = block.Hash()
+	}
+	incentive.RollbackHistory(bc.chainDb, dropped, added)
+
+	// Record and announce the reorg now that the new chain is in place
+	ev := bc.newReorgEvent(commonBlock, oldChain, newChain, diff)
+	bc.updateReOrg(ev)
+	go bc.reorgFeed.Send(*ev)
+
 	// When transactions get deleted from the database that means the
 	// receipts that were created in the fork must also be deleted
 	for _, tx := range diff {
@@ -1878,6 +1918,12 @@ func (bc *BlockChain) SetSlotValidator(validator Validator) {
 	bc.slotValidator = validator
 }
 
+// SetEquivocationDetector sets the detector the verified blocks are checked
+// against before their import.
+func (bc *BlockChain) SetEquivocationDetector(d *equivocation.Detector) {
+	bc.equivocations = d
+}
+
 // Validator returns the current validator.
 func (bc *BlockChain) SlotValidator() Validator {
 	return bc.slotValidator
@@ -1985,12 +2031,56 @@ func (bc *BlockChain) checkRestarting(chain types.Blocks) ([]uint, error) {
 	return idxs, nil
 
 }
-func (bc *BlockChain) updateReOrg(epochId uint64, slotid uint64, length uint64) {
+
+// newReorgEvent describes a switch of the canonical chain from the oldChain
+// branch to the newChain one, both newest first, forking at commonBlock.
+func (bc *BlockChain) newReorgEvent(commonBlock *types.Block, oldChain, newChain types.Blocks, dropped types.Transactions) *ReorgEvent {
+	epochId, slotid := posUtil.CalEpSlbyTd(newChain[len(newChain)-1].Header().Difficulty.Uint64())
+	ev := &ReorgEvent{
+		EpochId:        epochId,
+		SlotId:         slotid,
+		Len:            uint64(len(oldChain)),
+		NewHead:        newChain[0].Hash(),
+		NewNumber:      newChain[0].NumberU64(),
+		CommonAncestor: commonBlock.Hash(),
+		CommonNumber:   commonBlock.NumberU64(),
+		Time:           uint64(time.Now().Unix()),
+	}
+	if len(oldChain) > 0 {
+		ev.OldHead, ev.OldNumber = oldChain[0].Hash(), oldChain[0].NumberU64()
+	} else {
+		ev.OldHead, ev.OldNumber = ev.CommonAncestor, ev.CommonNumber
+	}
+	for _, block := range oldChain {
+		blkEpochId, blkSlotId := posUtil.CalEpSlbyTd(block.Difficulty().Uint64())
+		leader, err := bc.engine.Author(block.Header())
+		if err != nil {
+			leader = block.Coinbase()
+		}
+		ev.Dropped = append(ev.Dropped, ReorgBlock{
+			Number:  block.NumberU64(),
+			Hash:    block.Hash(),
+			EpochId: blkEpochId,
+			SlotId:  blkSlotId,
+			Leader:  leader,
+		})
+	}
+	for _, tx := range dropped {
+		ev.DroppedTxs = append(ev.DroppedTxs, tx.Hash())
+	}
+	return ev
+}
+
+// updateReOrg records a reorg in the reorg local database: the counters of
+// its epoch and the full event in the reorg history.
+func (bc *BlockChain) updateReOrg(ev *ReorgEvent) {
+	epochId, length := ev.EpochId, ev.Len
 
 	reOrgDb := posdb.GetDbByName(posconfig.ReorgLocalDB)
 	if reOrgDb == nil {
 		reOrgDb = posdb.NewDb(posconfig.ReorgLocalDB)
 	}
+	WriteReorgEvent(reOrgDb, ev)
 
 	numberBytes, _ := reOrgDb.Get(epochId, "reorgNumber")
 
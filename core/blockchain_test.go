@@ -32,6 +32,7 @@ import (
 	"github.com/wanchain/go-wanchain/crypto"
 	"github.com/wanchain/go-wanchain/ethdb"
 	"github.com/wanchain/go-wanchain/params"
+	"github.com/wanchain/go-wanchain/pos/incentive"
 )
 
 // newTestBlockChain creates a blockchain without validation.
@@ -1253,3 +1254,46 @@ func TestCanonicalBlockRetrieval(t *testing.T) {
 //		}
 //	*/
 //}
+
+// Tests that the pos blocks imported by a fast sync are noted as missing from
+// the incentive history, until it is rebuilt.
+func TestFastSyncIncentiveGap(t *testing.T) {
+	config := *params.TestChainConfig
+	config.PosFirstBlock = big.NewInt(3)
+	newFastChain := func(config *params.ChainConfig) *BlockChain {
+		db, _ := ethdb.NewMemDatabase()
+		gspec := DefaultPPOWTestingGenesisBlock()
+		gspec.Config = config
+		gspec.MustCommit(db)
+		bc, err := NewBlockChain(db, config, ethash.NewFullFaker(db), vm.Config{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return bc
+	}
+	pow := newFastChain(params.TestChainConfig)
+	defer pow.Stop()
+	pos := newFastChain(&config)
+	defer pos.Stop()
+
+	blocks := makeBlockChainWithDiff(pow.genesisBlock, []int{1, 2, 3}, 1)
+	headers := make([]*types.Header, len(blocks))
+	receipts := make([]types.Receipts, len(blocks))
+	for i, block := range blocks {
+		headers[i] = block.Header()
+	}
+	for _, bc := range []*BlockChain{pow, pos} {
+		if n, err := bc.InsertHeaderChain(headers, 1); err != nil {
+			t.Fatalf("failed to insert header %d: %v", n, err)
+		}
+		if n, err := bc.InsertReceiptChain(blocks, receipts); err != nil {
+			t.Fatalf("failed to insert receipt %d: %v", n, err)
+		}
+	}
+	if incentive.CheckHistory(pow.chainDb) {
+		t.Fatal("rebuild asked after a fast sync of pow blocks")
+	}
+	if !incentive.CheckHistory(pos.chainDb) {
+		t.Fatal("rebuild not asked after a fast sync of pos blocks")
+	}
+}
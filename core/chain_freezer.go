@@ -0,0 +1,98 @@
+// Copyright 2018 Wanchain Foundation Ltd
+// This file is part of the go-wanchain library.
+//
+// The go-wanchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-wanchain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-wanchain library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/wanchain/go-wanchain/common"
+	"github.com/wanchain/go-wanchain/ethdb"
+)
+
+// errNoFreezer is returned if blocks are frozen in a database without freezer.
+var errNoFreezer = errors.New("database has no freezer")
+
+// FreezeBlocks moves the canonical blocks below limit out of the LevelDB store
+// of db into its freezer, max of them at most. The accessors of this package
+// read them from either store, but the blocks must not be reorganised anymore:
+// the freezer only holds the canonical chain. The genesis block is kept in
+// LevelDB too. It returns the number of blocks frozen.
+func FreezeBlocks(db *ethdb.LDBDatabase, limit, max uint64) (uint64, error) {
+	f := db.Freezer()
+	if f == nil {
+		return 0, errNoFreezer
+	}
+	first := f.Ancients()
+	if limit > first+max {
+		limit = first + max
+	}
+	var err error
+	for number := first; number < limit && err == nil; number++ {
+		err = freezeBlock(db, f, number)
+	}
+	frozen := f.Ancients()
+	if frozen == first {
+		return 0, err
+	}
+	// Flush the freezer before dropping the blocks from LevelDB
+	if err := f.Sync(); err != nil {
+		return 0, err
+	}
+	batch := new(leveldb.Batch)
+	for number := first; number < frozen; number++ {
+		if number == 0 {
+			continue
+		}
+		hash := common.BytesToHash(readAncient(db, ethdb.FreezerHashTable, number))
+		batch.Delete(append(append(headerPrefix, encodeBlockNumber(number)...), numSuffix...))
+		batch.Delete(headerKey(hash, number))
+		batch.Delete(append(headerKey(hash, number), tdSuffix...))
+		batch.Delete(blockBodyKey(hash, number))
+		batch.Delete(blockReceiptsKey(hash, number))
+	}
+	if err := db.LDB().Write(batch, nil); err != nil {
+		return 0, err
+	}
+	return frozen - first, err
+}
+
+// freezeBlock appends the canonical block number stored in LevelDB to f.
+func freezeBlock(db *ethdb.LDBDatabase, f *ethdb.Freezer, number uint64) error {
+	hash := GetCanonicalHash(db, number)
+	if hash == (common.Hash{}) {
+		return fmt.Errorf("canonical hash of block %d missing", number)
+	}
+	header, _ := db.Get(headerKey(hash, number))
+	if len(header) == 0 {
+		return fmt.Errorf("header of block %d [%x…] missing", number, hash[:4])
+	}
+	body, _ := db.Get(blockBodyKey(hash, number))
+	if len(body) == 0 {
+		return fmt.Errorf("body of block %d [%x…] missing", number, hash[:4])
+	}
+	receipts, _ := db.Get(blockReceiptsKey(hash, number))
+	if len(receipts) == 0 {
+		return fmt.Errorf("receipts of block %d [%x…] missing", number, hash[:4])
+	}
+	td, _ := db.Get(append(headerKey(hash, number), tdSuffix...))
+	if len(td) == 0 {
+		return fmt.Errorf("total difficulty of block %d [%x…] missing", number, hash[:4])
+	}
+	return f.AppendAncient(number, hash[:], header, body, receipts, td)
+}
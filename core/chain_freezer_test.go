@@ -0,0 +1,100 @@
+package core
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/wanchain/go-wanchain/common"
+	"github.com/wanchain/go-wanchain/consensus/ethash"
+	"github.com/wanchain/go-wanchain/core/types"
+	"github.com/wanchain/go-wanchain/core/vm"
+	"github.com/wanchain/go-wanchain/ethdb"
+	"github.com/wanchain/go-wanchain/params"
+)
+
+func TestFreezeBlocks(t *testing.T) {
+	dir, err := ioutil.TempDir("", "freezer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	db, err := ethdb.NewLDBDatabase(dir, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if err := db.OpenFreezer(filepath.Join(dir, "ancient")); err != nil {
+		t.Fatal(err)
+	}
+
+	gspec := DefaultPPOWTestingGenesisBlock()
+	genesis := gspec.MustCommit(db)
+	engine := ethash.NewFaker(db)
+	blockchain, _ := NewBlockChain(db, params.TestChainConfig, engine, vm.Config{}, nil)
+	chainEnv := NewChainEnv(params.TestChainConfig, gspec, engine, blockchain, db)
+	blocks := chainEnv.makeBlockChain(genesis, 10, canonicalSeed)
+	if _, err := blockchain.InsertChain(blocks); err != nil {
+		t.Fatal(err)
+	}
+	blockchain.Stop()
+
+	if frozen, err := FreezeBlocks(db, 6, 4); err != nil || frozen != 4 {
+		t.Fatalf("froze %d blocks: %v", frozen, err)
+	}
+	if frozen, err := FreezeBlocks(db, 6, 100); err != nil || frozen != 2 {
+		t.Fatalf("froze %d blocks: %v", frozen, err)
+	}
+	if frozen, err := FreezeBlocks(db, 6, 100); err != nil || frozen != 0 {
+		t.Fatalf("froze %d blocks again: %v", frozen, err)
+	}
+	if db.Ancients() != 6 {
+		t.Fatalf("%d blocks frozen, want 6", db.Ancients())
+	}
+
+	// The frozen blocks are read from the freezer only
+	blockchain, _ = NewBlockChain(db, params.TestChainConfig, engine, vm.Config{}, nil)
+	defer blockchain.Stop()
+	for _, block := range append([]*types.Block{genesis}, blocks[:5]...) {
+		hash, number := block.Hash(), block.NumberU64()
+		if number != 0 {
+			if ok, _ := db.Has(headerKey(hash, number)); ok {
+				t.Errorf("header of block %d still in leveldb", number)
+			}
+			if ok, _ := db.Has(blockBodyKey(hash, number)); ok {
+				t.Errorf("body of block %d still in leveldb", number)
+			}
+		}
+		if have := GetCanonicalHash(db, number); have != hash {
+			t.Errorf("canonical hash of block %d: have %x, want %x", number, have, hash)
+		}
+		if have := blockchain.GetBlockByNumber(number); have == nil || have.Hash() != hash {
+			t.Errorf("block %d not read back", number)
+		}
+		if GetTd(db, hash, number) == nil {
+			t.Errorf("total difficulty of block %d not read back", number)
+		}
+		if GetBlockReceipts(db, hash, number) == nil {
+			t.Errorf("receipts of block %d not read back", number)
+		}
+		if !blockchain.HasHeader(hash, number) || !blockchain.HasBlock(hash, number) {
+			t.Errorf("block %d not found", number)
+		}
+	}
+	if GetHeader(db, blocks[7].Hash(), 3) != nil {
+		t.Errorf("frozen block read for another hash")
+	}
+
+	// Rewinding the chain drops the frozen blocks above the new head
+	if err := blockchain.SetHead(3); err != nil {
+		t.Fatal(err)
+	}
+	if db.Ancients() != 4 {
+		t.Fatalf("%d blocks frozen after the rewind, want 4", db.Ancients())
+	}
+	if GetCanonicalHash(db, 4) != (common.Hash{}) {
+		t.Errorf("rewound block 4 still canonical")
+	}
+}
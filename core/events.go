@@ -53,8 +53,33 @@ type ChainSideEvent struct {
 
 type ChainHeadEvent struct{ Block *types.Block }
 
+// StableHeadEvent is posted when a block gets confirmed by the pos protocol.
+type StableHeadEvent struct{ Header *types.Header }
+
+// ReorgEvent is posted when the canonical chain switches to another branch.
+// EpochId and SlotId are those of the first block of the new branch, Len is
+// the number of blocks dropped from the old one.
 type ReorgEvent struct {
-	EpochId uint64
-	SlotId  uint64
-	Len     uint64
+	EpochId uint64 `json:"epochId"`
+	SlotId  uint64 `json:"slotId"`
+	Len     uint64 `json:"length"`
+
+	OldHead        common.Hash   `json:"oldHead"`
+	OldNumber      uint64        `json:"oldNumber"`
+	NewHead        common.Hash   `json:"newHead"`
+	NewNumber      uint64        `json:"newNumber"`
+	CommonAncestor common.Hash   `json:"commonAncestor"`
+	CommonNumber   uint64        `json:"commonNumber"`
+	Dropped        []ReorgBlock  `json:"droppedBlocks"` // blocks of the old branch, newest first
+	DroppedTxs     []common.Hash `json:"droppedTxs"`    // transactions of the old branch missing from the new one
+	Time           uint64        `json:"time"`          // unix time the reorg happened at
+}
+
+// ReorgBlock is a block dropped by a reorg along with its slot leader.
+type ReorgBlock struct {
+	Number  uint64         `json:"number"`
+	Hash    common.Hash    `json:"hash"`
+	EpochId uint64         `json:"epochId"`
+	SlotId  uint64         `json:"slotId"`
+	Leader  common.Address `json:"leader"`
 }
@@ -382,7 +382,7 @@ func (hc *HeaderChain) HasHeader(hash common.Hash, number uint64) bool {
 		return true
 	}
 	ok, _ := hc.chainDb.Has(headerKey(hash, number))
-	return ok
+	return ok || isAncient(hc.chainDb, hash, number)
 }
 
 // GetHeaderByNumber retrieves a block header from the database by number,
@@ -0,0 +1,78 @@
+// Copyright 2018 Wanchain Foundation Ltd
+// This file is part of the go-wanchain library.
+//
+// The go-wanchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-wanchain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-wanchain library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"encoding/binary"
+
+	"github.com/wanchain/go-wanchain/log"
+	"github.com/wanchain/go-wanchain/pos/posdb"
+	"github.com/wanchain/go-wanchain/rlp"
+)
+
+// Keys of the reorg history in the reorg local database, per epoch.
+const (
+	reorgEventCountKey = "reorgEventCount" // number of reorg events of the epoch (uint64 big endian)
+	reorgEventKey      = "reorgEvent"      // reorgEventKey with the event index -> rlp(ReorgEvent)
+)
+
+// WriteReorgEvent appends a reorg event to the history of its epoch.
+func WriteReorgEvent(db *posdb.Db, ev *ReorgEvent) {
+	count := ReadReorgEventCount(db, ev.EpochId)
+	data, err := rlp.EncodeToBytes(ev)
+	if err != nil {
+		log.Crit("Failed to RLP encode reorg event", "err", err)
+	}
+	if _, err := db.PutWithIndex(ev.EpochId, count, reorgEventKey, data); err != nil {
+		log.Error("Failed to store reorg event", "epochID", ev.EpochId, "err", err)
+		return
+	}
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, count+1)
+	if _, err := db.Put(ev.EpochId, reorgEventCountKey, b); err != nil {
+		log.Error("Failed to store reorg event count", "epochID", ev.EpochId, "err", err)
+	}
+}
+
+// ReadReorgEventCount returns the number of reorg events of an epoch.
+func ReadReorgEventCount(db *posdb.Db, epochId uint64) uint64 {
+	data, err := db.Get(epochId, reorgEventCountKey)
+	if err != nil || len(data) != 8 {
+		return 0
+	}
+	return binary.BigEndian.Uint64(data)
+}
+
+// ReadReorgEvents returns the reorg events of an epoch in the order they
+// happened.
+func ReadReorgEvents(db *posdb.Db, epochId uint64) []*ReorgEvent {
+	count := ReadReorgEventCount(db, epochId)
+	events := make([]*ReorgEvent, 0, count)
+	for i := uint64(0); i < count; i++ {
+		data, err := db.GetWithIndex(epochId, i, reorgEventKey)
+		if err != nil {
+			continue
+		}
+		ev := new(ReorgEvent)
+		if err := rlp.DecodeBytes(data, ev); err != nil {
+			log.Error("Invalid reorg event", "epochID", epochId, "index", i, "err", err)
+			continue
+		}
+		events = append(events, ev)
+	}
+	return events
+}
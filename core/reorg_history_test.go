@@ -0,0 +1,105 @@
+package core
+
+import (
+	"testing"
+	"time"
+
+	"github.com/wanchain/go-wanchain/common"
+	"github.com/wanchain/go-wanchain/pos/posconfig"
+	"github.com/wanchain/go-wanchain/pos/posdb"
+	posUtil "github.com/wanchain/go-wanchain/pos/util"
+)
+
+// Tests that a reorg is announced and recorded with the blocks it dropped.
+func TestReorgEvent(t *testing.T) {
+	bc, _ := newTestBlockChain(true)
+	defer bc.Stop()
+
+	events := make(chan ReorgEvent, 1)
+	sub := bc.SubscribeReorgEvent(events)
+	defer sub.Unsubscribe()
+
+	first := makeBlockChainWithDiff(bc.genesisBlock, []int{1, 2, 3, 4}, 11)
+	second := makeBlockChainWithDiff(bc.genesisBlock, []int{1, 10}, 22)
+	if _, err := bc.InsertChain(first); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := bc.InsertChain(second); err != nil {
+		t.Fatal(err)
+	}
+
+	var ev ReorgEvent
+	select {
+	case ev = <-events:
+	case <-time.After(time.Second):
+		t.Fatal("reorg event not sent")
+	}
+	if ev.Len != 4 || ev.OldHead != first[3].Hash() || ev.OldNumber != 4 || ev.NewHead != second[1].Hash() || ev.NewNumber != 2 {
+		t.Fatalf("reorg heads mismatch: %+v", ev)
+	}
+	if ev.CommonAncestor != bc.genesisBlock.Hash() || ev.CommonNumber != 0 {
+		t.Fatalf("common ancestor mismatch: have %x #%d, want %x #0", ev.CommonAncestor, ev.CommonNumber, bc.genesisBlock.Hash())
+	}
+	if len(ev.Dropped) != 4 {
+		t.Fatalf("dropped blocks mismatch: have %d, want 4", len(ev.Dropped))
+	}
+	for i, block := range ev.Dropped {
+		want := first[3-i]
+		if block.Hash != want.Hash() || block.Number != want.NumberU64() || block.Leader != (common.Address{11}) {
+			t.Errorf("dropped block %d mismatch: %+v", i, block)
+		}
+	}
+
+	// The event is the last one of its epoch in the reorg history
+	history := ReadReorgEvents(posdb.GetDbByName(posconfig.ReorgLocalDB), ev.EpochId)
+	if len(history) == 0 {
+		t.Fatal("reorg event not recorded")
+	}
+	if last := history[len(history)-1]; last.NewHead != ev.NewHead || last.OldHead != ev.OldHead || len(last.Dropped) != 4 {
+		t.Fatalf("recorded reorg event mismatch: %+v", last)
+	}
+}
+
+// Tests that a reorg longer than K is rejected without being announced or
+// recorded.
+func TestReorgEventRejected(t *testing.T) {
+	bc, _ := newTestBlockChain(true)
+	defer bc.Stop()
+
+	firstEpochId, k := posconfig.FirstEpochId, posconfig.Cfg().K
+	posconfig.FirstEpochId, posconfig.Cfg().K = 1, 2
+	defer func() { posconfig.FirstEpochId, posconfig.Cfg().K = firstEpochId, k }()
+
+	events := make(chan ReorgEvent, 1)
+	sub := bc.SubscribeReorgEvent(events)
+	defer sub.Unsubscribe()
+
+	first := makeBlockChainWithDiff(bc.genesisBlock, []int{1, 2, 3, 4}, 11)
+	second := makeBlockChainWithDiff(bc.genesisBlock, []int{1, 1, 10}, 22)
+	if _, err := bc.InsertChain(first); err != nil {
+		t.Fatal(err)
+	}
+	reorgDb := posdb.NewDb(posconfig.ReorgLocalDB)
+	counts := make(map[uint64]uint64)
+	for _, block := range append(first, second...) {
+		epochId, _ := posUtil.CalEpSlbyTd(block.Difficulty().Uint64())
+		counts[epochId] = ReadReorgEventCount(reorgDb, epochId)
+	}
+	if _, err := bc.InsertChain(second); err != ErrSecurityViolated {
+		t.Fatalf("reorg error mismatch: have %v, want %v", err, ErrSecurityViolated)
+	}
+	if head := bc.CurrentBlock().Hash(); head != first[3].Hash() {
+		t.Fatalf("head mismatch: have %x, want %x", head, first[3].Hash())
+	}
+
+	select {
+	case ev := <-events:
+		t.Fatalf("rejected reorg announced: %+v", ev)
+	case <-time.After(100 * time.Millisecond):
+	}
+	for epochId, count := range counts {
+		if have := ReadReorgEventCount(reorgDb, epochId); have != count {
+			t.Fatalf("rejected reorg recorded in epoch %d: have %d events, want %d", epochId, have, count)
+		}
+	}
+}
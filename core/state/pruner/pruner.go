@@ -0,0 +1,190 @@
+// Copyright 2018 Wanchain Foundation Ltd
+// This file is part of the go-wanchain library.
+//
+// The go-wanchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-wanchain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-wanchain library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package pruner removes the historical states of a chain database which are
+// no longer needed. It runs offline, on the database of a stopped node.
+package pruner
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/util"
+	"github.com/wanchain/go-wanchain/common"
+	"github.com/wanchain/go-wanchain/core/state"
+	"github.com/wanchain/go-wanchain/crypto"
+	"github.com/wanchain/go-wanchain/ethdb"
+	"github.com/wanchain/go-wanchain/log"
+	"github.com/wanchain/go-wanchain/rlp"
+	"github.com/wanchain/go-wanchain/trie"
+)
+
+var emptyCodeHash = crypto.Keccak256(nil)
+
+// Stats summarizes a pruning.
+type Stats struct {
+	Kept    int                // trie nodes and codes of the retained states
+	Deleted int                // trie nodes and codes deleted
+	Size    common.StorageSize // size of the deleted entries
+	Missing []common.Hash      // retained roots whose state is incomplete
+}
+
+// Prune deletes from db the state trie nodes and contract codes which are not
+// part of the states of roots. The first root is the state the node runs on,
+// nothing is deleted unless it is complete. The states of the other roots are
+// kept as far as they are present in db.
+//
+// The trie nodes and codes are the entries keyed by the hash of their value.
+// The hashes of the retained entries are kept in memory while pruning.
+func Prune(db *ethdb.LDBDatabase, roots []common.Hash) (*Stats, error) {
+	if len(roots) == 0 {
+		return nil, fmt.Errorf("no state to retain")
+	}
+	stats := new(Stats)
+	marked := make(map[common.Hash]struct{})
+	sdb := state.NewDatabase(db)
+
+	start := time.Now()
+	for i, root := range roots {
+		if err := markState(sdb, root, marked); err != nil {
+			if _, ok := err.(*trie.MissingNodeError); !ok || i == 0 {
+				return nil, fmt.Errorf("state %x: %v", root, err)
+			}
+			log.Warn("Retained state incomplete", "root", root, "err", err)
+			stats.Missing = append(stats.Missing, root)
+		}
+	}
+	stats.Kept = len(marked)
+	log.Info("Marked retained states", "roots", len(roots), "nodes", stats.Kept, "elapsed", common.PrettyDuration(time.Since(start)))
+
+	start = time.Now()
+	if err := sweep(db, marked, stats); err != nil {
+		return stats, err
+	}
+	log.Info("Deleted pruned states", "nodes", stats.Deleted, "size", stats.Size, "elapsed", common.PrettyDuration(time.Since(start)))
+
+	start = time.Now()
+	if err := db.LDB().CompactRange(util.Range{}); err != nil {
+		return stats, err
+	}
+	log.Info("Compacted database", "elapsed", common.PrettyDuration(time.Since(start)))
+	return stats, nil
+}
+
+// markState adds the trie nodes and codes of the state of root to marked. The
+// subtries already marked are not visited again, as the states of the chain
+// share most of their nodes.
+func markState(sdb state.Database, root common.Hash, marked map[common.Hash]struct{}) error {
+	tr, err := sdb.OpenTrie(root)
+	if err != nil {
+		return err
+	}
+	return markTrie(tr.NodeIterator(nil), marked, func(leaf []byte) error {
+		var account state.Account
+		if err := rlp.DecodeBytes(leaf, &account); err != nil {
+			return err
+		}
+		if !bytes.Equal(account.CodeHash, emptyCodeHash) {
+			marked[common.BytesToHash(account.CodeHash)] = struct{}{}
+		}
+		storage, err := sdb.OpenStorageTrie(common.Hash{}, account.Root)
+		if err != nil {
+			return err
+		}
+		return markTrie(storage.NodeIterator(nil), marked, nil)
+	})
+}
+
+func markTrie(it trie.NodeIterator, marked map[common.Hash]struct{}, onLeaf func([]byte) error) error {
+	descend := true
+	for it.Next(descend) {
+		descend = true
+		if hash := it.Hash(); hash != (common.Hash{}) {
+			if _, ok := marked[hash]; ok {
+				descend = false
+				continue
+			}
+			marked[hash] = struct{}{}
+		}
+		if it.Leaf() && onLeaf != nil {
+			if err := onLeaf(it.LeafBlob()); err != nil {
+				return err
+			}
+		}
+	}
+	return it.Error()
+}
+
+// sweep deletes the trie nodes and codes of db which are not marked.
+func sweep(db *ethdb.LDBDatabase, marked map[common.Hash]struct{}, stats *Stats) error {
+	it := db.NewIterator()
+	defer it.Release()
+
+	batch := new(leveldb.Batch)
+	for it.Next() {
+		key := it.Key()
+		if len(key) != common.HashLength {
+			continue
+		}
+		hash := common.BytesToHash(key)
+		if _, ok := marked[hash]; ok {
+			continue
+		}
+		if crypto.Keccak256Hash(it.Value()) != hash {
+			// Not a trie node nor a code
+			continue
+		}
+		batch.Delete(key)
+		stats.Deleted++
+		stats.Size += common.StorageSize(len(key) + len(it.Value()))
+
+		if batch.Len() >= 10000 {
+			if err := db.LDB().Write(batch, nil); err != nil {
+				return err
+			}
+			batch.Reset()
+			log.Info("Deleting pruned states", "nodes", stats.Deleted, "size", stats.Size)
+		}
+	}
+	if err := it.Error(); err != nil {
+		return err
+	}
+	return db.LDB().Write(batch, nil)
+}
+
+// CheckStorage verifies that the storage of accounts is complete in the state
+// of root.
+func CheckStorage(db ethdb.Database, root common.Hash, accounts []common.Address) error {
+	statedb, err := state.New(root, state.NewDatabase(db))
+	if err != nil {
+		return err
+	}
+	for _, addr := range accounts {
+		tr := statedb.StorageTrie(addr)
+		if tr == nil {
+			continue
+		}
+		it := tr.NodeIterator(nil)
+		for it.Next(true) {
+		}
+		if err := it.Error(); err != nil {
+			return fmt.Errorf("storage of %x: %v", addr, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,118 @@
+package pruner
+
+import (
+	"io/ioutil"
+	"math/big"
+	"os"
+	"testing"
+
+	"github.com/wanchain/go-wanchain/common"
+	"github.com/wanchain/go-wanchain/core/state"
+	"github.com/wanchain/go-wanchain/crypto"
+	"github.com/wanchain/go-wanchain/ethdb"
+)
+
+func newTestDB(t *testing.T) (*ethdb.LDBDatabase, func()) {
+	dir, err := ioutil.TempDir("", "pruner")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db, err := ethdb.NewLDBDatabase(dir, 0, 0)
+	if err != nil {
+		os.RemoveAll(dir)
+		t.Fatal(err)
+	}
+	return db, func() {
+		db.Close()
+		os.RemoveAll(dir)
+	}
+}
+
+// commitStates commits a state per round, each round changing the balances
+// and storage of a few accounts.
+func commitStates(t *testing.T, db ethdb.Database, rounds int) []common.Hash {
+	var (
+		roots []common.Hash
+		root  common.Hash
+	)
+	for r := 0; r < rounds; r++ {
+		statedb, err := state.New(root, state.NewDatabase(db))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := byte(0); i < 20; i++ {
+			addr := common.BytesToAddress([]byte{i})
+			statedb.AddBalance(addr, big.NewInt(int64(r+1)))
+			if i%4 == 0 {
+				statedb.SetState(addr, common.BytesToHash([]byte{byte(r)}), common.BytesToHash([]byte{i, byte(r)}))
+				statedb.SetStateByteArray(addr, common.BytesToHash([]byte{i}), []byte{byte(r), i})
+				statedb.SetCode(addr, []byte{i, byte(r)})
+			}
+		}
+		if root, err = statedb.CommitTo(db, false); err != nil {
+			t.Fatal(err)
+		}
+		roots = append(roots, root)
+	}
+	return roots
+}
+
+func stateComplete(db ethdb.Database, root common.Hash) bool {
+	marked := make(map[common.Hash]struct{})
+	if err := markState(state.NewDatabase(db), root, marked); err != nil {
+		return false
+	}
+	for hash := range marked {
+		if ok, _ := db.Has(hash[:]); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func TestPrune(t *testing.T) {
+	db, done := newTestDB(t)
+	defer done()
+
+	roots := commitStates(t, db, 5)
+	other := crypto.Keccak256([]byte("not a trie node"))
+	db.Put(other, []byte("value"))
+	db.Put([]byte("LastBlock"), roots[4][:])
+
+	stats, err := Prune(db, []common.Hash{roots[4], roots[1]})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Deleted == 0 || len(stats.Missing) != 0 {
+		t.Fatalf("unexpected pruning: %+v", stats)
+	}
+	for i, root := range roots {
+		want := i == 1 || i == 4
+		if have := stateComplete(db, root); have != want {
+			t.Errorf("state %d complete: have %v, want %v", i, have, want)
+		}
+	}
+	if ok, _ := db.Has(other); !ok {
+		t.Error("entry not keyed by the hash of its value deleted")
+	}
+	if ok, _ := db.Has([]byte("LastBlock")); !ok {
+		t.Error("chain entry deleted")
+	}
+	addrs := []common.Address{common.BytesToAddress([]byte{0}), common.BytesToAddress([]byte{4})}
+	if err := CheckStorage(db, roots[4], addrs); err != nil {
+		t.Error(err)
+	}
+
+	// The state the node runs on must be complete, the others are kept as
+	// far as they are present.
+	if _, err := Prune(db, []common.Hash{roots[2]}); err == nil {
+		t.Error("pruned to an incomplete state")
+	}
+	stats, err = Prune(db, []common.Hash{roots[4], roots[1], roots[3]})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Deleted != 0 || len(stats.Missing) != 1 {
+		t.Fatalf("unexpected pruning: %+v", stats)
+	}
+}
@@ -18,6 +18,7 @@
 package vm
 
 import (
+	"bytes"
 	"crypto/sha256"
 	"errors"
 	"math/big"
@@ -772,6 +773,33 @@ func (c *wanCoinSC) ValidBuyCoinReq(stateDB StateDB, payload []byte, txValue *bi
 	return wanAddr, nil
 }
 
+// UnpackBuyCoinTx returns the OTA address and value of a transaction calling
+// buyCoinNote of the wancoin precompiled contract, or an error if the
+// transaction is not such a call.
+func UnpackBuyCoinTx(tx *types.Transaction) (otaAddr []byte, value *big.Int, err error) {
+	if tx.To() == nil || *tx.To() != wanCoinPrecompileAddr {
+		return nil, nil, errParameters
+	}
+	input := tx.Data()
+	if len(input) < 4 || !bytes.Equal(input[:4], buyIdArr[:]) {
+		return nil, nil, errMethodId
+	}
+
+	var outStruct struct {
+		OtaAddr string
+		Value   *big.Int
+	}
+	err = coinAbi.Unpack(&outStruct, "buyCoinNote", input[4:])
+	if err != nil || outStruct.Value == nil {
+		return nil, nil, errBuyCoin
+	}
+	otaAddr, err = hexutil.Decode(outStruct.OtaAddr)
+	if err != nil || len(otaAddr) != common.WAddressLength {
+		return nil, nil, ErrInvalidOTAAddr
+	}
+	return otaAddr, outStruct.Value, nil
+}
+
 func (c *wanCoinSC) buyCoin(in []byte, contract *Contract, evm *EVM) ([]byte, error) {
 	otaAddr, err := c.ValidBuyCoinReq(evm.StateDB, in, contract.value)
 	if err != nil {
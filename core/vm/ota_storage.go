@@ -38,6 +38,20 @@ func OTABalance2ContractAddr(balance *big.Int) common.Address {
 	//	return common.BigToAddress(balance)
 }
 
+// OTAStorageAddrs returns the accounts storing the OTAs of the privacy
+// contracts: the OTA sets of the wancoin and stamp values, the OTA balances and
+// the images of the spent OTAs.
+func OTAStorageAddrs() []common.Address {
+	addrs := []common.Address{otaBalanceStorageAddr, otaImageStorageAddr}
+	for _, set := range []map[string]string{WanCoinValueSet, StampValueSet} {
+		for _, value := range set {
+			balance, _ := new(big.Int).SetString(value, 10)
+			addrs = append(addrs, OTABalance2ContractAddr(balance))
+		}
+	}
+	return addrs
+}
+
 // GetAXFromWanAddr retrieve ota AX from ota WanAddr
 func GetAXFromWanAddr(otaWanAddr []byte) ([]byte, error) {
 	if len(otaWanAddr) != common.WAddressLength {
@@ -1605,3 +1605,33 @@ func TestAddOTAImage(t *testing.T) {
 		t.Errorf("err:%s", err.Error())
 	}
 }
+
+func TestOTAStorageAddrs(t *testing.T) {
+	var (
+		db, _      = ethdb.NewMemDatabase()
+		statedb, _ = state.New(common.Hash{}, state.NewDatabase(db))
+
+		otaWanAddr = common.FromHex(otaShortAddrs[1])
+		balance    = big.NewInt(0).Mul(big.NewInt(10), ether)
+	)
+
+	addrs := OTAStorageAddrs()
+	if len(addrs) != 2+len(WanCoinValueSet)+len(StampValueSet) {
+		t.Fatalf("OTA storage addresses count %d", len(addrs))
+	}
+
+	// An OTA is stored in the accounts of its value and of the OTA balances
+	if _, err := AddOTAIfNotExist(statedb, balance, otaWanAddr); err != nil {
+		t.Fatal(err)
+	}
+	stored := 0
+	for _, addr := range addrs {
+		statedb.ForEachStorageByteArray(addr, func(key common.Hash, value []byte) bool {
+			stored++
+			return true
+		})
+	}
+	if stored != 2 {
+		t.Errorf("OTA stored %d times in the OTA storage accounts, want 2", stored)
+	}
+}
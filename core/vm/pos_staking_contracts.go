@@ -60,7 +60,8 @@ const (
 	QuitDelay             = 3
 	JoinDelay             = 2
 	PSOutKeyHash          = 700
-	maxPartners           = 5
+	PSMaxPartners         = 5
+	maxPartners           = PSMaxPartners
 )
 
 var (
@@ -614,7 +615,12 @@ func (p *PosStaking) Run(input []byte, contract *Contract, evm *EVM) ([]byte, er
 }
 
 func (p *PosStaking) ValidTx(stateDB StateDB, signer types.Signer, tx *types.Transaction) error {
-	input := tx.Data()
+	eidNow, _ := util.CalEpochSlotID(uint64(time.Now().Unix()))
+	return p.ValidInput(tx.Data(), eidNow)
+}
+
+// ValidInput checks the input of a call in epoch epochID, without the state.
+func (p *PosStaking) ValidInput(input []byte, epochID uint64) error {
 	if len(input) < 4 {
 		return errors.New("parameter is too short")
 	}
@@ -626,8 +632,7 @@ func (p *PosStaking) ValidTx(stateDB StateDB, signer types.Signer, tx *types.Tra
 	copy(methodId[:], input[:4])
 
 	if methodId == stakeRegisterId {
-		eidNow, _ := util.CalEpochSlotID(uint64(time.Now().Unix()))
-		if eidNow < posconfig.ApolloEpochID {
+		if epochID < posconfig.ApolloEpochID {
 			return  errors.New("stakeRegister haven't enabled.")
 		}
 		_, err := p.stakeRegisterParseAndValid(input[4:])
@@ -672,8 +677,7 @@ func (p *PosStaking) ValidTx(stateDB StateDB, signer types.Signer, tx *types.Tra
 		}
 		return nil
 	} else if methodId == stakeUpdateFeeRateId {
-		eidNow, _ := util.CalEpochSlotID(uint64(time.Now().Unix()))
-		if eidNow < posconfig.ApolloEpochID {
+		if epochID < posconfig.ApolloEpochID {
 			return  errors.New("stakeUpdateFeeRateId haven't enabled.")
 		}
 		_, err := p.updateFeeRateParseAndValid(input[4:])
@@ -1202,6 +1206,12 @@ func CalLocktimeWeight(lockEpoch uint64) uint64 {
 	return 960 + lockEpoch*6
 }
 
+// GetPosStakingAbi returns the abi of the pos staking contract, for the tools
+// building its calls.
+func GetPosStakingAbi() abi.ABI {
+	return cscAbi
+}
+
 func GetStakeInKeyHash(address common.Address) common.Hash {
 	return common.BytesToHash(address[:])
 }
@@ -888,6 +888,30 @@ func TestStakeUpdateParam(t *testing.T) {
 	}
 }
 
+func TestValidInput(t *testing.T) {
+	input := getStakeInParam()
+	input.LockEpochs = big.NewInt(5)
+	bytes, err := cscAbi.Pack("stakeIn", input.SecPk, input.Bn256Pk, input.LockEpochs, input.FeeRate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = stakercontract.ValidInput(bytes, posconfig.ApolloEpochID)
+	if err == nil || err.Error() != "stakein verify failed" {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	bytes, err = cscAbi.Pack("stakeUpdateFeeRate", common.Address{1}, big.NewInt(100))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := stakercontract.ValidInput(bytes, posconfig.ApolloEpochID-1); err == nil {
+		t.Fatal("stakeUpdateFeeRate accepted before the apollo epoch")
+	}
+	if err := stakercontract.ValidInput(bytes, posconfig.ApolloEpochID); err != nil {
+		t.Fatal(err)
+	}
+}
+
 func TestUpdateFeeRateParam(t *testing.T) {
 	var input UpdateFeeRateParam
 	input.FeeRate = big.NewInt(5)
@@ -695,6 +695,16 @@ func IsRBActive(db StateDB, epochId uint64, proposerId uint32) bool {
 	return false
 }
 
+// GetRBProgress returns whether the dkg1, dkg2 and sig share of a proposer are
+// in the state.
+func GetRBProgress(db StateDB, epochId uint64, proposerId uint32) (dkg1, dkg2, sig bool) {
+	has := func(kind []byte) bool {
+		hash := GetRBKeyHash(kind, epochId, proposerId)
+		return len(db.GetStateByteArray(randomBeaconPrecompileAddr, *hash)) != 0
+	}
+	return has(kindCij), has(kindEns), has(sigShareId[:])
+}
+
 //
 // help function for serial
 //
@@ -1073,6 +1083,60 @@ func (c *RandomBeaconContract) sigShare(payload []byte, contract *Contract, evm
 	return nil, nil
 }
 
+// GetRandomProof returns the evidence of the random number of an epoch, which
+// the random proposers of the epoch before generated.
+func GetRandomProof(db StateDB, epochId uint64) (*rbselection.RandomProof, error) {
+	if epochId <= posconfig.FirstEpochId {
+		return nil, errors.New("random of the first epochs has no proof")
+	}
+	random := GetStateR(db, epochId)
+	if random == nil {
+		return nil, errors.New("random not generated, epochId " + strconv.FormatUint(epochId, 10))
+	}
+
+	eid := epochId - 1
+	pks, err := getRBProposerGroupVar(eid)
+	if err != nil {
+		return nil, err
+	}
+	mBuf, err := getRBMVar(db, eid)
+	if err != nil {
+		return nil, err
+	}
+
+	proof := &rbselection.RandomProof{
+		EpochId:     epochId,
+		Random:      random,
+		M:           new(big.Int).SetBytes(mBuf),
+		ProposerPks: pks,
+		Commits:     make([][]*bn256.G2, len(pks)),
+		Shares:      make([]*bn256.G1, len(pks)),
+	}
+	for id := range pks {
+		if IsJoinDKG2(db, eid, uint32(id)) {
+			if proof.Commits[id], err = GetCji(db, eid, uint32(id)); err != nil {
+				return nil, err
+			}
+		}
+		sig, err := GetSig(db, eid, uint32(id))
+		if err != nil {
+			return nil, err
+		}
+		if sig != nil {
+			proof.Shares[id] = sig.GSignShare
+		}
+	}
+
+	degree := int(posconfig.Cfg().PolymDegree)
+	if proof.GroupPublicKey, err = rbselection.GroupPublicKey(pks, proof.Commits, degree); err != nil {
+		return nil, err
+	}
+	if proof.Signature, err = rbselection.GroupSignature(pks, proof.Shares, degree); err != nil {
+		return nil, err
+	}
+	return proof, nil
+}
+
 //
 // calc random
 //
@@ -845,6 +845,16 @@ func GetValidSMA2Cnt(db StateDB, epochId uint64) uint64 {
 	return getValidIndexCnt(db, epochId, SlotLeaderStag2Indexes)
 }
 
+// GetSMAProgress tells whether the stage 1 and stage 2 transactions of the
+// epoch leader of an index are in the state.
+func GetSMAProgress(db StateDB, epochId uint64, index uint64) (stage1, stage2 bool) {
+	epochIDBuf := convert.Uint64ToBytes(epochId)
+	indexBuf := convert.Uint64ToBytes(index)
+	stage1 = len(db.GetStateByteArray(slotLeaderPrecompileAddr, GetSlotLeaderStage1KeyHash(epochIDBuf, indexBuf))) != 0
+	stage2 = len(db.GetStateByteArray(slotLeaderPrecompileAddr, GetSlotLeaderStage2KeyHash(epochIDBuf, indexBuf))) != 0
+	return stage1, stage2
+}
+
 func getValidIndexCnt(db StateDB, epochId uint64, indexKey string) uint64 {
 	var sendtransGet [posconfig.EpochLeaderCount]bool
 	epochIDBuf := convert.Uint64ToBytes(epochId)
@@ -398,6 +398,12 @@ func xScalarHashP(x []byte, pub *ecdsa.PublicKey) (I *ecdsa.PublicKey) {
 	return
 }
 
+// GenerateKeyImage calculates the key image [x]Hash(P) of an OTA, which is
+// recorded on chain once the OTA is refunded.
+func GenerateKeyImage(x []byte, pub *ecdsa.PublicKey) *ecdsa.PublicKey {
+	return xScalarHashP(x, pub)
+}
+
 var (
 	ErrInvalidRingSignParams = errors.New("invalid ring sign params")
 	ErrRingSignFail          = errors.New("ring sign fail")
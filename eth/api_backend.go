@@ -33,6 +33,7 @@ import (
 	"github.com/wanchain/go-wanchain/ethdb"
 	"github.com/wanchain/go-wanchain/event"
 	"github.com/wanchain/go-wanchain/params"
+	"github.com/wanchain/go-wanchain/pos/cfm"
 	"github.com/wanchain/go-wanchain/rpc"
 )
 
@@ -65,6 +66,9 @@ func (b *EthApiBackend) HeaderByNumber(ctx context.Context, blockNr rpc.BlockNum
 	if blockNr == rpc.LatestBlockNumber {
 		return b.eth.blockchain.CurrentBlock().Header(), nil
 	}
+	if blockNr == rpc.StableBlockNumber {
+		return b.eth.blockchain.GetHeaderByNumber(b.stableBlockNumber()), nil
+	}
 	return b.eth.blockchain.GetHeaderByNumber(uint64(blockNr)), nil
 }
 
@@ -78,9 +82,24 @@ func (b *EthApiBackend) BlockByNumber(ctx context.Context, blockNr rpc.BlockNumb
 	if blockNr == rpc.LatestBlockNumber {
 		return b.eth.blockchain.CurrentBlock(), nil
 	}
+	if blockNr == rpc.StableBlockNumber {
+		return b.eth.blockchain.GetBlockByNumber(b.stableBlockNumber()), nil
+	}
 	return b.eth.blockchain.GetBlockByNumber(uint64(blockNr)), nil
 }
 
+// stableBlockNumber returns the number of the highest block confirmed by the
+// pos protocol, or buried under cfm.SecPowBlks blocks before pos starts.
+func (b *EthApiBackend) stableBlockNumber() uint64 {
+	if c := cfm.GetCFM(); c != nil {
+		return c.GetMaxStableBlkNumber()
+	}
+	if number := b.eth.blockchain.CurrentBlock().NumberU64(); number > cfm.SecPowBlks {
+		return number - cfm.SecPowBlks
+	}
+	return 0
+}
+
 func (b *EthApiBackend) StateAndHeaderByNumber(ctx context.Context, blockNr rpc.BlockNumber) (*state.StateDB, *types.Header, error) {
 	// Pending state is only known by the miner
 	if blockNr == rpc.PendingBlockNumber {
@@ -128,6 +147,14 @@ func (b *EthApiBackend) SubscribeChainHeadEvent(ch chan<- core.ChainHeadEvent) e
 	return b.eth.BlockChain().SubscribeChainHeadEvent(ch)
 }
 
+func (b *EthApiBackend) SubscribeStableHeadEvent(ch chan<- core.StableHeadEvent) event.Subscription {
+	return cfm.SubscribeStableHeadEvent(ch)
+}
+
+func (b *EthApiBackend) SubscribeReorgEvent(ch chan<- core.ReorgEvent) event.Subscription {
+	return b.eth.BlockChain().SubscribeReorgEvent(ch)
+}
+
 func (b *EthApiBackend) SubscribeChainSideEvent(ch chan<- core.ChainSideEvent) event.Subscription {
 	return b.eth.BlockChain().SubscribeChainSideEvent(ch)
 }
@@ -21,7 +21,13 @@ import (
 	"errors"
 	"fmt"
 	"github.com/wanchain/go-wanchain/accounts/keystore"
+	"github.com/wanchain/go-wanchain/pos/equivocation"
+	"github.com/wanchain/go-wanchain/pos/incentive"
 	"github.com/wanchain/go-wanchain/pos/posapi"
+	"github.com/wanchain/go-wanchain/pos/posconfig"
+	"github.com/wanchain/go-wanchain/pos/possigner"
+	"github.com/wanchain/go-wanchain/pos/stakingindex"
+	"github.com/wanchain/go-wanchain/pos/validatorhealth"
 	"math/big"
 	"runtime"
 	"sync"
@@ -41,6 +47,7 @@ import (
 	"github.com/wanchain/go-wanchain/eth/downloader"
 	"github.com/wanchain/go-wanchain/eth/filters"
 	"github.com/wanchain/go-wanchain/eth/gasprice"
+	"github.com/wanchain/go-wanchain/eth/otascan"
 	"github.com/wanchain/go-wanchain/ethdb"
 	"github.com/wanchain/go-wanchain/event"
 	"github.com/wanchain/go-wanchain/internal/ethapi"
@@ -83,6 +90,11 @@ type Ethereum struct {
 
 	bloomRequests chan chan *bloombits.Retrieval // Channel receiving bloom data retrieval requests
 	bloomIndexer  *core.ChainIndexer             // Bloom indexer operating during block imports
+	otaScanner    *otascan.Scanner               // Indexer of the OTAs received by the keystore accounts
+	stakingIndex  *stakingindex.Indexer          // Indexer of the staking history of the accounts
+	health        *validatorhealth.Monitor       // Monitor of the duties of the validators
+	equivocations *equivocation.Detector         // Detector of the slot leaders signing two blocks of a slot
+	freezer       *chainFreezer                  // Mover of the stable blocks to the freezer
 
 	ApiBackend *EthApiBackend
 
@@ -163,6 +175,9 @@ func New(ctx *node.ServiceContext, config *Config) (*Ethereum, error) {
 	}
 	//eth.blockchain.RegisterSwitchEngine(eth)
 	eth.blockchain.PrependRegisterSwitchEngine(eth)
+	eth.equivocations = equivocation.New(chainDb, chainConfig, posEngine, eth.blockchain)
+	eth.blockchain.SetEquivocationDetector(eth.equivocations)
+	incentive.CheckHistory(chainDb)
 	// Rewind the chain in case of an incompatible config upgrade.
 	if compat, ok := genesisErr.(*params.ConfigCompatError); ok {
 		log.Warn("Rewinding chain to upgrade configuration", "err", compat)
@@ -184,6 +199,24 @@ func New(ctx *node.ServiceContext, config *Config) (*Ethereum, error) {
 	if eth.protocolManager, err = NewProtocolManager(eth.chainConfig, config.SyncMode, config.NetworkId, eth.eventMux, eth.txPool, eth.engine, eth.blockchain, chainDb); err != nil {
 		return nil, err
 	}
+	eth.protocolManager.equivocations = eth.equivocations
+	eth.protocolManager.posSyncer.signer = eth.minerSigner
+	if config.OTAScan {
+		if backends := ctx.AccountManager.Backends(keystore.KeyStoreType); len(backends) > 0 {
+			ks := backends[0].(*keystore.KeyStore)
+			if watchOnly := ctx.AccountManager.Backends(keystore.WatchOnlyKeyStoreType); len(watchOnly) > 0 {
+				eth.otaScanner = otascan.New(eth.blockchain, chainDb, ks, watchOnly[0].(*keystore.WatchOnlyKeyStore))
+			} else {
+				eth.otaScanner = otascan.New(eth.blockchain, chainDb, ks)
+			}
+		}
+	}
+	if config.StakingIndex {
+		eth.stakingIndex = stakingindex.New(eth.blockchain, chainDb)
+	}
+	if len(config.ValidatorHealth.Addresses) > 0 {
+		eth.health = validatorhealth.New(eth.blockchain, config.ValidatorHealth)
+	}
 	eth.miner = miner.New(eth, eth.chainConfig, eth.EventMux(), eth.engine)
 	eth.miner.SetExtra(makeExtraData(config.ExtraData))
 
@@ -194,6 +227,11 @@ func New(ctx *node.ServiceContext, config *Config) (*Ethereum, error) {
 	}
 	eth.ApiBackend.gpo = gasprice.NewOracle(eth.ApiBackend, gpoParams)
 
+	if db, ok := chainDb.(*ethdb.LDBDatabase); ok && db.Freezer() != nil {
+		head := func() uint64 { return eth.blockchain.CurrentBlock().NumberU64() }
+		eth.freezer = newChainFreezer(db, config.FreezerThreshold, head, eth.ApiBackend.stableBlockNumber)
+	}
+
 
     if inPosStage{
 		miner.PosInit(eth)
@@ -219,9 +257,10 @@ func makeExtraData(extra []byte) []byte {
 	return extra
 }
 
-// CreateDB creates the chain database.
+// CreateDB creates the chain database, with the freezer of the old canonical
+// blocks attached.
 func CreateDB(ctx *node.ServiceContext, config *Config, name string) (ethdb.Database, error) {
-	db, err := ctx.OpenDatabase(name, config.DatabaseCache, config.DatabaseHandles)
+	db, err := ctx.OpenDatabaseWithFreezer(name, config.DatabaseCache, config.DatabaseHandles, "")
 	if err != nil {
 		return nil, err
 	}
@@ -267,6 +306,16 @@ func (s *Ethereum) APIs() []rpc.API {
 	// Append any APIs exposed explicitly by the consensus engine
 	apis = append(apis, s.engine.APIs(s.BlockChain())...)
 	apis = append(apis, posapi.APIs(s.BlockChain(), s.ApiBackend)...)
+	apis = append(apis, equivocation.APIs(s.equivocations)...)
+	if s.otaScanner != nil {
+		apis = append(apis, otascan.APIs(s.otaScanner)...)
+	}
+	if s.stakingIndex != nil {
+		apis = append(apis, stakingindex.APIs(s.stakingIndex)...)
+	}
+	if s.health != nil {
+		apis = append(apis, validatorhealth.APIs(s.health)...)
+	}
 
 	// Append all the local APIs and return
 	return append(apis, []rpc.API{
@@ -337,6 +386,30 @@ func (s *Ethereum) Etherbase() (eb common.Address, err error) {
 	return common.Address{}, fmt.Errorf("etherbase address must be explicitly specified")
 }
 
+// minerSigner returns the pos signer of the miner, or nil if the etherbase
+// can't sign for it.
+func (s *Ethereum) minerSigner() posconfig.MinerSigner {
+	if signer := posconfig.Cfg().MinerSigner; signer != nil {
+		return signer
+	}
+	if key := posconfig.Cfg().MinerKey; key != nil && key.PrivateKey != nil {
+		return possigner.NewKeySigner(key)
+	}
+	eb, err := s.Etherbase()
+	if err != nil {
+		return nil
+	}
+	wallet, err := s.accountManager.Find(accounts.Account{Address: eb})
+	if wallet == nil || err != nil {
+		return nil
+	}
+	signer, err := possigner.FromWallet(wallet, eb)
+	if err != nil {
+		return nil
+	}
+	return signer
+}
+
 // set in js console via admin interface or wrapper from cli flags
 func (self *Ethereum) SetEtherbase(etherbase common.Address) {
 	self.lock.Lock()
@@ -366,16 +439,13 @@ func (s *Ethereum) StartMining(local bool) error {
 			log.Error("Etherbase account unavailable locally", "err", err)
 			return fmt.Errorf("signer missing: %v", err)
 		}
-		//------------Get local unlock publicKey
-		type getKey interface {
-			GetUnlockedKey(address common.Address) (*keystore.Key, error)
-		}
-		key, err := wallet.(getKey).GetUnlockedKey(eb)
-		if key == nil || err != nil {
+		//------------Get the local unlocked key or the remote signer
+		signer, err := possigner.FromWallet(wallet, eb)
+		if err != nil {
 			panic(err)
 		}
 		//------------
-		pluto.Authorize(eb, wallet.SignHash, key)
+		pluto.Authorize(eb, wallet.SignHash, signer)
 	}
 
 	if ethash, ok := s.engine.(*ethash.Ethash); ok {
@@ -443,6 +513,18 @@ func (s *Ethereum) Start(srvr *p2p.Server) error {
 	if s.lesServer != nil {
 		s.lesServer.Start(srvr)
 	}
+	if s.otaScanner != nil {
+		s.otaScanner.Start()
+	}
+	if s.stakingIndex != nil {
+		s.stakingIndex.Start()
+	}
+	if s.health != nil {
+		s.health.Start()
+	}
+	if s.freezer != nil {
+		s.freezer.Start()
+	}
 	return nil
 }
 
@@ -453,8 +535,21 @@ func (s *Ethereum) Stop() error {
 		s.stopDbUpgrade()
 	}
 	s.bloomIndexer.Close()
+	if s.otaScanner != nil {
+		s.otaScanner.Stop()
+	}
+	if s.stakingIndex != nil {
+		s.stakingIndex.Stop()
+	}
+	if s.health != nil {
+		s.health.Stop()
+	}
+	if s.freezer != nil {
+		s.freezer.Stop()
+	}
 	s.blockchain.Stop()
 	s.protocolManager.Stop()
+	s.equivocations.Stop()
 	if s.lesServer != nil {
 		s.lesServer.Stop()
 	}
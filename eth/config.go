@@ -29,6 +29,7 @@ import (
 	"github.com/wanchain/go-wanchain/core"
 	"github.com/wanchain/go-wanchain/eth/downloader"
 	"github.com/wanchain/go-wanchain/eth/gasprice"
+	"github.com/wanchain/go-wanchain/pos/validatorhealth"
 )
 
 // DefaultConfig contains default settings for use on the Ethereum main net.
@@ -42,6 +43,7 @@ var DefaultConfig = Config{
 	NetworkId:            1,
 	LightPeers:           20,
 	DatabaseCache:        128,
+	FreezerThreshold:     90000,
 	//GasPrice:             big.NewInt(0).Mul(big.NewInt(18 * params.Shannon),params.WanGasTimesFactor),
 	GasPrice:             big.NewInt(1 * params.Shannon),
 	TxPool: core.DefaultTxPoolConfig,
@@ -49,6 +51,7 @@ var DefaultConfig = Config{
 		Blocks:     10,
 		Percentile: 50,
 	},
+	ValidatorHealth: validatorhealth.DefaultConfig,
 }
 
 func init() {
@@ -84,6 +87,7 @@ type Config struct {
 	SkipBcVersionCheck bool `toml:"-"`
 	DatabaseHandles    int  `toml:"-"`
 	DatabaseCache      int
+	FreezerThreshold   uint64 // Number of recent blocks kept out of the freezer
 
 	// Mining-related options
 	Etherbase    common.Address `toml:",omitempty"`
@@ -108,6 +112,15 @@ type Config struct {
 	// Enables tracking of SHA3 preimages in the VM
 	EnablePreimageRecording bool
 
+	// Enables the background scanner indexing the OTAs of the keystore accounts
+	OTAScan bool
+
+	// Enables the background indexer of the staking history of the accounts
+	StakingIndex bool
+
+	// Validator health monitor options
+	ValidatorHealth validatorhealth.Config
+
 	// Miscellaneous options
 	DocRoot   string `toml:"-"`
 	PowFake   bool   `toml:"-"`
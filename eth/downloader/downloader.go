@@ -96,6 +96,7 @@ var (
 	errCancelContentProcessing = errors.New("content processing canceled (requested)")
 	errNoSyncActive            = errors.New("no sync active")
 	errTooOld                  = errors.New("peer doesn't speak recent enough protocol version (need version >= 62)")
+	errNoPosPivot              = errors.New("chain too close to the pos upgrade for a fast sync pivot, full sync required")
 )
 
 type Downloader struct {
@@ -120,6 +121,7 @@ type Downloader struct {
 
 	lightchain LightChain
 	blockchain BlockChain
+	posSyncer  PosSyncer // Rebuilds the pos databases on pos fast sync, see possync.go
 
 	// Callbacks
 	dropPeer peerDropFn // Drops a peer for misbehaving
@@ -447,8 +449,17 @@ func (d *Downloader) syncWithPeer(p *peerConnection, hash common.Hash, td *big.I
 		log.Error("find ancestor error")
 		return err
 	}
-	onlyPow := 0
 	posFirst := d.blockchain.GetFirstPosBlockNumber()
+	if d.mode == FastSync && d.posSyncer != nil && height > posFirst {
+		pivot, err := d.findPosPivot(p, height)
+		if err != nil {
+			return err
+		}
+		if pivot != nil {
+			return d.fastSyncWithPeerPos(p, origin, height, latest, td, pivot.Number.Uint64())
+		}
+	}
+	onlyPow := 0
 	if origin+1 < posFirst  {
 		if height > posFirst {
 			height = posFirst-1
@@ -472,10 +483,9 @@ func (d *Downloader) syncWithPeer(p *peerConnection, hash common.Hash, td *big.I
 			if err != nil {
 				return err
 			}
-		} else {
-			// currently don't support pos fast sync
-			//err = d.fastSyncWithPeerPos(p, origin, height, latest, td)
-			//log.Info("fastSyncWithPeerPos", "err:", err)
+		} else if d.mode == FastSync {
+			// Too close to the pos upgrade for a pos pivot
+			return errNoPosPivot
 		}
 
 	} else {
@@ -1402,9 +1412,9 @@ func (d *Downloader) processHeaders(origin uint64, td *big.Int) error {
 				}
 				//firstPosBlockNumber := d.blockchain.GetFirstPosBlockNumber()
 				if d.mode == FastSync || d.mode == LightSync {
-					lastPowPosition, bSwitchEngine := d.tryGetSwitchEnginePosition(headers)
-					if bSwitchEngine {
-						limit = int(lastPowPosition)
+					powCount, bSwitchEngine := d.tryGetSwitchEnginePosition(headers)
+					if bSwitchEngine && int(powCount) < limit {
+						limit = int(powCount)
 					}
 				}
 				log.Debug("return header", "from", strconv.FormatUint(headers[0].Number.Uint64(), 10), "to", strconv.FormatUint(headers[len(headers)-1].Number.Uint64(), 10))
@@ -1441,6 +1451,12 @@ func (d *Downloader) processHeaders(origin uint64, td *big.Int) error {
 					if len(rollback) > fsHeaderSafetyNet {
 						rollback = append(rollback[:0], rollback[len(rollback)-fsHeaderSafetyNet:]...)
 					}
+					// The headers past the last pow one are verified by the pos engine
+					if d.mode == FastSync && d.posSyncer != nil && chunk[len(chunk)-1].Number.Uint64()+1 == d.blockchain.GetFirstPosBlockNumber() {
+						if err := d.posSyncer.SwitchEngine(); err != nil {
+							return err
+						}
+					}
 				}
 				// If we're fast syncing and just pulled in the pivot, make sure it's the one locked in
 				if d.mode == FastSync && d.fsPivotLock != nil && chunk[0].Number.Uint64() <= pivot && chunk[len(chunk)-1].Number.Uint64() >= pivot {
@@ -1480,12 +1496,14 @@ func (d *Downloader) processHeaders(origin uint64, td *big.Int) error {
 	}
 }
 
+// tryGetSwitchEnginePosition returns the number of pow headers of a batch
+// crossing the pos upgrade, the two parts being verified by different engines.
 func (d *Downloader) tryGetSwitchEnginePosition(headers []*types.Header) (uint64, bool) {
 	firstPosBlockNumber := d.blockchain.GetFirstPosBlockNumber()
 	if headers[0].Number.Uint64() < firstPosBlockNumber && headers[len(headers)-1].Number.Uint64() >= firstPosBlockNumber {
-		lastPosition := firstPosBlockNumber - 1 - headers[0].Number.Uint64()
-		if headers[lastPosition].Number.Uint64() == firstPosBlockNumber-1 {
-			return lastPosition, true
+		powCount := firstPosBlockNumber - headers[0].Number.Uint64()
+		if headers[powCount-1].Number.Uint64() == firstPosBlockNumber-1 {
+			return powCount, true
 		}
 	}
 	return 0, false
@@ -1625,6 +1643,9 @@ func (d *Downloader) commitFastSyncData(results []*fetchResult, stateSync *state
 }
 
 func (d *Downloader) commitPivotBlock(result *fetchResult) error {
+	if d.posSyncer != nil && result.Header.Number.Uint64() >= d.blockchain.GetFirstPosBlockNumber() {
+		return d.commitPosPivotBlock(result)
+	}
 	b := types.NewBlockWithHeader(result.Header).WithBody(result.Transactions, result.Uncles)
 	// Sync the pivot block state. This should complete reasonably quickly because
 	// we've already synced up to the reported head block state earlier.
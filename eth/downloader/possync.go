@@ -0,0 +1,266 @@
+// Copyright 2018 Wanchain Foundation Ltd
+// This file is part of the go-wanchain library.
+//
+// The go-wanchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-wanchain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-wanchain library. If not, see <http://www.gnu.org/licenses/>.
+
+package downloader
+
+import (
+	"crypto/rand"
+	"fmt"
+	"math/big"
+	"sync/atomic"
+
+	"github.com/wanchain/go-wanchain/common"
+	"github.com/wanchain/go-wanchain/core/types"
+	"github.com/wanchain/go-wanchain/log"
+	"github.com/wanchain/go-wanchain/pos/util"
+)
+
+// posAnchorEpochs is the minimum number of pos epochs before the pivot one.
+// The leaders of an epoch are selected from the state at the end of the epoch
+// two before it, and the blocks of an epoch are proven against the leaders of
+// the previous one, so that the states at the end of the epochs before the
+// pivot one are needed to verify the blocks from the pivot epoch on.
+const posAnchorEpochs = 3
+
+// PosSyncer rebuilds the pos side of a chain fast synced past the pos upgrade.
+// Block execution fills the pos databases otherwise.
+type PosSyncer interface {
+	// SwitchEngine switches the chain to the pos consensus engine, once the
+	// pow headers are imported. It does nothing if already switched.
+	SwitchEngine() error
+
+	// VerifyHeaders selects the leaders the headers of an epoch are proven
+	// against from the anchor states of the epochs before it, and checks
+	// their slot leader proofs, returning the index of the first invalid one.
+	VerifyHeaders(epochID uint64, headers []*types.Header, anchors map[uint64]*types.Header) (int, error)
+
+	// StateHeaders returns the headers of the pivot epoch, up to the pivot,
+	// whose states Rebuild needs besides the anchor ones.
+	StateHeaders(headers []*types.Header) []*types.Header
+
+	// Rebuild selects the leaders of the epochs from the committed pivot one,
+	// from the anchor states, and stores the pos data the pivot epoch needs.
+	Rebuild(pivot *types.Header, anchors map[uint64]*types.Header) error
+}
+
+// SetPosSyncer enables fast sync past the pos upgrade. Without a pos syncer,
+// fast sync stops at the last pow block and the pos blocks are imported.
+func (d *Downloader) SetPosSyncer(s PosSyncer) {
+	d.posSyncer = s
+}
+
+// findPosPivot picks the pivot of a pos fast sync. It returns nil if the pivot
+// would be too close to the pos upgrade for its anchor epochs to exist, or if
+// a pow pivot is already locked in.
+func (d *Downloader) findPosPivot(p *peerConnection, height uint64) (*types.Header, error) {
+	posFirst := d.blockchain.GetFirstPosBlockNumber()
+	if d.fsPivotLock != nil {
+		if d.fsPivotLock.Number.Uint64() < posFirst {
+			return nil, nil
+		}
+		return d.fsPivotLock, nil
+	}
+	pivotOffset, err := rand.Int(rand.Reader, big.NewInt(int64(fsPivotInterval)))
+	if err != nil {
+		panic(fmt.Sprintf("Failed to access crypto random source: %v", err))
+	}
+	if height <= uint64(fsMinFullBlocks)+pivotOffset.Uint64() {
+		return nil, nil
+	}
+	number := height - uint64(fsMinFullBlocks) - pivotOffset.Uint64()
+	if number <= posFirst {
+		return nil, nil
+	}
+	first, _, err := d.fetchHeaderTd(p, posFirst)
+	if err != nil {
+		return nil, err
+	}
+	pivot, _, err := d.fetchHeaderTd(p, number)
+	if err != nil {
+		return nil, err
+	}
+	firstEpoch, _ := util.GetEpochSlotIDFromDifficulty(first.Difficulty)
+	pivotEpoch, _ := util.GetEpochSlotIDFromDifficulty(pivot.Difficulty)
+	if pivotEpoch <= firstEpoch+posAnchorEpochs {
+		return nil, nil
+	}
+	return pivot, nil
+}
+
+// fastSyncWithPeerPos fast syncs to a pos pivot block. Contrary to a pow one,
+// the pivot commit also syncs the anchor epoch states, verifies the pos headers
+// against them and rebuilds the pos databases, see commitPosPivotBlock.
+func (d *Downloader) fastSyncWithPeerPos(p *peerConnection, origin uint64, height uint64, heightHeader *types.Header, td *big.Int, pivot uint64) (err error) {
+	log.Info("fastSyncWithPeerPos", "origin", origin, "height", height, "pivot", pivot)
+	d.syncStatsLock.Lock()
+	if d.syncStatsChainHeight <= origin || d.syncStatsChainOrigin > origin {
+		d.syncStatsChainOrigin = origin
+	}
+	d.syncStatsChainHeight = height
+	d.syncStatsLock.Unlock()
+
+	if pivot < origin {
+		origin = pivot
+	}
+	// Headers past the upgrade are verified by the pos engine. The switch is
+	// otherwise done while processing the headers, see processHeaders.
+	if origin+1 >= d.blockchain.GetFirstPosBlockNumber() {
+		if err := d.posSyncer.SwitchEngine(); err != nil {
+			return err
+		}
+	}
+	d.queue.Prepare(origin+1, d.mode, pivot, heightHeader)
+	if d.syncInitHook != nil {
+		d.syncInitHook(origin, height)
+	}
+
+	fetchers := []func() error{
+		func() error { return d.fetchHeaders(p, origin+1, uint64(height)) },
+		func() error { return d.fetchBodies(origin + 1) },
+		func() error { return d.fetchReceipts(origin + 1) },
+		func() error { return d.processHeaders(origin+1, td) },
+		func() error { return d.processFastSyncContent(heightHeader) },
+	}
+	err = d.spawnSync(fetchers, true)
+	if err != nil && d.fsPivotLock != nil {
+		// If sync failed in the critical section, bump the fail counter.
+		atomic.AddUint32(&d.fsPivotFails, 1)
+	}
+	return err
+}
+
+// commitPosPivotBlock commits a pos pivot block once the slot leader proofs of
+// all the pos headers up to it are verified, and rebuilds the pos databases
+// from the anchor states. The blocks after the pivot are then fully verified
+// on import.
+func (d *Downloader) commitPosPivotBlock(result *fetchResult) error {
+	b := types.NewBlockWithHeader(result.Header).WithBody(result.Transactions, result.Uncles)
+	anchors, firstEpoch, err := d.posAnchors(b.Header())
+	if err != nil {
+		return err
+	}
+	headers, err := d.verifyPosHeaders(b.Header(), anchors, firstEpoch)
+	if err != nil {
+		return err
+	}
+	for _, header := range d.posSyncer.StateHeaders(headers) {
+		log.Debug("Syncing pos pivot epoch state", "number", header.Number, "hash", header.Hash())
+		if err := d.syncState(header.Root).Wait(); err != nil {
+			return err
+		}
+	}
+	if err := d.syncState(b.Root()).Wait(); err != nil {
+		return err
+	}
+	log.Debug("Committing pos fast sync pivot as new head", "number", b.Number(), "hash", b.Hash())
+	if _, err := d.blockchain.InsertReceiptChain([]*types.Block{b}, []types.Receipts{result.Receipts}); err != nil {
+		return err
+	}
+	if err := d.blockchain.FastSyncCommitHead(b.Hash()); err != nil {
+		return err
+	}
+	return d.posSyncer.Rebuild(b.Header(), anchors)
+}
+
+// verifyPosHeaders verifies the slot leader proofs of the pos headers up to
+// the pivot, epoch by epoch. The anchor state of the previous epoch is synced
+// before the headers of an epoch are verified, the consecutive anchor states
+// sharing most of their trie nodes. It returns the headers of the pivot epoch.
+func (d *Downloader) verifyPosHeaders(pivot *types.Header, anchors map[uint64]*types.Header, firstEpoch uint64) ([]*types.Header, error) {
+	pivotEpoch, _ := util.GetEpochSlotIDFromDifficulty(pivot.Difficulty)
+
+	synced := make(map[common.Hash]bool)
+	var headers []*types.Header
+	for epochID := firstEpoch; epochID <= pivotEpoch; epochID++ {
+		if anchor, ok := anchors[epochID-1]; ok && !synced[anchor.Root] {
+			log.Debug("Syncing pos anchor state", "epoch", epochID-1, "number", anchor.Number, "hash", anchor.Hash())
+			if err := d.syncState(anchor.Root).Wait(); err != nil {
+				return nil, err
+			}
+			synced[anchor.Root] = true
+		}
+		last := pivot
+		if epochID < pivotEpoch {
+			last = anchors[epochID]
+		}
+		var err error
+		if headers, err = d.posEpochHeaders(epochID, last); err != nil {
+			return nil, err
+		}
+		if n, err := d.posSyncer.VerifyHeaders(epochID, headers, anchors); err != nil {
+			if n < len(headers) {
+				log.Debug("Invalid pos header encountered", "number", headers[n].Number, "hash", headers[n].Hash(), "err", err)
+			}
+			return nil, errInvalidChain
+		}
+	}
+	return headers, nil
+}
+
+// posAnchors walks the local headers back from a pos pivot to the pos upgrade.
+// It returns the last header of every pos epoch before the pivot one, by
+// epoch, and the first pos epoch.
+func (d *Downloader) posAnchors(pivot *types.Header) (map[uint64]*types.Header, uint64, error) {
+	posFirst := d.blockchain.GetFirstPosBlockNumber()
+	pivotEpoch, _ := util.GetEpochSlotIDFromDifficulty(pivot.Difficulty)
+
+	anchors := make(map[uint64]*types.Header)
+	next := pivotEpoch
+	for header := pivot; ; {
+		if header.Number.Uint64() < posFirst {
+			return nil, 0, fmt.Errorf("pos pivot %d before the pos upgrade", pivot.Number)
+		}
+		epochID, _ := util.GetEpochSlotIDFromDifficulty(header.Difficulty)
+		// An epoch without blocks ends with the last block of the one before
+		for ; next > epochID; next-- {
+			anchors[next-1] = header
+		}
+		if header.Number.Uint64() == posFirst {
+			if pivotEpoch <= epochID+posAnchorEpochs {
+				return nil, 0, fmt.Errorf("pos pivot %d too close to the pos upgrade", pivot.Number)
+			}
+			return anchors, epochID, nil
+		}
+		parent := d.lightchain.GetHeaderByHash(header.ParentHash)
+		if parent == nil {
+			return nil, 0, errInvalidAncestor
+		}
+		header = parent
+	}
+}
+
+// posEpochHeaders walks the local headers of an epoch back from its last one,
+// returning them in ascending order.
+func (d *Downloader) posEpochHeaders(epochID uint64, last *types.Header) ([]*types.Header, error) {
+	posFirst := d.blockchain.GetFirstPosBlockNumber()
+
+	var headers []*types.Header
+	for header := last; header.Number.Uint64() >= posFirst; {
+		if id, _ := util.GetEpochSlotIDFromDifficulty(header.Difficulty); id != epochID {
+			break
+		}
+		headers = append(headers, header)
+		parent := d.lightchain.GetHeaderByHash(header.ParentHash)
+		if parent == nil {
+			return nil, errInvalidAncestor
+		}
+		header = parent
+	}
+	for i, j := 0, len(headers)-1; i < j; i, j = i+1, j-1 {
+		headers[i], headers[j] = headers[j], headers[i]
+	}
+	return headers, nil
+}
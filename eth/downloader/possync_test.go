@@ -0,0 +1,162 @@
+package downloader
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/wanchain/go-wanchain/common"
+	"github.com/wanchain/go-wanchain/core/types"
+	"github.com/wanchain/go-wanchain/ethdb"
+	"github.com/wanchain/go-wanchain/event"
+)
+
+// anchorChain is a header chain for the pos pivot commit, recording whether
+// the pivot got committed. The other chain methods are not used.
+type anchorChain struct {
+	BlockChain
+	posFirst  uint64
+	headers   map[common.Hash]*types.Header
+	committed bool
+}
+
+func (c *anchorChain) GetFirstPosBlockNumber() uint64 { return c.posFirst }
+
+func (c *anchorChain) GetHeaderByHash(hash common.Hash) *types.Header { return c.headers[hash] }
+
+func (c *anchorChain) InsertReceiptChain(types.Blocks, []types.Receipts) (int, error) {
+	c.committed = true
+	return 0, nil
+}
+
+func (c *anchorChain) FastSyncCommitHead(common.Hash) error { return nil }
+
+// verifySyncer is a pos syncer recording the verified epochs and headers,
+// failing the verification of the bad epoch.
+type verifySyncer struct {
+	bad     uint64
+	epochs  []uint64
+	headers []*types.Header
+	rebuilt bool
+}
+
+func (s *verifySyncer) SwitchEngine() error { return nil }
+
+func (s *verifySyncer) VerifyHeaders(epochID uint64, headers []*types.Header, anchors map[uint64]*types.Header) (int, error) {
+	s.epochs = append(s.epochs, epochID)
+	s.headers = append(s.headers, headers...)
+	if epochID == s.bad {
+		return 0, errors.New("invalid slot leader proof")
+	}
+	return 0, nil
+}
+
+func (s *verifySyncer) StateHeaders([]*types.Header) []*types.Header { return nil }
+
+func (s *verifySyncer) Rebuild(*types.Header, map[uint64]*types.Header) error {
+	s.rebuilt = true
+	return nil
+}
+
+// newAnchorChain builds a chain of pow blocks up to posFirst, followed by a
+// pos block in each of the given epochs, returning it and its headers.
+func newAnchorChain(posFirst uint64, epochs []uint64) (*anchorChain, []*types.Header) {
+	chain := &anchorChain{posFirst: posFirst, headers: make(map[common.Hash]*types.Header)}
+	var headers []*types.Header
+	parent := common.Hash{}
+	for i := uint64(0); i < posFirst+uint64(len(epochs)); i++ {
+		difficulty := big.NewInt(1)
+		if i >= posFirst {
+			difficulty = new(big.Int).SetUint64(epochs[i-posFirst]<<32 | i<<8)
+		}
+		header := &types.Header{ParentHash: parent, Number: new(big.Int).SetUint64(i), Difficulty: difficulty, Root: types.EmptyRootHash}
+		chain.headers[header.Hash()] = header
+		headers = append(headers, header)
+		parent = header.Hash()
+	}
+	return chain, headers
+}
+
+func TestPosAnchors(t *testing.T) {
+	// Epoch 12 has no blocks, it ends with the last block of epoch 11
+	chain, headers := newAnchorChain(5, []uint64{10, 10, 11, 11, 13, 14, 14, 14})
+	d := &Downloader{blockchain: chain, lightchain: chain}
+
+	pivot := headers[len(headers)-2]
+	anchors, firstEpoch, err := d.posAnchors(pivot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if firstEpoch != 10 {
+		t.Errorf("first epoch mismatch: have %d, want 10", firstEpoch)
+	}
+	want := map[uint64]*types.Header{13: headers[9], 12: headers[8], 11: headers[8], 10: headers[6]}
+	if len(anchors) != len(want) {
+		t.Fatalf("anchor count mismatch: have %d, want %d", len(anchors), len(want))
+	}
+	for epochID, header := range want {
+		if anchors[epochID] != header {
+			t.Errorf("epoch %d anchor mismatch: have %v, want %d", epochID, anchors[epochID].Number, header.Number)
+		}
+	}
+	epochHeaders, err := d.posEpochHeaders(14, pivot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(epochHeaders) != 2 || epochHeaders[0] != headers[10] || epochHeaders[1] != pivot {
+		t.Errorf("pivot epoch headers mismatch: have %d headers", len(epochHeaders))
+	}
+
+	// Pivots too close to the pos upgrade are refused
+	if _, _, err := d.posAnchors(headers[9]); err == nil {
+		t.Error("anchors of a pivot too close to the upgrade accepted")
+	}
+}
+
+// Every pos header up to the pivot is verified, epoch by epoch, before the
+// pivot is committed.
+func TestPosVerifyHeaders(t *testing.T) {
+	chain, headers := newAnchorChain(5, []uint64{10, 11, 11, 13, 14, 14, 14})
+	syncer := &verifySyncer{bad: 15}
+	db, _ := ethdb.NewMemDatabase()
+	d := New(FastSync, db, new(event.TypeMux), chain, nil, func(string) {})
+	defer d.Terminate()
+	d.SetPosSyncer(syncer)
+
+	pivot := headers[len(headers)-2]
+	if err := d.commitPosPivotBlock(&fetchResult{Header: pivot}); err != nil {
+		t.Fatal(err)
+	}
+	if want := []uint64{10, 11, 12, 13, 14}; len(syncer.epochs) != len(want) {
+		t.Errorf("verified epochs mismatch: have %v, want %v", syncer.epochs, want)
+	}
+	if len(syncer.headers) != 6 {
+		t.Fatalf("verified header count mismatch: have %d, want 6", len(syncer.headers))
+	}
+	for i, header := range syncer.headers {
+		if header.Hash() != headers[5+i].Hash() {
+			t.Errorf("verified header %d mismatch: have %d, want %d", i, header.Number, headers[5+i].Number)
+		}
+	}
+	if !chain.committed || !syncer.rebuilt {
+		t.Error("verified pivot not committed")
+	}
+}
+
+// A pos header failing its proof before the pivot epoch keeps the pivot from
+// being committed.
+func TestPosVerifyBeforeCommit(t *testing.T) {
+	chain, headers := newAnchorChain(5, []uint64{10, 11, 12, 13, 14, 14})
+	syncer := &verifySyncer{bad: 11}
+	db, _ := ethdb.NewMemDatabase()
+	d := New(FastSync, db, new(event.TypeMux), chain, nil, func(string) {})
+	defer d.Terminate()
+	d.SetPosSyncer(syncer)
+
+	if err := d.commitPosPivotBlock(&fetchResult{Header: headers[len(headers)-1]}); err != errInvalidChain {
+		t.Fatalf("commit error mismatch: have %v, want %v", err, errInvalidChain)
+	}
+	if chain.committed || syncer.rebuilt {
+		t.Error("pivot committed with an invalid pos header")
+	}
+}
@@ -40,6 +40,8 @@ type Backend interface {
 	SubscribeChainEvent(ch chan<- core.ChainEvent) event.Subscription
 	SubscribeRemovedLogsEvent(ch chan<- core.RemovedLogsEvent) event.Subscription
 	SubscribeLogsEvent(ch chan<- []*types.Log) event.Subscription
+	SubscribeStableHeadEvent(ch chan<- core.StableHeadEvent) event.Subscription
+	SubscribeReorgEvent(ch chan<- core.ReorgEvent) event.Subscription
 
 	BloomStatus() (uint64, uint64)
 	ServiceFilter(ctx context.Context, session *bloombits.MatcherSession)
@@ -102,13 +104,28 @@ func (f *Filter) Logs(ctx context.Context) ([]*types.Log, error) {
 	}
 	head := header.Number.Uint64()
 
+	// Resolve the stable block if used by the range
+	var stable uint64
+	if f.begin == rpc.StableBlockNumber.Int64() || f.end == rpc.StableBlockNumber.Int64() {
+		header, err := f.backend.HeaderByNumber(ctx, rpc.StableBlockNumber)
+		if header == nil || err != nil {
+			return nil, err
+		}
+		stable = header.Number.Uint64()
+	}
 	if f.begin == -1 {
 		f.begin = int64(head)
 	}
+	if f.begin == rpc.StableBlockNumber.Int64() {
+		f.begin = int64(stable)
+	}
 	end := uint64(f.end)
 	if f.end == -1 {
 		end = head
 	}
+	if f.end == rpc.StableBlockNumber.Int64() {
+		end = stable
+	}
 	// Gather all indexed logs, and finish with non indexed ones
 	var (
 		logs []*types.Log
@@ -31,6 +31,7 @@ import (
 	"github.com/wanchain/go-wanchain/crypto"
 	"github.com/wanchain/go-wanchain/ethdb"
 	"github.com/wanchain/go-wanchain/event"
+	"github.com/wanchain/go-wanchain/rpc"
 )
 
 func makeReceipt(addr common.Address) *types.Receipt {
@@ -118,6 +119,19 @@ func BenchmarkFilters(b *testing.B) {
 	}
 }
 
+// stableBackend is a test backend with a fixed stable block.
+type stableBackend struct {
+	*testBackend
+	stable uint64
+}
+
+func (b *stableBackend) HeaderByNumber(ctx context.Context, blockNr rpc.BlockNumber) (*types.Header, error) {
+	if blockNr == rpc.StableBlockNumber {
+		blockNr = rpc.BlockNumber(b.stable)
+	}
+	return b.testBackend.HeaderByNumber(ctx, blockNr)
+}
+
 func TestFilters(t *testing.T) {
 	dir, err := ioutil.TempDir("", "filtertest")
 	if err != nil {
@@ -242,6 +256,19 @@ func TestFilters(t *testing.T) {
 		t.Error("expected 2 log, got", len(logs))
 	}
 
+	// The stable block bounds the range
+	stable := &stableBackend{backend, 995}
+	filter = New(stable, 0, rpc.StableBlockNumber.Int64(), []common.Address{addr}, [][]common.Hash{{hash1, hash2, hash3, hash4}})
+	logs, _ = filter.Logs(context.Background())
+	if len(logs) != 2 {
+		t.Error("expected 2 log up to the stable block, got", len(logs))
+	}
+	filter = New(stable, rpc.StableBlockNumber.Int64(), -1, []common.Address{addr}, [][]common.Hash{{hash1, hash2, hash3, hash4}})
+	logs, _ = filter.Logs(context.Background())
+	if len(logs) != 2 {
+		t.Error("expected 2 log from the stable block, got", len(logs))
+	}
+
 	failHash := common.BytesToHash([]byte("fail"))
 	filter = New(backend, 0, -1, nil, [][]common.Hash{{failHash}})
 
@@ -0,0 +1,122 @@
+// Copyright 2018 Wanchain Foundation Ltd
+// This file is part of the go-wanchain library.
+//
+// The go-wanchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-wanchain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-wanchain library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"sync"
+	"time"
+
+	"github.com/wanchain/go-wanchain/common"
+	"github.com/wanchain/go-wanchain/core"
+	"github.com/wanchain/go-wanchain/ethdb"
+	"github.com/wanchain/go-wanchain/log"
+)
+
+const (
+	// freezeRecheckInterval is the time between two rounds of freezing.
+	freezeRecheckInterval = time.Minute
+
+	// freezeBatchLimit is the number of blocks frozen at once at most.
+	freezeBatchLimit = 30000
+)
+
+// chainFreezer moves the canonical blocks which can not be reorganised anymore
+// out of LevelDB into the freezer of the chain database, in the background.
+type chainFreezer struct {
+	db        *ethdb.LDBDatabase
+	threshold uint64        // Number of recent blocks kept in LevelDB
+	head      func() uint64 // Number of the head block
+	stable    func() uint64 // Number of the highest stable block
+
+	quit chan struct{}
+	wg   sync.WaitGroup
+}
+
+func newChainFreezer(db *ethdb.LDBDatabase, threshold uint64, head, stable func() uint64) *chainFreezer {
+	return &chainFreezer{
+		db:        db,
+		threshold: threshold,
+		head:      head,
+		stable:    stable,
+		quit:      make(chan struct{}),
+	}
+}
+
+// Start starts freezing the blocks in the background.
+func (f *chainFreezer) Start() {
+	f.wg.Add(1)
+	go f.loop()
+}
+
+// Stop stops freezing, waiting for the blocks being frozen.
+func (f *chainFreezer) Stop() {
+	close(f.quit)
+	f.wg.Wait()
+}
+
+func (f *chainFreezer) loop() {
+	defer f.wg.Done()
+
+	ticker := time.NewTicker(freezeRecheckInterval)
+	defer ticker.Stop()
+	for {
+		f.freeze()
+
+		select {
+		case <-ticker.C:
+		case <-f.quit:
+			return
+		}
+	}
+}
+
+// freeze moves the blocks below the limit to the freezer, a batch at a time.
+func (f *chainFreezer) freeze() {
+	limit := f.limit()
+	for {
+		start := time.Now()
+		frozen, err := core.FreezeBlocks(f.db, limit, freezeBatchLimit)
+		if err != nil {
+			log.Error("Failed to freeze blocks", "err", err)
+			return
+		}
+		if frozen == 0 {
+			return
+		}
+		log.Info("Moved blocks to the freezer", "count", frozen, "frozen", f.db.Ancients(), "elapsed", common.PrettyDuration(time.Since(start)))
+
+		select {
+		case <-f.quit:
+			return
+		default:
+		}
+	}
+}
+
+// limit returns the number of the first block not to freeze. The blocks frozen
+// are stable and buried under threshold blocks.
+func (f *chainFreezer) limit() uint64 {
+	head := f.head()
+	if head < f.threshold {
+		return 0
+	}
+	limit := head - f.threshold
+	if stable := f.stable(); stable < limit {
+		limit = stable
+	}
+	return limit
+}
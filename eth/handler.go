@@ -39,6 +39,7 @@ import (
 	"github.com/wanchain/go-wanchain/p2p"
 	"github.com/wanchain/go-wanchain/p2p/discover"
 	"github.com/wanchain/go-wanchain/params"
+	"github.com/wanchain/go-wanchain/pos/equivocation"
 	"github.com/wanchain/go-wanchain/rlp"
 )
 
@@ -49,6 +50,9 @@ const (
 	// txChanSize is the size of channel listening to TxPreEvent.
 	// The number is referenced from the size of tx pool.
 	txChanSize = 4096
+
+	// evidenceChanSize is the size of channel listening to NewEvidenceEvent.
+	evidenceChanSize = 16
 )
 
 var (
@@ -77,6 +81,7 @@ type ProtocolManager struct {
 
 	downloader *downloader.Downloader
 	fetcher    *fetcher.Fetcher
+	posSyncer  *posSyncer
 	peers      *peerSet
 
 	SubProtocols []p2p.Protocol
@@ -86,6 +91,10 @@ type ProtocolManager struct {
 	txSub         event.Subscription
 	minedBlockSub *event.TypeMuxSubscription
 
+	equivocations *equivocation.Detector
+	evidenceCh    chan equivocation.NewEvidenceEvent
+	evidenceSub   event.Subscription
+
 	// channels for fetcher, syncer, txsyncLoop
 	newPeerCh   chan *peer
 	txsyncCh    chan *txsync
@@ -162,10 +171,10 @@ func NewProtocolManager(config *params.ChainConfig, mode downloader.SyncMode, ne
 	}
 	// Construct the different synchronisation mechanisms
 	manager.downloader = downloader.New(mode, chaindb, manager.eventMux, blockchain, nil, manager.removePeer)
+	manager.posSyncer = &posSyncer{blockchain: blockchain}
+	manager.downloader.SetPosSyncer(manager.posSyncer)
 
-	validator := func(header *types.Header) error {
-		return engine.VerifyHeader(blockchain, header, true)
-	}
+	validator := manager.headerValidator(engine)
 	heighter := func() uint64 {
 		return blockchain.CurrentBlock().NumberU64()
 	}
@@ -186,11 +195,20 @@ func NewProtocolManager(config *params.ChainConfig, mode downloader.SyncMode, ne
 }
 
 func (pm *ProtocolManager) SwitchEngine(engine consensus.Engine) {
-	validator := func(header *types.Header) error {
-		return engine.VerifyHeader(pm.blockchain, header, true)
-	}
+	pm.fetcher.UpdateValidator(pm.headerValidator(engine))
+}
 
-	pm.fetcher.UpdateValidator(validator)
+// headerValidator returns the header verifier of the fetcher. The propagated
+// headers passing verification are checked for equivocation, even if their
+// block is never imported.
+func (pm *ProtocolManager) headerValidator(engine consensus.Engine) func(*types.Header) error {
+	return func(header *types.Header) error {
+		err := engine.VerifyHeader(pm.blockchain, header, true)
+		if err == nil && pm.equivocations != nil {
+			pm.equivocations.Observe(header)
+		}
+		return err
+	}
 }
 
 func (pm *ProtocolManager) removePeer(id string) {
@@ -224,6 +242,13 @@ func (pm *ProtocolManager) Start(maxPeers int) {
 	pm.minedBlockSub = pm.eventMux.Subscribe(core.NewMinedBlockEvent{})
 	go pm.minedBroadcastLoop()
 
+	// broadcast equivocation evidence
+	if pm.equivocations != nil {
+		pm.evidenceCh = make(chan equivocation.NewEvidenceEvent, evidenceChanSize)
+		pm.evidenceSub = pm.equivocations.SubscribeNewEvidence(pm.evidenceCh)
+		go pm.evidenceBroadcastLoop()
+	}
+
 	// start sync handlers
 	go pm.syncer()
 	go pm.txsyncLoop()
@@ -237,6 +262,9 @@ func (pm *ProtocolManager) Stop() {
 
 	pm.txSub.Unsubscribe()         // quits txBroadcastLoop
 	pm.minedBlockSub.Unsubscribe() // quits blockBroadcastLoop
+	if pm.evidenceSub != nil {
+		pm.evidenceSub.Unsubscribe() // quits evidenceBroadcastLoop
+	}
 
 	// Quit the sync loop.
 	// After this send has completed, no new peers will be accepted.
@@ -747,6 +775,26 @@ func (pm *ProtocolManager) handleMsg(p *peer) error {
 			log.Debug("Failed to deliver header td", "err", err)
 		}
 
+	case p.version >= eth64 && msg.Code == EquivocationEvidenceMsg:
+		var ev equivocation.Evidence
+		if err := msg.Decode(&ev); err != nil {
+			return errResp(ErrDecode, "%v: %v", msg, err)
+		}
+		p.MarkEvidence(ev.Hash())
+		if pm.equivocations == nil {
+			break
+		}
+		// Evidence of epochs whose leaders are still unknown to us is dropped
+		// without the peer, which may be ahead of us. Any other evidence is
+		// verified, and the peer relaying forged evidence is dropped.
+		err := pm.equivocations.Add(&ev)
+		switch {
+		case equivocation.IsInvalid(err):
+			return errResp(ErrDecode, "%v: %v", msg, err)
+		case err != nil && err != equivocation.ErrKnownEvidence:
+			log.Debug("Equivocation evidence dropped", "peer", p.id, "epochID", ev.EpochId, "slotID", ev.SlotId, "err", err)
+		}
+
 	default:
 		return errResp(ErrInvalidMsgCode, "%v", msg.Code)
 	}
@@ -798,6 +846,17 @@ func (pm *ProtocolManager) BroadcastTx(hash common.Hash, tx *types.Transaction)
 	log.Trace("Broadcast transaction", "hash", hash, "recipients", len(peers))
 }
 
+// BroadcastEvidence propagates the evidence of an equivocating slot leader to
+// the peers not knowing about it.
+func (pm *ProtocolManager) BroadcastEvidence(ev *equivocation.Evidence) {
+	hash := ev.Hash()
+	peers := pm.peers.PeersWithoutEvidence(hash)
+	for _, peer := range peers {
+		peer.SendEquivocationEvidence(ev)
+	}
+	log.Trace("Broadcast equivocation evidence", "hash", hash, "recipients", len(peers))
+}
+
 func (pm *ProtocolManager) sendBufferTxs(p *peer) {
 	if !atomic.CompareAndSwapInt32(&p.handlingSend, 0, 1) {
 		return
@@ -873,6 +932,19 @@ func (self *ProtocolManager) txBroadcastLoop() {
 	}
 }
 
+func (self *ProtocolManager) evidenceBroadcastLoop() {
+	for {
+		select {
+		case event := <-self.evidenceCh:
+			self.BroadcastEvidence(event.Evidence)
+
+		// Err() channel will be closed when unsubscribing.
+		case <-self.evidenceSub.Err():
+			return
+		}
+	}
+}
+
 // EthNodeInfo represents a short summary of the Ethereum sub-protocol metadata known
 // about the host peer.
 type EthNodeInfo struct {
@@ -0,0 +1,140 @@
+// Copyright 2018 Wanchain Foundation Ltd
+// This file is part of the go-wanchain library.
+//
+// The go-wanchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-wanchain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-wanchain library. If not, see <http://www.gnu.org/licenses/>.
+
+package otascan
+
+import (
+	"math/big"
+
+	"github.com/wanchain/go-wanchain/common"
+	"github.com/wanchain/go-wanchain/common/hexutil"
+	"github.com/wanchain/go-wanchain/rpc"
+)
+
+// RPCOTA is the JSON representation of an indexed OTA.
+type RPCOTA struct {
+	Address     hexutil.Bytes  `json:"otaAddress"`
+	Value       *hexutil.Big   `json:"value"`
+	BlockNumber hexutil.Uint64 `json:"blockNumber"`
+	BlockHash   common.Hash    `json:"blockHash"`
+	TxHash      common.Hash    `json:"transactionHash"`
+	KeyImage    hexutil.Bytes  `json:"keyImage"`
+	Spent       bool           `json:"spent"`
+}
+
+func newRPCOTA(ota *OTA) *RPCOTA {
+	return &RPCOTA{
+		Address:     ota.Address[:],
+		Value:       (*hexutil.Big)(ota.Value),
+		BlockNumber: hexutil.Uint64(ota.BlockNumber),
+		BlockHash:   ota.BlockHash,
+		TxHash:      ota.TxHash,
+		KeyImage:    ota.KeyImage,
+		Spent:       ota.Spent,
+	}
+}
+
+// ScanStatus is the scan progress reported by wan_getOTAScanStatus.
+type ScanStatus struct {
+	TargetBlock hexutil.Uint64                    `json:"targetBlock"`
+	Accounts    map[common.Address]hexutil.Uint64 `json:"accounts"`
+}
+
+// APIs returns the RPC services of the OTA scanner.
+func APIs(s *Scanner) []rpc.API {
+	return []rpc.API{
+		{
+			Namespace: "personal",
+			Version:   "1.0",
+			Service:   &PrivateOTAScanAPI{s},
+			Public:    false,
+		}, {
+			Namespace: "wan",
+			Version:   "1.0",
+			Service:   &PublicOTAScanAPI{s},
+			Public:    true,
+		},
+	}
+}
+
+// PrivateOTAScanAPI exposes the OTA index of the keystore accounts.
+type PrivateOTAScanAPI struct {
+	s *Scanner
+}
+
+func (api *PrivateOTAScanAPI) filterOTAs(addr common.Address, keep func(*OTA) bool) []*RPCOTA {
+	otas, _ := api.s.OTAs(addr)
+	result := make([]*RPCOTA, 0, len(otas))
+	for _, ota := range otas {
+		if keep(ota) {
+			result = append(result, newRPCOTA(ota))
+		}
+	}
+	return result
+}
+
+// GetOTAs returns all OTAs found for an account.
+func (api *PrivateOTAScanAPI) GetOTAs(addr common.Address) []*RPCOTA {
+	return api.filterOTAs(addr, func(*OTA) bool { return true })
+}
+
+// GetUnspentOTAs returns the OTAs of an account which were not refunded yet.
+func (api *PrivateOTAScanAPI) GetUnspentOTAs(addr common.Address) []*RPCOTA {
+	return api.filterOTAs(addr, func(ota *OTA) bool { return !ota.Spent })
+}
+
+// GetSpentOTAs returns the OTAs of an account which were refunded.
+func (api *PrivateOTAScanAPI) GetSpentOTAs(addr common.Address) []*RPCOTA {
+	return api.filterOTAs(addr, func(ota *OTA) bool { return ota.Spent })
+}
+
+// GetUnspentOTABalance returns the total value of the unspent OTAs of an account.
+func (api *PrivateOTAScanAPI) GetUnspentOTABalance(addr common.Address) *hexutil.Big {
+	otas, _ := api.s.OTAs(addr)
+	total := new(big.Int)
+	for _, ota := range otas {
+		if !ota.Spent {
+			total.Add(total, ota.Value)
+		}
+	}
+	return (*hexutil.Big)(total)
+}
+
+// RescanOTAs drops the OTAs of an account found from block from on and scans
+// these blocks again.
+func (api *PrivateOTAScanAPI) RescanOTAs(addr common.Address, from hexutil.Uint64) bool {
+	api.s.Rescan(addr, uint64(from))
+	return true
+}
+
+// PublicOTAScanAPI exposes the progress of the OTA scanner.
+type PublicOTAScanAPI struct {
+	s *Scanner
+}
+
+// GetOTAScanStatus returns the block the scanner targets and the number of the
+// last block scanned for each keystore account.
+func (api *PublicOTAScanAPI) GetOTAScanStatus() *ScanStatus {
+	head, progress := api.s.Status()
+	status := &ScanStatus{
+		TargetBlock: hexutil.Uint64(head),
+		Accounts:    make(map[common.Address]hexutil.Uint64, len(progress)),
+	}
+	for addr, number := range progress {
+		status.Accounts[addr] = hexutil.Uint64(number)
+	}
+	return status
+}
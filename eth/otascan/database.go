@@ -0,0 +1,93 @@
+// Copyright 2018 Wanchain Foundation Ltd
+// This file is part of the go-wanchain library.
+//
+// The go-wanchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-wanchain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-wanchain library. If not, see <http://www.gnu.org/licenses/>.
+
+package otascan
+
+import (
+	"encoding/binary"
+	"math/big"
+
+	"github.com/wanchain/go-wanchain/common"
+	"github.com/wanchain/go-wanchain/ethdb"
+	"github.com/wanchain/go-wanchain/log"
+	"github.com/wanchain/go-wanchain/rlp"
+)
+
+var (
+	progressPrefix = []byte("otascan-n") // progressPrefix + address -> last scanned block number (uint64 big endian)
+	otasPrefix     = []byte("otascan-o") // otasPrefix + address -> rlp([]*OTA)
+)
+
+// OTA is an one-time address owned by an account, as found by the scanner.
+type OTA struct {
+	Address     common.WAddress // the OTA itself
+	Value       *big.Int
+	BlockNumber uint64
+	BlockHash   common.Hash
+	TxHash      common.Hash
+	KeyImage    []byte // recorded on chain once the OTA is refunded
+	Spent       bool
+}
+
+func accountKey(prefix []byte, addr common.Address) []byte {
+	return append(append([]byte{}, prefix...), addr[:]...)
+}
+
+// readProgress returns the number of the last block scanned for an account
+// and whether the account was scanned at all.
+func readProgress(db ethdb.Database, addr common.Address) (uint64, bool) {
+	data, _ := db.Get(accountKey(progressPrefix, addr))
+	if len(data) != 8 {
+		return 0, false
+	}
+	return binary.BigEndian.Uint64(data), true
+}
+
+func writeProgress(db ethdb.Putter, addr common.Address, number uint64) {
+	enc := make([]byte, 8)
+	binary.BigEndian.PutUint64(enc, number)
+	if err := db.Put(accountKey(progressPrefix, addr), enc); err != nil {
+		log.Crit("Failed to store OTA scan progress", "err", err)
+	}
+}
+
+func deleteProgress(db ethdb.Database, addr common.Address) {
+	db.Delete(accountKey(progressPrefix, addr))
+}
+
+// readOTAs returns the OTAs indexed for an account, in the order they were found.
+func readOTAs(db ethdb.Database, addr common.Address) []*OTA {
+	data, _ := db.Get(accountKey(otasPrefix, addr))
+	if len(data) == 0 {
+		return nil
+	}
+	var otas []*OTA
+	if err := rlp.DecodeBytes(data, &otas); err != nil {
+		log.Error("Invalid OTA index", "account", addr, "err", err)
+		return nil
+	}
+	return otas
+}
+
+func writeOTAs(db ethdb.Putter, addr common.Address, otas []*OTA) {
+	data, err := rlp.EncodeToBytes(otas)
+	if err != nil {
+		log.Crit("Failed to RLP encode OTA index", "err", err)
+	}
+	if err := db.Put(accountKey(otasPrefix, addr), data); err != nil {
+		log.Crit("Failed to store OTA index", "err", err)
+	}
+}
@@ -0,0 +1,364 @@
+// Copyright 2018 Wanchain Foundation Ltd
+// This file is part of the go-wanchain library.
+//
+// The go-wanchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-wanchain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-wanchain library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package otascan implements a background service which indexes the privacy
+// payments (one-time addresses bought through the wancoin contract) received
+// by the keystore accounts.
+package otascan
+
+import (
+	"sync"
+	"time"
+
+	"github.com/wanchain/go-wanchain/accounts"
+	"github.com/wanchain/go-wanchain/common"
+	"github.com/wanchain/go-wanchain/core"
+	"github.com/wanchain/go-wanchain/core/state"
+	"github.com/wanchain/go-wanchain/core/types"
+	"github.com/wanchain/go-wanchain/core/vm"
+	"github.com/wanchain/go-wanchain/ethdb"
+	"github.com/wanchain/go-wanchain/event"
+	"github.com/wanchain/go-wanchain/log"
+)
+
+const (
+	// scanConfirmations is the number of blocks the scanner stays behind the
+	// chain head, so that indexed OTAs are not undone by short reorgs.
+	scanConfirmations = 30
+
+	// scanBatchBlocks is the maximum number of blocks scanned in one round.
+	scanBatchBlocks = 4096
+
+	// scanInterval is how often the accounts are checked for being unlocked
+	// when no new chain head arrives.
+	scanInterval = 10 * time.Second
+)
+
+// blockChain is the part of core.BlockChain the scanner relies on.
+type blockChain interface {
+	CurrentBlock() *types.Block
+	GetBlockByNumber(number uint64) *types.Block
+	State() (*state.StateDB, error)
+	SubscribeChainHeadEvent(ch chan<- core.ChainHeadEvent) event.Subscription
+}
+
+// keyStore is the part of keystore.KeyStore and keystore.WatchOnlyKeyStore
+// the scanner relies on.
+type keyStore interface {
+	Accounts() []accounts.Account
+	IsUnlocked(addr common.Address) bool
+	CheckOTAOwnership(a accounts.Account, otaWAddr []byte) (bool, []byte, error)
+}
+
+// Scanner walks the chain and keeps a per-account index of the OTAs sent to
+// the unlocked keystore accounts. OTA ownership can only be established with
+// the view key of an account, so locked accounts are not scanned until they
+// get unlocked; the scan then resumes where it stopped.
+//
+// Watch-only accounts are scanned the same way, but their OTAs carry no key
+// image, so they are never reported as spent.
+type Scanner struct {
+	chain  blockChain
+	stores []keyStore
+	db     ethdb.Database
+
+	lock sync.RWMutex // Protects the index against concurrent scans and API reads
+	head uint64       // Number of the last block the scanner looked at
+
+	quit chan struct{}
+	wg   sync.WaitGroup
+}
+
+// scanAccount is an account along with the key store holding its view key.
+type scanAccount struct {
+	accounts.Account
+	store keyStore
+}
+
+// New creates an OTA scanner indexing the accounts of the given key stores and
+// storing its index in db.
+func New(chain blockChain, db ethdb.Database, stores ...keyStore) *Scanner {
+	return &Scanner{
+		chain:  chain,
+		stores: stores,
+		db:     db,
+		quit:   make(chan struct{}),
+	}
+}
+
+// accounts returns the accounts of all the key stores.
+func (s *Scanner) accounts() []scanAccount {
+	var all []scanAccount
+	for _, store := range s.stores {
+		for _, account := range store.Accounts() {
+			all = append(all, scanAccount{account, store})
+		}
+	}
+	return all
+}
+
+// Start launches the background scanning.
+func (s *Scanner) Start() {
+	s.wg.Add(1)
+	go s.loop()
+	log.Info("OTA scanner started")
+}
+
+// Stop terminates the background scanning.
+func (s *Scanner) Stop() {
+	close(s.quit)
+	s.wg.Wait()
+	log.Info("OTA scanner stopped")
+}
+
+func (s *Scanner) loop() {
+	defer s.wg.Done()
+
+	headCh := make(chan core.ChainHeadEvent, 10)
+	sub := s.chain.SubscribeChainHeadEvent(headCh)
+	defer sub.Unsubscribe()
+
+	ticker := time.NewTicker(scanInterval)
+	defer ticker.Stop()
+
+	for {
+		// Keep scanning while accounts are behind, checking for quit in between
+		for s.scan() {
+			select {
+			case <-s.quit:
+				return
+			default:
+			}
+		}
+		select {
+		case <-headCh:
+		case <-ticker.C:
+		case <-sub.Err():
+			return
+		case <-s.quit:
+			return
+		}
+	}
+}
+
+// scan runs one scanning round and tells if there are blocks left to scan.
+func (s *Scanner) scan() bool {
+	current := s.chain.CurrentBlock().NumberU64()
+	if current <= scanConfirmations {
+		return false
+	}
+	target := current - scanConfirmations
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.head = target
+
+	statedb, err := s.chain.State()
+	if err != nil {
+		log.Warn("OTA scanner failed to open state", "err", err)
+		return false
+	}
+
+	// Collect the unlocked accounts lagging behind the target block
+	var (
+		pending []scanAccount
+		from    = target + 1
+	)
+	for _, account := range s.accounts() {
+		s.dropOrphaned(account.Address)
+		s.checkSpent(statedb, account.Address)
+
+		if !account.store.IsUnlocked(account.Address) {
+			continue
+		}
+		start := uint64(1)
+		if number, ok := readProgress(s.db, account.Address); ok {
+			start = number + 1
+		}
+		if start > target {
+			continue
+		}
+		pending = append(pending, account)
+		if start < from {
+			from = start
+		}
+	}
+	if len(pending) == 0 {
+		return false
+	}
+	to := target
+	if to-from >= scanBatchBlocks {
+		to = from + scanBatchBlocks - 1
+	}
+
+	found := make(map[common.Address][]*OTA)
+	for number := from; number <= to; number++ {
+		block := s.chain.GetBlockByNumber(number)
+		if block == nil {
+			to = number - 1
+			break
+		}
+		for _, tx := range block.Transactions() {
+			otaAddr, value, err := vm.UnpackBuyCoinTx(tx)
+			if err != nil {
+				continue
+			}
+			if !s.otaExists(statedb, otaAddr) {
+				continue
+			}
+			for _, account := range pending {
+				if start, ok := readProgress(s.db, account.Address); ok && start >= number {
+					continue
+				}
+				owned, keyImage, err := account.store.CheckOTAOwnership(account.Account, otaAddr)
+				if err != nil || !owned {
+					continue
+				}
+				ota := &OTA{
+					Value:       value,
+					BlockNumber: number,
+					BlockHash:   block.Hash(),
+					TxHash:      tx.Hash(),
+					KeyImage:    keyImage,
+				}
+				copy(ota.Address[:], otaAddr)
+				found[account.Address] = append(found[account.Address], ota)
+				log.Debug("Found OTA", "account", account.Address, "ota", common.ToHex(otaAddr), "number", number)
+			}
+		}
+	}
+	if to < from {
+		return false
+	}
+	for _, account := range pending {
+		if otas := found[account.Address]; len(otas) > 0 {
+			otas = append(readOTAs(s.db, account.Address), otas...)
+			s.checkSpentOTAs(statedb, otas)
+			writeOTAs(s.db, account.Address, otas)
+		}
+		if number, ok := readProgress(s.db, account.Address); !ok || number < to {
+			writeProgress(s.db, account.Address, to)
+		}
+	}
+	log.Debug("OTA scan round done", "accounts", len(pending), "from", from, "to", to)
+	return to < target
+}
+
+// otaExists tells if a bought OTA is recorded in the state, i.e. the buy
+// transaction didn't fail.
+func (s *Scanner) otaExists(statedb *state.StateDB, otaAddr []byte) bool {
+	ax, err := vm.GetAXFromWanAddr(otaAddr)
+	if err != nil {
+		return false
+	}
+	exist, _, err := vm.CheckOTAAXExist(statedb, ax)
+	return err == nil && exist
+}
+
+// checkSpent marks the OTAs of an account refunded since the last round.
+func (s *Scanner) checkSpent(statedb *state.StateDB, addr common.Address) {
+	otas := readOTAs(s.db, addr)
+	if s.checkSpentOTAs(statedb, otas) {
+		writeOTAs(s.db, addr, otas)
+	}
+}
+
+func (s *Scanner) checkSpentOTAs(statedb *state.StateDB, otas []*OTA) bool {
+	changed := false
+	for _, ota := range otas {
+		if ota.Spent || len(ota.KeyImage) == 0 {
+			continue
+		}
+		if exist, _, err := vm.CheckOTAImageExist(statedb, ota.KeyImage); err == nil && exist {
+			ota.Spent = true
+			changed = true
+		}
+	}
+	return changed
+}
+
+// dropOrphaned removes the OTAs of an account found in blocks which are not
+// canonical anymore, and rewinds its scan to the first of them so that the
+// blocks replacing them are scanned.
+func (s *Scanner) dropOrphaned(addr common.Address) {
+	var (
+		orphaned int
+		lowest   uint64
+	)
+	for _, ota := range readOTAs(s.db, addr) {
+		if core.GetCanonicalHash(s.db, ota.BlockNumber) != ota.BlockHash {
+			if orphaned == 0 || ota.BlockNumber < lowest {
+				lowest = ota.BlockNumber
+			}
+			orphaned++
+		}
+	}
+	if orphaned > 0 {
+		log.Warn("Dropped OTAs of reorged blocks", "account", addr, "count", orphaned, "rescan", lowest)
+		s.rewind(addr, lowest)
+	}
+}
+
+// OTAs returns the OTAs indexed for an account and the number of the last
+// block scanned for it.
+func (s *Scanner) OTAs(addr common.Address) ([]*OTA, uint64) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	number, _ := readProgress(s.db, addr)
+	return readOTAs(s.db, addr), number
+}
+
+// Rescan makes the scanner index the OTAs of an account again, starting at
+// block from.
+func (s *Scanner) Rescan(addr common.Address, from uint64) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.rewind(addr, from)
+}
+
+// rewind drops the OTAs of an account found from block from on, and moves its
+// scan progress back before it.
+func (s *Scanner) rewind(addr common.Address, from uint64) {
+	otas := readOTAs(s.db, addr)
+	kept := otas[:0]
+	for _, ota := range otas {
+		if ota.BlockNumber < from {
+			kept = append(kept, ota)
+		}
+	}
+	writeOTAs(s.db, addr, kept)
+	if from <= 1 {
+		deleteProgress(s.db, addr)
+	} else {
+		writeProgress(s.db, addr, from-1)
+	}
+}
+
+// Status returns the number of the block the scanner targets and the scan
+// progress of each account.
+func (s *Scanner) Status() (uint64, map[common.Address]uint64) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	progress := make(map[common.Address]uint64)
+	for _, account := range s.accounts() {
+		number, _ := readProgress(s.db, account.Address)
+		progress[account.Address] = number
+	}
+	return s.head, progress
+}
@@ -0,0 +1,193 @@
+package otascan
+
+import (
+	"io/ioutil"
+	"math/big"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/wanchain/go-wanchain/accounts"
+	"github.com/wanchain/go-wanchain/accounts/abi"
+	"github.com/wanchain/go-wanchain/accounts/keystore"
+	"github.com/wanchain/go-wanchain/common"
+	"github.com/wanchain/go-wanchain/common/hexutil"
+	"github.com/wanchain/go-wanchain/consensus/ethash"
+	"github.com/wanchain/go-wanchain/core"
+	"github.com/wanchain/go-wanchain/core/state"
+	"github.com/wanchain/go-wanchain/core/types"
+	"github.com/wanchain/go-wanchain/core/vm"
+	"github.com/wanchain/go-wanchain/crypto"
+	"github.com/wanchain/go-wanchain/ethdb"
+)
+
+const buyCoinDefinition = `[{"constant": false,"type": "function","stateMutability": "nonpayable","inputs": [{"name": "OtaAddr","type":"string"},{"name": "Value","type": "uint256"}],"name": "buyCoinNote","outputs": [{"name": "OtaAddr","type":"string"},{"name": "Value","type": "uint256"}]}]`
+
+var (
+	testKey, _  = crypto.HexToECDSA("f1572f76b75b40a7da72d6f2ee7fda3d1189c2d28f0a2f096347055abe344d7f")
+	wanCoinAddr = common.BytesToAddress([]byte{100})
+	coinValue   = new(big.Int).Mul(big.NewInt(10), big.NewInt(1e18))
+)
+
+// newOTA generates a fresh OTA for the given account.
+func newOTA(t *testing.T, ks *keystore.KeyStore, account accounts.Account) []byte {
+	waddr, err := ks.GetWanAddress(account)
+	if err != nil {
+		t.Fatal(err)
+	}
+	A, B, err := keystore.GeneratePKPairFromWAddress(waddr[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	pks := hexutil.PKPair2HexSlice(A, B)
+	ota, err := crypto.GenerateOneTimeKey(pks[0], pks[1], pks[2], pks[3])
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, err := hexutil.Decode("0x" + strings.Replace(strings.Join(ota, ""), "0x", "", -1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	otaAddr, err := keystore.WaddrFromUncompressedRawBytes(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return otaAddr[:]
+}
+
+func buyCoinTx(t *testing.T, nonce uint64, otaAddr []byte) *types.Transaction {
+	coinAbi, err := abi.JSON(strings.NewReader(buyCoinDefinition))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := coinAbi.Pack("buyCoinNote", hexutil.Encode(otaAddr), coinValue)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx := types.NewTransaction(nonce, wanCoinAddr, coinValue, big.NewInt(300000), big.NewInt(1), data)
+	signed, err := types.SignTx(tx, types.HomesteadSigner{}, testKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed
+}
+
+func TestScanner(t *testing.T) {
+	dir, err := ioutil.TempDir("", "otascan-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	ks := keystore.NewKeyStore(dir, keystore.LightScryptN, keystore.LightScryptP)
+
+	owner, err := ks.NewAccount("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := ks.NewAccount("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ks.Unlock(owner, ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := ks.Unlock(other, ""); err != nil {
+		t.Fatal(err)
+	}
+	owned := [][]byte{newOTA(t, ks, owner), newOTA(t, ks, owner)}
+	foreign := newOTA(t, ks, other)
+
+	var (
+		db, _         = ethdb.NewMemDatabase()
+		engine        = ethash.NewFaker(db)
+		gspec         = core.DefaultPPOWTestingGenesisBlock()
+		genesis       = gspec.MustCommit(db)
+		blockchain, _ = core.NewBlockChain(db, gspec.Config, engine, vm.Config{})
+	)
+	defer blockchain.Stop()
+
+	env := core.NewChainEnv(gspec.Config, gspec, engine, blockchain, db)
+	blocks, _ := env.GenerateChain(genesis, scanConfirmations+5, func(i int, gen *core.BlockGen) {
+		switch i {
+		case 1:
+			gen.AddTx(buyCoinTx(t, gen.TxNonce(crypto.PubkeyToAddress(testKey.PublicKey)), owned[0]))
+			gen.AddTx(buyCoinTx(t, gen.TxNonce(crypto.PubkeyToAddress(testKey.PublicKey)), foreign))
+		case 3:
+			gen.AddTx(buyCoinTx(t, gen.TxNonce(crypto.PubkeyToAddress(testKey.PublicKey)), owned[1]))
+		}
+	})
+	if _, err := blockchain.InsertChain(blocks); err != nil {
+		t.Fatal(err)
+	}
+
+	// Lock the second account, it must not be scanned
+	if err := ks.Lock(other.Address); err != nil {
+		t.Fatal(err)
+	}
+	scanner := New(blockchain, db, ks)
+	if scanner.scan() {
+		t.Fatal("scan should complete in one round")
+	}
+
+	otas, number := scanner.OTAs(owner.Address)
+	if number != 5 {
+		t.Fatalf("scan progress mismatch: have %d, want %d", number, 5)
+	}
+	if len(otas) != len(owned) {
+		t.Fatalf("owned OTA count mismatch: have %d, want %d", len(otas), len(owned))
+	}
+	for i, ota := range otas {
+		if string(ota.Address[:]) != string(owned[i]) {
+			t.Errorf("OTA %d mismatch: have %x, want %x", i, ota.Address, owned[i])
+		}
+		if ota.Value.Cmp(coinValue) != 0 || ota.Spent || len(ota.KeyImage) == 0 {
+			t.Errorf("OTA %d invalid: %+v", i, ota)
+		}
+	}
+	if otas, number := scanner.OTAs(other.Address); len(otas) != 0 || number != 0 {
+		t.Fatalf("locked account scanned: %d OTAs up to %d", len(otas), number)
+	}
+
+	// Unlocking the account makes the scanner catch up
+	if err := ks.Unlock(other, ""); err != nil {
+		t.Fatal(err)
+	}
+	scanner.scan()
+	if otas, _ := scanner.OTAs(other.Address); len(otas) != 1 || string(otas[0].Address[:]) != string(foreign) {
+		t.Fatalf("foreign OTA not found after unlock: %v", otas)
+	}
+
+	// Refunding an OTA records its key image, which marks it spent
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(db))
+	if err := vm.AddOTAImage(statedb, otas[0].KeyImage, coinValue.Bytes()); err != nil {
+		t.Fatal(err)
+	}
+	otas, _ = scanner.OTAs(owner.Address)
+	if !scanner.checkSpentOTAs(statedb, otas) || !otas[0].Spent || otas[1].Spent {
+		t.Fatal("refunded OTA not marked spent")
+	}
+
+	// Rescanning drops the later OTAs until they are found again
+	scanner.Rescan(owner.Address, 3)
+	if otas, number := scanner.OTAs(owner.Address); len(otas) != 1 || number != 2 {
+		t.Fatalf("rescan mismatch: %d OTAs up to %d", len(otas), number)
+	}
+	scanner.scan()
+	if otas, _ := scanner.OTAs(owner.Address); len(otas) != 2 {
+		t.Fatalf("rescanned OTA count mismatch: have %d, want 2", len(otas))
+	}
+
+	// An OTA of a reorged block is dropped and its block number scanned again
+	otas, _ = scanner.OTAs(owner.Address)
+	otas[1].BlockHash = common.Hash{1}
+	writeOTAs(db, owner.Address, otas)
+	scanner.dropOrphaned(owner.Address)
+	if otas, number := scanner.OTAs(owner.Address); len(otas) != 1 || number != 3 {
+		t.Fatalf("orphaned OTA mismatch: %d OTAs up to %d", len(otas), number)
+	}
+	scanner.scan()
+	otas, number = scanner.OTAs(owner.Address)
+	if len(otas) != 2 || number != 5 || otas[1].BlockHash != blocks[3].Hash() {
+		t.Fatalf("reorged block not scanned again: %d OTAs up to %d", len(otas), number)
+	}
+}
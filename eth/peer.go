@@ -26,6 +26,7 @@ import (
 	"github.com/wanchain/go-wanchain/common"
 	"github.com/wanchain/go-wanchain/core/types"
 	"github.com/wanchain/go-wanchain/p2p"
+	"github.com/wanchain/go-wanchain/pos/equivocation"
 	"github.com/wanchain/go-wanchain/rlp"
 	"gopkg.in/fatih/set.v0"
 )
@@ -39,6 +40,7 @@ var (
 const (
 	maxKnownTxs      = 32768 // Maximum transactions hashes to keep in the known list (prevent DOS)
 	maxKnownBlocks   = 1024  // Maximum block hashes to keep in the known list (prevent DOS)
+	maxKnownEvidence = 1024  // Maximum equivocation evidence hashes to keep in the known list (prevent DOS)
 	handshakeTimeout = 5 * time.Second
 )
 
@@ -66,6 +68,8 @@ type peer struct {
 	knownTxs    *set.Set // Set of transaction hashes known to be known by this peer
 	knownBlocks *set.Set // Set of block hashes known to be known by this peer
 
+	knownEvidence *set.Set // Set of equivocation evidence hashes known to be known by this peer
+
 	bufferTxs  *set.Set
 	receiveTxs *set.Set
 
@@ -88,6 +92,8 @@ func newPeer(version int, p *p2p.Peer, rw p2p.MsgReadWriter) *peer {
 		knownBlocks: set.New(),
 		bufferTxs:   set.New(),
 		receiveTxs:  set.New(),
+
+		knownEvidence: set.New(),
 	}
 
 
@@ -145,6 +151,16 @@ func (p *peer) MarkTransaction(hash common.Hash) {
 	p.knownTxs.Add(hash)
 }
 
+// MarkEvidence marks equivocation evidence as known for the peer, ensuring
+// that it will never be propagated to this particular peer.
+func (p *peer) MarkEvidence(hash common.Hash) {
+	// If we reached the memory allowance, drop a previously known evidence hash
+	for p.knownEvidence.Size() >= maxKnownEvidence {
+		p.knownEvidence.Pop()
+	}
+	p.knownEvidence.Add(hash)
+}
+
 // SendTransactions sends transactions to the peer and includes the hashes
 // in its transaction hash set for future reference.
 func (p *peer) SendTransactions(txs types.Transactions) error {
@@ -189,6 +205,13 @@ func (p *peer) SendNewBlock(block *types.Block, td *big.Int) error {
 	return p2p.Send(p.rw, NewBlockMsg, []interface{}{block, td})
 }
 
+// SendEquivocationEvidence propagates the evidence of an equivocating slot
+// leader to a remote peer.
+func (p *peer) SendEquivocationEvidence(ev *equivocation.Evidence) error {
+	p.MarkEvidence(ev.Hash())
+	return p2p.Send(p.rw, EquivocationEvidenceMsg, ev)
+}
+
 // SendBlockHeaders sends a batch of block headers to the remote peer.
 func (p *peer) SendBlockHeaders(headers []*types.Header) error {
 	return p2p.Send(p.rw, BlockHeadersMsg, headers)
@@ -464,6 +487,21 @@ func (ps *peerSet) PeersWithoutTx(hash common.Hash) []*peer {
 	return list
 }
 
+// PeersWithoutEvidence retrieves a list of peers able to receive equivocation
+// evidence that do not have the given one in their set of known hashes.
+func (ps *peerSet) PeersWithoutEvidence(hash common.Hash) []*peer {
+	ps.lock.RLock()
+	defer ps.lock.RUnlock()
+
+	list := make([]*peer, 0, len(ps.peers))
+	for _, p := range ps.peers {
+		if p.version >= eth64 && !p.knownEvidence.Has(hash) {
+			list = append(list, p)
+		}
+	}
+	return list
+}
+
 func (ps *peerSet) PeersList() []*peer {
 	ps.lock.RLock()
 	defer ps.lock.RUnlock()
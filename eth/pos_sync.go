@@ -0,0 +1,135 @@
+// Copyright 2018 Wanchain Foundation Ltd
+// This file is part of the go-wanchain library.
+//
+// The go-wanchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-wanchain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-wanchain library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"github.com/wanchain/go-wanchain/consensus/pluto"
+	"github.com/wanchain/go-wanchain/core"
+	"github.com/wanchain/go-wanchain/core/state"
+	"github.com/wanchain/go-wanchain/core/types"
+	"github.com/wanchain/go-wanchain/log"
+	"github.com/wanchain/go-wanchain/pos/epochLeader"
+	"github.com/wanchain/go-wanchain/pos/incentive"
+	"github.com/wanchain/go-wanchain/pos/posconfig"
+	"github.com/wanchain/go-wanchain/pos/slotleader"
+	"github.com/wanchain/go-wanchain/pos/util"
+)
+
+// posSyncer rebuilds the pos databases of a chain fast synced past the pos
+// upgrade, which are otherwise filled while executing the blocks.
+type posSyncer struct {
+	blockchain *core.BlockChain
+	signer     func() posconfig.MinerSigner // validator whose security messages are rebuilt, may return nil
+}
+
+// SwitchEngine implements downloader.PosSyncer, switching the chain and its
+// agents like the import of the last pow block does.
+func (s *posSyncer) SwitchEngine() error {
+	if _, ok := s.blockchain.Engine().(*pluto.Pluto); ok {
+		return nil
+	}
+	return s.blockchain.SwitchClientEngine()
+}
+
+// setFirstEpoch sets the first pos epoch from the synced headers, as the
+// import of the first pos block does.
+func (s *posSyncer) setFirstEpoch() {
+	if first := s.blockchain.GetHeaderByNumber(s.blockchain.GetFirstPosBlockNumber()); first != nil {
+		posconfig.FirstEpochId, _ = util.CalEpSlbyTd(first.Difficulty.Uint64())
+	}
+}
+
+// StateHeaders implements downloader.PosSyncer. The stake-out of the pivot
+// epoch is replayed on the state of the parent of the block which ran it,
+// when that block is not after the pivot.
+func (s *posSyncer) StateHeaders(headers []*types.Header) []*types.Header {
+	s.setFirstEpoch()
+	for i, header := range headers {
+		if epochID, slotID := util.GetEpochSlotIDFromDifficulty(header.Difficulty); incentive.RunsAt(epochID, slotID) {
+			if i == 0 {
+				// The parent is the anchor of the previous epoch
+				return nil
+			}
+			return []*types.Header{headers[i-1]}
+		}
+	}
+	return nil
+}
+
+// Rebuild implements downloader.PosSyncer. The leaders of the pivot epoch and
+// of the next one are selected from the anchor states, the ones of the later
+// epochs being selected as the blocks are imported. The stake-out record of
+// the pivot epoch and the security message the validator generated in the
+// previous epoch are rebuilt too, the later ones being stored as the blocks are
+// imported and the slot leader workflow runs.
+func (s *posSyncer) Rebuild(pivot *types.Header, anchors map[uint64]*types.Header) error {
+	s.setFirstEpoch()
+	for epochID, header := range anchors {
+		util.SetEpochBlock(epochID, header.Number.Uint64(), header.Hash())
+	}
+	pivotEpoch, _ := util.CalEpSlbyTd(pivot.Difficulty.Uint64())
+	util.SetEpochBlock(pivotEpoch, pivot.Number.Uint64(), pivot.Hash())
+	posconfig.CurrentEpochId = pivotEpoch
+
+	epocher := epochLeader.NewEpocher(s.blockchain)
+	for epochID := pivotEpoch; epochID <= pivotEpoch+1; epochID++ {
+		if err := epocher.SelectLeadersLoop(epochID); err != nil {
+			return err
+		}
+	}
+	// The pos database only serves the api and the slot leader proofs of the
+	// validator, the sync goes on without it.
+	if _, err := epocher.RebuildStakeOut(pivotEpoch); err != nil {
+		log.Warn("Failed to rebuild the stake-out of the pivot epoch", "epochID", pivotEpoch, "err", err)
+	}
+	if s.signer != nil {
+		if signer := s.signer(); signer != nil {
+			if _, err := slotleader.RebuildSecurityMsg(s.blockchain, epocher, pivotEpoch-1, signer); err != nil {
+				log.Warn("Failed to rebuild the security message", "epochID", pivotEpoch-1, "err", err)
+			}
+		}
+	}
+	return nil
+}
+
+// VerifyHeaders implements downloader.PosSyncer. The leaders of the previous
+// epoch are selected from the anchor state two epochs before it, and the
+// proofs are validated like the import of the blocks does, reading the random
+// and the stage two transactions from the anchor state of the previous epoch.
+func (s *posSyncer) VerifyHeaders(epochID uint64, headers []*types.Header, anchors map[uint64]*types.Header) (int, error) {
+	s.setFirstEpoch()
+	var stateDb *state.StateDB
+	if anchor, ok := anchors[epochID-1]; ok {
+		util.SetEpochBlock(epochID-1, anchor.Number.Uint64(), anchor.Hash())
+		if epochID > posconfig.FirstEpochId+2 {
+			if err := epochLeader.NewEpocher(s.blockchain).SelectLeadersLoop(epochID - 1); err != nil {
+				return 0, err
+			}
+			var err error
+			if stateDb, err = s.blockchain.StateAt(anchor.Root); err != nil {
+				return 0, err
+			}
+		}
+	}
+	sls := slotleader.GetSlotLeaderSelection()
+	for i, header := range headers {
+		if err := sls.ValidateHeaderAt(header, stateDb); err != nil {
+			return i, err
+		}
+	}
+	return 0, nil
+}
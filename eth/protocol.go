@@ -32,16 +32,17 @@ import (
 const (
 	eth62 = 62
 	eth63 = 63
+	eth64 = 64
 )
 
 // Official short name of the protocol used during capability negotiation.
 var ProtocolName = "wan"
 
 // Supported versions of the eth protocol (first is primary).
-var ProtocolVersions = []uint{eth63, eth62}
+var ProtocolVersions = []uint{eth64, eth63, eth62}
 
 // Number of implemented message corresponding to different protocol versions.
-var ProtocolLengths = []uint64{25,  8}
+var ProtocolLengths = []uint64{25, 25, 8}
 
 const ProtocolMaxMsgSize = 10 * 1024 * 1024 // Maximum cap on the size of a protocol message
 
@@ -70,6 +71,9 @@ const (
 	PivotMsg       		= 0x14
 	GetBlockHeaderTdMsg = 0x15
 	BlockHeaderTdMsg 	= 0x16
+
+	// Protocol messages belonging to eth/64
+	EquivocationEvidenceMsg = 0x17
 )
 
 type errCode int
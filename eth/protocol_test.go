@@ -17,16 +17,20 @@
 package eth
 
 import (
+	"errors"
 	"fmt"
+	"math/big"
 	"sync"
 	"testing"
 	"time"
 
 	"github.com/wanchain/go-wanchain/common"
+	"github.com/wanchain/go-wanchain/consensus"
 	"github.com/wanchain/go-wanchain/core/types"
 	"github.com/wanchain/go-wanchain/crypto"
 	"github.com/wanchain/go-wanchain/eth/downloader"
 	"github.com/wanchain/go-wanchain/p2p"
+	"github.com/wanchain/go-wanchain/pos/equivocation"
 	"github.com/wanchain/go-wanchain/rlp"
 )
 
@@ -174,6 +178,92 @@ func testSendTransactions(t *testing.T, protocol int) {
 	wg.Wait()
 }
 
+// testEvidenceEngine takes the coinbase as the signer of any header, and
+// rejects the seals of forgedSigner.
+type testEvidenceEngine struct{}
+
+var forgedSigner = common.Address{0xb}
+
+func (testEvidenceEngine) Author(header *types.Header) (common.Address, error) {
+	return header.Coinbase, nil
+}
+
+func (testEvidenceEngine) VerifySeal(chain consensus.ChainReader, header *types.Header) error {
+	if header.Coinbase == forgedSigner {
+		return errors.New("invalid seal")
+	}
+	return nil
+}
+
+// Tests that the equivocation evidence received is stored and propagated to
+// the peers not knowing about it, and that the peers relaying forged evidence
+// are dropped.
+func TestEquivocationEvidence64(t *testing.T) {
+	pm := newTestProtocolManagerMust(t, downloader.FullSync, 0, nil, nil)
+	// The test chain has no pos blocks, the detector verifies any epoch without it
+	pm.equivocations = equivocation.New(pm.chaindb, pm.chainconfig, testEvidenceEngine{}, nil)
+	pm.evidenceCh = make(chan equivocation.NewEvidenceEvent, evidenceChanSize)
+	pm.evidenceSub = pm.equivocations.SubscribeNewEvidence(pm.evidenceCh)
+	go pm.evidenceBroadcastLoop()
+	defer pm.Stop()
+
+	source, _ := newTestPeer("source", eth64, pm, true)
+	defer source.close()
+	sink, _ := newTestPeer("sink", eth64, pm, true)
+	defer sink.close()
+
+	header := func(signer common.Address, extra byte) *types.Header {
+		return &types.Header{
+			Number:     big.NewInt(1),
+			Difficulty: new(big.Int).SetUint64(3<<32 | 5<<8 | 1),
+			Coinbase:   signer,
+			Extra:      []byte{extra},
+		}
+	}
+	ev := equivocation.NewEvidence(common.Address{0xa}, header(common.Address{0xa}, 1), header(common.Address{0xa}, 2))
+	if err := p2p.Send(source.app, EquivocationEvidenceMsg, ev); err != nil {
+		t.Fatalf("send error: %v", err)
+	}
+	msg, err := sink.app.ReadMsg()
+	if err != nil {
+		t.Fatalf("read error: %v", err)
+	}
+	if msg.Code != EquivocationEvidenceMsg {
+		t.Fatalf("got code %d, want EquivocationEvidenceMsg", msg.Code)
+	}
+	var got equivocation.Evidence
+	if err := msg.Decode(&got); err != nil {
+		t.Fatalf("decode error: %v", err)
+	}
+	if got.Hash() != ev.Hash() {
+		t.Fatalf("evidence mismatch: have %x, want %x", got.Hash(), ev.Hash())
+	}
+	if evs := pm.equivocations.Evidence(3); len(evs) != 1 || evs[0].Hash() != ev.Hash() {
+		t.Fatalf("evidence not stored: %v", evs)
+	}
+	if !source.knownEvidence.Has(ev.Hash()) || len(pm.peers.PeersWithoutEvidence(ev.Hash())) != 0 {
+		t.Fatal("evidence not marked as known")
+	}
+
+	forger, errc := newTestPeer("forger", eth64, pm, true)
+	defer forger.close()
+	forged := equivocation.NewEvidence(forgedSigner, header(forgedSigner, 1), header(forgedSigner, 2))
+	if err := p2p.Send(forger.app, EquivocationEvidenceMsg, forged); err != nil {
+		t.Fatalf("send error: %v", err)
+	}
+	select {
+	case err := <-errc:
+		if err == nil {
+			t.Fatal("forger disconnected without error")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("forger not disconnected")
+	}
+	if evs := pm.equivocations.Evidence(3); len(evs) != 1 {
+		t.Fatalf("forged evidence stored: %v", evs)
+	}
+}
+
 // Tests that the custom union field encoder and decoder works correctly.
 func TestGetBlockHeadersDataEncodeDecode(t *testing.T) {
 	// Create a "random" hash for testing
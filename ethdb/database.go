@@ -27,6 +27,7 @@ import (
 	"github.com/syndtr/goleveldb/leveldb/filter"
 	"github.com/syndtr/goleveldb/leveldb/iterator"
 	"github.com/syndtr/goleveldb/leveldb/opt"
+	"github.com/syndtr/goleveldb/leveldb/util"
 	"github.com/wanchain/go-wanchain/log"
 	"github.com/wanchain/go-wanchain/metrics"
 
@@ -39,6 +40,8 @@ type LDBDatabase struct {
 	fn string      // filename for reporting
 	db *leveldb.DB // LevelDB instance
 
+	ancients *Freezer // Freezer of the old canonical blocks, nil if none
+
 	getTimer       gometrics.Timer // Timer for measuring the database get request counts and latencies
 	putTimer       gometrics.Timer // Timer for measuring the database put request counts and latencies
 	delTimer       gometrics.Timer // Timer for measuring the database delete request counts and latencies
@@ -149,6 +152,75 @@ func (db *LDBDatabase) NewIterator() iterator.Iterator {
 	return db.db.NewIterator(nil, nil)
 }
 
+// NewIteratorWithPrefix returns an iterator over the pairs whose key starts
+// with prefix.
+func (db *LDBDatabase) NewIteratorWithPrefix(prefix []byte) Iterator {
+	return db.db.NewIterator(util.BytesPrefix(prefix), nil)
+}
+
+// DeleteRange deletes the keys in [start, limit), up to the last key if limit
+// is nil. LevelDB has no range deletion, the keys are deleted in batches.
+func (db *LDBDatabase) DeleteRange(start, limit []byte) error {
+	it := db.db.NewIterator(&util.Range{Start: start, Limit: limit}, nil)
+	defer it.Release()
+
+	batch := new(leveldb.Batch)
+	for it.Next() {
+		batch.Delete(it.Key())
+		if len(batch.Dump()) >= IdealBatchSize {
+			if err := db.db.Write(batch, nil); err != nil {
+				return err
+			}
+			batch.Reset()
+		}
+	}
+	if err := it.Error(); err != nil {
+		return err
+	}
+	return db.db.Write(batch, nil)
+}
+
+// NewSnapshot returns a read-only view of the current state of the database.
+func (db *LDBDatabase) NewSnapshot() (Snapshot, error) {
+	snap, err := db.db.GetSnapshot()
+	if err != nil {
+		return nil, err
+	}
+	return &ldbSnapshot{snap: snap}, nil
+}
+
+// OpenFreezer attaches the freezer in dir to the database. The database then
+// reads the old canonical blocks moved out of LevelDB from it.
+func (db *LDBDatabase) OpenFreezer(dir string) error {
+	ancients, err := NewFreezer(dir)
+	if err != nil {
+		return err
+	}
+	db.ancients = ancients
+	return nil
+}
+
+// Freezer returns the freezer attached to the database, nil if none.
+func (db *LDBDatabase) Freezer() *Freezer {
+	return db.ancients
+}
+
+// Ancient returns an item of a frozen block.
+func (db *LDBDatabase) Ancient(kind string, number uint64) ([]byte, error) {
+	if db.ancients == nil {
+		return nil, errOutOfBounds
+	}
+	return db.ancients.Ancient(kind, number)
+}
+
+// Ancients returns the number of blocks frozen.
+func (db *LDBDatabase) Ancients() uint64 {
+	if db.ancients == nil {
+		return 0
+	}
+	return db.ancients.Ancients()
+}
+
 func (db *LDBDatabase) Close() {
 	// Stop the metrics collection to avoid internal database races
 	db.quitLock.Lock()
@@ -161,6 +233,11 @@ func (db *LDBDatabase) Close() {
 			db.log.Error("Metrics collection failed", "err", err)
 		}
 	}
+	if db.ancients != nil {
+		if err := db.ancients.Close(); err != nil {
+			db.log.Error("Failed to close freezer", "err", err)
+		}
+	}
 	err := db.db.Close()
 	if err == nil {
 		db.log.Info("Database closed")
@@ -299,6 +376,26 @@ func (b *ldbBatch) ValueSize() int {
 	return b.size
 }
 
+type ldbSnapshot struct {
+	snap *leveldb.Snapshot
+}
+
+func (s *ldbSnapshot) Get(key []byte) ([]byte, error) {
+	return s.snap.Get(key, nil)
+}
+
+func (s *ldbSnapshot) Has(key []byte) (bool, error) {
+	return s.snap.Has(key, nil)
+}
+
+func (s *ldbSnapshot) NewIteratorWithPrefix(prefix []byte) Iterator {
+	return s.snap.NewIterator(util.BytesPrefix(prefix), nil)
+}
+
+func (s *ldbSnapshot) Release() {
+	s.snap.Release()
+}
+
 type table struct {
 	db     Database
 	prefix string
@@ -333,6 +430,61 @@ func (dt *table) Close() {
 	// Do nothing; don't close the underlying DB.
 }
 
+func (dt *table) NewIteratorWithPrefix(prefix []byte) Iterator {
+	return &tableIterator{dt.db.NewIteratorWithPrefix(append([]byte(dt.prefix), prefix...)), len(dt.prefix)}
+}
+
+func (dt *table) DeleteRange(start, limit []byte) error {
+	if limit == nil {
+		// Stop at the end of the table
+		_, tableLimit := PrefixRange([]byte(dt.prefix))
+		return dt.db.DeleteRange(append([]byte(dt.prefix), start...), tableLimit)
+	}
+	return dt.db.DeleteRange(append([]byte(dt.prefix), start...), append([]byte(dt.prefix), limit...))
+}
+
+func (dt *table) NewSnapshot() (Snapshot, error) {
+	snap, err := dt.db.NewSnapshot()
+	if err != nil {
+		return nil, err
+	}
+	return &tableSnapshot{snap, dt.prefix}, nil
+}
+
+// tableIterator strips the table prefix off the keys of the pairs iterated.
+type tableIterator struct {
+	Iterator
+	prefixLen int
+}
+
+func (it *tableIterator) Key() []byte {
+	if key := it.Iterator.Key(); key != nil {
+		return key[it.prefixLen:]
+	}
+	return nil
+}
+
+type tableSnapshot struct {
+	snap   Snapshot
+	prefix string
+}
+
+func (ts *tableSnapshot) Get(key []byte) ([]byte, error) {
+	return ts.snap.Get(append([]byte(ts.prefix), key...))
+}
+
+func (ts *tableSnapshot) Has(key []byte) (bool, error) {
+	return ts.snap.Has(append([]byte(ts.prefix), key...))
+}
+
+func (ts *tableSnapshot) NewIteratorWithPrefix(prefix []byte) Iterator {
+	return &tableIterator{ts.snap.NewIteratorWithPrefix(append([]byte(ts.prefix), prefix...)), len(ts.prefix)}
+}
+
+func (ts *tableSnapshot) Release() {
+	ts.snap.Release()
+}
+
 type tableBatch struct {
 	batch  Batch
 	prefix string
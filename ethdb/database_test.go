@@ -190,3 +190,91 @@ func testParallelPutGet(db ethdb.Database, t *testing.T) {
 	}
 	pending.Wait()
 }
+
+func TestLDB_IterateDeleteRange(t *testing.T) {
+	db, remove := newTestLDB()
+	defer remove()
+	testIterateDeleteRange(db, t)
+}
+
+func TestMemoryDB_IterateDeleteRange(t *testing.T) {
+	db, _ := ethdb.NewMemDatabase()
+	testIterateDeleteRange(db, t)
+}
+
+func TestTable_IterateDeleteRange(t *testing.T) {
+	db, _ := ethdb.NewMemDatabase()
+	db.Put([]byte("a"), []byte("outside"))
+	db.Put([]byte("tc"), []byte("outside"))
+	testIterateDeleteRange(ethdb.NewTable(db, "tb"), t)
+	for _, key := range []string{"a", "tc"} {
+		if ok, _ := db.Has([]byte(key)); !ok {
+			t.Fatalf("key %q outside of the table deleted", key)
+		}
+	}
+}
+
+// iterated returns the keys of the pairs of it, checking their values.
+func iterated(it ethdb.Iterator, t *testing.T) []string {
+	defer it.Release()
+
+	var keys []string
+	for it.Next() {
+		if !bytes.Equal(it.Value(), append([]byte("v"), it.Key()...)) {
+			t.Fatalf("iterated %q with value %q", it.Key(), it.Value())
+		}
+		keys = append(keys, string(it.Key()))
+	}
+	if err := it.Error(); err != nil {
+		t.Fatal(err)
+	}
+	return keys
+}
+
+func testIterateDeleteRange(db ethdb.Database, t *testing.T) {
+	for _, key := range []string{"1_2", "1_10", "1", "12_1", "2_1", "2_2", "\xff"} {
+		if err := db.Put([]byte(key), []byte("v"+key)); err != nil {
+			t.Fatalf("put failed: %v", err)
+		}
+	}
+
+	if keys := iterated(db.NewIteratorWithPrefix([]byte("1_")), t); fmt.Sprint(keys) != "[1_10 1_2]" {
+		t.Fatalf("iterated %q with prefix 1_", keys)
+	}
+	if keys := iterated(db.NewIteratorWithPrefix(nil), t); len(keys) != 7 {
+		t.Fatalf("iterated %q without prefix", keys)
+	}
+	snap, err := db.NewSnapshot()
+	if err != nil {
+		t.Fatalf("snapshot failed: %v", err)
+	}
+	defer snap.Release()
+
+	if err := db.DeleteRange(ethdb.PrefixRange([]byte("1_"))); err != nil {
+		t.Fatalf("delete range failed: %v", err)
+	}
+	if keys := iterated(db.NewIteratorWithPrefix(nil), t); fmt.Sprint(keys) != "[1 12_1 2_1 2_2 \xff]" {
+		t.Fatalf("iterated %q after deleting prefix 1_", keys)
+	}
+	if err := db.DeleteRange([]byte("2_2"), nil); err != nil {
+		t.Fatalf("delete range failed: %v", err)
+	}
+	if keys := iterated(db.NewIteratorWithPrefix(nil), t); fmt.Sprint(keys) != "[1 12_1 2_1]" {
+		t.Fatalf("iterated %q after deleting from 2_2 on", keys)
+	}
+
+	// The snapshot still holds the deleted pairs
+	if keys := iterated(snap.NewIteratorWithPrefix([]byte("1_")), t); fmt.Sprint(keys) != "[1_10 1_2]" {
+		t.Fatalf("iterated %q with prefix 1_ in the snapshot", keys)
+	}
+	if ok, _ := snap.Has([]byte("\xff")); !ok {
+		t.Fatal("deleted key missing from the snapshot")
+	}
+	if data, err := snap.Get([]byte("2_2")); err != nil || string(data) != "v2_2" {
+		t.Fatalf("get in snapshot returned %q, %v", data, err)
+	}
+	db.Put([]byte("3"), []byte("v3"))
+	if ok, _ := snap.Has([]byte("3")); ok {
+		t.Fatal("key put after the snapshot found in it")
+	}
+}
@@ -0,0 +1,172 @@
+// Copyright 2018 Wanchain Foundation Ltd
+// This file is part of the go-wanchain library.
+//
+// The go-wanchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-wanchain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-wanchain library. If not, see <http://www.gnu.org/licenses/>.
+
+package ethdb
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+)
+
+// The tables of the freezer, each holding an item of every frozen block.
+const (
+	// FreezerHashTable holds the canonical block hashes.
+	FreezerHashTable = "hashes"
+
+	// FreezerHeaderTable holds the rlp encoded block headers.
+	FreezerHeaderTable = "headers"
+
+	// FreezerBodiesTable holds the rlp encoded block bodies.
+	FreezerBodiesTable = "bodies"
+
+	// FreezerReceiptTable holds the rlp encoded block receipts.
+	FreezerReceiptTable = "receipts"
+
+	// FreezerDifficultyTable holds the rlp encoded total difficulties.
+	FreezerDifficultyTable = "diffs"
+)
+
+var freezerTables = []string{FreezerHashTable, FreezerHeaderTable, FreezerBodiesTable, FreezerReceiptTable, FreezerDifficultyTable}
+
+// Freezer is an append-only flat-file store of the canonical blocks old enough
+// not to be reorganised anymore. The blocks are numbered from the genesis on,
+// without gaps, and are only dropped from the top when the chain is rewound.
+type Freezer struct {
+	frozen uint64 // Number of blocks frozen, accessed atomically
+
+	tables map[string]*freezerTable
+	lock   sync.Mutex // Serializes appends and truncations
+}
+
+// NewFreezer opens the freezer in dir, creating it if missing.
+func NewFreezer(dir string) (*Freezer, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	f := &Freezer{tables: make(map[string]*freezerTable)}
+	for _, name := range freezerTables {
+		table, err := newFreezerTable(dir, name)
+		if err != nil {
+			f.Close()
+			return nil, err
+		}
+		f.tables[name] = table
+	}
+	// Drop the blocks an interrupted append did not write to every table
+	frozen := f.tables[FreezerHashTable].Items()
+	for _, table := range f.tables {
+		if items := table.Items(); items < frozen {
+			frozen = items
+		}
+	}
+	if err := f.truncate(frozen); err != nil {
+		f.Close()
+		return nil, err
+	}
+	f.frozen = frozen
+	return f, nil
+}
+
+// Ancient returns the item of block number in table kind.
+func (f *Freezer) Ancient(kind string, number uint64) ([]byte, error) {
+	table, ok := f.tables[kind]
+	if !ok {
+		return nil, fmt.Errorf("unknown freezer table %s", kind)
+	}
+	if number >= f.Ancients() {
+		return nil, errOutOfBounds
+	}
+	return table.Retrieve(number)
+}
+
+// Ancients returns the number of blocks frozen.
+func (f *Freezer) Ancients() uint64 {
+	return atomic.LoadUint64(&f.frozen)
+}
+
+// AppendAncient adds block number to the freezer. It must be the block right
+// after the last one frozen.
+func (f *Freezer) AppendAncient(number uint64, hash, header, body, receipts, td []byte) error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if frozen := f.Ancients(); number != frozen {
+		return fmt.Errorf("appending block %d to %d frozen blocks: %v", number, frozen, errOutOrderInsertion)
+	}
+	items := map[string][]byte{
+		FreezerHashTable:       hash,
+		FreezerHeaderTable:     header,
+		FreezerBodiesTable:     body,
+		FreezerReceiptTable:    receipts,
+		FreezerDifficultyTable: td,
+	}
+	for _, name := range freezerTables {
+		if err := f.tables[name].Append(number, items[name]); err != nil {
+			f.truncate(number)
+			return err
+		}
+	}
+	atomic.StoreUint64(&f.frozen, number+1)
+	return nil
+}
+
+// TruncateAncients drops the frozen blocks from number items on.
+func (f *Freezer) TruncateAncients(items uint64) error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if items >= f.Ancients() {
+		return nil
+	}
+	// Hide the blocks before dropping them
+	atomic.StoreUint64(&f.frozen, items)
+	return f.truncate(items)
+}
+
+func (f *Freezer) truncate(items uint64) error {
+	for _, table := range f.tables {
+		if err := table.truncate(items); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Sync flushes the frozen blocks to disk.
+func (f *Freezer) Sync() error {
+	for _, table := range f.tables {
+		if err := table.Sync(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close closes the freezer files.
+func (f *Freezer) Close() error {
+	var errs []error
+	for _, table := range f.tables {
+		if err := table.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) != 0 {
+		return fmt.Errorf("%v", errs)
+	}
+	return nil
+}
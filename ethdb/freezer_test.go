@@ -0,0 +1,87 @@
+package ethdb
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFreezer(t *testing.T) {
+	dir, err := ioutil.TempDir("", "freezer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	f, err := NewFreezer(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	item := func(kind string, number uint64) []byte {
+		return append([]byte(kind), bytes.Repeat([]byte{byte(number)}, int(number))...)
+	}
+	appendBlock := func(number uint64) error {
+		return f.AppendAncient(number, item(FreezerHashTable, number), item(FreezerHeaderTable, number),
+			item(FreezerBodiesTable, number), item(FreezerReceiptTable, number), item(FreezerDifficultyTable, number))
+	}
+	check := func(frozen uint64) {
+		if f.Ancients() != frozen {
+			t.Fatalf("%d blocks frozen, want %d", f.Ancients(), frozen)
+		}
+		for _, kind := range freezerTables {
+			for number := uint64(0); number < frozen; number++ {
+				if blob, err := f.Ancient(kind, number); err != nil || !bytes.Equal(blob, item(kind, number)) {
+					t.Fatalf("item %d of %s: have %x, %v", number, kind, blob, err)
+				}
+			}
+			if _, err := f.Ancient(kind, frozen); err != errOutOfBounds {
+				t.Fatalf("item %d of %s not frozen, found: %v", frozen, kind, err)
+			}
+		}
+	}
+	for number := uint64(0); number < 10; number++ {
+		if err := appendBlock(number); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := appendBlock(11); err == nil {
+		t.Fatal("block appended out of order")
+	}
+	check(10)
+
+	if err := f.TruncateAncients(7); err != nil {
+		t.Fatal(err)
+	}
+	check(7)
+	if err := appendBlock(7); err != nil {
+		t.Fatal(err)
+	}
+	check(8)
+
+	// Leave an interrupted append behind, it is dropped on reopening
+	if err := f.tables[FreezerHashTable].Append(8, item(FreezerHashTable, 8)); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.tables[FreezerBodiesTable].Append(8, item(FreezerBodiesTable, 8)); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	data, err := os.OpenFile(filepath.Join(dir, FreezerHeaderTable+".dat"), os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data.Write([]byte("partial"))
+	data.Close()
+
+	if f, err = NewFreezer(dir); err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	check(8)
+	if err := appendBlock(8); err != nil {
+		t.Fatal(err)
+	}
+	check(9)
+}
@@ -33,6 +33,9 @@ type Database interface {
 	Delete(key []byte) error
 	Close()
 	NewBatch() Batch
+	Iteratee
+	DeleteRange(start, limit []byte) error // deletes the keys in [start, limit), up to the last key if limit is nil
+	NewSnapshot() (Snapshot, error)
 }
 
 // Batch is a write-only database that commits changes to its host database
@@ -42,3 +45,51 @@ type Batch interface {
 	ValueSize() int // amount of data in the batch
 	Write() error
 }
+
+// Iterator iterates over key/value pairs of a database in ascending key order.
+// It starts before the first pair, Next moves it to the next one. Key and Value
+// must not be modified, nor kept after Next is called.
+type Iterator interface {
+	Next() bool
+	Error() error
+	Key() []byte
+	Value() []byte
+	Release()
+}
+
+// Iteratee wraps the NewIteratorWithPrefix method of a backing data store.
+type Iteratee interface {
+	// NewIteratorWithPrefix returns an iterator over the pairs whose key
+	// starts with prefix.
+	NewIteratorWithPrefix(prefix []byte) Iterator
+}
+
+// Snapshot is a read-only view of a database frozen at the time it was taken.
+// It must be released once done with.
+type Snapshot interface {
+	Get(key []byte) ([]byte, error)
+	Has(key []byte) (bool, error)
+	Iteratee
+	Release()
+}
+
+// PrefixRange returns the range of the keys starting with prefix, for
+// DeleteRange. limit is nil if no key above prefix is outside the range.
+func PrefixRange(prefix []byte) (start, limit []byte) {
+	for i := len(prefix) - 1; i >= 0; i-- {
+		if prefix[i] < 0xff {
+			limit = make([]byte, i+1)
+			copy(limit, prefix)
+			limit[i]++
+			break
+		}
+	}
+	return prefix, limit
+}
+
+// AncientReader wraps the read operations of a database keeping the old
+// canonical blocks in a freezer.
+type AncientReader interface {
+	Ancient(kind string, number uint64) ([]byte, error) // item of a frozen block, see the Freezer tables
+	Ancients() uint64                                   // number of blocks frozen
+}
@@ -18,6 +18,8 @@ package ethdb
 
 import (
 	"errors"
+	"sort"
+	"strings"
 	"sync"
 
 	"github.com/wanchain/go-wanchain/common"
@@ -90,12 +92,100 @@ func (db *MemDatabase) Delete(key []byte) error {
 	return nil
 }
 
+// DeleteRange deletes the keys in [start, limit), up to the last key if limit
+// is nil.
+func (db *MemDatabase) DeleteRange(start, limit []byte) error {
+	db.lock.Lock()
+	defer db.lock.Unlock()
+
+	for key := range db.db {
+		if key >= string(start) && (limit == nil || key < string(limit)) {
+			delete(db.db, key)
+		}
+	}
+	return nil
+}
+
+// NewIteratorWithPrefix returns an iterator over the pairs whose key starts
+// with prefix. The pairs are the ones stored when the iterator is created.
+func (db *MemDatabase) NewIteratorWithPrefix(prefix []byte) Iterator {
+	db.lock.RLock()
+	defer db.lock.RUnlock()
+
+	it := &memIterator{index: -1}
+	for key := range db.db {
+		if strings.HasPrefix(key, string(prefix)) {
+			it.keys = append(it.keys, key)
+		}
+	}
+	sort.Strings(it.keys)
+	it.values = make([][]byte, len(it.keys))
+	for i, key := range it.keys {
+		it.values[i] = db.db[key]
+	}
+	return it
+}
+
+// NewSnapshot returns a read-only copy of the database.
+func (db *MemDatabase) NewSnapshot() (Snapshot, error) {
+	db.lock.RLock()
+	defer db.lock.RUnlock()
+
+	snap := &MemDatabase{db: make(map[string][]byte, len(db.db))}
+	for key, value := range db.db {
+		snap.db[key] = value
+	}
+	return &memSnapshot{snap}, nil
+}
+
 func (db *MemDatabase) Close() {}
 
 func (db *MemDatabase) NewBatch() Batch {
 	return &memBatch{db: db}
 }
 
+type memIterator struct {
+	keys   []string
+	values [][]byte
+	index  int
+}
+
+func (it *memIterator) Next() bool {
+	if it.index >= len(it.keys) {
+		return false
+	}
+	it.index++
+	return it.index < len(it.keys)
+}
+
+func (it *memIterator) Error() error {
+	return nil
+}
+
+func (it *memIterator) Key() []byte {
+	if it.index < 0 || it.index >= len(it.keys) {
+		return nil
+	}
+	return []byte(it.keys[it.index])
+}
+
+func (it *memIterator) Value() []byte {
+	if it.index < 0 || it.index >= len(it.keys) {
+		return nil
+	}
+	return it.values[it.index]
+}
+
+func (it *memIterator) Release() {
+	it.keys, it.values = nil, nil
+}
+
+type memSnapshot struct {
+	*MemDatabase
+}
+
+func (s *memSnapshot) Release() {}
+
 type kv struct{ k, v []byte }
 
 type memBatch struct {
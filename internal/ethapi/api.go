@@ -400,6 +400,57 @@ func (s *PrivateAccountAPI) LockAccount(addr common.Address) bool {
 	return fetchKeystore(s.am).Lock(addr) == nil
 }
 
+// fetchWatchOnlyKeystore retrieves the watch-only keystore from the account manager.
+func fetchWatchOnlyKeystore(am *accounts.Manager) (*keystore.WatchOnlyKeyStore, error) {
+	backends := am.Backends(keystore.WatchOnlyKeyStoreType)
+	if len(backends) == 0 {
+		return nil, errors.New("watch-only keystore not available")
+	}
+	return backends[0].(*keystore.WatchOnlyKeyStore), nil
+}
+
+// ExportViewKey exports the view key of an account, encrypted with newPassword.
+// The view key lets a watch-only node detect the OTAs sent to the account
+// without being able to spend them.
+func (s *PrivateAccountAPI) ExportViewKey(addr common.Address, password string, newPassword string) (string, error) {
+	keyJSON, err := fetchKeystore(s.am).ExportViewKey(accounts.Account{Address: addr}, password, newPassword)
+	if err != nil {
+		return "", err
+	}
+	return string(keyJSON), nil
+}
+
+// ImportViewKey stores an exported view key into the watch-only keystore,
+// encrypting it with newPassword.
+func (s *PrivateAccountAPI) ImportViewKey(keyJSON string, password string, newPassword string) (common.Address, error) {
+	ks, err := fetchWatchOnlyKeystore(s.am)
+	if err != nil {
+		return common.Address{}, err
+	}
+	acc, err := ks.Import([]byte(keyJSON), password, newPassword)
+	return acc.Address, err
+}
+
+// UnlockViewKey unlocks the view key of a watch-only account, which enables
+// scanning for its OTAs.
+func (s *PrivateAccountAPI) UnlockViewKey(addr common.Address, password string) (bool, error) {
+	ks, err := fetchWatchOnlyKeystore(s.am)
+	if err != nil {
+		return false, err
+	}
+	err = ks.Unlock(addr, password)
+	return err == nil, err
+}
+
+// LockViewKey locks the view key of a watch-only account.
+func (s *PrivateAccountAPI) LockViewKey(addr common.Address) bool {
+	ks, err := fetchWatchOnlyKeystore(s.am)
+	if err != nil {
+		return false
+	}
+	return ks.Lock(addr) == nil
+}
+
 // SendTransaction will create a transaction from the given arguments and
 // tries to sign it with the key associated with args.To. If the given passwd isn't
 // able to decrypt the key it fails.
@@ -31,6 +31,7 @@ var Modules = map[string]string{
 	"shh":        Shh_JS,
 	"swarmfs":    SWARMFS_JS,
 	"txpool":     TxPool_JS,
+	"wan":        Wan_JS,
 }
 
 const Chequebook_JS = `
@@ -189,6 +190,11 @@ web3._extend({
 			call: 'pos_getRandom',
 			params: 2
 		}),
+		new web3._extend.Method({
+			name: 'getRandomProof',
+			call: 'pos_getRandomProof',
+			params: 2
+		}),
 		new web3._extend.Method({
 			name: 'getRbSignatureCount',
 			call: 'pos_getRbSignatureCount',
@@ -204,6 +210,11 @@ web3._extend({
 			call: 'pos_getReorgState',
 			params: 1
 		}),
+		new web3._extend.Method({
+			name: 'getReorgEvents',
+			call: 'pos_getReorgEvents',
+			params: 2
+		}),
 
 		new web3._extend.Method({
 			name: 'getPosInfo',
@@ -245,6 +256,11 @@ web3._extend({
 			call: 'pos_getEpochIncentivePayDetail',
 			params: 1
 		}),
+		new web3._extend.Method({
+			name: 'simulateIncentive',
+			call: 'pos_simulateIncentive',
+			params: 2
+		}),
 		new web3._extend.Method({
 			name: 'getTotalIncentive',
 			call: 'pos_getTotalIncentive',
@@ -391,6 +407,33 @@ web3._extend({
 			call: 'pos_getValidRBCnt',
 			params: 1
 		}),
+		new web3._extend.Method({
+			name: 'getRbParticipation',
+			call: 'pos_getRbParticipation',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'getRbProposerStatus',
+			call: 'pos_getRbProposerStatus',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'getEpochSchedule',
+			call: 'pos_getEpochSchedule',
+			params: 2,
+			inputFormatter: [null, web3._extend.formatters.inputAddressFormatter]
+		}),
+		new web3._extend.Method({
+			name: 'getNextLeaderSlot',
+			call: 'pos_getNextLeaderSlot',
+			params: 1,
+			inputFormatter: [web3._extend.formatters.inputAddressFormatter]
+		}),
+		new web3._extend.Method({
+			name: 'getEquivocationEvidence',
+			call: 'pos_getEquivocationEvidence',
+			params: 1
+		}),
 		new web3._extend.Method({
 			name: 'getRbStage',
 			call: 'pos_getRbStage',
@@ -786,6 +829,26 @@ web3._extend({
 			call: 'personal_importRawKey',
 			params: 3
 		}),
+		new web3._extend.Method({
+			name: 'exportViewKey',
+			call: 'personal_exportViewKey',
+			params: 3
+		}),
+		new web3._extend.Method({
+			name: 'importViewKey',
+			call: 'personal_importViewKey',
+			params: 3
+		}),
+		new web3._extend.Method({
+			name: 'unlockViewKey',
+			call: 'personal_unlockViewKey',
+			params: 2
+		}),
+		new web3._extend.Method({
+			name: 'lockViewKey',
+			call: 'personal_lockViewKey',
+			params: 1
+		}),
 		new web3._extend.Method({
 			name: 'sign',
 			call: 'personal_sign',
@@ -807,6 +870,37 @@ web3._extend({
 			call: 'personal_deriveAccount',
 			params: 3
 		}),
+		new web3._extend.Method({
+			name: 'getOTAs',
+			call: 'personal_getOTAs',
+			params: 1,
+			inputFormatter: [web3._extend.formatters.inputAddressFormatter]
+		}),
+		new web3._extend.Method({
+			name: 'getUnspentOTAs',
+			call: 'personal_getUnspentOTAs',
+			params: 1,
+			inputFormatter: [web3._extend.formatters.inputAddressFormatter]
+		}),
+		new web3._extend.Method({
+			name: 'getSpentOTAs',
+			call: 'personal_getSpentOTAs',
+			params: 1,
+			inputFormatter: [web3._extend.formatters.inputAddressFormatter]
+		}),
+		new web3._extend.Method({
+			name: 'getUnspentOTABalance',
+			call: 'personal_getUnspentOTABalance',
+			params: 1,
+			inputFormatter: [web3._extend.formatters.inputAddressFormatter],
+			outputFormatter: web3._extend.utils.toBigNumber
+		}),
+		new web3._extend.Method({
+			name: 'rescanOTAs',
+			call: 'personal_rescanOTAs',
+			params: 2,
+			inputFormatter: [web3._extend.formatters.inputAddressFormatter, web3._extend.utils.fromDecimal]
+		}),
 	],
 	properties: [
 		new web3._extend.Property({
@@ -900,3 +994,16 @@ web3._extend({
 	]
 });
 `
+
+const Wan_JS = `
+web3._extend({
+	property: 'wan',
+	methods: [
+		new web3._extend.Method({
+			name: 'getOTAScanStatus',
+			call: 'wan_getOTAScanStatus',
+			params: 0
+		}),
+	]
+});
+`
@@ -0,0 +1,74 @@
+// Copyright 2018 Wanchain Foundation Ltd
+// This file is part of the go-wanchain library.
+//
+// The go-wanchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-wanchain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-wanchain library. If not, see <http://www.gnu.org/licenses/>.
+
+package kms
+
+import (
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	awskms "github.com/aws/aws-sdk-go/service/kms"
+	"github.com/wanchain/go-wanchain/log"
+)
+
+// AWSProvider encrypts with a key of the AWS Key Management Service.
+type AWSProvider struct {
+	AKID      string
+	SecretKey string
+	Region    string
+	KeyID     string // only needed for encryption
+}
+
+func (p *AWSProvider) client() (*awskms.KMS, error) {
+	sess, err := session.NewSession(&aws.Config{
+		Region:      aws.String(p.Region),
+		Credentials: credentials.NewStaticCredentials(p.AKID, p.SecretKey, ""),
+	})
+	if err != nil {
+		log.Error("create kms session fail", "err", err)
+		return nil, err
+	}
+	return awskms.New(sess), nil
+}
+
+func (p *AWSProvider) Encrypt(plaintext []byte) ([]byte, error) {
+	svc, err := p.client()
+	if err != nil {
+		return nil, err
+	}
+	result, err := svc.Encrypt(&awskms.EncryptInput{
+		KeyId:     aws.String(p.KeyID),
+		Plaintext: plaintext,
+	})
+	if err != nil {
+		log.Error("kms encrypt fail", "err", err)
+		return nil, err
+	}
+	return result.CiphertextBlob, nil
+}
+
+func (p *AWSProvider) Decrypt(ciphertext []byte) ([]byte, error) {
+	svc, err := p.client()
+	if err != nil {
+		return nil, err
+	}
+	result, err := svc.Decrypt(&awskms.DecryptInput{CiphertextBlob: ciphertext})
+	if err != nil {
+		log.Error("kms decrypt fail", "err", err)
+		return nil, err
+	}
+	return result.Plaintext, nil
+}
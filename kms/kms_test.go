@@ -0,0 +1,171 @@
+package kms
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func tmpDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "kms-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func TestLocalProvider(t *testing.T) {
+	dir := tmpDir(t)
+	defer os.RemoveAll(dir)
+
+	keyFile := filepath.Join(dir, "master.key")
+	if _, err := NewLocalProvider(keyFile, false); err == nil {
+		t.Fatal("missing key file created without create")
+	}
+	p, err := NewLocalProvider(keyFile, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	plaintext := []byte(`{"address":"0102"}`)
+	ciphertext, err := p.Encrypt(plaintext)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(ciphertext, plaintext) {
+		t.Fatal("plaintext in ciphertext")
+	}
+
+	// The key file is reused on the next load
+	p, err = NewLocalProvider(keyFile, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if have, err := p.Decrypt(ciphertext); err != nil || !bytes.Equal(have, plaintext) {
+		t.Fatalf("decrypt mismatch: have %q, %v, want %q", have, err, plaintext)
+	}
+
+	ciphertext[len(ciphertext)-1] ^= 1
+	if _, err := p.Decrypt(ciphertext); err != ErrInvalidCiphertext {
+		t.Fatalf("tampered ciphertext: have %v, want %v", err, ErrInvalidCiphertext)
+	}
+	ciphertext[len(ciphertext)-1] ^= 1
+
+	other, err := NewLocalProvider(filepath.Join(dir, "other.key"), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := other.Decrypt(ciphertext); err != ErrWrongMasterKey {
+		t.Fatalf("wrong master key: have %v, want %v", err, ErrWrongMasterKey)
+	}
+}
+
+// newTransitStub emulates the transit engine of a Vault server, mounted at
+// "transit" with a key named "wan".
+func newTransitStub(t *testing.T, dir, token string) *httptest.Server {
+	local, err := NewLocalProvider(filepath.Join(dir, "vault.key"), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reply := func(w http.ResponseWriter, status int, data interface{}, errs ...string) {
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(map[string]interface{}{"data": data, "errors": errs})
+	}
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != token {
+			reply(w, http.StatusForbidden, nil, "permission denied")
+			return
+		}
+		var req map[string]string
+		json.NewDecoder(r.Body).Decode(&req)
+		switch r.URL.Path {
+		case "/v1/transit/encrypt/wan":
+			plaintext, _ := base64.StdEncoding.DecodeString(req["plaintext"])
+			ciphertext, _ := local.Encrypt(plaintext)
+			reply(w, http.StatusOK, map[string]string{"ciphertext": "vault:v1:" + base64.StdEncoding.EncodeToString(ciphertext)})
+		case "/v1/transit/decrypt/wan":
+			ciphertext, _ := base64.StdEncoding.DecodeString(strings.TrimPrefix(req["ciphertext"], "vault:v1:"))
+			plaintext, err := local.Decrypt(ciphertext)
+			if err != nil {
+				reply(w, http.StatusBadRequest, nil, err.Error())
+				return
+			}
+			reply(w, http.StatusOK, map[string]string{"plaintext": base64.StdEncoding.EncodeToString(plaintext)})
+		default:
+			reply(w, http.StatusNotFound, nil)
+		}
+	}))
+}
+
+func TestVaultProvider(t *testing.T) {
+	dir := tmpDir(t)
+	defer os.RemoveAll(dir)
+
+	srv := newTransitStub(t, dir, "s3cr3t")
+	defer srv.Close()
+
+	cfg := Config{Provider: ProviderVault, VaultAddr: srv.URL, KeyID: "wan"}
+	os.Setenv("VAULT_TOKEN", "")
+	p, err := NewProvider(&cfg, func(name string) (string, error) {
+		if name != "token" {
+			t.Fatalf("unexpected prompt for %s", name)
+		}
+		return "s3cr3t", nil
+	}, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	plaintext := []byte("keystore")
+	ciphertext, err := p.Encrypt(plaintext)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(ciphertext, []byte("vault:v1:")) {
+		t.Fatalf("unexpected ciphertext %q", ciphertext)
+	}
+	if have, err := p.Decrypt(ciphertext); err != nil || !bytes.Equal(have, plaintext) {
+		t.Fatalf("decrypt mismatch: have %q, %v, want %q", have, err, plaintext)
+	}
+
+	denied := NewVaultProvider(srv.URL, "", "wan", "wrong")
+	if _, err := denied.Decrypt(ciphertext); err == nil || !strings.Contains(err.Error(), "permission denied") {
+		t.Fatalf("expected permission denied, got %v", err)
+	}
+	missing := NewVaultProvider(srv.URL, "", "other", "s3cr3t")
+	if _, err := missing.Encrypt(plaintext); err == nil {
+		t.Fatal("encrypted with unknown key")
+	}
+}
+
+func TestEncryptFile(t *testing.T) {
+	dir := tmpDir(t)
+	defer os.RemoveAll(dir)
+
+	cfg := Config{Provider: ProviderLocal, KeyFile: filepath.Join(dir, "master.key")}
+	p, err := NewProvider(&cfg, nil, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	src, cipher, plain := filepath.Join(dir, "key"), filepath.Join(dir, "key-cipher"), filepath.Join(dir, "key-plain")
+	if err := ioutil.WriteFile(src, []byte("keyjson"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := EncryptFile(p, src, cipher); err != nil {
+		t.Fatal(err)
+	}
+	if err := DecryptFile(p, cipher, plain); err != nil {
+		t.Fatal(err)
+	}
+	if have, _ := ioutil.ReadFile(plain); string(have) != "keyjson" {
+		t.Fatalf("decrypted file mismatch: have %q", have)
+	}
+	if _, err := NewProvider(&Config{Provider: "hsm"}, nil, false); err == nil {
+		t.Fatal("unknown provider accepted")
+	}
+}
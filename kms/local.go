@@ -0,0 +1,117 @@
+// Copyright 2018 Wanchain Foundation Ltd
+// This file is part of the go-wanchain library.
+//
+// The go-wanchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-wanchain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-wanchain library. If not, see <http://www.gnu.org/licenses/>.
+
+package kms
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/wanchain/go-wanchain/crypto"
+	"github.com/wanchain/go-wanchain/log"
+)
+
+const (
+	localKeyLen         = 32 // AES-256 master key
+	localFingerprintLen = 8
+)
+
+// localMagic prefixes the ciphertexts of the local provider.
+var localMagic = []byte("wkms-local-v1:")
+
+var (
+	ErrInvalidMasterKey  = errors.New("invalid master key file")
+	ErrWrongMasterKey    = errors.New("data was encrypted with another master key")
+	ErrInvalidCiphertext = errors.New("invalid ciphertext")
+)
+
+// LocalProvider emulates an HSM with a master key kept in a local file,
+// encrypting with AES-256-GCM. It lets validators without a cloud KMS keep the
+// master key apart from the keystore, e.g. on a removable or mounted volume,
+// and serves as a stand-in for the other providers in tests.
+type LocalProvider struct {
+	aead        cipher.AEAD
+	fingerprint []byte // identifies the master key in the ciphertexts
+}
+
+// NewLocalProvider loads the hex encoded master key from keyFile. If the file
+// doesn't exist and create is set, a new random master key is stored there.
+func NewLocalProvider(keyFile string, create bool) (*LocalProvider, error) {
+	data, err := ioutil.ReadFile(keyFile)
+	if os.IsNotExist(err) && create {
+		data = make([]byte, hex.EncodedLen(localKeyLen))
+		key := make([]byte, localKeyLen)
+		if _, err := io.ReadFull(rand.Reader, key); err != nil {
+			return nil, err
+		}
+		hex.Encode(data, key)
+		if err := writeFile(keyFile, data); err != nil {
+			return nil, err
+		}
+		log.Info("Created new KMS master key", "file", keyFile)
+	} else if err != nil {
+		return nil, err
+	}
+	key, err := hex.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil || len(key) != localKeyLen {
+		return nil, ErrInvalidMasterKey
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &LocalProvider{aead: aead, fingerprint: crypto.Keccak256(key)[:localFingerprintLen]}, nil
+}
+
+// Encrypt seals the plaintext as magic || fingerprint || nonce || ciphertext.
+func (p *LocalProvider) Encrypt(plaintext []byte) ([]byte, error) {
+	header := append(append([]byte{}, localMagic...), p.fingerprint...)
+	nonce := make([]byte, p.aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	out := append(header, nonce...)
+	return p.aead.Seal(out, nonce, plaintext, header), nil
+}
+
+func (p *LocalProvider) Decrypt(ciphertext []byte) ([]byte, error) {
+	headerLen := len(localMagic) + localFingerprintLen
+	if len(ciphertext) < headerLen+p.aead.NonceSize() || !bytes.HasPrefix(ciphertext, localMagic) {
+		return nil, ErrInvalidCiphertext
+	}
+	header := ciphertext[:headerLen]
+	if !bytes.Equal(header[len(localMagic):], p.fingerprint) {
+		return nil, ErrWrongMasterKey
+	}
+	nonce := ciphertext[headerLen : headerLen+p.aead.NonceSize()]
+	plaintext, err := p.aead.Open(nil, nonce, ciphertext[headerLen+p.aead.NonceSize():], header)
+	if err != nil {
+		return nil, ErrInvalidCiphertext
+	}
+	return plaintext, nil
+}
@@ -0,0 +1,180 @@
+// Copyright 2018 Wanchain Foundation Ltd
+// This file is part of the go-wanchain library.
+//
+// The go-wanchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-wanchain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-wanchain library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package kms encrypts keystore files with a master key held by a key
+// management service, so that a stolen keystore file is useless without access
+// to the service.
+package kms
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// Names of the supported providers.
+const (
+	ProviderAWS   = "aws"
+	ProviderLocal = "local"
+	ProviderVault = "vault"
+)
+
+// KeyEncryptionProvider encrypts and decrypts data with a master key that it
+// holds and never reveals.
+type KeyEncryptionProvider interface {
+	Encrypt(plaintext []byte) ([]byte, error)
+	Decrypt(ciphertext []byte) ([]byte, error)
+}
+
+// Config selects and configures the key encryption provider. Secrets such as
+// credentials and tokens are not part of it, they are asked for when needed.
+type Config struct {
+	// Provider is the name of the provider: "aws", "local" or "vault".
+	Provider string
+
+	// KeyID is the AWS KMS key used for encryption, or the name of the Vault
+	// transit key.
+	KeyID string `toml:",omitempty"`
+
+	// AWSRegion is the region of the AWS KMS key.
+	AWSRegion string `toml:",omitempty"`
+
+	// KeyFile is the master key file of the local provider.
+	KeyFile string `toml:",omitempty"`
+
+	// VaultAddr is the address of the Vault server, e.g. https://127.0.0.1:8200.
+	VaultAddr string `toml:",omitempty"`
+
+	// VaultMount is the mount path of the transit secrets engine.
+	VaultMount string `toml:",omitempty"`
+}
+
+// DefaultConfig keeps the AWS KMS behaviour of previous versions.
+var DefaultConfig = Config{
+	Provider:   ProviderAWS,
+	VaultMount: "transit",
+}
+
+// Prompter asks the user for a setting or secret the provider needs.
+type Prompter func(name string) (string, error)
+
+// NewProvider creates the provider selected by the config. Missing settings and
+// secrets are asked for through prompt. When encrypt is false the settings only
+// needed for encryption are not asked for.
+func NewProvider(cfg *Config, prompt Prompter, encrypt bool) (KeyEncryptionProvider, error) {
+	get := func(value, name string) (string, error) {
+		if value != "" {
+			return value, nil
+		}
+		return prompt(name)
+	}
+	switch cfg.Provider {
+	case ProviderAWS, "":
+		p := &AWSProvider{Region: cfg.AWSRegion, KeyID: cfg.KeyID}
+		var err error
+		if p.AKID, err = prompt("aKID"); err != nil {
+			return nil, err
+		}
+		if p.SecretKey, err = prompt("secretKey"); err != nil {
+			return nil, err
+		}
+		if p.Region, err = get(p.Region, "region"); err != nil {
+			return nil, err
+		}
+		if encrypt {
+			if p.KeyID, err = get(p.KeyID, "keyId"); err != nil {
+				return nil, err
+			}
+		}
+		return p, nil
+
+	case ProviderLocal:
+		if cfg.KeyFile == "" {
+			return nil, errors.New("no key file configured for the local provider")
+		}
+		return NewLocalProvider(cfg.KeyFile, encrypt)
+
+	case ProviderVault:
+		if cfg.VaultAddr == "" || cfg.KeyID == "" {
+			return nil, errors.New("the vault provider needs an address and a key name")
+		}
+		token := os.Getenv("VAULT_TOKEN")
+		if token == "" {
+			var err error
+			if token, err = prompt("token"); err != nil {
+				return nil, err
+			}
+		}
+		return NewVaultProvider(cfg.VaultAddr, cfg.VaultMount, cfg.KeyID, token), nil
+	}
+	return nil, fmt.Errorf("unknown key encryption provider %q", cfg.Provider)
+}
+
+// EncryptFile encrypts srcFile into desFile.
+func EncryptFile(p KeyEncryptionProvider, srcFile, desFile string) error {
+	plaintext, err := ioutil.ReadFile(srcFile)
+	if err != nil {
+		return err
+	}
+	ciphertext, err := p.Encrypt(plaintext)
+	if err != nil {
+		return err
+	}
+	return writeFile(desFile, ciphertext)
+}
+
+// DecryptFile decrypts srcFile into desFile.
+func DecryptFile(p KeyEncryptionProvider, srcFile, desFile string) error {
+	plaintext, err := DecryptFileToBuffer(p, srcFile)
+	if err != nil {
+		return err
+	}
+	return writeFile(desFile, plaintext)
+}
+
+// DecryptFileToBuffer decrypts srcFile in memory.
+func DecryptFileToBuffer(p KeyEncryptionProvider, srcFile string) ([]byte, error) {
+	ciphertext, err := ioutil.ReadFile(srcFile)
+	if err != nil {
+		return nil, err
+	}
+	return p.Decrypt(ciphertext)
+}
+
+// writeFile atomically writes content into file with mode 0600.
+func writeFile(file string, content []byte) error {
+	// Create the keystore directory with appropriate permissions
+	// in case it is not present yet.
+	const dirPerm = 0700
+	if err := os.MkdirAll(filepath.Dir(file), dirPerm); err != nil {
+		return err
+	}
+	// Atomic write: create a temporary hidden file first
+	// then move it into place. TempFile assigns mode 0600.
+	f, err := ioutil.TempFile(filepath.Dir(file), "."+filepath.Base(file)+".tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(content); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return err
+	}
+	f.Close()
+	return os.Rename(f.Name(), file)
+}
@@ -0,0 +1,118 @@
+// Copyright 2018 Wanchain Foundation Ltd
+// This file is part of the go-wanchain library.
+//
+// The go-wanchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-wanchain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-wanchain library. If not, see <http://www.gnu.org/licenses/>.
+
+package kms
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const vaultTimeout = 30 * time.Second
+
+// VaultProvider encrypts with a named key of the transit secrets engine of a
+// HashiCorp Vault server, or of any service implementing its API.
+type VaultProvider struct {
+	addr   string
+	mount  string
+	key    string
+	token  string
+	client *http.Client
+}
+
+// NewVaultProvider creates a provider using the transit key named key, of the
+// engine mounted at mount on the Vault server at addr.
+func NewVaultProvider(addr, mount, key, token string) *VaultProvider {
+	if mount == "" {
+		mount = DefaultConfig.VaultMount
+	}
+	return &VaultProvider{
+		addr:   strings.TrimRight(addr, "/"),
+		mount:  strings.Trim(mount, "/"),
+		key:    key,
+		token:  token,
+		client: &http.Client{Timeout: vaultTimeout},
+	}
+}
+
+// Encrypt returns the Vault ciphertext, e.g. "vault:v1:...", of the plaintext.
+func (p *VaultProvider) Encrypt(plaintext []byte) ([]byte, error) {
+	var res struct {
+		Ciphertext string `json:"ciphertext"`
+	}
+	req := map[string]string{"plaintext": base64.StdEncoding.EncodeToString(plaintext)}
+	if err := p.call("encrypt", req, &res); err != nil {
+		return nil, err
+	}
+	if res.Ciphertext == "" {
+		return nil, errors.New("vault returned no ciphertext")
+	}
+	return []byte(res.Ciphertext), nil
+}
+
+func (p *VaultProvider) Decrypt(ciphertext []byte) ([]byte, error) {
+	var res struct {
+		Plaintext string `json:"plaintext"`
+	}
+	req := map[string]string{"ciphertext": strings.TrimSpace(string(ciphertext))}
+	if err := p.call("decrypt", req, &res); err != nil {
+		return nil, err
+	}
+	return base64.StdEncoding.DecodeString(res.Plaintext)
+}
+
+// call posts req to the transit endpoint op of the key and decodes the data of
+// the response into res.
+func (p *VaultProvider) call(op string, req interface{}, res interface{}) error {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+	url := fmt.Sprintf("%s/v1/%s/%s/%s", p.addr, p.mount, op, p.key)
+	hreq, err := http.NewRequest("POST", url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	hreq.Header.Set("Content-Type", "application/json")
+	hreq.Header.Set("X-Vault-Token", p.token)
+
+	resp, err := p.client.Do(hreq)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	var reply struct {
+		Data   json.RawMessage `json:"data"`
+		Errors []string        `json:"errors"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&reply); err != nil {
+		return fmt.Errorf("vault %s failed: %s", op, resp.Status)
+	}
+	if len(reply.Errors) > 0 {
+		return fmt.Errorf("vault %s failed: %s", op, strings.Join(reply.Errors, "; "))
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("vault %s failed: %s", op, resp.Status)
+	}
+	return json.Unmarshal(reply.Data, res)
+}
@@ -64,6 +64,12 @@ func LesRequest(req light.OdrRequest) LesOdrRequest {
 		return (*CodeRequest)(r)
 	case *light.ChtRequest:
 		return (*ChtRequest)(r)
+	case *light.EpochLeaderRequest:
+		return (*EpochLeaderRequest)(r)
+	case *light.SmaRequest:
+		return (*SmaRequest)(r)
+	case *light.RandomBeaconRequest:
+		return (*RandomBeaconRequest)(r)
 	default:
 		return nil
 	}
@@ -354,3 +360,138 @@ func (r *ChtRequest) Validate(db ethdb.Database, msg *Msg) error {
 
 	return nil
 }
+
+// ODR request type for epoch leader groups, see LesOdrRequest interface
+type EpochLeaderRequest light.EpochLeaderRequest
+
+// GetCost returns the cost of the given ODR request according to the serving
+// peer's cost table (implementation of LesOdrRequest)
+func (r *EpochLeaderRequest) GetCost(peer *peer) uint64 {
+	return peer.GetRequestCost(GetPosDataMsg, 1)
+}
+
+// CanSend tells if a certain peer is suitable for serving the given request
+func (r *EpochLeaderRequest) CanSend(peer *peer) bool {
+	return peer.ServesPosData() && peer.HasBlock(r.Id.BlockHash, r.Id.BlockNumber)
+}
+
+// Request sends an ODR request to the LES network (implementation of LesOdrRequest)
+func (r *EpochLeaderRequest) Request(reqID uint64, peer *peer) error {
+	peer.Log().Debug("Requesting epoch leaders", "epochID", r.EpochID)
+	req := &PosDataReq{
+		Kind:    posDataEpochLeaders,
+		BHash:   r.Id.BlockHash,
+		EpochID: r.EpochID,
+	}
+	return peer.RequestPosData(reqID, r.GetCost(peer), []*PosDataReq{req})
+}
+
+// Valid processes an ODR request reply message from the LES network
+// returns true and stores results in memory if the message was a valid reply
+// to the request (implementation of LesOdrRequest)
+func (r *EpochLeaderRequest) Validate(db ethdb.Database, msg *Msg) error {
+	log.Debug("Validating epoch leaders", "epochID", r.EpochID)
+
+	resp, err := posDataResponse(msg)
+	if err != nil {
+		return err
+	}
+	if err := verifyEpochLeaders(resp.Leaders); err != nil {
+		return err
+	}
+	r.Leaders = resp.Leaders
+	return nil
+}
+
+// ODR request type for slot leader selection stage2 data, see LesOdrRequest interface
+type SmaRequest light.SmaRequest
+
+// GetCost returns the cost of the given ODR request according to the serving
+// peer's cost table (implementation of LesOdrRequest)
+func (r *SmaRequest) GetCost(peer *peer) uint64 {
+	return peer.GetRequestCost(GetPosDataMsg, 1)
+}
+
+// CanSend tells if a certain peer is suitable for serving the given request
+func (r *SmaRequest) CanSend(peer *peer) bool {
+	return peer.ServesPosData() && peer.HasBlock(r.Id.BlockHash, r.Id.BlockNumber)
+}
+
+// Request sends an ODR request to the LES network (implementation of LesOdrRequest)
+func (r *SmaRequest) Request(reqID uint64, peer *peer) error {
+	peer.Log().Debug("Requesting stage2 data", "epochID", r.EpochID)
+	req := &PosDataReq{
+		Kind:    posDataSma,
+		BHash:   r.Id.BlockHash,
+		EpochID: r.EpochID,
+	}
+	return peer.RequestPosData(reqID, r.GetCost(peer), []*PosDataReq{req})
+}
+
+// Valid processes an ODR request reply message from the LES network
+// returns true and stores results in memory if the message was a valid reply
+// to the request (implementation of LesOdrRequest)
+func (r *SmaRequest) Validate(db ethdb.Database, msg *Msg) error {
+	log.Debug("Validating stage2 data", "epochID", r.EpochID)
+
+	resp, err := posDataResponse(msg)
+	if err != nil {
+		return err
+	}
+	values, nodes, err := verifyPosData(r.Id.Root, posDataSma, r.EpochID, resp)
+	if err != nil {
+		return err
+	}
+	if len(values) > 0 {
+		r.Indexes = values[0]
+		r.Payloads = values[1:]
+	}
+	r.Proof = nodes
+	return nil
+}
+
+// ODR request type for random beacon outputs, see LesOdrRequest interface
+type RandomBeaconRequest light.RandomBeaconRequest
+
+// GetCost returns the cost of the given ODR request according to the serving
+// peer's cost table (implementation of LesOdrRequest)
+func (r *RandomBeaconRequest) GetCost(peer *peer) uint64 {
+	return peer.GetRequestCost(GetPosDataMsg, 1)
+}
+
+// CanSend tells if a certain peer is suitable for serving the given request
+func (r *RandomBeaconRequest) CanSend(peer *peer) bool {
+	return peer.ServesPosData() && peer.HasBlock(r.Id.BlockHash, r.Id.BlockNumber)
+}
+
+// Request sends an ODR request to the LES network (implementation of LesOdrRequest)
+func (r *RandomBeaconRequest) Request(reqID uint64, peer *peer) error {
+	peer.Log().Debug("Requesting random beacon", "epochID", r.EpochID)
+	req := &PosDataReq{
+		Kind:    posDataRandomBeacon,
+		BHash:   r.Id.BlockHash,
+		EpochID: r.EpochID,
+	}
+	return peer.RequestPosData(reqID, r.GetCost(peer), []*PosDataReq{req})
+}
+
+// Valid processes an ODR request reply message from the LES network
+// returns true and stores results in memory if the message was a valid reply
+// to the request (implementation of LesOdrRequest)
+func (r *RandomBeaconRequest) Validate(db ethdb.Database, msg *Msg) error {
+	log.Debug("Validating random beacon", "epochID", r.EpochID)
+
+	resp, err := posDataResponse(msg)
+	if err != nil {
+		return err
+	}
+	values, nodes, err := verifyPosData(r.Id.Root, posDataRandomBeacon, r.EpochID, resp)
+	if err != nil {
+		return err
+	}
+	if len(values) > 0 {
+		r.R = values[0]
+	}
+	r.Proof = nodes
+	return nil
+}
@@ -160,6 +160,16 @@ func (p *peer) GetRequestCost(msgcode uint64, amount int) uint64 {
 	return cost
 }
 
+// ServesPosData tells if the peer speaks a protocol version carrying pos data
+// requests and announced a cost for them, i.e. it is a server that knows how
+// to answer them.
+func (p *peer) ServesPosData() bool {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+
+	return p.version >= lpv2 && p.fcCosts[GetPosDataMsg] != nil
+}
+
 // HasBlock checks if the peer has a given block
 func (p *peer) HasBlock(hash common.Hash, number uint64) bool {
 	p.lock.RLock()
@@ -207,6 +217,11 @@ func (p *peer) SendHeaderProofs(reqID, bv uint64, proofs []ChtResp) error {
 	return sendResponse(p.rw, HeaderProofsMsg, reqID, bv, proofs)
 }
 
+// SendPosData sends a batch of pos consensus data, corresponding to the ones requested.
+func (p *peer) SendPosData(reqID, bv uint64, data []PosDataResp) error {
+	return sendResponse(p.rw, PosDataMsg, reqID, bv, data)
+}
+
 // RequestHeadersByHash fetches a batch of blocks' headers corresponding to the
 // specified header query, based on the hash of an origin block.
 func (p *peer) RequestHeadersByHash(reqID, cost uint64, origin common.Hash, amount int, skip int, reverse bool) error {
@@ -253,6 +268,12 @@ func (p *peer) RequestHeaderProofs(reqID, cost uint64, reqs []*ChtReq) error {
 	return sendRequest(p.rw, GetHeaderProofsMsg, reqID, cost, reqs)
 }
 
+// RequestPosData fetches a batch of pos consensus data from a remote node.
+func (p *peer) RequestPosData(reqID, cost uint64, reqs []*PosDataReq) error {
+	p.Log().Debug("Fetching batch of pos data", "count", len(reqs))
+	return sendRequest(p.rw, GetPosDataMsg, reqID, cost, reqs)
+}
+
 func (p *peer) SendTxs(reqID, cost uint64, txs types.Transactions) error {
 	p.Log().Debug("Fetching batch of transactions", "count", len(txs))
 	return p2p.Send(p.rw, SendTxMsg, txs)
@@ -0,0 +1,222 @@
+// Copyright 2018 Wanchain Foundation Ltd
+// This file is part of the go-wanchain library.
+//
+// The go-wanchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-wanchain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-wanchain library. If not, see <http://www.gnu.org/licenses/>.
+
+package les
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/wanchain/go-wanchain/common"
+	"github.com/wanchain/go-wanchain/core"
+	"github.com/wanchain/go-wanchain/core/state"
+	"github.com/wanchain/go-wanchain/core/vm"
+	"github.com/wanchain/go-wanchain/crypto"
+	"github.com/wanchain/go-wanchain/pos/posconfig"
+	posUtil "github.com/wanchain/go-wanchain/pos/util"
+	"github.com/wanchain/go-wanchain/pos/util/convert"
+	"github.com/wanchain/go-wanchain/rlp"
+	"github.com/wanchain/go-wanchain/trie"
+)
+
+// Kinds of pos consensus data served by GetPosDataMsg
+const (
+	posDataEpochLeaders = iota
+	posDataSma
+	posDataRandomBeacon
+)
+
+const pkBytesLength = 65 // Length of an uncompressed secp256k1 public key
+
+var (
+	errInvalidPosDataKind = errors.New("invalid pos data kind")
+	errPosDataProofs      = errors.New("pos data proof count mismatch")
+	errInvalidEpochLeader = errors.New("invalid epoch leader group")
+)
+
+// PosDataReq is a request for pos consensus data of an epoch, proven against
+// the state of the block BHash.
+type PosDataReq struct {
+	Kind    uint64
+	BHash   common.Hash
+	EpochID uint64
+}
+
+// PosDataResp carries the requested pos consensus data. Leaders is only set
+// for epoch leader requests, state backed data is given by merkle proofs: the
+// account proof of the precompiled contract and one storage proof per key.
+type PosDataResp struct {
+	Leaders  [][]byte
+	AccProof []rlp.RawValue
+	Proofs   [][]rlp.RawValue
+}
+
+// posDataAccount returns the precompiled contract holding the given kind of
+// pos data and the first storage key to prove.
+func posDataAccount(kind, epochID uint64) (common.Address, common.Hash, error) {
+	switch kind {
+	case posDataSma:
+		return vm.GetSlotLeaderSCAddress(), vm.GetSlotLeaderStage2IndexesKeyHash(convert.Uint64ToBytes(epochID)), nil
+	case posDataRandomBeacon:
+		return vm.GetRBAddress(), *vm.GetRBRKeyHash(epochID), nil
+	default:
+		return common.Address{}, common.Hash{}, errInvalidPosDataKind
+	}
+}
+
+// smaPayloadKeys returns the storage keys of the stage2 payloads marked as sent
+// in the rlp encoded stage2 indexes.
+func smaPayloadKeys(epochID uint64, indexes []byte) []common.Hash {
+	var sent [posconfig.EpochLeaderCount]bool
+	if len(indexes) == 0 || rlp.DecodeBytes(indexes, &sent) != nil {
+		return nil
+	}
+	keys := make([]common.Hash, 0)
+	for i, ok := range sent {
+		if ok {
+			keys = append(keys, vm.GetSlotLeaderStage2KeyHash(convert.Uint64ToBytes(epochID), convert.Uint64ToBytes(uint64(i))))
+		}
+	}
+	return keys
+}
+
+// getPosData gathers the requested pos consensus data from the local chain
+// and returns it together with its approximate size.
+func (pm *ProtocolManager) getPosData(req *PosDataReq) (PosDataResp, int) {
+	var resp PosDataResp
+
+	header := core.GetHeader(pm.chainDb, req.BHash, core.GetBlockNumber(pm.chainDb, req.BHash))
+	if header == nil {
+		return resp, 0
+	}
+	if req.Kind == posDataEpochLeaders {
+		if selector := posUtil.GetEpocherInst(); selector != nil {
+			resp.Leaders = selector.GetEpochLeaders(req.EpochID)
+		}
+		return resp, len(resp.Leaders) * pkBytesLength
+	}
+
+	addr, key, err := posDataAccount(req.Kind, req.EpochID)
+	if err != nil {
+		return resp, 0
+	}
+	tr, _ := trie.New(header.Root, pm.chainDb)
+	if tr == nil {
+		return resp, 0
+	}
+	accKey := crypto.Keccak256(addr[:])
+	resp.AccProof = tr.Prove(accKey)
+	size := len(resp.AccProof)
+
+	var acc state.Account
+	if err := rlp.DecodeBytes(tr.Get(accKey), &acc); err != nil {
+		return resp, size
+	}
+	st, _ := trie.New(acc.Root, pm.chainDb)
+	if st == nil {
+		return resp, size
+	}
+	keys := []common.Hash{key}
+	if req.Kind == posDataSma {
+		keys = append(keys, smaPayloadKeys(req.EpochID, st.Get(crypto.Keccak256(key[:])))...)
+	}
+	for _, key := range keys {
+		proof := st.Prove(crypto.Keccak256(key[:]))
+		resp.Proofs = append(resp.Proofs, proof)
+		size += len(proof)
+	}
+	return resp, size
+}
+
+// verifyPosData checks the merkle proofs of a pos data response against the
+// given state root. It returns the proven storage values in key order and all
+// the trie nodes the proofs consist of.
+func verifyPosData(root common.Hash, kind, epochID uint64, resp *PosDataResp) ([][]byte, []rlp.RawValue, error) {
+	addr, key, err := posDataAccount(kind, epochID)
+	if err != nil {
+		return nil, nil, err
+	}
+	data, err := trie.VerifyProof(root, crypto.Keccak256(addr[:]), resp.AccProof)
+	if err != nil {
+		return nil, nil, fmt.Errorf("merkle proof verification failed: %v", err)
+	}
+	nodes := append([]rlp.RawValue{}, resp.AccProof...)
+	if data == nil {
+		// the precompiled contract has no storage at all
+		if len(resp.Proofs) != 0 {
+			return nil, nil, errPosDataProofs
+		}
+		return nil, nodes, nil
+	}
+	var acc state.Account
+	if err := rlp.DecodeBytes(data, &acc); err != nil {
+		return nil, nil, err
+	}
+
+	var values [][]byte
+	keys := []common.Hash{key}
+	for i := 0; i < len(keys); i++ {
+		if i >= len(resp.Proofs) {
+			return nil, nil, errPosDataProofs
+		}
+		value, err := trie.VerifyProof(acc.Root, crypto.Keccak256(keys[i][:]), resp.Proofs[i])
+		if err != nil {
+			return nil, nil, fmt.Errorf("merkle proof verification failed: %v", err)
+		}
+		if i == 0 && kind == posDataSma {
+			keys = append(keys, smaPayloadKeys(epochID, value)...)
+		}
+		values = append(values, value)
+		nodes = append(nodes, resp.Proofs[i]...)
+	}
+	if len(resp.Proofs) != len(keys) {
+		return nil, nil, errPosDataProofs
+	}
+	return values, nodes, nil
+}
+
+// verifyEpochLeaders does the sanity checks of an epoch leader group, the
+// group itself is cross-checked by the light pos verifier.
+func verifyEpochLeaders(leaders [][]byte) error {
+	if len(leaders) == 0 {
+		return nil
+	}
+	if len(leaders) != posconfig.EpochLeaderCount {
+		return errInvalidEpochLeader
+	}
+	for _, leader := range leaders {
+		if len(leader) != pkBytesLength {
+			return errInvalidEpochLeader
+		}
+		pk := crypto.ToECDSAPub(leader)
+		if pk == nil || pk.X == nil || !pk.IsOnCurve(pk.X, pk.Y) {
+			return errInvalidEpochLeader
+		}
+	}
+	return nil
+}
+
+// posDataResponse extracts the single pos data response of a reply message.
+func posDataResponse(msg *Msg) (*PosDataResp, error) {
+	if msg.MsgType != MsgPosData {
+		return nil, errInvalidMessageType
+	}
+	resps := msg.Obj.([]PosDataResp)
+	if len(resps) != 1 {
+		return nil, errMultipleEntries
+	}
+	return &resps[0], nil
+}
@@ -67,6 +67,7 @@ type LightChain struct {
 	wg            sync.WaitGroup
 
 	engine consensus.Engine
+	pos    *posVerifier // Verifier of pluto pos headers, nil if the chain has no pos stage
 }
 
 // NewLightChain returns a fully initialised light chain using information
@@ -95,6 +96,9 @@ func NewLightChain(odr OdrBackend, config *params.ChainConfig, engine consensus.
 	if bc.genesisBlock == nil {
 		return nil, core.ErrNoGenesis
 	}
+	if config.Pluto != nil && config.PosFirstBlock != nil {
+		bc.pos = newPosVerifier(config, odr, func() *types.Header { return bc.genesisBlock.Header() })
+	}
 	if bc.genesisBlock.Hash() == params.MainnetGenesisHash {
 		// add trusted CHT
 		WriteTrustedCht(bc.chainDb, TrustedCht{Number: 1040, Root: common.HexToHash("bb4fb4076cbe6923c8a8ce8f158452bbe19564959313466989fda095a60884ca")})
@@ -157,6 +161,9 @@ func (bc *LightChain) SetHead(head uint64) {
 
 	bc.hc.SetHead(head, nil)
 	bc.loadLastState()
+	if bc.pos != nil {
+		bc.pos.reset()
+	}
 }
 
 // GasLimit returns the gas limit of the current HEAD block.
@@ -320,6 +327,9 @@ func (self *LightChain) Rollback(chain []common.Hash) {
 			self.hc.SetCurrentHeader(self.GetHeader(head.ParentHash, head.Number.Uint64()-1))
 		}
 	}
+	if self.pos != nil {
+		self.pos.reset()
+	}
 }
 
 // postChainEvents iterates over the events generated by a chain insertion and
@@ -351,7 +361,7 @@ func (self *LightChain) postChainEvents(events []interface{}) {
 // chain events when necessary.
 func (self *LightChain) InsertHeaderChain(chain []*types.Header, checkFreq int) (int, error) {
 	start := time.Now()
-	if i, err := self.hc.ValidateHeaderChain(chain, checkFreq); err != nil {
+	if i, err := self.validateHeaderChain(chain, checkFreq); err != nil {
 		return i, err
 	}
 
@@ -370,11 +380,16 @@ func (self *LightChain) InsertHeaderChain(chain []*types.Header, checkFreq int)
 		self.mu.Lock()
 		defer self.mu.Unlock()
 
+		head := self.hc.CurrentHeader()
 		status, err := self.hc.WriteHeader(header)
 
 		switch status {
 		case core.CanonStatTy:
 			log.Debug("Inserted new header", "number", header.Number, "hash", header.Hash())
+			if self.pos != nil && header.ParentHash != head.Hash() {
+				// The head moved to another fork
+				self.pos.reset()
+			}
 			events = append(events, core.ChainEvent{Block: types.NewBlockWithHeader(header), Hash: header.Hash()})
 
 		case core.SideStatTy:
@@ -388,6 +403,44 @@ func (self *LightChain) InsertHeaderChain(chain []*types.Header, checkFreq int)
 	return i, err
 }
 
+// validateHeaderChain checks the headers before the pos upgrade with the
+// consensus engine and the pos headers with the light pos verifier, which only
+// needs the consensus data retrievable through the ODR backend.
+func (self *LightChain) validateHeaderChain(chain []*types.Header, checkFreq int) (int, error) {
+	split := len(chain)
+	if self.pos != nil {
+		for i, header := range chain {
+			if self.pos.isPosHeader(header) {
+				split = i
+				break
+			}
+		}
+	}
+	if split > 0 {
+		if i, err := self.hc.ValidateHeaderChain(chain[:split], checkFreq); err != nil {
+			return i, err
+		}
+	}
+	if split == len(chain) {
+		return 0, nil
+	}
+	for i := split; i < len(chain); i++ {
+		if core.BadHashes[chain[i].Hash()] {
+			return i, core.ErrBlacklistedHash
+		}
+	}
+	var parent *types.Header
+	if split > 0 {
+		parent = chain[split-1]
+	}
+	i, err := self.pos.verifyHeaderChain(chain[split:], parent, self.GetHeader)
+	if err != nil {
+		log.Debug("Invalid light pos header", "number", chain[split+i].Number, "hash", chain[split+i].Hash(), "err", err)
+		return split + i, err
+	}
+	return 0, nil
+}
+
 // CurrentHeader retrieves the current head header of the canonical chain. The
 // header is retrieved from the HeaderChain's internal cache.
 func (self *LightChain) CurrentHeader() *types.Header {
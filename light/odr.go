@@ -157,3 +157,50 @@ func (req *ChtRequest) StoreResult(db ethdb.Database) {
 	core.WriteCanonicalHash(db, hash, num)
 	//storeProof(db, req.Proof)
 }
+
+// EpochLeaderRequest is the ODR request type for retrieving the epoch leader
+// group selected for an epoch. The group can't be proven against the state on
+// its own, it is cross-checked against the stage2 txs of SmaRequest instead.
+type EpochLeaderRequest struct {
+	OdrRequest
+	Id      *TrieID // references the state of the block the group is requested at
+	EpochID uint64
+	Leaders [][]byte
+}
+
+// StoreResult stores the retrieved data in local database
+func (req *EpochLeaderRequest) StoreResult(db ethdb.Database) {
+	WriteEpochLeaders(db, req.EpochID, req.Leaders)
+}
+
+// SmaRequest is the ODR request type for retrieving the slot leader selection
+// stage2 data of an epoch, together with the merkle proofs of the storage
+// entries of the slot leader precompiled contract.
+type SmaRequest struct {
+	OdrRequest
+	Id       *TrieID // references the state of the block the data is requested at
+	EpochID  uint64
+	Indexes  []byte   // rlp encoded stage2 indexes, nil if no stage2 tx was sent
+	Payloads [][]byte // stage2 payloads of the sent indexes, in index order
+	Proof    []rlp.RawValue
+}
+
+// StoreResult stores the retrieved data in local database
+func (req *SmaRequest) StoreResult(db ethdb.Database) {
+	storeProof(db, req.Proof)
+}
+
+// RandomBeaconRequest is the ODR request type for retrieving the random beacon
+// output of an epoch together with its merkle proof.
+type RandomBeaconRequest struct {
+	OdrRequest
+	Id      *TrieID // references the state of the block the data is requested at
+	EpochID uint64
+	R       []byte // nil if no random was generated for the epoch
+	Proof   []rlp.RawValue
+}
+
+// StoreResult stores the retrieved data in local database
+func (req *RandomBeaconRequest) StoreResult(db ethdb.Database) {
+	storeProof(db, req.Proof)
+}
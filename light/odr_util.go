@@ -19,6 +19,7 @@ package light
 import (
 	"bytes"
 	"context"
+	"encoding/binary"
 	"errors"
 	"math/big"
 
@@ -39,6 +40,8 @@ var (
 	ChtFrequency     = uint64(4096)
 	ChtConfirmations = uint64(2048)
 	trustedChtKey    = []byte("TrustedCHT")
+
+	epochLeadersPrefix = []byte("posEpochLeaders-") // epochLeadersPrefix + num (uint64 big endian) -> leaders
 )
 
 type ChtNode struct {
@@ -69,6 +72,37 @@ func DeleteTrustedCht(db ethdb.Database) {
 	db.Delete(trustedChtKey)
 }
 
+func epochLeadersKey(epochID uint64) []byte {
+	enc := make([]byte, 8)
+	binary.BigEndian.PutUint64(enc, epochID)
+	return append(append([]byte{}, epochLeadersPrefix...), enc...)
+}
+
+// ReadEpochLeaders retrieves the epoch leader group of an epoch stored by an
+// earlier EpochLeaderRequest.
+func ReadEpochLeaders(db ethdb.Database, epochID uint64) [][]byte {
+	data, _ := db.Get(epochLeadersKey(epochID))
+	if len(data) == 0 {
+		return nil
+	}
+	var leaders [][]byte
+	if err := rlp.DecodeBytes(data, &leaders); err != nil {
+		return nil
+	}
+	return leaders
+}
+
+// WriteEpochLeaders stores the epoch leader group of an epoch.
+func WriteEpochLeaders(db ethdb.Database, epochID uint64, leaders [][]byte) {
+	data, _ := rlp.EncodeToBytes(leaders)
+	db.Put(epochLeadersKey(epochID), data)
+}
+
+// DeleteEpochLeaders removes the epoch leader group of an epoch.
+func DeleteEpochLeaders(db ethdb.Database, epochID uint64) {
+	db.Delete(epochLeadersKey(epochID))
+}
+
 func GetHeaderByNumber(ctx context.Context, odr OdrBackend, number uint64) (*types.Header, error) {
 	db := odr.Database()
 	hash := core.GetCanonicalHash(db, number)
@@ -162,3 +196,36 @@ func GetBlockReceipts(ctx context.Context, odr OdrBackend, hash common.Hash, num
 	}
 	return r.Receipts, nil
 }
+
+// GetEpochLeaders retrieves the epoch leader group of an epoch, asking the
+// network for it at the state of the given header if it's not stored yet.
+func GetEpochLeaders(ctx context.Context, odr OdrBackend, header *types.Header, epochID uint64) ([][]byte, error) {
+	if leaders := ReadEpochLeaders(odr.Database(), epochID); leaders != nil {
+		return leaders, nil
+	}
+	r := &EpochLeaderRequest{Id: StateTrieID(header), EpochID: epochID}
+	if err := odr.Retrieve(ctx, r); err != nil {
+		return nil, err
+	}
+	return r.Leaders, nil
+}
+
+// GetSmaData retrieves the proven slot leader selection stage2 data of an
+// epoch at the state of the given header.
+func GetSmaData(ctx context.Context, odr OdrBackend, header *types.Header, epochID uint64) (indexes []byte, payloads [][]byte, err error) {
+	r := &SmaRequest{Id: StateTrieID(header), EpochID: epochID}
+	if err := odr.Retrieve(ctx, r); err != nil {
+		return nil, nil, err
+	}
+	return r.Indexes, r.Payloads, nil
+}
+
+// GetRandomBeacon retrieves the proven random beacon output of an epoch at the
+// state of the given header. It returns nil if no random was generated.
+func GetRandomBeacon(ctx context.Context, odr OdrBackend, header *types.Header, epochID uint64) ([]byte, error) {
+	r := &RandomBeaconRequest{Id: StateTrieID(header), EpochID: epochID}
+	if err := odr.Retrieve(ctx, r); err != nil {
+		return nil, err
+	}
+	return r.R, nil
+}
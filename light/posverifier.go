@@ -0,0 +1,326 @@
+// Copyright 2018 Wanchain Foundation Ltd
+// This file is part of the go-wanchain library.
+//
+// The go-wanchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-wanchain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-wanchain library. If not, see <http://www.gnu.org/licenses/>.
+
+package light
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"math"
+	"math/big"
+	"sync"
+	"time"
+
+	lru "github.com/hashicorp/golang-lru"
+	"github.com/wanchain/go-wanchain/common"
+	"github.com/wanchain/go-wanchain/common/hexutil"
+	"github.com/wanchain/go-wanchain/consensus"
+	"github.com/wanchain/go-wanchain/consensus/pluto"
+	"github.com/wanchain/go-wanchain/core/types"
+	"github.com/wanchain/go-wanchain/core/vm"
+	"github.com/wanchain/go-wanchain/crypto"
+	"github.com/wanchain/go-wanchain/log"
+	"github.com/wanchain/go-wanchain/params"
+	"github.com/wanchain/go-wanchain/pos/posconfig"
+	"github.com/wanchain/go-wanchain/pos/slotleader"
+	"github.com/wanchain/go-wanchain/pos/uleaderselection"
+	posUtil "github.com/wanchain/go-wanchain/pos/util"
+	"github.com/wanchain/go-wanchain/rlp"
+)
+
+const (
+	posExtraSeal       = 65  // Fixed number of extra-data suffix bytes reserved for signer seal
+	posMaxExtra        = 512 // Maximum extra-data length of a pos header (proof, proof message and seal)
+	posSignatureLimit  = 4096
+	posProofCacheLimit = 16
+	posAnchorLimit     = 4096
+
+	posRetrieveTimeout = 30 * time.Second // Time allowance for retrieving the pos data of a header batch
+)
+
+var (
+	errPosUnknownBlock      = errors.New("unknown block")
+	errPosInvalidExtra      = errors.New("invalid pos header extra-data length")
+	errPosSlotMismatch      = errors.New("epochId or slotid do not match")
+	errPosSignerMismatch    = errors.New("pos header signer is not the proof owner")
+	errPosInvalidProof      = errors.New("invalid slot leader proof")
+	errPosNoDefaultLeaders  = errors.New("default epoch leaders unavailable")
+	errPosLeadersNotMatched = errors.New("epoch leaders do not match stage2 data")
+)
+
+// posVerifier checks the seals of pluto pos headers on a light client. It only
+// relies on the epoch leader, SMA and random beacon data retrieved through the
+// ODR backend, never on the posdb or a full state.
+type posVerifier struct {
+	config   *params.ChainConfig
+	odr      OdrBackend
+	genesis  func() *types.Header
+	sigcache *lru.ARCCache
+	proofs   *lru.Cache // proofKey -> *slotleader.ProofData
+	anchors  *lru.Cache // header hash -> hash of the last header before its epoch
+
+	lock         sync.Mutex
+	genesisProof *slotleader.ProofData
+}
+
+// proofKey identifies the proof data of an epoch on a fork. The data is read
+// from the state at the end of the previous epoch, so the last header before
+// the epoch anchors it.
+type proofKey struct {
+	epochID uint64
+	anchor  common.Hash
+}
+
+func newPosVerifier(config *params.ChainConfig, odr OdrBackend, genesis func() *types.Header) *posVerifier {
+	sigcache, _ := lru.NewARC(posSignatureLimit)
+	proofs, _ := lru.New(posProofCacheLimit)
+	anchors, _ := lru.New(posAnchorLimit)
+	return &posVerifier{
+		config:   config,
+		odr:      odr,
+		genesis:  genesis,
+		sigcache: sigcache,
+		proofs:   proofs,
+		anchors:  anchors,
+	}
+}
+
+// reset drops the cached proof data, which is done when the head of the light
+// chain moves to another fork.
+func (v *posVerifier) reset() {
+	v.proofs.Purge()
+	v.anchors.Purge()
+}
+
+// isPosHeader tells if a header has to be sealed by a slot leader.
+func (v *posVerifier) isPosHeader(header *types.Header) bool {
+	return v.config.PosFirstBlock != nil && v.config.IsPosBlockNumber(header.Number)
+}
+
+// verifyHeader checks the pos seal of a header, mirroring the checks done by
+// the pluto engine on a full node. The ancestors of parent are looked up
+// through getHeader.
+func (v *posVerifier) verifyHeader(ctx context.Context, header, parent *types.Header, getHeader func(common.Hash, uint64) *types.Header) error {
+	number := header.Number.Uint64()
+	if number == 0 {
+		return errPosUnknownBlock
+	}
+	if parent == nil || parent.Hash() != header.ParentHash || parent.Number.Uint64()+1 != number {
+		return consensus.ErrUnknownAncestor
+	}
+	if len(header.Extra) <= posExtraSeal || len(header.Extra) > posMaxExtra {
+		return errPosInvalidExtra
+	}
+
+	epochIDTime, slotIDTime := posUtil.CalEpochSlotID(header.Time.Uint64())
+	epochID, slotID := posUtil.GetEpochSlotIDFromDifficulty(header.Difficulty)
+	if epochIDTime != epochID || slotIDTime != slotID || header.Difficulty.Cmp(new(big.Int).SetUint64(math.MaxUint64)) > 0 {
+		return errPosSlotMismatch
+	}
+
+	proof, proofMeg, err := slotleader.UnpackSlotProof(epochID, header.Extra[:len(header.Extra)-posExtraSeal])
+	if err != nil {
+		return err
+	}
+	signer, err := pluto.SealSigner(header, v.sigcache)
+	if err != nil {
+		return err
+	}
+	if signer != crypto.PubkeyToAddress(*proofMeg[0]) {
+		return errPosSignerMismatch
+	}
+
+	anchor := v.epochAnchor(parent, epochID, getHeader)
+	data, err := v.proofData(ctx, parent, epochID, anchor)
+	if err != nil {
+		return err
+	}
+	if !slotleader.VerifySlotProofWithData(data, epochID, slotID, proof, proofMeg) {
+		log.Debug("Light pos header proof rejected", "number", number, "epochID", epochID, "slotID", slotID)
+		return errPosInvalidProof
+	}
+	v.anchors.Add(header.Hash(), anchor)
+	return nil
+}
+
+// epochAnchor returns the hash of the last header before epochID on the chain
+// ending with parent. If an ancestor is missing, parent itself anchors the data.
+func (v *posVerifier) epochAnchor(parent *types.Header, epochID uint64, getHeader func(common.Hash, uint64) *types.Header) common.Hash {
+	for header := parent; header != nil; {
+		if !v.isPosHeader(header) {
+			return header.Hash()
+		}
+		if headerEpochID, _ := posUtil.GetEpochSlotIDFromDifficulty(header.Difficulty); headerEpochID < epochID {
+			return header.Hash()
+		}
+		if anchor, ok := v.anchors.Get(header.Hash()); ok {
+			return anchor.(common.Hash)
+		}
+		header = getHeader(header.ParentHash, header.Number.Uint64()-1)
+	}
+	return parent.Hash()
+}
+
+// proofData assembles the slot proof data of an epoch from the state of the
+// parent header, falling back to the genesis data in the same cases as
+// slotleader.SLS.VerifySlotProof. It is cached by epoch and anchor.
+func (v *posVerifier) proofData(ctx context.Context, parent *types.Header, epochID uint64, anchor common.Hash) (*slotleader.ProofData, error) {
+	if epochID <= posconfig.FirstEpochId+2 {
+		return v.genesisProofData(ctx)
+	}
+	key := proofKey{epochID, anchor}
+	if cached, ok := v.proofs.Get(key); ok {
+		return cached.(*slotleader.ProofData), nil
+	}
+
+	leaderBufs, err := GetEpochLeaders(ctx, v.odr, parent, epochID-1)
+	if err != nil {
+		return nil, err
+	}
+	if len(leaderBufs) == 0 {
+		return v.genesisProofData(ctx)
+	}
+	leaders := make([]*ecdsa.PublicKey, len(leaderBufs))
+	for i, buf := range leaderBufs {
+		leaders[i] = crypto.ToECDSAPub(buf)
+	}
+
+	indexesBuf, payloads, err := GetSmaData(ctx, v.odr, parent, epochID-1)
+	if err != nil {
+		return nil, err
+	}
+	var indexes [posconfig.EpochLeaderCount]bool
+	if indexesBuf == nil || rlp.DecodeBytes(indexesBuf, &indexes) != nil {
+		// no stage2 trans on the block chain.
+		return v.genesisProofData(ctx)
+	}
+
+	data := &slotleader.ProofData{EpochLeaders: leaders}
+	hasValidTx, leadersChecked := false, false
+	next := 0
+	for i := 0; i < posconfig.EpochLeaderCount; i++ {
+		if !indexes[i] {
+			continue
+		}
+		if next >= len(payloads) {
+			break
+		}
+		payload := payloads[next]
+		next++
+		if len(payload) < 4 {
+			continue
+		}
+		epID, selfIndex, selfPK, alphaPki, dleqProof, err := vm.RlpUnpackStage2DataForTx(payload)
+		if err != nil || epID != epochID-1 || selfIndex != uint64(i) || len(alphaPki) != posconfig.EpochLeaderCount {
+			continue
+		}
+		// The stage2 tx was validated against the epoch leaders when it was
+		// included, so its dleq proof binds the whole leader group.
+		if i >= len(leaders) || !uleaderselection.PublicKeyEqual(selfPK, leaders[i]) {
+			DeleteEpochLeaders(v.odr.Database(), epochID-1)
+			return nil, errPosLeadersNotMatched
+		}
+		if !leadersChecked {
+			if !uleaderselection.VerifyDleqProof(leaders, alphaPki, dleqProof) {
+				DeleteEpochLeaders(v.odr.Database(), epochID-1)
+				return nil, errPosLeadersNotMatched
+			}
+			leadersChecked = true
+		}
+		data.ValidIndexes[i] = true
+		for j := 0; j < posconfig.EpochLeaderCount; j++ {
+			data.AlphaPki[i][j] = alphaPki[j]
+		}
+		hasValidTx = true
+	}
+	if !hasValidTx {
+		return v.genesisProofData(ctx)
+	}
+
+	rb, err := GetRandomBeacon(ctx, v.odr, parent, epochID)
+	if err != nil {
+		return nil, err
+	}
+	if len(rb) != 0 {
+		data.Random = new(big.Int).SetBytes(rb)
+	} else {
+		// same as vm.GetR: use the first epoch R if none was generated
+		data.Random = vm.GetStateR(nil, posconfig.FirstEpochId)
+	}
+
+	v.proofs.Add(key, data)
+	return data, nil
+}
+
+// genesisProofData builds the proof data of the default epoch leaders which
+// are configured in the genesis state.
+func (v *posVerifier) genesisProofData(ctx context.Context) (*slotleader.ProofData, error) {
+	v.lock.Lock()
+	defer v.lock.Unlock()
+
+	if v.genesisProof != nil {
+		return v.genesisProof, nil
+	}
+
+	var whites []string
+	if posconfig.SelfTestMode {
+		whites = posconfig.WhiteListOrig[:]
+	} else {
+		genesis := v.genesis()
+		if genesis == nil {
+			return nil, errPosNoDefaultLeaders
+		}
+		statedb := NewState(ctx, genesis, v.odr)
+		if statedb == nil || len(vm.GetWlConfig(statedb)) == 0 {
+			return nil, errPosNoDefaultLeaders
+		}
+		info := vm.GetEpochWLInfo(statedb, 0)
+		start, count := info.WlIndex.Uint64(), info.WlCount.Uint64()
+		if start+count > uint64(len(posconfig.WhiteList)) {
+			return nil, errPosNoDefaultLeaders
+		}
+		whites = posconfig.WhiteList[start : start+count]
+	}
+	if len(whites) == 0 {
+		return nil, errPosNoDefaultLeaders
+	}
+
+	pks := make([]*ecdsa.PublicKey, len(whites))
+	for i, white := range whites {
+		pks[i] = crypto.ToECDSAPub(hexutil.MustDecode(white))
+	}
+	v.genesisProof = slotleader.NewGenesisProofData(pks)
+	return v.genesisProof, nil
+}
+
+// verifyHeaderChain checks a contiguous chain of pos headers. If parent is nil
+// the parent of the first header is looked up through getHeader.
+func (v *posVerifier) verifyHeaderChain(chain []*types.Header, parent *types.Header, getHeader func(common.Hash, uint64) *types.Header) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), posRetrieveTimeout)
+	defer cancel()
+
+	if parent == nil && len(chain) > 0 {
+		parent = getHeader(chain[0].ParentHash, chain[0].Number.Uint64()-1)
+	}
+	for i, header := range chain {
+		if err := v.verifyHeader(ctx, header, parent, getHeader); err != nil {
+			return i, err
+		}
+		parent = header
+	}
+	return 0, nil
+}
@@ -0,0 +1,67 @@
+package light
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/wanchain/go-wanchain/common"
+	"github.com/wanchain/go-wanchain/core/types"
+	"github.com/wanchain/go-wanchain/params"
+)
+
+// makePosHeaders builds a chain of pos headers on parent, one per epoch and
+// slot given.
+func makePosHeaders(parent *types.Header, slots [][2]uint64, seed byte) []*types.Header {
+	var chain []*types.Header
+	for _, slot := range slots {
+		header := &types.Header{
+			ParentHash: parent.Hash(),
+			Number:     new(big.Int).Add(parent.Number, common.Big1),
+			Difficulty: new(big.Int).SetUint64(slot[0]<<32 | slot[1]<<8),
+			Coinbase:   common.Address{seed},
+		}
+		chain = append(chain, header)
+		parent = header
+	}
+	return chain
+}
+
+// Tests that the proof data of an epoch is anchored to the last header before
+// the epoch on each fork.
+func TestPosVerifierEpochAnchor(t *testing.T) {
+	config := *params.TestChainConfig
+	config.PosFirstBlock = big.NewInt(1)
+	v := newPosVerifier(&config, nil, nil)
+
+	genesis := &types.Header{Number: big.NewInt(0), Difficulty: big.NewInt(1)}
+	shared := makePosHeaders(genesis, [][2]uint64{{10, 1}, {10, 2}}, 1)
+	first := makePosHeaders(shared[1], [][2]uint64{{10, 3}, {11, 0}, {11, 1}}, 2)
+	second := makePosHeaders(shared[1], [][2]uint64{{11, 0}, {11, 2}}, 3)
+
+	known := make(map[common.Hash]*types.Header)
+	for _, header := range append(append(append([]*types.Header{genesis}, shared...), first...), second...) {
+		known[header.Hash()] = header
+	}
+	getHeader := func(hash common.Hash, number uint64) *types.Header { return known[hash] }
+
+	if anchor := v.epochAnchor(first[2], 11, getHeader); anchor != first[0].Hash() {
+		t.Fatalf("first fork anchor mismatch: have %x, want %x", anchor, first[0].Hash())
+	}
+	if anchor := v.epochAnchor(second[1], 11, getHeader); anchor != shared[1].Hash() {
+		t.Fatalf("second fork anchor mismatch: have %x, want %x", anchor, shared[1].Hash())
+	}
+	if anchor := v.epochAnchor(shared[1], 10, getHeader); anchor != genesis.Hash() {
+		t.Fatalf("pre-pos anchor mismatch: have %x, want %x", anchor, genesis.Hash())
+	}
+
+	// Verified headers share the anchor of their epoch, until a reorg
+	v.anchors.Add(first[1].Hash(), first[0].Hash())
+	if anchor := v.epochAnchor(first[1], 11, nil); anchor != first[0].Hash() {
+		t.Fatalf("cached anchor mismatch: have %x, want %x", anchor, first[0].Hash())
+	}
+	v.proofs.Add(proofKey{11, first[0].Hash()}, nil)
+	v.reset()
+	if v.anchors.Len() != 0 || v.proofs.Len() != 0 {
+		t.Fatalf("cache not dropped: %d anchors, %d proofs", v.anchors.Len(), v.proofs.Len())
+	}
+}
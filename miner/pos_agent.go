@@ -5,8 +5,6 @@ import (
 	"fmt"
 
 	"github.com/wanchain/go-wanchain/accounts"
-	"github.com/wanchain/go-wanchain/accounts/keystore"
-	"github.com/wanchain/go-wanchain/common"
 	"github.com/wanchain/go-wanchain/consensus/pluto"
 
 	//"github.com/wanchain/go-wanchain/common/hexutil"
@@ -18,6 +16,7 @@ import (
 	"github.com/wanchain/go-wanchain/pos/epochLeader"
 	"github.com/wanchain/go-wanchain/pos/incentive"
 	"github.com/wanchain/go-wanchain/pos/posconfig"
+	"github.com/wanchain/go-wanchain/pos/possigner"
 	"github.com/wanchain/go-wanchain/pos/randombeacon"
 	"github.com/wanchain/go-wanchain/pos/slotleader"
 	"github.com/wanchain/go-wanchain/pos/util"
@@ -62,12 +61,12 @@ func PosInit(s Backend) *epochLeader.Epocher {
 	return epochSelector
 }
 
-func posInitMiner(s Backend, key *keystore.Key) {
+func posInitMiner(s Backend, signer posconfig.MinerSigner) {
 	log.Debug("posInitMiner is running")
 
 	// config
-	if key != nil {
-		posconfig.Cfg().MinerKey = key
+	if signer != nil {
+		posconfig.Cfg().MinerSigner = signer
 	}
 	epochSelector := epochLeader.NewEpocher(s.BlockChain())
 	randombeacon.GetRandonBeaconInst().Init(epochSelector)
@@ -95,20 +94,17 @@ func (self *Miner) backendTimerLoop(s Backend) {
 	if wallet == nil || errf != nil {
 		panic(errf)
 	}
-	type getKey interface {
-		GetUnlockedKey(address common.Address) (*keystore.Key, error)
-	}
-	key, err := wallet.(getKey).GetUnlockedKey(eb)
-	if key == nil || err != nil {
+	signer, err := possigner.FromWallet(wallet, eb)
+	if err != nil {
 		panic(err)
 	}
-	log.Debug("Get unlocked key success address:" + eb.Hex())
-	localPublicKey := hex.EncodeToString(crypto.FromECDSAPub(&key.PrivateKey.PublicKey))
+	log.Debug("Get miner signer success address:" + eb.Hex())
+	localPublicKey := hex.EncodeToString(crypto.FromECDSAPub(signer.PublicKey()))
 
 	if pluto, ok := self.engine.(*pluto.Pluto); ok {
-		pluto.Authorize(eb, wallet.SignHash, key)
+		pluto.Authorize(eb, wallet.SignHash, signer)
 	}
-	posInitMiner(s, key)
+	posInitMiner(s, signer)
 	// get rpcClient
 	url := posconfig.Cfg().NodeCfg.IPCEndpoint()
 	rc, err := rpc.Dial(url)
@@ -159,7 +155,7 @@ func (self *Miner) backendTimerLoop(s Backend) {
 		log.Debug("get current period", "epochid", epochID, "slotid", slotID)
 
 		sls := slotleader.GetSlotLeaderSelection()
-		sls.Loop(rc, key, epochID, slotID)
+		sls.Loop(rc, signer, epochID, slotID)
 
 		prePks, isDefault := sls.GetPreEpochLeadersPK(epochID)
 		targetEpochLeaderID := epochID
@@ -27,6 +27,7 @@ import (
 
 	"github.com/wanchain/go-wanchain/accounts"
 	"github.com/wanchain/go-wanchain/accounts/keystore"
+	"github.com/wanchain/go-wanchain/accounts/remotesigner"
 	"github.com/wanchain/go-wanchain/accounts/usbwallet"
 	"github.com/wanchain/go-wanchain/common"
 	"github.com/wanchain/go-wanchain/crypto"
@@ -36,11 +37,12 @@ import (
 )
 
 const (
-	datadirPrivateKey      = "nodekey"            // Path within the datadir to the node's private key
-	datadirDefaultKeyStore = "keystore"           // Path within the datadir to the keystore
-	datadirStaticNodes     = "static-nodes.json"  // Path within the datadir to the static node list
-	datadirTrustedNodes    = "trusted-nodes.json" // Path within the datadir to the trusted node list
-	datadirNodeDatabase    = "nodes"              // Path within the datadir to store the node infos
+	datadirPrivateKey        = "nodekey"            // Path within the datadir to the node's private key
+	datadirDefaultKeyStore   = "keystore"           // Path within the datadir to the keystore
+	datadirWatchOnlyKeyStore = "watchonly"          // Path within the keystore to the watch-only view keys
+	datadirStaticNodes       = "static-nodes.json"  // Path within the datadir to the static node list
+	datadirTrustedNodes      = "trusted-nodes.json" // Path within the datadir to the trusted node list
+	datadirNodeDatabase      = "nodes"              // Path within the datadir to store the node infos
 )
 
 // Config represents a small collection of configuration values to fine tune the
@@ -85,6 +87,14 @@ type Config struct {
 	// NoUSB disables hardware wallet monitoring and connectivity.
 	NoUSB bool `toml:",omitempty"`
 
+	// RemoteSigner is the HTTP URL or IPC path of an external signer daemon. Its
+	// accounts are made available to the node, with all the operations done by
+	// the signer, validator ones included, so that the keys never reach the node.
+	RemoteSigner string `toml:",omitempty"`
+
+	// RemoteSignerToken authenticates the node with a signer served over HTTP.
+	RemoteSignerToken string `toml:",omitempty"`
+
 	// IPCPath is the requested location to place the IPC endpoint. If the path is
 	// a simple file name, it is placed inside the data directory (or on the root
 	// pipe path on Windows), whereas if it's a resolvable path name (absolute or
@@ -397,6 +407,7 @@ func makeAccountManager(conf *Config) (*accounts.Manager, string, error) {
 	// Assemble the account manager and supported backends
 	backends := []accounts.Backend{
 		keystore.NewKeyStore(keydir, scryptN, scryptP),
+		keystore.NewWatchOnlyKeyStore(filepath.Join(keydir, datadirWatchOnlyKeyStore), scryptN, scryptP),
 	}
 	if !conf.NoUSB {
 		// Start a USB hub for Ledger hardware wallets
@@ -412,5 +423,12 @@ func makeAccountManager(conf *Config) (*accounts.Manager, string, error) {
 			backends = append(backends, trezorhub)
 		}
 	}
+	if conf.RemoteSigner != "" {
+		signer, err := remotesigner.NewBackendWithToken(conf.RemoteSigner, conf.RemoteSignerToken)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to connect to remote signer: %v", err)
+		}
+		backends = append(backends, signer)
+	}
 	return accounts.NewManager(backends...), ephemeral, nil
 }
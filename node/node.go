@@ -642,6 +642,31 @@ func (n *Node) OpenDatabase(name string, cache, handles int) (ethdb.Database, er
 	return ethdb.NewLDBDatabase(n.config.resolvePath(name), cache, handles)
 }
 
+// OpenDatabaseWithFreezer opens an existing database with the given name like
+// OpenDatabase, and attaches the freezer of the old canonical blocks to it.
+// The freezer is in the ancient directory of the database if freezer is empty.
+func (n *Node) OpenDatabaseWithFreezer(name string, cache, handles int, freezer string) (ethdb.Database, error) {
+	if n.config.DataDir == "" {
+		return ethdb.NewMemDatabase()
+	}
+	return openDatabaseWithFreezer(n.config, name, cache, handles, freezer)
+}
+
+func openDatabaseWithFreezer(config *Config, name string, cache, handles int, freezer string) (ethdb.Database, error) {
+	db, err := ethdb.NewLDBDatabase(config.resolvePath(name), cache, handles)
+	if err != nil {
+		return nil, err
+	}
+	if freezer == "" {
+		freezer = filepath.Join(name, "ancient")
+	}
+	if err := db.OpenFreezer(config.resolvePath(freezer)); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
 // ResolvePath returns the absolute path of a resource in the instance directory.
 func (n *Node) ResolvePath(x string) string {
 	return n.config.resolvePath(x)
@@ -175,12 +175,12 @@ func (net *Network) StopAll() error {
 
 // Start starts the node with the given ID
 func (net *Network) Start(id discover.NodeID) error {
-	return net.startWithSnapshots(id, nil)
+	return net.StartWithSnapshots(id, nil)
 }
 
-// startWithSnapshots starts the node with the given ID using the give
+// StartWithSnapshots starts the node with the given ID using the given
 // snapshots
-func (net *Network) startWithSnapshots(id discover.NodeID, snapshots map[string][]byte) error {
+func (net *Network) StartWithSnapshots(id discover.NodeID, snapshots map[string][]byte) error {
 	node := net.GetNode(id)
 	if node == nil {
 		return fmt.Errorf("node %v does not exist", id)
@@ -673,7 +673,7 @@ func (net *Network) Load(snap *Snapshot) error {
 		if !n.Node.Up {
 			continue
 		}
-		if err := net.startWithSnapshots(n.Node.Config.ID, n.Snapshots); err != nil {
+		if err := net.StartWithSnapshots(n.Node.Config.ID, n.Snapshots); err != nil {
 			return err
 		}
 	}
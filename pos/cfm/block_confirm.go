@@ -5,9 +5,11 @@ import (
 	"github.com/wanchain/go-wanchain/common"
 	"github.com/wanchain/go-wanchain/common/hexutil"
 	"github.com/wanchain/go-wanchain/core"
+	"github.com/wanchain/go-wanchain/core/types"
 	"github.com/wanchain/go-wanchain/crypto"
 	"github.com/wanchain/go-wanchain/log"
 	"github.com/wanchain/go-wanchain/pos/posconfig"
+	"sync"
 	"time"
 )
 
@@ -26,6 +28,11 @@ var (
 type CFM struct {
 	bc        *core.BlockChain
 	whiteList map[common.Address]int
+
+	lock    sync.Mutex    // Protects stable
+	stable  *types.Header // highest stable block found so far
+	emitted uint64        // number of the last stable head event sent
+	quit    chan struct{}
 }
 
 type SuffixBlkStatic struct {
@@ -41,8 +48,18 @@ type BlkStatus struct {
 var c *CFM
 
 func InitCFM(bc *core.BlockChain) {
+	old := c
 	c = &CFM{}
 	c.bc = bc
+	// keep the stable head across engine switches, so no event gets lost
+	if old != nil {
+		if old.quit != nil {
+			close(old.quit)
+		}
+		old.lock.Lock()
+		c.stable, c.emitted = old.stable, old.emitted
+		old.lock.Unlock()
+	}
 	c.whiteList = make(map[common.Address]int, 0)
 	for _, value := range posconfig.WhiteList {
 
@@ -50,6 +67,10 @@ func InitCFM(bc *core.BlockChain) {
 		address := crypto.PubkeyToAddress(*(crypto.ToECDSAPub(b)))
 		c.whiteList[address] = 1
 	}
+	if bc != nil {
+		c.quit = make(chan struct{})
+		go c.loop(c.quit)
+	}
 	log.Info("InitCFM success")
 }
 
@@ -57,15 +78,60 @@ func GetCFM() *CFM {
 	return c
 }
 
+// GetMaxStableBlkNumber returns the number of the highest block confirmed by
+// the pos protocol. Blocks never lose their stability, so only the blocks
+// above the last stable one found are scanned.
 func (c *CFM) GetMaxStableBlkNumber() uint64 {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return c.update()
+}
+
+// update computes the highest stable block, records it and returns its number.
+func (c *CFM) update() uint64 {
+	curBlk := c.bc.CurrentBlock()
+	if curBlk == nil {
+		log.SyslogErr("confirm block", "GetMaxStableBlkNumber get currentBlock", ErrNullBlk.Error())
+		return 0
+	}
+	if c.stable != nil {
+		if header := c.bc.GetHeaderByNumber(c.stable.Number.Uint64()); header == nil || header.Hash() != c.stable.Hash() {
+			log.Error("Stable block is not canonical anymore", "number", c.stable.Number, "hash", c.stable.Hash())
+			c.stable = nil
+		}
+	}
+	number := c.computeMaxStableBlkNumber(curBlk.Header())
+	if c.stable != nil && number <= c.stable.Number.Uint64() {
+		return c.stable.Number.Uint64()
+	}
+	if header := c.bc.GetHeaderByNumber(number); header != nil {
+		c.stable = header
+	}
+	return number
+}
+
+func (c *CFM) computeMaxStableBlkNumber(curHeader *types.Header) uint64 {
 	// In pow phase
 	if posconfig.FirstEpochId == 0 {
-		return c.getPowMaxStableBlkNumber(c.getCurrentBlkNumber())
+		return c.getPowMaxStableBlkNumber(curHeader.Number.Uint64())
 	}
 	// In pos phase
 	timeNow := uint64(time.Now().Unix())
-	// stopNumber is the min block number, startNumber is max bock number
-	blkStatusArr, stopNumber, startNumber, err := c.scanAllBlockStatus(timeNow)
+	// stopNumber is the min block number, startNumber is max bock number.
+	// The blocks up to the last stable one need no scan.
+	startNumber := curHeader.Number.Uint64()
+	var stopNumber uint64
+	if startNumber > uint64(posconfig.K) {
+		stopNumber = startNumber - uint64(posconfig.K)
+	}
+	if c.stable != nil && c.stable.Number.Uint64() > stopNumber && c.stable.Number.Uint64() <= startNumber {
+		stopNumber = c.stable.Number.Uint64()
+	}
+	if stopNumber == startNumber {
+		return stopNumber
+	}
+	blkStatusArr, err := c.scanBlockStatus(curHeader, stopNumber, timeNow)
 
 	maxStableBlkNumber := c.getMaxStableBlkNumber(blkStatusArr, stopNumber, startNumber, err)
 
@@ -81,15 +147,6 @@ func (c *CFM) GetMaxStableBlkNumber() uint64 {
 	return posconfig.Pow2PosUpgradeBlockNumber
 }
 
-func (c *CFM) getCurrentBlkNumber() uint64 {
-	curBlk := c.bc.CurrentBlock()
-	if curBlk == nil {
-		log.SyslogErr("confirm block", "scanAllBlockStatus get currentBlock", ErrNullBlk.Error())
-		return 0
-	}
-	return curBlk.NumberU64()
-}
-
 func (c *CFM) getPowMaxStableBlkNumber(curBlkNumber uint64) uint64 {
 	if curBlkNumber < uint64(SecPowBlks) {
 		return 0
@@ -136,33 +193,23 @@ func (c *CFM) getMaxStableBlkNumber(blkStatusArr []*BlkStatus, stopNumber uint64
 	}
 }
 
-func (c *CFM) scanAllBlockStatus(timeNow uint64) (blkStatus []*BlkStatus, stop uint64, start uint64, err error) {
+// scanBlockStatus returns the status of the blocks from curHeader down to
+// stopNumber excluded.
+func (c *CFM) scanBlockStatus(curHeader *types.Header, stopNumber uint64, timeNow uint64) ([]*BlkStatus, error) {
 	blkStatusArr := make([]*BlkStatus, 0)
-	curBlk := c.bc.CurrentBlock()
-	if curBlk == nil {
-		log.SyslogErr("confirm block", "scanAllBlockStatus get currentBlock", ErrNullBlk.Error())
-		return blkStatusArr, 0, 0, ErrNullBlk
-	}
-
-	startNumber := curBlk.NumberU64()
-	var stopNumber uint64
-	if startNumber > uint64(posconfig.K) {
-		stopNumber = startNumber - uint64(posconfig.K)
-	} else {
-		stopNumber = 0
-	}
+	startNumber := curHeader.Number.Uint64()
 
 	sbs := SuffixBlkStatic{0, 0}
 	var inWhiteList = false
-	hash := curBlk.Hash()
+	hash := curHeader.Hash()
 	for i := startNumber; i > stopNumber && i < MaxUint64; i-- {
-		blk := c.bc.GetBlock(hash, i)
+		blk := c.bc.GetHeader(hash, i)
 		if blk == nil {
-			log.SyslogErr("confirm block", "scanAllBlockStatus", ErrNullBlk.Error(), "block number", i)
-			return blkStatusArr, stopNumber, startNumber, ErrNullBlk
+			log.SyslogErr("confirm block", "scanBlockStatus", ErrNullBlk.Error(), "block number", i)
+			return blkStatusArr, ErrNullBlk
 		}
 
-		inWhiteList = c.isInWhiteList(blk.Coinbase())
+		inWhiteList = c.isInWhiteList(blk.Coinbase)
 
 		if inWhiteList {
 			sbs.SuffixBlockTrusted = sbs.SuffixBlockTrusted + 1
@@ -170,7 +217,7 @@ func (c *CFM) scanAllBlockStatus(timeNow uint64) (blkStatus []*BlkStatus, stop u
 			sbs.SuffixBlockNonTrusted = sbs.SuffixBlockNonTrusted + 1
 		}
 
-		slotsCount := c.getSlotsCount(blk.Time().Uint64(), timeNow, posconfig.SlotTime)
+		slotsCount := c.getSlotsCount(blk.Time.Uint64(), timeNow, posconfig.SlotTime)
 		//X				= Sx + NHX + Empty
 		//Empty			= X - Sx - NHX
 		//Sx - Empty 	= Sx - (X-Sx-NHX) = Sx -X + Sx +NHX = 2Sx+NHX-X
@@ -181,23 +228,23 @@ func (c *CFM) scanAllBlockStatus(timeNow uint64) (blkStatus []*BlkStatus, stop u
 			status = true
 		}
 
-		log.Debug("scanAllBlockStatus",
-			"Number", blk.NumberU64(),
+		log.Debug("scanBlockStatus",
+			"Number", blk.Number.Uint64(),
 			"hash", blk.Hash(),
-			"ParentHash", blk.ParentHash(),
-			"Coinbase", blk.Coinbase(),
+			"ParentHash", blk.ParentHash,
+			"Coinbase", blk.Coinbase,
 			"wl", inWhiteList,
 			"now", timeNow,
-			"blokTime", blk.Time().Uint64(),
+			"blokTime", blk.Time.Uint64(),
 			"slotCounts", slotsCount,
 			"Sx", sbs.SuffixBlockTrusted,
 			"NHx", sbs.SuffixBlockNonTrusted,
 			"diffBlk", diffBlk)
 
-		blkStatusArr = append(blkStatusArr, &BlkStatus{blk.NumberU64(), status})
-		hash = blk.ParentHash()
+		blkStatusArr = append(blkStatusArr, &BlkStatus{blk.Number.Uint64(), status})
+		hash = blk.ParentHash
 	}
-	return blkStatusArr, stopNumber, startNumber, nil
+	return blkStatusArr, nil
 }
 
 func (c *CFM) isInWhiteList(coinBase common.Address) bool {
@@ -0,0 +1,80 @@
+package cfm
+
+import (
+	"github.com/wanchain/go-wanchain/core"
+	"github.com/wanchain/go-wanchain/event"
+)
+
+// maxStableHeadEvents bounds the number of events sent at once when many
+// blocks get stable together, e.g. after a sync.
+const maxStableHeadEvents = 1024
+
+var (
+	stableHeadFeed  event.Feed
+	stableHeadScope event.SubscriptionScope
+)
+
+// SubscribeStableHeadEvent registers a subscription of the blocks getting
+// stable, in increasing order.
+func SubscribeStableHeadEvent(ch chan<- core.StableHeadEvent) event.Subscription {
+	return stableHeadScope.Track(stableHeadFeed.Subscribe(ch))
+}
+
+// loop updates the stable block on each new chain head and announces the
+// blocks which got stable.
+func (c *CFM) loop(quit chan struct{}) {
+	headCh := make(chan core.ChainHeadEvent, 16)
+	sub := c.bc.SubscribeChainHeadEvent(headCh)
+	defer sub.Unsubscribe()
+
+	for {
+		select {
+		case <-headCh:
+			// Skip the heads queued meanwhile, the last one covers them
+			for drained := false; !drained; {
+				select {
+				case <-headCh:
+				default:
+					drained = true
+				}
+			}
+			c.announce()
+
+		case <-sub.Err():
+			return
+		case <-quit:
+			return
+		}
+	}
+}
+
+// announce sends a stable head event for each block which got stable since
+// the last call.
+func (c *CFM) announce() {
+	c.lock.Lock()
+	number := c.update()
+	if number == 0 {
+		c.lock.Unlock()
+		return
+	}
+	from := c.emitted + 1
+	if c.emitted == 0 {
+		// nothing announced yet, start with the current stable block
+		from = number
+	}
+	if number >= from && number-from >= maxStableHeadEvents {
+		from = number - maxStableHeadEvents + 1
+	}
+	if number >= from {
+		c.emitted = number
+	}
+	c.lock.Unlock()
+
+	for n := from; n <= number; n++ {
+		header := c.bc.GetHeaderByNumber(n)
+		if header == nil {
+			break
+		}
+		stableHeadFeed.Send(core.StableHeadEvent{Header: header})
+	}
+}
@@ -0,0 +1,246 @@
+package devnet
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"reflect"
+	"time"
+
+	"github.com/wanchain/go-wanchain/common"
+	"github.com/wanchain/go-wanchain/core/types"
+	"github.com/wanchain/go-wanchain/params"
+	"github.com/wanchain/go-wanchain/pos/posapi"
+	"github.com/wanchain/go-wanchain/pos/posapi/client"
+	"github.com/wanchain/go-wanchain/pos/posconfig"
+	"github.com/wanchain/go-wanchain/pos/staking"
+)
+
+// pollInterval is how often the waits query the nodes, once per slot.
+const pollInterval = posconfig.SlotTime * time.Second
+
+// WaitEpoch waits until the network enters an epoch.
+func (n *Network) WaitEpoch(ctx context.Context, epochID uint64) error {
+	return n.poll(ctx, func() (bool, error) {
+		pc, err := n.PosClient(0)
+		if err != nil {
+			return false, err
+		}
+		current, err := pc.GetEpochID(ctx)
+		return current >= epochID, err
+	})
+}
+
+// WaitBlock waits until every node imported block number.
+func (n *Network) WaitBlock(ctx context.Context, number uint64) error {
+	return n.poll(ctx, func() (bool, error) {
+		for i := range n.nodes {
+			ec, err := n.EthClient(i)
+			if err != nil {
+				return false, err
+			}
+			header, err := ec.HeaderByNumber(ctx, nil)
+			if err != nil {
+				return false, err
+			}
+			if header.Number.Uint64() < number {
+				return false, nil
+			}
+		}
+		return true, nil
+	})
+}
+
+// WaitReceipt waits until a transaction is mined on node i, and returns its
+// receipt.
+func (n *Network) WaitReceipt(ctx context.Context, i int, hash common.Hash) (*types.Receipt, error) {
+	ec, err := n.EthClient(i)
+	if err != nil {
+		return nil, err
+	}
+	var receipt *types.Receipt
+	err = n.poll(ctx, func() (bool, error) {
+		receipt, _ = ec.TransactionReceipt(ctx, hash)
+		return receipt != nil, nil
+	})
+	return receipt, err
+}
+
+func (n *Network) poll(ctx context.Context, done func() (bool, error)) error {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+	for {
+		ok, err := done()
+		if err != nil {
+			return err
+		}
+		if ok {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// agree queries every node and fails unless they all return the same value,
+// which it returns.
+func (n *Network) agree(what string, query func(i int) (interface{}, error)) (interface{}, error) {
+	var first interface{}
+	for i := range n.nodes {
+		v, err := query(i)
+		if err != nil {
+			return nil, fmt.Errorf("%s of %s: %v", what, n.Name(i), err)
+		}
+		if i == 0 {
+			first = v
+		} else if !reflect.DeepEqual(first, v) {
+			return nil, fmt.Errorf("%s mismatch: %s has %v, %s has %v", what, n.Name(0), first, n.Name(i), v)
+		}
+	}
+	return first, nil
+}
+
+// agreePos is agree for queries of the pos API.
+func (n *Network) agreePos(what string, query func(pc *client.PosClient) (interface{}, error)) (interface{}, error) {
+	return n.agree(what, func(i int) (interface{}, error) {
+		pc, err := n.PosClient(i)
+		if err != nil {
+			return nil, err
+		}
+		return query(pc)
+	})
+}
+
+// CheckConsensus checks that every node has the same block at height number.
+func (n *Network) CheckConsensus(ctx context.Context, number uint64) error {
+	_, err := n.agree(fmt.Sprintf("block %d", number), func(i int) (interface{}, error) {
+		ec, err := n.EthClient(i)
+		if err != nil {
+			return nil, err
+		}
+		header, err := ec.HeaderByNumber(ctx, new(big.Int).SetUint64(number))
+		if err != nil {
+			return nil, err
+		}
+		return header.Hash(), nil
+	})
+	return err
+}
+
+// CheckEpochLeaders checks that the nodes selected the same epoch leaders for
+// an epoch.
+func (n *Network) CheckEpochLeaders(ctx context.Context, epochID uint64) error {
+	leaders, err := n.agreePos("epoch leaders", func(pc *client.PosClient) (interface{}, error) {
+		return pc.GetEpochLeadersAddrByEpochID(ctx, epochID)
+	})
+	if err != nil {
+		return err
+	}
+	if len(leaders.([]common.Address)) == 0 {
+		return fmt.Errorf("no epoch leaders selected for epoch %d", epochID)
+	}
+	return nil
+}
+
+// CheckSMA checks that the slot leaders of an epoch published valid secret
+// messages in both SMA stages, and that the nodes count them alike.
+func (n *Network) CheckSMA(ctx context.Context, epochID uint64) error {
+	cnt, err := n.agreePos("valid SMA count", func(pc *client.PosClient) (interface{}, error) {
+		return pc.GetValidSMACnt(ctx, epochID)
+	})
+	if err != nil {
+		return err
+	}
+	if smas := cnt.([]uint64); len(smas) != 2 || smas[0] == 0 || smas[1] == 0 {
+		return fmt.Errorf("no valid SMA in epoch %d: %v", epochID, smas)
+	}
+	return nil
+}
+
+// CheckRandomBeacon checks that the nodes agree on the random number of an
+// epoch, and that its proof verifies.
+func (n *Network) CheckRandomBeacon(ctx context.Context, epochID uint64) error {
+	random, err := n.agreePos("random", func(pc *client.PosClient) (interface{}, error) {
+		return pc.GetRandom(ctx, epochID, -1)
+	})
+	if err != nil {
+		return err
+	}
+	if random.(*big.Int) == nil {
+		return fmt.Errorf("no random for epoch %d", epochID)
+	}
+	pc, err := n.PosClient(0)
+	if err != nil {
+		return err
+	}
+	proof, err := pc.GetRandomProof(ctx, epochID, -1)
+	if err != nil {
+		return err
+	}
+	if proof.Random == nil || (*big.Int)(proof.Random).Cmp(random.(*big.Int)) != 0 {
+		return fmt.Errorf("random proof of epoch %d is for another random", epochID)
+	}
+	return proof.Verify()
+}
+
+// CheckIncentive checks that the incentive of an epoch was paid, the same on
+// every node.
+func (n *Network) CheckIncentive(ctx context.Context, epochID uint64) error {
+	detail, err := n.agreePos("incentive", func(pc *client.PosClient) (interface{}, error) {
+		return pc.GetEpochIncentivePayDetail(ctx, epochID)
+	})
+	if err != nil {
+		return err
+	}
+	if len(detail.([]posapi.ValidatorInfo)) == 0 {
+		return fmt.Errorf("no incentive paid for epoch %d", epochID)
+	}
+	return nil
+}
+
+// CheckStakeOut checks that every node refunded a stake to an account in an
+// epoch.
+func (n *Network) CheckStakeOut(ctx context.Context, epochID uint64, account common.Address) error {
+	refunds, err := n.agreePos("stake-out", func(pc *client.PosClient) (interface{}, error) {
+		return pc.GetEpochStakeOut(ctx, epochID)
+	})
+	if err != nil {
+		return err
+	}
+	for _, refund := range refunds.([]posapi.RefundInfo) {
+		if refund.Addr == account {
+			return nil
+		}
+	}
+	return fmt.Errorf("no stake refunded to %x in epoch %d", account, epochID)
+}
+
+// SendStakingCall signs a call of the staking contract with the key of
+// validator i, and sends it to its node.
+func (n *Network) SendStakingCall(ctx context.Context, i int, call *staking.Call) (common.Hash, error) {
+	if i < 0 || i >= len(n.Validators) {
+		return common.Hash{}, errors.New("unknown validator")
+	}
+	v := n.Validators[i]
+	ec, err := n.EthClient(i)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	nonce, err := ec.PendingNonceAt(ctx, v.Address)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	gasPrice, err := ec.SuggestGasPrice(ctx)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	tx, err := staking.SignTx(call.Tx(nonce, big.NewInt(staking.DefaultGasLimit), gasPrice), params.PlutoChainConfig.ChainId, v.Key1)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return tx.Hash(), ec.SendTransaction(ctx, tx)
+}
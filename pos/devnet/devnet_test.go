@@ -0,0 +1,63 @@
+package devnet
+
+import (
+	"bytes"
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/wanchain/go-wanchain/common"
+	"github.com/wanchain/go-wanchain/core/vm"
+	"github.com/wanchain/go-wanchain/rlp"
+)
+
+func TestValidatorJSON(t *testing.T) {
+	v, err := NewValidator()
+	if err != nil {
+		t.Fatal(err)
+	}
+	enc, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var dec Validator
+	if err := json.Unmarshal(enc, &dec); err != nil {
+		t.Fatal(err)
+	}
+	if dec.Address != v.Address || dec.Key2.D.Cmp(v.Key2.D) != 0 {
+		t.Fatalf("validator mismatch after decoding: have %x, want %x", dec.Address, v.Address)
+	}
+	if err := json.Unmarshal([]byte(`{"key1":"0x01"}`), &dec); err == nil {
+		t.Fatal("validator without key2 accepted")
+	}
+}
+
+func TestGenesis(t *testing.T) {
+	var stakers []Staker
+	for i := 0; i < 2; i++ {
+		v, err := NewValidator()
+		if err != nil {
+			t.Fatal(err)
+		}
+		stakers = append(stakers, v.Staker())
+	}
+	stake := big.NewInt(1e18)
+	genesis := Genesis(stakers, stake)
+
+	_, statedb := genesis.ToBlock()
+	for addr, account := range genesis.Alloc {
+		if account.Staking.S256pk != nil && addr != stakers[0].Address && addr != stakers[1].Address {
+			t.Fatalf("plutodev staker %x kept", addr)
+		}
+	}
+	for _, s := range stakers {
+		data := statedb.GetStateByteArray(vm.StakersInfoAddr, common.BytesToHash(s.Address[:]))
+		var staker vm.StakerInfo
+		if err := rlp.DecodeBytes(data, &staker); err != nil {
+			t.Fatalf("validator %x not staked: %v", s.Address, err)
+		}
+		if staker.Amount.Cmp(stake) != 0 || !bytes.Equal(staker.PubBn256, s.Bn256pk) {
+			t.Fatalf("staker mismatch: %+v", staker)
+		}
+	}
+}
@@ -0,0 +1,112 @@
+//go:build devnet
+// +build devnet
+
+package devnet
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/wanchain/go-wanchain/core/vm"
+	"github.com/wanchain/go-wanchain/pos/posconfig"
+	"github.com/wanchain/go-wanchain/pos/staking"
+)
+
+// TestDevnet runs a devnet through a few epochs, checking every pos protocol
+// on the way, and takes a validator out of it. The stake-out takes about ten
+// epochs, under an hour. Run it with
+//
+//	go test -tags devnet -run TestDevnet ./pos/devnet
+func TestDevnet(t *testing.T) {
+	n, err := New(DefaultConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer n.Stop()
+	if err := n.Start(); err != nil {
+		t.Fatal(err)
+	}
+
+	epochTime := time.Duration(posconfig.SlotTime*posconfig.SlotCount) * time.Second
+	ctx, cancel := context.WithTimeout(context.Background(), (vm.PSMinEpochNum+8)*epochTime)
+	defer cancel()
+
+	pc, err := n.PosClient(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	start, err := pc.GetEpochID(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Validator 1 stakes in a new validator, which quits at the end of its
+	// first staking period. The genesis stakes never expire.
+	owner := 1
+	quitter, err := NewValidator()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stakeIn, err := staking.StakeIn(quitter.Secp256PK(), quitter.Bn256PK(), vm.PSMinEpochNum, 100, vm.MinValidatorStake)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stakeUpdate, err := staking.StakeUpdate(quitter.Address, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, call := range []*staking.Call{stakeIn, stakeUpdate} {
+		hash, err := n.SendStakingCall(ctx, owner, call)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if receipt, err := n.WaitReceipt(ctx, owner, hash); err != nil {
+			t.Fatal(err)
+		} else if receipt.Status == 0 {
+			t.Fatalf("%s failed", call.Method)
+		}
+	}
+
+	// Epoch start+2 is the first one run entirely by the network.
+	epoch := start + 2
+	if err := n.WaitEpoch(ctx, epoch+2); err != nil {
+		t.Fatal(err)
+	}
+	ec, err := n.EthClient(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	head, err := ec.HeaderByNumber(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := n.WaitBlock(ctx, head.Number.Uint64()); err != nil {
+		t.Fatal(err)
+	}
+	if err := n.CheckConsensus(ctx, head.Number.Uint64()-1); err != nil {
+		t.Fatal(err)
+	}
+	if err := n.CheckEpochLeaders(ctx, epoch); err != nil {
+		t.Error(err)
+	}
+	if err := n.CheckSMA(ctx, epoch); err != nil {
+		t.Error(err)
+	}
+	if err := n.CheckRandomBeacon(ctx, epoch+1); err != nil {
+		t.Error(err)
+	}
+	if err := n.CheckIncentive(ctx, epoch); err != nil {
+		t.Error(err)
+	}
+
+	// The stake is refunded to its owner once the staking period ended.
+	for id := epoch; ; id++ {
+		if err := n.WaitEpoch(ctx, id+1); err != nil {
+			t.Fatalf("stake of %x not refunded: %v", quitter.Address, err)
+		}
+		if n.CheckStakeOut(ctx, id, n.Validators[owner].Address) == nil {
+			break
+		}
+	}
+}
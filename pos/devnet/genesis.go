@@ -0,0 +1,56 @@
+package devnet
+
+import (
+	"math/big"
+
+	"github.com/wanchain/go-wanchain/common"
+	"github.com/wanchain/go-wanchain/common/hexutil"
+	"github.com/wanchain/go-wanchain/core"
+	"github.com/wanchain/go-wanchain/params"
+)
+
+// NetworkId is the network of the devnet, the one of gwan --plutodev.
+const NetworkId = 6
+
+// fundFactor is the balance of a validator, in multiples of its genesis stake,
+// left to pay the staking transactions of the tests.
+const fundFactor = 2
+
+// DefaultStake is the genesis stake of the validators, the one of the
+// plutodev stakers.
+var DefaultStake = new(big.Int).Mul(big.NewInt(400000), big.NewInt(params.Wan))
+
+// Staker is the public part of a validator staked in the genesis block.
+type Staker struct {
+	Address common.Address `json:"address"`
+	S256pk  hexutil.Bytes  `json:"s256pk"`
+	Bn256pk hexutil.Bytes  `json:"bn256pk"`
+}
+
+// Staker returns the genesis staker of the validator.
+func (v *Validator) Staker() Staker {
+	return Staker{Address: v.Address, S256pk: v.Secp256PK(), Bn256pk: v.Bn256PK()}
+}
+
+// Genesis returns the dev genesis block staking the devnet validators instead
+// of the plutodev ones. Every staker gets stake locked forever, and twice as
+// much as balance.
+func Genesis(stakers []Staker, stake *big.Int) *core.Genesis {
+	genesis := core.PlutoDevGenesisBlock()
+	for addr, account := range genesis.Alloc {
+		if account.Staking.S256pk != nil {
+			delete(genesis.Alloc, addr)
+		}
+	}
+	for _, s := range stakers {
+		genesis.Alloc[s.Address] = core.GenesisAccount{
+			Balance: new(big.Int).Mul(stake, big.NewInt(fundFactor)),
+			Staking: core.GenesisAccountStaking{
+				Amount:  new(big.Int).Set(stake),
+				S256pk:  s.S256pk,
+				Bn256pk: s.Bn256pk,
+			},
+		}
+	}
+	return genesis
+}
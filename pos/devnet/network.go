@@ -0,0 +1,211 @@
+// Package devnet boots a local PoS network of validators, and checks that
+// its nodes agree on the outcome of the pos protocols. It is meant for
+// integration tests and for trying the pos modules out.
+//
+// Binaries built with the devnet tag shorten the slots and epochs, so that
+// SMA, random beacon, incentive and stake-out all run within minutes.
+package devnet
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+
+	"github.com/wanchain/go-wanchain/ethclient"
+	"github.com/wanchain/go-wanchain/log"
+	"github.com/wanchain/go-wanchain/p2p/discover"
+	"github.com/wanchain/go-wanchain/p2p/simulations"
+	"github.com/wanchain/go-wanchain/p2p/simulations/adapters"
+	"github.com/wanchain/go-wanchain/pos/posapi/client"
+	"github.com/wanchain/go-wanchain/rpc"
+)
+
+// ipcName is the IPC endpoint of the validator nodes, relative to their data
+// directory. The pos miner talks to its node through it.
+const ipcName = "gwan.ipc"
+
+// Config is the setup of a devnet.
+type Config struct {
+	// Validators is the number of validators. The first one is the white
+	// list, leading the epochs until the stakers are selected.
+	Validators int
+
+	// Stake is the genesis stake of each validator.
+	Stake *big.Int
+
+	// BaseDir holds the node directories. A temporary directory removed
+	// on Stop is used when empty. Keep it short, the IPC endpoints are
+	// unix sockets in it.
+	BaseDir string
+}
+
+// DefaultConfig is a devnet of four validators.
+var DefaultConfig = Config{
+	Validators: 4,
+	Stake:      DefaultStake,
+}
+
+// Network is a running devnet. Node i is run by Validators[i], node 0 being
+// the white list.
+type Network struct {
+	Validators []*Validator
+	Stakers    []Staker
+
+	config  Config
+	tempDir bool
+	adapter *adapters.ExecAdapter
+	net     *simulations.Network
+	nodes   []*simulations.Node
+}
+
+// New generates the validators of a devnet and creates its nodes.
+func New(config Config) (*Network, error) {
+	if config.Validators < 1 {
+		return nil, fmt.Errorf("invalid validator count %d", config.Validators)
+	}
+	if config.Stake == nil {
+		config.Stake = DefaultStake
+	}
+	n := &Network{config: config}
+	if n.config.BaseDir == "" {
+		dir, err := ioutil.TempDir("", "devnet")
+		if err != nil {
+			return nil, err
+		}
+		n.config.BaseDir, n.tempDir = dir, true
+	} else if err := os.MkdirAll(n.config.BaseDir, 0755); err != nil {
+		return nil, err
+	}
+
+	for i := 0; i < config.Validators; i++ {
+		v, err := NewValidator()
+		if err != nil {
+			n.cleanup()
+			return nil, err
+		}
+		n.Validators = append(n.Validators, v)
+	}
+	for _, v := range n.Validators {
+		n.Stakers = append(n.Stakers, v.Staker())
+	}
+
+	n.adapter = adapters.NewExecAdapter(n.config.BaseDir)
+	n.net = simulations.NewNetwork(n.adapter, &simulations.NetworkConfig{
+		ID:             "devnet",
+		DefaultService: serviceName,
+	})
+	for i := range n.Validators {
+		conf := adapters.RandomNodeConfig()
+		conf.Name = fmt.Sprintf("validator%02d", i)
+		conf.Services = []string{serviceName}
+		node, err := n.net.NewNodeWithConfig(conf)
+		if err != nil {
+			n.cleanup()
+			return nil, err
+		}
+		stack := &node.Node.(*adapters.ExecNode).Config.Stack
+		stack.IPCPath = ipcName
+		stack.UseLightweightKDF = true
+		n.nodes = append(n.nodes, node)
+	}
+	return n, nil
+}
+
+// Start boots the validators, connects them to each other and starts mining.
+func (n *Network) Start() error {
+	for i, node := range n.nodes {
+		snapshot, err := json.Marshal(&nodeConfig{
+			Stakers:   n.Stakers,
+			Stake:     n.config.Stake,
+			Validator: n.Validators[i],
+			DataDir:   n.execNode(i).Config.Stack.DataDir,
+			IPCPath:   ipcName,
+		})
+		if err != nil {
+			return err
+		}
+		if err := n.net.StartWithSnapshots(node.ID(), map[string][]byte{serviceName: snapshot}); err != nil {
+			return fmt.Errorf("starting %s: %v", node.Config.Name, err)
+		}
+	}
+	for i := range n.nodes {
+		for j := i + 1; j < len(n.nodes); j++ {
+			if err := n.net.Connect(n.nodes[i].ID(), n.nodes[j].ID()); err != nil {
+				return fmt.Errorf("connecting %s to %s: %v", n.nodes[i].Config.Name, n.nodes[j].Config.Name, err)
+			}
+		}
+	}
+	for i, node := range n.nodes {
+		c, err := n.Client(i)
+		if err != nil {
+			return err
+		}
+		if err := c.Call(nil, "miner_start", 1); err != nil {
+			return fmt.Errorf("starting %s miner: %v", node.Config.Name, err)
+		}
+	}
+	log.Info("Devnet started", "validators", len(n.nodes), "dir", n.config.BaseDir)
+	return nil
+}
+
+// Stop shuts the validators down.
+func (n *Network) Stop() {
+	n.net.Shutdown()
+	n.cleanup()
+}
+
+func (n *Network) cleanup() {
+	if n.tempDir {
+		os.RemoveAll(n.config.BaseDir)
+	}
+}
+
+// Len returns the number of validator nodes.
+func (n *Network) Len() int {
+	return len(n.nodes)
+}
+
+// NodeID returns the p2p identity of node i.
+func (n *Network) NodeID(i int) discover.NodeID {
+	return n.nodes[i].ID()
+}
+
+// Name returns the name of node i.
+func (n *Network) Name(i int) string {
+	return n.nodes[i].Config.Name
+}
+
+// IPCEndpoint returns the IPC endpoint of node i.
+func (n *Network) IPCEndpoint(i int) string {
+	return filepath.Join(n.execNode(i).Config.Stack.DataDir, ipcName)
+}
+
+// Client returns the RPC client of running node i.
+func (n *Network) Client(i int) (*rpc.Client, error) {
+	return n.nodes[i].Client()
+}
+
+// EthClient returns the eth API client of running node i.
+func (n *Network) EthClient(i int) (*ethclient.Client, error) {
+	c, err := n.Client(i)
+	if err != nil {
+		return nil, err
+	}
+	return ethclient.NewClient(c), nil
+}
+
+// PosClient returns the pos API client of running node i.
+func (n *Network) PosClient(i int) (*client.PosClient, error) {
+	c, err := n.Client(i)
+	if err != nil {
+		return nil, err
+	}
+	return client.NewClient(c), nil
+}
+
+func (n *Network) execNode(i int) *adapters.ExecNode {
+	return n.nodes[i].Node.(*adapters.ExecNode)
+}
@@ -0,0 +1,95 @@
+package devnet
+
+import (
+	"encoding/json"
+	"errors"
+	"math/big"
+
+	"github.com/wanchain/go-wanchain/accounts"
+	"github.com/wanchain/go-wanchain/accounts/keystore"
+	"github.com/wanchain/go-wanchain/common/hexutil"
+	"github.com/wanchain/go-wanchain/eth"
+	"github.com/wanchain/go-wanchain/eth/downloader"
+	"github.com/wanchain/go-wanchain/node"
+	"github.com/wanchain/go-wanchain/p2p/simulations/adapters"
+	"github.com/wanchain/go-wanchain/pos/posconfig"
+	"github.com/wanchain/go-wanchain/pos/posdb"
+)
+
+const (
+	// serviceName is the simulation service running a devnet validator.
+	serviceName = "wan"
+
+	// keyPassword encrypts the validator keys in the node keystores.
+	keyPassword = "devnet"
+)
+
+// nodeConfig is the snapshot the validator service is started with.
+type nodeConfig struct {
+	Stakers   []Staker   `json:"stakers"`
+	Stake     *big.Int   `json:"stake"`
+	Validator *Validator `json:"validator"`
+	DataDir   string     `json:"dataDir"`
+	IPCPath   string     `json:"ipcPath"`
+}
+
+// The validators run in child processes of the harness, the pos modules
+// keeping their state in package globals.
+func init() {
+	adapters.RegisterServices(adapters.Services{
+		serviceName: newService,
+	})
+}
+
+// newService sets a devnet validator up like gwan --plutodev does, with the
+// validator key unlocked as etherbase and the first staker as white list.
+// Mining is started through RPC once the validators are connected.
+func newService(ctx *adapters.ServiceContext) (node.Service, error) {
+	var conf nodeConfig
+	if err := json.Unmarshal(ctx.Snapshot, &conf); err != nil {
+		return nil, err
+	}
+	if conf.Validator == nil || len(conf.Stakers) == 0 {
+		return nil, errors.New("missing devnet validators")
+	}
+	if err := unlockValidator(ctx.NodeContext.AccountManager, conf.Validator); err != nil {
+		return nil, err
+	}
+
+	posconfig.IsDev = true
+	posconfig.MineEnabled = true
+	posdb.DbInitAll(conf.DataDir)
+	posconfig.Init(&node.Config{DataDir: conf.DataDir, IPCPath: conf.IPCPath}, NetworkId)
+	setWhiteList(conf.Stakers[0].S256pk)
+
+	config := eth.DefaultConfig
+	config.Genesis = Genesis(conf.Stakers, conf.Stake)
+	config.NetworkId = NetworkId
+	config.SyncMode = downloader.FullSync
+	config.Etherbase = conf.Validator.Address
+	posconfig.Cfg().DefaultGasPrice = config.GasPrice
+	return eth.New(ctx.NodeContext, &config)
+}
+
+func unlockValidator(am *accounts.Manager, v *Validator) error {
+	backends := am.Backends(keystore.KeyStoreType)
+	if len(backends) == 0 {
+		return errors.New("keystore not available")
+	}
+	ks := backends[0].(*keystore.KeyStore)
+	if !ks.HasAddress(v.Address) {
+		if _, err := ks.ImportECDSA(v.Key1, v.Key2, keyPassword); err != nil {
+			return err
+		}
+	}
+	return ks.Unlock(accounts.Account{Address: v.Address}, keyPassword)
+}
+
+// setWhiteList replaces the plutodev white list by a single key. The white
+// list leads the epochs until the stakers are selected.
+func setWhiteList(pk []byte) {
+	for i := range posconfig.WhiteList {
+		posconfig.WhiteList[i] = hexutil.Encode(pk)
+		posconfig.EpochLeadersHold[i] = pk
+	}
+}
@@ -0,0 +1,84 @@
+package devnet
+
+import (
+	"crypto/ecdsa"
+	"encoding/json"
+	"errors"
+
+	"github.com/wanchain/go-wanchain/common"
+	"github.com/wanchain/go-wanchain/common/hexutil"
+	"github.com/wanchain/go-wanchain/crypto"
+	bn256 "github.com/wanchain/go-wanchain/crypto/bn256/cloudflare"
+	"github.com/wanchain/go-wanchain/pos/posconfig"
+)
+
+// Validator is the account of a devnet validator. Key1 signs the blocks and
+// transactions, Key2 derives the bn256 key of the random beacon.
+type Validator struct {
+	Address common.Address
+	Key1    *ecdsa.PrivateKey
+	Key2    *ecdsa.PrivateKey
+}
+
+type validatorJSON struct {
+	Key1 hexutil.Bytes `json:"key1"`
+	Key2 hexutil.Bytes `json:"key2"`
+}
+
+// NewValidator generates the keys of a validator.
+func NewValidator() (*Validator, error) {
+	key1, err := crypto.GenerateKey()
+	if err != nil {
+		return nil, err
+	}
+	key2, err := crypto.GenerateKey()
+	if err != nil {
+		return nil, err
+	}
+	return newValidator(key1, key2), nil
+}
+
+func newValidator(key1, key2 *ecdsa.PrivateKey) *Validator {
+	return &Validator{
+		Address: crypto.PubkeyToAddress(key1.PublicKey),
+		Key1:    key1,
+		Key2:    key2,
+	}
+}
+
+// Secp256PK returns the public key the validator signs with.
+func (v *Validator) Secp256PK() []byte {
+	return crypto.FromECDSAPub(&v.Key1.PublicKey)
+}
+
+// Bn256PK returns the public key of the validator in the random beacon.
+func (v *Validator) Bn256PK() []byte {
+	return new(bn256.G1).ScalarBaseMult(posconfig.GenerateD3byKey2(v.Key2)).Marshal()
+}
+
+func (v *Validator) MarshalJSON() ([]byte, error) {
+	return json.Marshal(&validatorJSON{
+		Key1: crypto.FromECDSA(v.Key1),
+		Key2: crypto.FromECDSA(v.Key2),
+	})
+}
+
+func (v *Validator) UnmarshalJSON(input []byte) error {
+	var dec validatorJSON
+	if err := json.Unmarshal(input, &dec); err != nil {
+		return err
+	}
+	if dec.Key1 == nil || dec.Key2 == nil {
+		return errors.New("missing validator key")
+	}
+	key1, err := crypto.ToECDSA(dec.Key1)
+	if err != nil {
+		return err
+	}
+	key2, err := crypto.ToECDSA(dec.Key2)
+	if err != nil {
+		return err
+	}
+	*v = *newValidator(key1, key2)
+	return nil
+}
@@ -117,22 +117,25 @@ NOTE: if the targetEpochId is future, will return current blockNumber.
 */
 func (e *Epocher) GetEpochLastBlkNumber(targetEpochId uint64) uint64 {
 
-	var curBlockHeader *types.Header
-
 	curNum := e.blkChain.CurrentBlock().NumberU64()
-	for {
-		curBlockHeader := e.blkChain.GetHeaderByNumber(curNum)
-		curEpochId, _ := util.GetEpochSlotIDFromDifficulty(curBlockHeader.Difficulty)
-		if curEpochId <= targetEpochId {
-			break
-		}
-		curNum--
+	first := posconfig.Pow2PosUpgradeBlockNumber
+	if first > curNum {
+		first = curNum
+	}
+	// the epochs of the canonical pos blocks never decrease
+	n := sort.Search(int(curNum-first+1), func(i int) bool {
+		header := e.blkChain.GetHeaderByNumber(first + uint64(i))
+		epochId, _ := util.GetEpochSlotIDFromDifficulty(header.Difficulty)
+		return epochId > targetEpochId
+	})
+	targetBlkNum := first + uint64(n)
+	if targetBlkNum > 0 {
+		targetBlkNum--
 	}
 
-	targetBlkNum := curNum
 	epochid, _ := util.CalEpochSlotID(uint64(time.Now().Unix()))
 	if targetEpochId < epochid && targetEpochId >= posconfig.FirstEpochId {
-		util.SetEpochBlock(targetEpochId, targetBlkNum, curBlockHeader.Hash())
+		util.SetEpochBlock(targetEpochId, targetBlkNum, e.blkChain.GetHeaderByNumber(targetBlkNum).Hash())
 	}
 
 	return targetBlkNum
@@ -140,12 +143,31 @@ func (e *Epocher) GetEpochLastBlkNumber(targetEpochId uint64) uint64 {
 
 func (e *Epocher) SelectLeadersLoop(epochId uint64) error {
 
+	r, stateDb, err := e.selectionInput(epochId)
+	if err != nil {
+		return err
+	}
+	err = e.selectLeaders(r, stateDb, epochId)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// selectionInput returns the random and the state the leaders of epochId are
+// selected from, the state of the last block two epochs before.
+func (e *Epocher) selectionInput(epochId uint64) ([]byte, *state.StateDB, error) {
 	targetBlkNum := e.GetTargetBlkNumber(epochId)
 
+	header := e.blkChain.GetHeaderByNumber(targetBlkNum)
+	if header == nil {
+		return nil, nil, fmt.Errorf("block %d missing", targetBlkNum)
+	}
 	//stateDb, err := e.blkChain.StateAt(e.blkChain.GetBlockByNumber(targetBlkNum).Root())
-	stateDb, err := e.blkChain.StateAt(e.blkChain.GetHeaderByNumber(targetBlkNum).Root)
+	stateDb, err := e.blkChain.StateAt(header.Root)
 	if err != nil {
-		return err
+		return nil, nil, err
 	}
 
 	epochIdIn := epochId
@@ -158,13 +180,7 @@ func (e *Epocher) SelectLeadersLoop(epochId uint64) error {
 		rb = new(big.Int).SetBytes(crypto.Keccak256(big.NewInt(1).Bytes()))
 	}
 
-	r := rb.Bytes()
-	err = e.selectLeaders(r, stateDb, epochId)
-	if err != nil {
-		return err
-	}
-
-	return nil
+	return rb.Bytes(), stateDb, nil
 }
 
 func (e *Epocher) reportSelectELFailed(epochId uint64) {
@@ -210,6 +226,7 @@ func (e *Epocher) reportSelectRBPFailed(epochId uint64) {
 func (e *Epocher) selectLeaders(r []byte, statedb *state.StateDB, epochId uint64) error {
 	log.Debug("select randoms", "epochId", epochId, "r", common.ToHex(r))
 
+	e.saveStakers(statedb, epochId)
 	pa, err := e.createStakerProbabilityArray(statedb, epochId)
 	if pa == nil || err != nil {
 		e.reportSelectELFailed(epochId)
@@ -316,10 +333,46 @@ func (e *Epocher) createStakerProbabilityArray(statedb *state.StateDB, epochID u
 	return ps, nil
 }
 
+// stakerInfos returns the encoded staker infos of statedb by address.
+func stakerInfos(statedb *state.StateDB) map[common.Address][]byte {
+	infos := make(map[common.Address][]byte)
+	statedb.ForEachStorageByteArray(vm.StakersInfoAddr, func(key common.Hash, value []byte) bool {
+		staker := vm.StakerInfo{}
+		if err := rlp.DecodeBytes(value, &staker); err != nil {
+			log.Error(err.Error())
+			return true
+		}
+		infos[staker.Address] = value
+		return true
+	})
+	return infos
+}
+
+// saveStakers keeps the staker infos the leaders of epochID are selected from.
+func (e *Epocher) saveStakers(statedb *state.StateDB, epochID uint64) {
+	for addr, info := range stakerInfos(statedb) {
+		if err := posdb.PutStakerInfoBytes(epochID, addr, info); err != nil {
+			log.Error("save staker info failed", "epochID", epochID, "address", addr, "err", err)
+		}
+	}
+}
+
 //select epoch leader from PublicKeys based on proportion of Probabilities
 func (e *Epocher) epochLeaderSelection(r []byte, ps ProposerSorter, epochId uint64) error {
+	leaders, err := e.selectEpochLeaders(r, ps, epochId)
+	if err != nil {
+		return err
+	}
+	for i, val := range leaders {
+		e.epochLeadersDb.PutWithIndex(epochId, uint64(i), "", val)
+	}
+	return nil
+}
+
+// selectEpochLeaders returns the rlp encoded epoch leaders selected from ps.
+func (e *Epocher) selectEpochLeaders(r []byte, ps ProposerSorter, epochId uint64) ([][]byte, error) {
 	if r == nil || len(ps) == 0 {
-		return ErrInvalidRandomProposerSelection
+		return nil, ErrInvalidRandomProposerSelection
 	}
 
 	//the last one is total properties
@@ -339,6 +392,7 @@ func (e *Epocher) epochLeaderSelection(r []byte, ps ProposerSorter, epochId uint
 	if err == nil {
 		selectionCount = posconfig.EpochLeaderCount - int(info.WlCount.Uint64())
 	}
+	leaders := make([][]byte, 0, selectionCount)
 	for i := 0; i < selectionCount; i++ {
 
 		crBig := new(big.Int).SetBytes(cr)
@@ -352,14 +406,14 @@ func (e *Epocher) epochLeaderSelection(r []byte, ps ProposerSorter, epochId uint
 		//randomProposerPublicKeys = append(randomProposerPublicKeys, ps[idx].PubSec256)
 		val, err := rlp.EncodeToBytes(&ps[idx])
 		if err != nil {
-			continue
+			return nil, err
 		}
-		e.epochLeadersDb.PutWithIndex(epochId, uint64(i), "", val)
+		leaders = append(leaders, val)
 
 		cr = crypto.Keccak256(cr)
 	}
 
-	return nil
+	return leaders, nil
 }
 
 func (e *Epocher) GetWhiteInfo(epochId uint64) (*vm.UpgradeWhiteEpochLeaderParam, error) {
@@ -399,8 +453,20 @@ func (e *Epocher) GetWhiteArrayByEpochId(epochId uint64) ([][]byte, error) {
 //*bn256.G1
 //samples ne epoch leaders by random number r from PublicKeys based on proportion of Probabilities
 func (e *Epocher) randomProposerSelection(r []byte, ps ProposerSorter, epochId uint64) error {
+	proposers, err := e.selectRandomProposers(r, ps)
+	if err != nil {
+		return err
+	}
+	for i, val := range proposers {
+		e.rbLeadersDb.PutWithIndex(epochId, uint64(i), "", val)
+	}
+	return nil
+}
+
+// selectRandomProposers returns the rlp encoded random proposers selected from ps.
+func (e *Epocher) selectRandomProposers(r []byte, ps ProposerSorter) ([][]byte, error) {
 	if r == nil || len(ps) == 0 {
-		return ErrInvalidEpochProposerSelection
+		return nil, ErrInvalidEpochProposerSelection
 	}
 
 	//the last one is total properties
@@ -414,6 +480,7 @@ func (e *Epocher) randomProposerSelection(r []byte, ps ProposerSorter, epochId u
 	cr := crypto.Keccak256(r1) //cr = hash(r1)
 
 	log.Info("random proposer selecting...\n")
+	proposers := make([][]byte, 0, posconfig.RandomProperCount)
 	for i := 0; i < posconfig.RandomProperCount; i++ {
 
 		crBig := new(big.Int).SetBytes(cr)
@@ -425,15 +492,14 @@ func (e *Epocher) randomProposerSelection(r []byte, ps ProposerSorter, epochId u
 		val, err := rlp.EncodeToBytes(ps[idx])
 
 		if err != nil {
-			continue
+			return nil, err
 		}
-
-		e.rbLeadersDb.PutWithIndex(epochId, uint64(i), "", val)
+		proposers = append(proposers, val)
 
 		cr = crypto.Keccak256(cr)
 	}
 
-	return nil
+	return proposers, nil
 }
 
 func (e *Epocher) IsGenerateELSuc(epochID uint64) bool {
@@ -650,6 +716,16 @@ func CalEpochProbabilityStaker(staker *vm.StakerInfo, epochID uint64) (infors []
 
 // incentive  use it.
 func (e *Epocher) GetEpochProbability(epochId uint64, addr common.Address) (*vm.ValidatorInfo, error) {
+	curStateDb, err := e.blkChain.StateAt(e.blkChain.CurrentBlock().Root())
+	if err != nil {
+		return nil, err
+	}
+	return e.GetEpochProbabilityAt(epochId, addr, curStateDb)
+}
+
+// GetEpochProbabilityAt is GetEpochProbability with the fee rate read from
+// feeRateState instead of the state of the current block.
+func (e *Epocher) GetEpochProbabilityAt(epochId uint64, addr common.Address, feeRateState *state.StateDB) (*vm.ValidatorInfo, error) {
 
 	targetBlkNum := e.GetTargetBlkNumber(epochId)
 
@@ -676,16 +752,11 @@ func (e *Epocher) GetEpochProbability(epochId uint64, addr common.Address) (*vm.
 
 	// try to get current feeRate
 	feeRate := staker.FeeRate
-	curStateDb, err := e.blkChain.StateAt(e.blkChain.CurrentBlock().Root())
-	if err != nil {
-		return nil, err
-	} else {
-		stakeBytesNew := curStateDb.GetStateByteArray(vm.StakersInfoAddr, addrHash)
-		stakeNew := vm.StakerInfo{}
-		err = rlp.DecodeBytes(stakeBytesNew, &stakeNew)
-		if nil == err {
-			feeRate = stakeNew.FeeRate
-		}
+	stakeBytesNew := feeRateState.GetStateByteArray(vm.StakersInfoAddr, addrHash)
+	stakeNew := vm.StakerInfo{}
+	err = rlp.DecodeBytes(stakeBytesNew, &stakeNew)
+	if nil == err {
+		feeRate = stakeNew.FeeRate
 	}
 
 	validator := &vm.ValidatorInfo{
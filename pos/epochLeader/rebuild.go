@@ -0,0 +1,171 @@
+package epochLeader
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/wanchain/go-wanchain/common"
+	"github.com/wanchain/go-wanchain/core/vm"
+	"github.com/wanchain/go-wanchain/pos/incentive"
+	"github.com/wanchain/go-wanchain/pos/posconfig"
+	"github.com/wanchain/go-wanchain/pos/posdb"
+	"github.com/wanchain/go-wanchain/pos/util"
+)
+
+// Selection is the outcome of the leader selection of an epoch.
+type Selection struct {
+	Leaders   [][]byte                  // rlp encoded epoch leaders
+	Proposers [][]byte                  // rlp encoded random proposers
+	Stakers   map[common.Address][]byte // staker infos the leaders are selected from
+}
+
+// ComputeSelection selects the leaders of epochId again from the chain, the
+// way SelectLeadersLoop does, without storing them.
+func (e *Epocher) ComputeSelection(epochId uint64) (*Selection, error) {
+	r, stateDb, err := e.selectionInput(epochId)
+	if err != nil {
+		return nil, err
+	}
+	sel := &Selection{Stakers: stakerInfos(stateDb)}
+
+	ps, err := e.createStakerProbabilityArray(stateDb, epochId)
+	if err != nil {
+		return nil, err
+	}
+	if len(ps) == 0 {
+		// No staker to select from, the epoch is led by the white list
+		return sel, nil
+	}
+	if sel.Leaders, err = e.selectEpochLeaders(r, ps, epochId); err != nil {
+		return nil, err
+	}
+	if sel.Proposers, err = e.selectRandomProposers(r, ps); err != nil {
+		return nil, err
+	}
+	return sel, nil
+}
+
+// RebuildSelection replaces the stored leaders and stakers of epochId by the
+// ones selected from the chain.
+func (e *Epocher) RebuildSelection(epochId uint64) error {
+	sel, err := e.ComputeSelection(epochId)
+	if err != nil {
+		return err
+	}
+	stakerDb := posdb.NewDb(posconfig.StakerLocalDB)
+	for _, db := range []*posdb.Db{e.epochLeadersDb, e.rbLeadersDb, stakerDb} {
+		if err := db.DeleteEpoch(epochId); err != nil {
+			return err
+		}
+	}
+	for i, val := range sel.Leaders {
+		if _, err := e.epochLeadersDb.PutWithIndex(epochId, uint64(i), "", val); err != nil {
+			return err
+		}
+	}
+	for i, val := range sel.Proposers {
+		if _, err := e.rbLeadersDb.PutWithIndex(epochId, uint64(i), "", val); err != nil {
+			return err
+		}
+	}
+	for addr, info := range sel.Stakers {
+		if err := posdb.PutStakerInfoBytes(epochId, addr, info); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RebuildStakeOut stores again the stake-out record of epochID, running the
+// stake-out again on the state of the parent of the block of the epoch which
+// ran it. The blocks without state are skipped, as left by gwan snapshot
+// prune-state. It returns false if no block of the chain ran it yet.
+func (e *Epocher) RebuildStakeOut(epochID uint64) (bool, error) {
+	if epochID == 0 {
+		return false, nil
+	}
+	head := e.blkChain.CurrentBlock()
+	skipped := false
+	for number := util.GetEpochBlock(epochID-1) + 1; number <= head.NumberU64(); number++ {
+		header := e.blkChain.GetHeaderByNumber(number)
+		if header == nil {
+			return false, fmt.Errorf("block %d missing", number)
+		}
+		blockEpochID, slotID := util.GetEpochSlotIDFromDifficulty(header.Difficulty)
+		if blockEpochID > epochID {
+			break
+		}
+		if blockEpochID < epochID || !incentive.RunsAt(blockEpochID, slotID) {
+			continue
+		}
+		blockState, err := e.blkChain.StateAt(header.Root)
+		if err != nil {
+			skipped = true
+			continue
+		}
+		// A failed run is retried by the next block
+		if !vm.StakeoutIsFinished(blockState, epochID) {
+			continue
+		}
+		parent := e.blkChain.GetHeader(header.ParentHash, number-1)
+		if parent == nil {
+			return false, fmt.Errorf("parent of block %d missing", number)
+		}
+		stateDb, err := e.blkChain.StateAt(parent.Root)
+		if err != nil {
+			return false, fmt.Errorf("state of block %d missing: %v", number-1, err)
+		}
+		if vm.StakeoutIsFinished(stateDb, epochID) {
+			return false, nil
+		}
+		return StakeOutRun(stateDb, epochID), nil
+	}
+	// A stake-out run by the chain but not found had its block skipped
+	if skipped {
+		headState, err := e.blkChain.StateAt(head.Root())
+		if err != nil {
+			return false, fmt.Errorf("state of the head block missing: %v", err)
+		}
+		if vm.StakeoutIsFinished(headState, epochID) {
+			return false, errors.New("state of the block which ran the stake-out missing, pruned or not synced")
+		}
+	}
+	return false, nil
+}
+
+// VerifySelection checks the stored leaders and stakers of epochId against the
+// ones selected from the chain. It returns the differences found.
+func (e *Epocher) VerifySelection(epochId uint64) ([]string, error) {
+	sel, err := e.ComputeSelection(epochId)
+	if err != nil {
+		return nil, err
+	}
+	var diffs []string
+	diffs = append(diffs, compareStored("epoch leaders", e.epochLeadersDb, epochId, sel.Leaders)...)
+	diffs = append(diffs, compareStored("random proposers", e.rbLeadersDb, epochId, sel.Proposers)...)
+
+	stored := len(posdb.NewDb(posconfig.StakerLocalDB).GetStorageByteArray(epochId))
+	if stored != len(sel.Stakers) {
+		diffs = append(diffs, fmt.Sprintf("%d stakers stored, want %d", stored, len(sel.Stakers)))
+	}
+	for addr, info := range sel.Stakers {
+		if have := posdb.GetStakerInfoBytes(epochId, addr); !bytes.Equal(have, info) {
+			diffs = append(diffs, fmt.Sprintf("staker %x differs", addr))
+		}
+	}
+	return diffs, nil
+}
+
+func compareStored(what string, db *posdb.Db, epochId uint64, want [][]byte) []string {
+	var diffs []string
+	if stored := len(db.GetStorageByteArray(epochId)); stored != len(want) {
+		diffs = append(diffs, fmt.Sprintf("%d %s stored, want %d", stored, what, len(want)))
+	}
+	for i, val := range want {
+		if have, _ := db.GetWithIndex(epochId, uint64(i), ""); !bytes.Equal(have, val) {
+			diffs = append(diffs, fmt.Sprintf("%s %d differs", what, i))
+		}
+	}
+	return diffs
+}
@@ -0,0 +1,45 @@
+package epochLeader
+
+import (
+	"testing"
+
+	"github.com/wanchain/go-wanchain/pos/posconfig"
+)
+
+func TestRebuildSelection(t *testing.T) {
+	posconfig.Init(nil, 6)
+	blkChain, _ := newTestBlockChain(true)
+	epocher := NewEpocherWithLBN(blkChain, "rebuildrb", "rebuildep")
+
+	if err := epocher.SelectLeadersLoop(0); err != nil {
+		t.Fatal(err)
+	}
+	sel, err := epocher.ComputeSelection(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sel.Leaders) == 0 || len(sel.Proposers) != posconfig.RandomProperCount || len(sel.Stakers) == 0 {
+		t.Fatalf("selection of %d leaders, %d proposers and %d stakers", len(sel.Leaders), len(sel.Proposers), len(sel.Stakers))
+	}
+	if diffs, err := epocher.VerifySelection(0); err != nil || len(diffs) != 0 {
+		t.Fatalf("selected leaders differ from the chain: %v, %v", diffs, err)
+	}
+
+	// Corrupt a leader and store an extra proposer
+	epocher.epochLeadersDb.PutWithIndex(0, 1, "", []byte{0x80})
+	epocher.rbLeadersDb.PutWithIndex(0, uint64(posconfig.RandomProperCount), "", []byte{0x80})
+	diffs, err := epocher.VerifySelection(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diffs) != 2 {
+		t.Fatalf("differences not found: %v", diffs)
+	}
+
+	if err := epocher.RebuildSelection(0); err != nil {
+		t.Fatal(err)
+	}
+	if diffs, err := epocher.VerifySelection(0); err != nil || len(diffs) != 0 {
+		t.Fatalf("rebuilt leaders differ from the chain: %v, %v", diffs, err)
+	}
+}
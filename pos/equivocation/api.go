@@ -0,0 +1,57 @@
+package equivocation
+
+import (
+	"github.com/wanchain/go-wanchain/common"
+	"github.com/wanchain/go-wanchain/common/hexutil"
+	"github.com/wanchain/go-wanchain/core/types"
+	"github.com/wanchain/go-wanchain/rpc"
+)
+
+// APIs returns the RPC services of the equivocation detector. They extend the
+// pos namespace.
+func APIs(d *Detector) []rpc.API {
+	return []rpc.API{{
+		Namespace: "pos",
+		Version:   "1.0",
+		Service:   &PublicEquivocationAPI{d},
+		Public:    true,
+	}}
+}
+
+// PublicEquivocationAPI exposes the evidence of equivocating slot leaders.
+type PublicEquivocationAPI struct {
+	d *Detector
+}
+
+// RPCEvidence is the evidence of an equivocation, along with the slot leader
+// proofs packed in the extra of both headers.
+type RPCEvidence struct {
+	EpochId uint64         `json:"epochId"`
+	SlotId  uint64         `json:"slotId"`
+	Signer  common.Address `json:"signer"`
+	Hash    common.Hash    `json:"hash"`
+	Header1 *types.Header  `json:"header1"`
+	Header2 *types.Header  `json:"header2"`
+	Proof1  hexutil.Bytes  `json:"proof1"`
+	Proof2  hexutil.Bytes  `json:"proof2"`
+}
+
+// GetEquivocationEvidence returns the evidence stored for an epoch.
+func (api *PublicEquivocationAPI) GetEquivocationEvidence(epochID uint64) []*RPCEvidence {
+	evs := api.d.Evidence(epochID)
+	res := make([]*RPCEvidence, 0, len(evs))
+	for _, ev := range evs {
+		proof1, proof2 := ev.Proofs()
+		res = append(res, &RPCEvidence{
+			EpochId: ev.EpochId,
+			SlotId:  ev.SlotId,
+			Signer:  ev.Signer,
+			Hash:    ev.Hash(),
+			Header1: ev.Header1,
+			Header2: ev.Header2,
+			Proof1:  proof1,
+			Proof2:  proof2,
+		})
+	}
+	return res
+}
@@ -0,0 +1,150 @@
+// Package equivocation detects slot leaders signing two different blocks for
+// the same slot, and keeps the evidence of it.
+package equivocation
+
+import (
+	"sync"
+
+	lru "github.com/hashicorp/golang-lru"
+	"github.com/wanchain/go-wanchain/common"
+	"github.com/wanchain/go-wanchain/consensus"
+	"github.com/wanchain/go-wanchain/core/types"
+	"github.com/wanchain/go-wanchain/ethdb"
+	"github.com/wanchain/go-wanchain/event"
+	"github.com/wanchain/go-wanchain/log"
+	"github.com/wanchain/go-wanchain/params"
+	"github.com/wanchain/go-wanchain/pos/util"
+)
+
+// recentSlots is the number of slots whose first block is remembered.
+const recentSlots = 4096
+
+// Engine is the part of the pos consensus engine verifying evidence.
+type Engine interface {
+	Author(header *types.Header) (common.Address, error)
+	VerifySeal(chain consensus.ChainReader, header *types.Header) error
+}
+
+// NewEvidenceEvent is posted when new evidence is stored.
+type NewEvidenceEvent struct{ Evidence *Evidence }
+
+type slotSigner struct {
+	epochID uint64
+	slotID  uint64
+	signer  common.Address
+}
+
+// Detector remembers the first block seen for each recent slot and records
+// evidence when its signer seals another one.
+type Detector struct {
+	db     ethdb.Database
+	config *params.ChainConfig
+	engine Engine
+	chain  consensus.ChainReader
+
+	seen *lru.Cache // slotSigner -> first header seen
+	lock sync.Mutex // Serializes the updates of the evidence store
+
+	feed  event.Feed
+	scope event.SubscriptionScope
+}
+
+// New creates a detector storing the evidence in db. Blocks are verified by
+// the pos engine.
+func New(db ethdb.Database, config *params.ChainConfig, engine Engine, chain consensus.ChainReader) *Detector {
+	seen, _ := lru.New(recentSlots)
+	return &Detector{
+		db:     db,
+		config: config,
+		engine: engine,
+		chain:  chain,
+		seen:   seen,
+	}
+}
+
+// Observe checks a verified header against the block seen first for its slot.
+// It returns the evidence when the slot leader signed both.
+func (d *Detector) Observe(header *types.Header) *Evidence {
+	if d.config.PosFirstBlock == nil || !d.config.IsPosBlockNumber(header.Number) {
+		return nil
+	}
+	signer, err := d.engine.Author(header)
+	if err != nil {
+		return nil
+	}
+	epochID, slotID := util.GetEpochSlotIDFromDifficulty(header.Difficulty)
+	key := slotSigner{epochID, slotID, signer}
+
+	first, ok := d.seen.Get(key)
+	if !ok {
+		d.seen.Add(key, header)
+		return nil
+	}
+	if first.(*types.Header).Hash() == header.Hash() {
+		return nil
+	}
+	ev := NewEvidence(signer, first.(*types.Header), header)
+	if err := d.store(ev); err != nil {
+		return nil
+	}
+	log.Warn("Slot leader equivocation detected", "epochID", epochID, "slotID", slotID, "signer", signer,
+		"block1", ev.Header1.Hash(), "block2", ev.Header2.Hash())
+	return ev
+}
+
+// Add verifies and stores evidence received from a peer. Evidence of epochs
+// after the next one of the chain head is dropped with ErrFutureEvidence, as
+// their leaders aren't known yet.
+func (d *Detector) Add(ev *Evidence) error {
+	if HasEvidence(d.db, ev.EpochId, ev.SlotId, ev.Signer) {
+		return ErrKnownEvidence
+	}
+	if d.chain != nil {
+		head := d.chain.CurrentHeader()
+		if !d.config.IsPosBlockNumber(head.Number) {
+			return ErrFutureEvidence
+		}
+		if epochID, _ := util.GetEpochSlotIDFromDifficulty(head.Difficulty); ev.EpochId > epochID+1 {
+			return ErrFutureEvidence
+		}
+	}
+	if err := ev.Verify(d.engine, d.chain); err != nil {
+		return err
+	}
+	if err := d.store(ev); err != nil {
+		return err
+	}
+	log.Warn("Slot leader equivocation reported", "epochID", ev.EpochId, "slotID", ev.SlotId, "signer", ev.Signer,
+		"block1", ev.Header1.Hash(), "block2", ev.Header2.Hash())
+	return nil
+}
+
+// store writes new evidence and announces it.
+func (d *Detector) store(ev *Evidence) error {
+	d.lock.Lock()
+	err := WriteEvidence(d.db, ev)
+	d.lock.Unlock()
+	if err != nil {
+		if err != ErrKnownEvidence {
+			log.Error("Failed to store equivocation evidence", "epochID", ev.EpochId, "slotID", ev.SlotId, "err", err)
+		}
+		return err
+	}
+	d.feed.Send(NewEvidenceEvent{ev})
+	return nil
+}
+
+// Evidence returns the evidence stored for an epoch.
+func (d *Detector) Evidence(epochID uint64) []*Evidence {
+	return ReadEvidence(d.db, epochID)
+}
+
+// SubscribeNewEvidence registers a subscription of NewEvidenceEvent.
+func (d *Detector) SubscribeNewEvidence(ch chan<- NewEvidenceEvent) event.Subscription {
+	return d.scope.Track(d.feed.Subscribe(ch))
+}
+
+// Stop closes the subscriptions.
+func (d *Detector) Stop() {
+	d.scope.Close()
+}
@@ -0,0 +1,130 @@
+package equivocation
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/wanchain/go-wanchain/common"
+	"github.com/wanchain/go-wanchain/consensus"
+	"github.com/wanchain/go-wanchain/core/types"
+	"github.com/wanchain/go-wanchain/ethdb"
+	"github.com/wanchain/go-wanchain/params"
+)
+
+// testEngine takes the coinbase as signer and rejects the seals of bad.
+type testEngine struct {
+	bad common.Address
+}
+
+func (e *testEngine) Author(header *types.Header) (common.Address, error) {
+	return header.Coinbase, nil
+}
+
+func (e *testEngine) VerifySeal(chain consensus.ChainReader, header *types.Header) error {
+	if header.Coinbase == e.bad {
+		return errors.New("invalid seal")
+	}
+	return nil
+}
+
+func testHeader(epochID, slotID uint64, signer common.Address, extra byte) *types.Header {
+	return &types.Header{
+		Number:     big.NewInt(10),
+		Difficulty: new(big.Int).SetUint64(epochID<<32 | slotID<<8 | 1),
+		Coinbase:   signer,
+		Extra:      append([]byte{extra}, make([]byte, extraSeal)...),
+	}
+}
+
+func TestDetector(t *testing.T) {
+	var (
+		a = common.Address{0xa}
+		b = common.Address{0xb}
+	)
+	db, _ := ethdb.NewMemDatabase()
+	config := &params.ChainConfig{PosFirstBlock: big.NewInt(1)}
+	d := New(db, config, &testEngine{bad: b}, nil)
+
+	events := make(chan NewEvidenceEvent, 10)
+	sub := d.SubscribeNewEvidence(events)
+	defer sub.Unsubscribe()
+
+	// Blocks of different slots or signers, and the same block twice, are fine
+	h1 := testHeader(5, 7, a, 1)
+	for _, h := range []*types.Header{h1, h1, testHeader(5, 8, a, 2), testHeader(5, 7, b, 3)} {
+		if ev := d.Observe(h); ev != nil {
+			t.Fatalf("unexpected evidence: %+v", ev)
+		}
+	}
+
+	// A second block of a signing the same slot is an equivocation
+	h2 := testHeader(5, 7, a, 4)
+	ev := d.Observe(h2)
+	if ev == nil {
+		t.Fatal("equivocation not detected")
+	}
+	if ev.EpochId != 5 || ev.SlotId != 7 || ev.Signer != a {
+		t.Fatalf("evidence mismatch: %+v", ev)
+	}
+	if err := ev.Verify(d.engine, nil); err != nil {
+		t.Fatalf("evidence verification failed: %v", err)
+	}
+	if proof1, _ := ev.Proofs(); len(proof1) != 1 {
+		t.Fatalf("proof length mismatch: have %d, want 1", len(proof1))
+	}
+	if ev := <-events; ev.Evidence.Hash() != NewEvidence(a, h2, h1).Hash() {
+		t.Fatal("evidence depends on the order of the blocks")
+	}
+	if d.Observe(testHeader(5, 7, a, 5)) != nil {
+		t.Fatal("evidence stored twice")
+	}
+	if evs := d.Evidence(5); len(evs) != 1 || evs[0].Hash() != ev.Hash() {
+		t.Fatalf("stored evidence mismatch: %v", evs)
+	}
+
+	// Evidence received from peers is verified before being stored
+	other := New(db, config, &testEngine{bad: b}, nil)
+	if err := other.Add(ev); err != ErrKnownEvidence {
+		t.Fatalf("known evidence accepted: %v", err)
+	}
+	if err := other.Add(NewEvidence(b, testHeader(5, 9, b, 1), testHeader(5, 9, b, 2))); !IsInvalid(err) {
+		t.Fatalf("evidence with invalid seals accepted: %v", err)
+	}
+	forged := NewEvidence(a, testHeader(6, 1, a, 1), testHeader(6, 2, a, 2))
+	if err := other.Add(forged); !IsInvalid(err) {
+		t.Fatalf("evidence of different slots accepted: %v", err)
+	}
+	if err := other.Add(NewEvidence(a, testHeader(6, 1, a, 1), testHeader(6, 1, a, 2))); err != nil {
+		t.Fatalf("valid evidence rejected: %v", err)
+	}
+	if err := other.Add(NewEvidence(b, testHeader(6, 1, b, 1), testHeader(6, 1, b, 2))); !IsInvalid(err) {
+		t.Fatalf("evidence with invalid seals accepted: %v", err)
+	}
+	if err := other.Add(NewEvidence(a, testHeader(6, 3, a, 1), testHeader(6, 3, a, 2))); err != nil {
+		t.Fatalf("valid evidence rejected: %v", err)
+	}
+	if evs := ReadEvidence(db, 6); len(evs) != 2 || evs[0].SlotId != 1 || evs[1].SlotId != 3 {
+		t.Fatalf("stored evidence mismatch: %v", evs)
+	}
+	if evs := ReadEvidence(db, 7); len(evs) != 0 {
+		t.Fatalf("stored evidence count mismatch: have %d, want 0", len(evs))
+	}
+
+	// Evidence of epochs whose leaders are unknown isn't verified
+	ahead := New(db, config, &testEngine{bad: b}, &headChain{head: testHeader(6, 0, a, 0)})
+	if err := ahead.Add(NewEvidence(b, testHeader(8, 1, b, 1), testHeader(8, 1, b, 2))); err != ErrFutureEvidence {
+		t.Fatalf("future evidence error mismatch: have %v, want %v", err, ErrFutureEvidence)
+	}
+	if err := ahead.Add(NewEvidence(a, testHeader(7, 1, a, 1), testHeader(7, 1, a, 2))); err != nil {
+		t.Fatalf("evidence of the next epoch rejected: %v", err)
+	}
+}
+
+// headChain is a chain reader knowing its head only.
+type headChain struct {
+	consensus.ChainReader
+	head *types.Header
+}
+
+func (c *headChain) CurrentHeader() *types.Header { return c.head }
@@ -0,0 +1,168 @@
+package equivocation
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/wanchain/go-wanchain/common"
+	"github.com/wanchain/go-wanchain/consensus"
+	"github.com/wanchain/go-wanchain/core/types"
+	"github.com/wanchain/go-wanchain/crypto/sha3"
+	"github.com/wanchain/go-wanchain/ethdb"
+	"github.com/wanchain/go-wanchain/log"
+	"github.com/wanchain/go-wanchain/pos/util"
+	"github.com/wanchain/go-wanchain/rlp"
+)
+
+// extraSeal is the length of the seal signature at the end of the header extra.
+const extraSeal = 65
+
+// The evidence is kept in the chain database, one entry per slot and signer.
+var evidencePrefix = []byte("equivocation-e") // evidencePrefix + epoch ID + slot ID (uint64 big endian) + signer -> rlp(evidence)
+
+var (
+	ErrKnownEvidence   = errors.New("known equivocation evidence")
+	ErrInvalidEvidence = errors.New("invalid equivocation evidence")
+	ErrFutureEvidence  = errors.New("equivocation evidence of an epoch whose leaders are unknown")
+)
+
+// invalidError is the error of evidence failing verification.
+type invalidError struct{ msg string }
+
+func (e *invalidError) Error() string { return e.msg }
+
+func invalidEvidence(format string, args ...interface{}) error {
+	return &invalidError{fmt.Sprintf("%v: ", ErrInvalidEvidence) + fmt.Sprintf(format, args...)}
+}
+
+// IsInvalid reports whether err is the failure of the verification of
+// evidence, rather than of the node checking it.
+func IsInvalid(err error) bool {
+	_, ok := err.(*invalidError)
+	return ok
+}
+
+// Evidence proves that a slot leader signed two different blocks for the same
+// slot. Each header carries the slot leader proof of its signer in its extra.
+type Evidence struct {
+	EpochId uint64
+	SlotId  uint64
+	Signer  common.Address
+	Header1 *types.Header
+	Header2 *types.Header
+}
+
+// NewEvidence makes the evidence of two headers sealed by signer for the same
+// slot. The headers are ordered by hash so that every node builds the same
+// evidence whichever block it saw first.
+func NewEvidence(signer common.Address, a, b *types.Header) *Evidence {
+	if ha, hb := a.Hash(), b.Hash(); bytes.Compare(ha[:], hb[:]) > 0 {
+		a, b = b, a
+	}
+	epochID, slotID := util.GetEpochSlotIDFromDifficulty(a.Difficulty)
+	return &Evidence{
+		EpochId: epochID,
+		SlotId:  slotID,
+		Signer:  signer,
+		Header1: a,
+		Header2: b,
+	}
+}
+
+// Hash returns the keccak256 hash of the RLP encoding of the evidence.
+func (ev *Evidence) Hash() (h common.Hash) {
+	hw := sha3.NewKeccak256()
+	rlp.Encode(hw, ev)
+	hw.Sum(h[:0])
+	return h
+}
+
+// Proofs returns the slot leader proofs of both headers, as packed in their
+// extra.
+func (ev *Evidence) Proofs() (proof1, proof2 []byte) {
+	return headerProof(ev.Header1), headerProof(ev.Header2)
+}
+
+func headerProof(header *types.Header) []byte {
+	if len(header.Extra) < extraSeal {
+		return nil
+	}
+	return header.Extra[:len(header.Extra)-extraSeal]
+}
+
+// Verify checks that both headers are different blocks of the slot of the
+// evidence, carrying valid seals and slot leader proofs of the signer.
+func (ev *Evidence) Verify(engine Engine, chain consensus.ChainReader) error {
+	if ev.Header1 == nil || ev.Header2 == nil || ev.Header1.Difficulty == nil || ev.Header2.Difficulty == nil {
+		return invalidEvidence("missing header")
+	}
+	if ev.Header1.Hash() == ev.Header2.Hash() {
+		return invalidEvidence("same block")
+	}
+	for _, header := range []*types.Header{ev.Header1, ev.Header2} {
+		epochID, slotID := util.GetEpochSlotIDFromDifficulty(header.Difficulty)
+		if epochID != ev.EpochId || slotID != ev.SlotId {
+			return invalidEvidence("block %x is in epoch %d slot %d", header.Hash(), epochID, slotID)
+		}
+		if err := engine.VerifySeal(chain, header); err != nil {
+			return invalidEvidence("block %x: %v", header.Hash(), err)
+		}
+		signer, err := engine.Author(header)
+		if err != nil {
+			return invalidEvidence("block %x: %v", header.Hash(), err)
+		}
+		if signer != ev.Signer {
+			return invalidEvidence("block %x is signed by %x", header.Hash(), signer)
+		}
+	}
+	return nil
+}
+
+func epochEvidenceKey(epochID uint64) []byte {
+	enc := make([]byte, 8)
+	binary.BigEndian.PutUint64(enc, epochID)
+	return append(append([]byte{}, evidencePrefix...), enc...)
+}
+
+func evidenceKey(epochID, slotID uint64, signer common.Address) []byte {
+	enc := make([]byte, 8)
+	binary.BigEndian.PutUint64(enc, slotID)
+	return append(append(epochEvidenceKey(epochID), enc...), signer[:]...)
+}
+
+// ReadEvidence returns the evidence stored for an epoch, ordered by slot.
+func ReadEvidence(db ethdb.Database, epochID uint64) []*Evidence {
+	var evs []*Evidence
+	it := db.NewIteratorWithPrefix(epochEvidenceKey(epochID))
+	defer it.Release()
+	for it.Next() {
+		ev := new(Evidence)
+		if err := rlp.DecodeBytes(it.Value(), ev); err != nil {
+			log.Error("Invalid equivocation evidence", "epochID", epochID, "err", err)
+			continue
+		}
+		evs = append(evs, ev)
+	}
+	return evs
+}
+
+// HasEvidence reports whether evidence against signer is stored for a slot.
+func HasEvidence(db ethdb.Database, epochID, slotID uint64, signer common.Address) bool {
+	has, _ := db.Has(evidenceKey(epochID, slotID, signer))
+	return has
+}
+
+// WriteEvidence stores evidence. A single evidence is kept per slot and signer,
+// the later ones prove nothing more.
+func WriteEvidence(db ethdb.Database, ev *Evidence) error {
+	if HasEvidence(db, ev.EpochId, ev.SlotId, ev.Signer) {
+		return ErrKnownEvidence
+	}
+	data, err := rlp.EncodeToBytes(ev)
+	if err != nil {
+		return err
+	}
+	return db.Put(evidenceKey(ev.EpochId, ev.SlotId, ev.Signer), data)
+}
@@ -10,11 +10,11 @@ import (
 )
 
 // delegate can calc the delegate division
-func delegate(addrs []common.Address, values []*big.Int, epochID uint64) ([][]vm.ClientIncentive, *big.Int, error) {
+func delegate(getStaker GetStakerInfoFn, addrs []common.Address, values []*big.Int, epochID uint64) ([][]vm.ClientIncentive, *big.Int, error) {
 	finalIncentive := make([][]vm.ClientIncentive, 0)
 	remain := big.NewInt(0)
 	for i := 0; i < len(addrs); i++ {
-		stakers, division, totalProbility, err := getStakerInfoAndCheck(epochID, addrs[i])
+		stakers, division, totalProbility, err := getStakerInfoAndCheck(getStaker, epochID, addrs[i])
 		if err != nil {
 			log.SyslogErr(err.Error())
 			continue
@@ -27,9 +27,9 @@ func delegate(addrs []common.Address, values []*big.Int, epochID uint64) ([][]vm
 	return finalIncentive, remain, nil
 }
 
-func getStakerInfoAndCheck(epochID uint64, addr common.Address) ([]vm.ClientProbability, uint64, *big.Int, error) {
+func getStakerInfoAndCheck(getStaker GetStakerInfoFn, epochID uint64, addr common.Address) ([]vm.ClientProbability, uint64, *big.Int, error) {
 	//stakers, division, totalProbility, err
-	validator, err := getStakerInfo(epochID, addr)
+	validator, err := getStaker(epochID, addr)
 	if err != nil {
 		log.SyslogErr("getStakerInfo error", "error", err.Error())
 		return nil, 0, nil, err
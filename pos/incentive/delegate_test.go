@@ -18,7 +18,7 @@ func TestDelegate(t *testing.T) {
 		values[i] = big.NewInt(1e18)
 	}
 
-	finalIncentive, remain, err := delegate(epAddrs, values, 0)
+	finalIncentive, remain, err := delegate(getStakerInfo, epAddrs, values, 0)
 
 	if err != nil {
 		t.FailNow()
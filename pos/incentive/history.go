@@ -0,0 +1,354 @@
+package incentive
+
+import (
+	"encoding/binary"
+	"errors"
+	"math/big"
+	"os"
+	"path"
+
+	lru "github.com/hashicorp/golang-lru"
+	"github.com/wanchain/go-wanchain/common"
+	"github.com/wanchain/go-wanchain/core/types"
+	"github.com/wanchain/go-wanchain/core/vm"
+	"github.com/wanchain/go-wanchain/ethdb"
+	"github.com/wanchain/go-wanchain/log"
+	"github.com/wanchain/go-wanchain/pos/posconfig"
+	"github.com/wanchain/go-wanchain/rlp"
+)
+
+// The incentive history is kept in the chain database. Each record belongs to
+// the block which paid it, so that the records of the blocks dropped by a
+// reorg are left aside instead of mixing with the canonical ones.
+var (
+	historyHeadKey      = []byte("incentive-head") // historyHeadKey -> hash of the last canonical block which paid an incentive
+	historyRecordPrefix = []byte("incentive-r")    // historyRecordPrefix + block hash -> rlp(Record)
+	historyEpochPrefix  = []byte("incentive-e")    // historyEpochPrefix + epoch ID (uint64 big endian) + block hash -> nil, for each block which paid the epoch
+	historyGapKey       = []byte("incentive-gap")  // historyGapKey -> number (uint64 big endian) of the last pos block imported without execution
+)
+
+// legacyHistoryDB is the pos database the former versions kept the incentive
+// history in.
+const legacyHistoryDB = "incentive"
+
+// MarkHistoryGap notes that the pos blocks up to number were imported without
+// being executed, by a fast sync, so that the incentives they paid are not
+// recorded. The history has to be rebuilt, from the states of those blocks.
+func MarkHistoryGap(db ethdb.Database, number uint64) {
+	if data, _ := db.Get(historyGapKey); len(data) == 8 && binary.BigEndian.Uint64(data) >= number {
+		return
+	}
+	enc := make([]byte, 8)
+	binary.BigEndian.PutUint64(enc, number)
+	if err := db.Put(historyGapKey, enc); err != nil {
+		log.Crit("Failed to store incentive history gap", "err", err)
+	}
+	log.Warn("Incentive history not recorded for the fast synced blocks, run gwan pos rebuild-incentive", "number", number)
+}
+
+// CheckHistory warns if the incentive history misses records: if blocks were
+// fast synced, or if the history of a former version is left in the pos
+// databases while db holds none, as it is no longer read. It returns whether
+// the history has to be rebuilt.
+func CheckHistory(db ethdb.Database) bool {
+	if data, _ := db.Get(historyGapKey); len(data) == 8 {
+		log.Warn("Incentive history missing for fast synced blocks, run gwan pos rebuild-incentive", "number", binary.BigEndian.Uint64(data))
+		return true
+	}
+	if readHistoryHead(db) != (common.Hash{}) || posconfig.Cfg().Dbpath == "" {
+		return false
+	}
+	dir := path.Join(posconfig.Cfg().Dbpath, "gwan", legacyHistoryDB)
+	if _, err := os.Stat(dir); err != nil {
+		return false
+	}
+	log.Warn("Incentive history of a former version found, run gwan pos rebuild-incentive to move it to the chain database", "dir", dir)
+	return true
+}
+
+// Record is the incentive of an epoch paid by a block, along with the totals
+// of the chain up to that block.
+type Record struct {
+	EpochID     uint64
+	Number      uint64      // number of the block which paid the incentive
+	Prev        common.Hash // previous block of the same chain which paid an incentive
+	Payments    [][]vm.ClientIncentive
+	Paid        *big.Int
+	Remain      *big.Int
+	TotalPaid   *big.Int
+	TotalRemain *big.Int
+	RunTimes    uint64
+}
+
+func recordKey(hash common.Hash) []byte {
+	return append(append([]byte{}, historyRecordPrefix...), hash[:]...)
+}
+
+func epochKey(epochID uint64) []byte {
+	enc := make([]byte, 8)
+	binary.BigEndian.PutUint64(enc, epochID)
+	return append(append([]byte{}, historyEpochPrefix...), enc...)
+}
+
+func epochBlockKey(epochID uint64, hash common.Hash) []byte {
+	return append(epochKey(epochID), hash[:]...)
+}
+
+// ReadRecord returns the incentive paid by a block, nil if it paid none.
+func ReadRecord(db ethdb.Database, hash common.Hash) *Record {
+	data, _ := db.Get(recordKey(hash))
+	if len(data) == 0 {
+		return nil
+	}
+	r := new(Record)
+	if err := rlp.DecodeBytes(data, r); err != nil {
+		log.Error("Invalid incentive record", "hash", hash, "err", err)
+		return nil
+	}
+	return r
+}
+
+func readHistoryHead(db ethdb.Database) common.Hash {
+	data, _ := db.Get(historyHeadKey)
+	return common.BytesToHash(data)
+}
+
+func writeHistoryHead(db ethdb.Putter, hash common.Hash) {
+	if err := db.Put(historyHeadKey, hash.Bytes()); err != nil {
+		log.Crit("Failed to store incentive history head", "err", err)
+	}
+}
+
+// readEpochBlocks returns the blocks which paid the incentive of an epoch, on
+// any chain.
+func readEpochBlocks(db ethdb.Database, epochID uint64) []common.Hash {
+	prefix := epochKey(epochID)
+	it := db.NewIteratorWithPrefix(prefix)
+	defer it.Release()
+
+	var hashes []common.Hash
+	for it.Next() {
+		hashes = append(hashes, common.BytesToHash(it.Key()[len(prefix):]))
+	}
+	return hashes
+}
+
+// paymentCacheLimit is the number of payments kept from the finalization of
+// the blocks which paid them to their writing.
+const paymentCacheLimit = 64
+
+// keptPayment is the incentive of an epoch paid by a finalized block.
+type keptPayment struct {
+	epochID uint64
+	payment *EpochPayment
+}
+
+var payments, _ = lru.New(paymentCacheLimit)
+
+// KeepPayment keeps the incentive of an epoch Run paid while a block got
+// finalized, until WriteHistory records it. The payment is keyed by the state
+// root of the block, which the seal of a mined block doesn't change, unlike
+// its hash.
+func KeepPayment(root common.Hash, epochID uint64, payment *EpochPayment) {
+	payments.Add(root, &keptPayment{epochID, payment})
+}
+
+// WriteHistory records the incentive paid by a block, if any, when the block
+// gets written along with its state. The record goes through w, keyed by the
+// block hash, the other records are read from db.
+func WriteHistory(db ethdb.Database, w ethdb.Putter, header *types.Header) {
+	kept, ok := payments.Get(header.Root)
+	if !ok {
+		return
+	}
+	p := kept.(*keptPayment)
+	writeRecord(db, w, header.Hash(), header.Number.Uint64(), p.epochID, p.payment)
+}
+
+// writeRecord stores the incentive of an epoch paid by a block. The totals
+// carry on from the last record of the canonical chain before the epoch; as
+// an epoch is longer than the deepest reorg allowed, it is also the last one
+// of the chain of the block.
+func writeRecord(db ethdb.Database, w ethdb.Putter, hash common.Hash, number uint64, epochID uint64, payment *EpochPayment) {
+	r := &Record{
+		EpochID:     epochID,
+		Number:      number,
+		Payments:    payment.Incentives,
+		Paid:        sumIncentive(payment.Incentives),
+		Remain:      new(big.Int).Set(payment.Remain),
+		TotalPaid:   new(big.Int),
+		TotalRemain: new(big.Int),
+	}
+	prev := readHistoryHead(db)
+	for prev != (common.Hash{}) {
+		prevRecord := ReadRecord(db, prev)
+		if prevRecord == nil {
+			break
+		}
+		if prevRecord.EpochID < epochID {
+			r.Prev = prev
+			r.TotalPaid.Set(prevRecord.TotalPaid)
+			r.TotalRemain.Set(prevRecord.TotalRemain)
+			r.RunTimes = prevRecord.RunTimes
+			break
+		}
+		prev = prevRecord.Prev
+	}
+	r.TotalPaid.Add(r.TotalPaid, r.Paid)
+	r.TotalRemain.Add(r.TotalRemain, r.Remain)
+	r.RunTimes++
+
+	data, err := rlp.EncodeToBytes(r)
+	if err != nil {
+		log.Crit("Failed to RLP encode incentive record", "err", err)
+	}
+	if err := w.Put(recordKey(hash), data); err != nil {
+		log.Crit("Failed to store incentive record", "err", err)
+	}
+	if err := w.Put(epochBlockKey(epochID, hash), nil); err != nil {
+		log.Crit("Failed to store incentive epoch index", "err", err)
+	}
+}
+
+// UpdateHistoryHead moves the head of the incentive history to a block which
+// became the head of the canonical chain, if it paid an incentive.
+func UpdateHistoryHead(db ethdb.Database, hash common.Hash) {
+	if ReadRecord(db, hash) != nil {
+		writeHistoryHead(db, hash)
+	}
+}
+
+// RollbackHistory moves the head of the incentive history from the blocks
+// dropped by a reorg to the ones which replaced them, oldest first.
+func RollbackHistory(db ethdb.Database, dropped []common.Hash, added []common.Hash) {
+	head := readHistoryHead(db)
+	orig := head
+
+	gone := make(map[common.Hash]bool, len(dropped))
+	for _, hash := range dropped {
+		gone[hash] = true
+	}
+	for gone[head] {
+		r := ReadRecord(db, head)
+		if r == nil {
+			break
+		}
+		head = r.Prev
+	}
+	for _, hash := range added {
+		if ReadRecord(db, hash) != nil {
+			head = hash
+		}
+	}
+	if head != orig {
+		log.Debug("Incentive history head moved by reorg", "from", orig, "to", head)
+		writeHistoryHead(db, head)
+	}
+}
+
+// HeaderReader is the part of the chain the incentive history checks the
+// records against.
+type HeaderReader interface {
+	GetHeaderByNumber(number uint64) *types.Header
+}
+
+// History reads the incentive history of the canonical chain.
+type History struct {
+	db    ethdb.Database
+	chain HeaderReader
+}
+
+// NewHistory returns the incentive history of chain stored in db.
+func NewHistory(db ethdb.Database, chain HeaderReader) *History {
+	return &History{db, chain}
+}
+
+func (h *History) canonical(hash common.Hash, number uint64) bool {
+	header := h.chain.GetHeaderByNumber(number)
+	return header != nil && header.Hash() == hash
+}
+
+// Epoch returns the incentive of an epoch paid by the canonical chain, nil if
+// it was not paid yet.
+func (h *History) Epoch(epochID uint64) *Record {
+	for _, hash := range readEpochBlocks(h.db, epochID) {
+		if r := ReadRecord(h.db, hash); r != nil && h.canonical(hash, r.Number) {
+			return r
+		}
+	}
+	return nil
+}
+
+// Head returns the last incentive paid by the canonical chain, nil if none.
+func (h *History) Head() *Record {
+	hash := readHistoryHead(h.db)
+	for hash != (common.Hash{}) {
+		r := ReadRecord(h.db, hash)
+		if r == nil {
+			return nil
+		}
+		if h.canonical(hash, r.Number) {
+			return r
+		}
+		hash = r.Prev
+	}
+	return nil
+}
+
+// GetEpochPayDetail use to get detail payment array
+func (h *History) GetEpochPayDetail(epochID uint64) ([][]vm.ClientIncentive, error) {
+	r := h.Epoch(epochID)
+	if r == nil {
+		return nil, errors.New("incentive of epoch not paid")
+	}
+	return r.Payments, nil
+}
+
+// GetTotalIncentive get total incentive of all epoch
+func (h *History) GetTotalIncentive() (*big.Int, error) {
+	if r := h.Head(); r != nil {
+		return r.TotalPaid, nil
+	}
+	return big.NewInt(0), nil
+}
+
+// GetEpochIncentive get total incentive of an epoch
+func (h *History) GetEpochIncentive(epochID uint64) (*big.Int, error) {
+	if r := h.Epoch(epochID); r != nil {
+		return r.Paid, nil
+	}
+	return big.NewInt(0), nil
+}
+
+// GetEpochIncentiveBlockNumber returns the number of the block which paid the
+// incentive of an epoch.
+func (h *History) GetEpochIncentiveBlockNumber(epochID uint64) (*big.Int, error) {
+	if r := h.Epoch(epochID); r != nil {
+		return new(big.Int).SetUint64(r.Number), nil
+	}
+	return big.NewInt(0), nil
+}
+
+// GetEpochRemain get remain of epoch input
+func (h *History) GetEpochRemain(epochID uint64) (*big.Int, error) {
+	if r := h.Epoch(epochID); r != nil {
+		return r.Remain, nil
+	}
+	return big.NewInt(0), nil
+}
+
+// GetTotalRemain get remain of all epoch
+func (h *History) GetTotalRemain() (*big.Int, error) {
+	if r := h.Head(); r != nil {
+		return r.TotalRemain, nil
+	}
+	return big.NewInt(0), nil
+}
+
+// GetRunTimes returns incentive run times
+func (h *History) GetRunTimes() (*big.Int, error) {
+	if r := h.Head(); r != nil {
+		return new(big.Int).SetUint64(r.RunTimes), nil
+	}
+	return big.NewInt(0), nil
+}
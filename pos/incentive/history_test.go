@@ -0,0 +1,203 @@
+package incentive
+
+import (
+	"encoding/binary"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/wanchain/go-wanchain/common"
+	"github.com/wanchain/go-wanchain/core/state"
+	"github.com/wanchain/go-wanchain/core/types"
+	"github.com/wanchain/go-wanchain/core/vm"
+	"github.com/wanchain/go-wanchain/ethdb"
+	"github.com/wanchain/go-wanchain/pos/posconfig"
+)
+
+// testHeaders is a chain of headers by number.
+type testHeaders map[uint64]*types.Header
+
+func (h testHeaders) GetHeaderByNumber(number uint64) *types.Header { return h[number] }
+
+// add makes a header of the given number and extra the canonical one and
+// returns its hash.
+func (h testHeaders) add(number uint64, extra byte) common.Hash {
+	h[number] = &types.Header{Number: new(big.Int).SetUint64(number), Extra: []byte{extra}}
+	return h[number].Hash()
+}
+
+func testPayment(remain int64, amounts ...int64) *EpochPayment {
+	generateTestAddrs()
+	group := make([]vm.ClientIncentive, len(amounts))
+	for i, amount := range amounts {
+		group[i] = vm.ClientIncentive{WalletAddr: epAddrs[i], Incentive: big.NewInt(amount)}
+	}
+	return &EpochPayment{Incentives: [][]vm.ClientIncentive{group}, Remain: big.NewInt(remain)}
+}
+
+// writePayment writes the incentive of an epoch paid by a block the way the
+// chain does.
+func writePayment(t *testing.T, db ethdb.Database, hash common.Hash, number, epochID uint64, payment *EpochPayment) {
+	batch := db.NewBatch()
+	writeRecord(db, batch, hash, number, epochID, payment)
+	if err := batch.Write(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestHistory(t *testing.T) {
+	db, _ := ethdb.NewMemDatabase()
+	headers := testHeaders{}
+	h := NewHistory(db, headers)
+
+	if total, err := h.GetTotalIncentive(); err != nil || total.Sign() != 0 {
+		t.Fatalf("empty history total mismatch: %v %v", total, err)
+	}
+	if _, err := h.GetEpochPayDetail(1); err == nil {
+		t.Fatal("pay detail of an unpaid epoch returned")
+	}
+
+	b10 := headers.add(10, 0)
+	writePayment(t, db, b10, 10, 1, testPayment(100, 100, 200))
+	UpdateHistoryHead(db, b10)
+	b20 := headers.add(20, 0)
+	writePayment(t, db, b20, 20, 2, testPayment(300, 300, 400, 800))
+	UpdateHistoryHead(db, b20)
+
+	detail, err := h.GetEpochPayDetail(2)
+	if err != nil || len(detail) != 1 || len(detail[0]) != 3 || detail[0][2].Incentive.Int64() != 800 {
+		t.Fatalf("pay detail mismatch: %v %v", detail, err)
+	}
+	if total, _ := h.GetTotalIncentive(); total.Int64() != 1800 {
+		t.Fatalf("total incentive mismatch: have %v, want 1800", total)
+	}
+	if total, _ := h.GetEpochIncentive(2); total.Int64() != 1500 {
+		t.Fatalf("epoch incentive mismatch: have %v, want 1500", total)
+	}
+	if remain, _ := h.GetEpochRemain(2); remain.Int64() != 300 {
+		t.Fatalf("epoch remain mismatch: have %v, want 300", remain)
+	}
+	if remain, _ := h.GetTotalRemain(); remain.Int64() != 400 {
+		t.Fatalf("total remain mismatch: have %v, want 400", remain)
+	}
+	if number, _ := h.GetEpochIncentiveBlockNumber(2); number.Uint64() != 20 {
+		t.Fatalf("block number mismatch: have %v, want 20", number)
+	}
+	if times, _ := h.GetRunTimes(); times.Int64() != 2 {
+		t.Fatalf("run times mismatch: have %v, want 2", times)
+	}
+
+	// A reorg replaces the block which paid epoch 2 by one paying less
+	delete(headers, 20)
+	b21 := headers.add(21, 1)
+	writePayment(t, db, b21, 21, 2, testPayment(0, 50))
+	RollbackHistory(db, []common.Hash{b20}, []common.Hash{b21})
+
+	if total, _ := h.GetTotalIncentive(); total.Int64() != 350 {
+		t.Fatalf("total incentive after reorg mismatch: have %v, want 350", total)
+	}
+	if remain, _ := h.GetTotalRemain(); remain.Int64() != 100 {
+		t.Fatalf("total remain after reorg mismatch: have %v, want 100", remain)
+	}
+	if number, _ := h.GetEpochIncentiveBlockNumber(2); number.Uint64() != 21 {
+		t.Fatalf("block number after reorg mismatch: have %v, want 21", number)
+	}
+	if times, _ := h.GetRunTimes(); times.Int64() != 2 {
+		t.Fatalf("run times after reorg mismatch: have %v, want 2", times)
+	}
+
+	// A reorg dropping the payment altogether falls back to epoch 1
+	delete(headers, 21)
+	RollbackHistory(db, []common.Hash{b21}, nil)
+	if total, _ := h.GetTotalIncentive(); total.Int64() != 300 {
+		t.Fatalf("total incentive after rollback mismatch: have %v, want 300", total)
+	}
+	if _, err := h.GetEpochPayDetail(2); err == nil {
+		t.Fatal("pay detail of a dropped block returned")
+	}
+}
+
+func TestWriteHistory(t *testing.T) {
+	posconfig.Init(nil, 4)
+	Init(getInfo, setInfo, testGetRBAddress)
+	TestSetActivityInterface(t)
+	TestSetStakerInterface(t)
+
+	memDb, _ := ethdb.NewMemDatabase()
+	blockState, _ := state.New(common.Hash{}, state.NewDatabase(memDb))
+	epochID := uint64(10)
+	parent := &types.Header{Number: big.NewInt(99), Difficulty: new(big.Int).SetUint64(epochID<<32 | 1<<8)}
+	header := &types.Header{
+		ParentHash: parent.Hash(),
+		Number:     big.NewInt(100),
+		Difficulty: new(big.Int).SetUint64(epochID<<32 | uint64(posconfig.SlotCount-1)<<8),
+	}
+
+	// The payment of the run is kept by the state root when finalizing the block
+	payment, ok := Run(&chainAt{&TestChainReader{}, parent}, blockState, epochID-posconfig.IncentiveDelayEpochs)
+	if !ok || payment == nil {
+		t.Fatal("incentive run failed")
+	}
+	header.Root = blockState.IntermediateRoot(true)
+	KeepPayment(header.Root, epochID-posconfig.IncentiveDelayEpochs, payment)
+
+	db, _ := ethdb.NewMemDatabase()
+	WriteHistory(db, db, header)
+	r := ReadRecord(db, header.Hash())
+	if r == nil {
+		t.Fatal("incentive not recorded")
+	}
+	if r.EpochID != epochID-posconfig.IncentiveDelayEpochs || r.Number != 100 || r.Paid.Cmp(sumIncentive(payment.Incentives)) != 0 {
+		t.Fatalf("record mismatch: epoch %d, number %d, paid %v", r.EpochID, r.Number, r.Paid)
+	}
+
+	// A second run of the epoch pays nothing
+	if payment, ok := Run(&chainAt{&TestChainReader{}, parent}, blockState, epochID-posconfig.IncentiveDelayEpochs); !ok || payment != nil {
+		t.Fatalf("second run mismatch: %v %v", payment, ok)
+	}
+
+	// A block which paid nothing is not recorded
+	db, _ = ethdb.NewMemDatabase()
+	header.Root = common.Hash{1}
+	WriteHistory(db, db, header)
+	if ReadRecord(db, header.Hash()) != nil {
+		t.Fatal("incentive recorded for a block which paid none")
+	}
+}
+
+func TestCheckHistory(t *testing.T) {
+	dir, err := ioutil.TempDir("", "incentive")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	defer func(dbpath string) { posconfig.Cfg().Dbpath = dbpath }(posconfig.Cfg().Dbpath)
+	posconfig.Cfg().Dbpath = dir
+
+	db, _ := ethdb.NewMemDatabase()
+	if CheckHistory(db) {
+		t.Fatal("rebuild asked without a former history")
+	}
+	if err := os.MkdirAll(filepath.Join(dir, "gwan", legacyHistoryDB), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if !CheckHistory(db) {
+		t.Fatal("rebuild not asked with a former history")
+	}
+	writeHistoryHead(db, common.Hash{1})
+	if CheckHistory(db) {
+		t.Fatal("rebuild asked with a history in the chain database")
+	}
+
+	// Fast synced blocks leave a gap until the history is rebuilt
+	MarkHistoryGap(db, 100)
+	MarkHistoryGap(db, 50)
+	if data, _ := db.Get(historyGapKey); binary.BigEndian.Uint64(data) != 100 {
+		t.Fatalf("gap mismatch: have %d, want 100", binary.BigEndian.Uint64(data))
+	}
+	if !CheckHistory(db) {
+		t.Fatal("rebuild not asked after a fast sync")
+	}
+}
@@ -47,41 +47,99 @@ func Init(get GetStakerInfoFn, set SetStakerInfoFn, getRbAddr GetRandomProposerA
 	setActivityInterface(getEpochLeaderActivity, getRandomProposerActivity, getSlotLeaderActivity)
 	setRBAddressInterface(getRbAddr)
 
-	initLocalDb(posconfig.IncentiveLocalDB)
 	log.Info("--------Incentive Init Finish----------")
 }
 
-// Run is use to run the incentive should be called in Finalize of consensus
-func Run(chain consensus.ChainReader, stateDb *state.StateDB, epochID uint64) bool {
+// Run is use to run the incentive should be called in Finalize of consensus.
+// It returns the payment made, nil if the incentive of the epoch was already
+// paid, along with whether the run succeeded.
+func Run(chain consensus.ChainReader, stateDb *state.StateDB, epochID uint64) (*EpochPayment, bool) {
 	if chain == nil || stateDb == nil {
 		log.SyslogErr("incentive Run input param error (chain == nil || stateDb == nil)")
-		return false
+		return nil, false
 	}
 
 	if isFinished(stateDb, epochID) || !openIncentive {
-		return true
+		return nil, true
+	}
+
+	payment, err := calculate(stateDb, epochID, collectActivity(chain, stateDb, epochID), getStakerInfo)
+	if err != nil {
+		log.SyslogErr("Incentive calculate error", "epochID", epochID, "error", err.Error())
+		return nil, false
 	}
+
+	saveIncentiveIncome(payment.Total, payment.Foundation, payment.GasPool)
+	saveIncentiveDivide(payment.EpochLeaderSubsidy, payment.RandomProposerSubsidy, payment.SlotLeaderSubsidy)
+	addRemainIncentivePool(stateDb, epochID, payment.Remain)
+
+	pay(payment.Incentives, stateDb)
+
+	setStakerInfo(epochID, payment.Incentives)
+
+	finished(stateDb, epochID)
+	return payment, true
+}
+
+// EpochPayment is the outcome of the incentive of an epoch.
+type EpochPayment struct {
+	Total                 *big.Int
+	Foundation            *big.Int
+	GasPool               *big.Int
+	EpochLeaderSubsidy    *big.Int
+	RandomProposerSubsidy *big.Int
+	SlotLeaderSubsidy     *big.Int
+	Incentives            [][]vm.ClientIncentive // one group per validator, the validator first
+	Remain                *big.Int               // the part of the total not paid, back to the pool
+}
+
+// epochActivity is the work done by the protocol participants of an epoch.
+type epochActivity struct {
+	epAddrs   []common.Address
+	epAct     []int
+	rpAddrs   []common.Address
+	rpAct     []int
+	slAddrs   []common.Address
+	slBlk     []int
+	slAct     float64
+	ctrlCount int
+}
+
+func collectActivity(chain consensus.ChainReader, stateDb *state.StateDB, epochID uint64) *epochActivity {
+	act := &epochActivity{}
+	act.epAddrs, act.epAct = getEpochLeaderInfo(stateDb, epochID)
+	log.Info("epoch addr", "len", len(act.epAddrs))
+	act.rpAddrs, act.rpAct = getRandomProposerInfo(stateDb, epochID)
+	log.Info("rp Addrs", "len", len(act.rpAddrs))
+
+	act.slAddrs, act.slBlk, act.slAct, act.ctrlCount = getSlotLeaderInfo(chain, epochID, posconfig.SlotCount)
+	log.Info("sl Addr ", "len", len(act.slAddrs), "slAct", act.slAct, "ctrlCount", act.ctrlCount)
+	log.Info("sl Blk ", "len", len(act.slBlk), "blks", act.slBlk)
+	return act
+}
+
+// calculate divides the incentive of an epoch between its protocol
+// participants according to their activity, and each participant's part
+// between its stakers as getStaker describes them. It does not modify stateDb.
+func calculate(stateDb *state.StateDB, epochID uint64, act *epochActivity, getStaker GetStakerInfoFn) (*EpochPayment, error) {
 	finalIncentive := make([][]vm.ClientIncentive, 0)
 	remainsAll := big.NewInt(0)
 
 	total, foundation, gasPool := calculateIncentivePool(stateDb, epochID)
-	saveIncentiveIncome(total, foundation, gasPool)
-
-	epAddrs, epAct := getEpochLeaderInfo(stateDb, epochID)
-	log.Info("epoch addr", "len", len(epAddrs))
-	rpAddrs, rpAct := getRandomProposerInfo(stateDb, epochID)
-	log.Info("rp Addrs", "len", len(rpAddrs))
-
-	slAddrs, slBlk, slAct, ctrlCount := getSlotLeaderInfo(chain, epochID, posconfig.SlotCount)
-	log.Info("sl Addr ", "len", len(slAddrs), "slAct", slAct, "ctrlCount", ctrlCount)
-	log.Info("sl Blk ", "len", len(slBlk), "blks", slBlk)
 
 	percentOfEpochLeader, percentOfRandomProposer, percentOfSlotLeader := calcIncentivePercent(stateDb, epochID)
 
 	epochLeaderSubsidy := calcPercent(total, float64(percentOfEpochLeader*100.0))
 	randomProposerSubsidy := calcPercent(total, float64(percentOfRandomProposer*100.0))
 	slotLeaderSubsidy := calcPercent(total, float64(percentOfSlotLeader*100.0))
-	saveIncentiveDivide(epochLeaderSubsidy, randomProposerSubsidy, slotLeaderSubsidy)
+	payment := &EpochPayment{
+		Total:                 total,
+		Foundation:            foundation,
+		GasPool:               gasPool,
+		EpochLeaderSubsidy:    new(big.Int).Set(epochLeaderSubsidy),
+		RandomProposerSubsidy: new(big.Int).Set(randomProposerSubsidy),
+		SlotLeaderSubsidy:     new(big.Int).Set(slotLeaderSubsidy),
+	}
 
 	sum := big.NewInt(0)
 	sum.Add(sum, epochLeaderSubsidy)
@@ -90,10 +148,10 @@ func Run(chain consensus.ChainReader, stateDb *state.StateDB, epochID uint64) bo
 	sumRemain := big.NewInt(0).Sub(total, sum)
 	remainsAll.Add(remainsAll, sumRemain)
 
-	incentives, remains, err := epochLeaderAllocate(epochLeaderSubsidy, epAddrs, epAct, epochID)
+	incentives, remains, err := epochLeaderAllocate(getStaker, epochLeaderSubsidy, act.epAddrs, act.epAct, epochID)
 	if err != nil {
-		log.SyslogErr("Incentive epochLeaderAllocate error", "error", err.Error(), "epochLeaderSubsidy", epochLeaderSubsidy.String(), "epAddrs", epAddrs)
-		return false
+		log.SyslogErr("Incentive epochLeaderAllocate error", "error", err.Error(), "epochLeaderSubsidy", epochLeaderSubsidy.String(), "epAddrs", act.epAddrs)
+		return nil, err
 	}
 
 	if incentives != nil {
@@ -105,10 +163,10 @@ func Run(chain consensus.ChainReader, stateDb *state.StateDB, epochID uint64) bo
 
 	remainsAll.Add(remainsAll, remains)
 
-	incentives, remains, err = randomProposerAllocate(randomProposerSubsidy, rpAddrs, rpAct, epochID)
+	incentives, remains, err = randomProposerAllocate(getStaker, randomProposerSubsidy, act.rpAddrs, act.rpAct, epochID)
 	if err != nil {
-		log.SyslogErr("Incentive randomProposerAllocate error", "error", err.Error(), "randomProposerSubsidy", randomProposerSubsidy.String(), "rpAddrs", rpAddrs)
-		return false
+		log.SyslogErr("Incentive randomProposerAllocate error", "error", err.Error(), "randomProposerSubsidy", randomProposerSubsidy.String(), "rpAddrs", act.rpAddrs)
+		return nil, err
 	}
 
 	if incentives != nil {
@@ -120,10 +178,10 @@ func Run(chain consensus.ChainReader, stateDb *state.StateDB, epochID uint64) bo
 
 	remainsAll.Add(remainsAll, remains)
 
-	incentives, remains, err = slotLeaderAllocate(slotLeaderSubsidy, slAddrs, slBlk, slAct, posconfig.SlotCount-ctrlCount, epochID)
+	incentives, remains, err = slotLeaderAllocate(getStaker, slotLeaderSubsidy, act.slAddrs, act.slBlk, act.slAct, posconfig.SlotCount-act.ctrlCount, epochID)
 	if err != nil {
-		log.SyslogErr("Incentive slotLeaderAllocate error", "slotLeaderSubsidy", slotLeaderSubsidy.String(), "slAddrs", slAddrs)
-		return false
+		log.SyslogErr("Incentive slotLeaderAllocate error", "slotLeaderSubsidy", slotLeaderSubsidy.String(), "slAddrs", act.slAddrs)
+		return nil, err
 	}
 
 	if incentives != nil {
@@ -140,20 +198,11 @@ func Run(chain consensus.ChainReader, stateDb *state.StateDB, epochID uint64) bo
 	remainsAll.Add(remainsAll, extraRemain)
 	if !checkTotalValue(total, sumPay, remainsAll) {
 		log.SyslogErr("Incentive checkTotalValue error", "sumPay", sumPay.String(), "remainsAll", remainsAll.String(), "total", total.String())
-		return false
+		return nil, errors.New("incentive payout above the total")
 	}
 
-	addRemainIncentivePool(stateDb, epochID, remainsAll)
-	saveRemain(epochID, remainsAll)
-
-	pay(finalIncentive, stateDb)
-
-	setStakerInfo(epochID, finalIncentive)
-	saveIncentiveHistory(epochID, finalIncentive)
-	localDbSetValue(epochID, dictEpochBlock, chain.CurrentHeader().Number)
-
-	finished(stateDb, epochID)
-	return true
+	payment.Incentives, payment.Remain = finalIncentive, remainsAll
+	return payment, nil
 }
 
 func getIncentivePrecompileAddress() common.Address {
@@ -173,12 +222,17 @@ func isFinished(stateDb *state.StateDB, epochID uint64) bool {
 	return true
 }
 
+// IsPaid returns whether the incentive of epochID is paid in stateDb.
+func IsPaid(stateDb *state.StateDB, epochID uint64) bool {
+	return isFinished(stateDb, epochID)
+}
+
 func finished(stateDb *state.StateDB, epochID uint64) {
 	stateDb.SetStateByteArray(getIncentivePrecompileAddress(), getRunFlagKey(epochID), []byte(dictFinished))
 }
 
 // protocalRunerAllocate use to calc the subsidy of protocal Participant (Epoch leader and Random proposer)
-func protocalRunerAllocate(funds *big.Int, addrs []common.Address, acts []int,
+func protocalRunerAllocate(getStaker GetStakerInfoFn, funds *big.Int, addrs []common.Address, acts []int,
 	epochID uint64) ([][]vm.ClientIncentive, *big.Int, error) {
 	remains := big.NewInt(0)
 
@@ -212,7 +266,7 @@ func protocalRunerAllocate(funds *big.Int, addrs []common.Address, acts []int,
 		}
 	}
 
-	finalIncentive, subRemain, err := delegate(fundAddrs, fundValues, epochID)
+	finalIncentive, subRemain, err := delegate(getStaker, fundAddrs, fundValues, epochID)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -222,20 +276,20 @@ func protocalRunerAllocate(funds *big.Int, addrs []common.Address, acts []int,
 }
 
 // epochLeaderAllocate input funds, address and activity returns address and its amount allocate and remaining funds.
-func epochLeaderAllocate(funds *big.Int, addrs []common.Address, acts []int,
+func epochLeaderAllocate(getStaker GetStakerInfoFn, funds *big.Int, addrs []common.Address, acts []int,
 	epochID uint64) ([][]vm.ClientIncentive, *big.Int, error) {
-	return protocalRunerAllocate(funds, addrs, acts, epochID)
+	return protocalRunerAllocate(getStaker, funds, addrs, acts, epochID)
 }
 
 //randomProposerAllocate input funds, address and activity returns address and its amount allocate and remaining funds.
-func randomProposerAllocate(funds *big.Int, addrs []common.Address, acts []int,
+func randomProposerAllocate(getStaker GetStakerInfoFn, funds *big.Int, addrs []common.Address, acts []int,
 	epochID uint64) ([][]vm.ClientIncentive, *big.Int, error) {
-	return protocalRunerAllocate(funds, addrs, acts, epochID)
+	return protocalRunerAllocate(getStaker, funds, addrs, acts, epochID)
 }
 
 //slotLeaderAllocate input funds, address, blocks and activity returns address and its amount allocate and remaining funds.
 //slotCount is the slot count ctrled by others not foundation.
-func slotLeaderAllocate(funds *big.Int, addrs []common.Address, blocks []int,
+func slotLeaderAllocate(getStaker GetStakerInfoFn, funds *big.Int, addrs []common.Address, blocks []int,
 	act float64, slotCount int, epochID uint64) ([][]vm.ClientIncentive, *big.Int, error) {
 	remains := big.NewInt(0)
 
@@ -265,7 +319,7 @@ func slotLeaderAllocate(funds *big.Int, addrs []common.Address, blocks []int,
 		fundValues = append(fundValues, big.NewInt(0).Mul(incentiveActive, big.NewInt(int64(blocks[i]))))
 	}
 
-	finalIncentive, subRemain, err := delegate(fundAddrs, fundValues, epochID-1)
+	finalIncentive, subRemain, err := delegate(getStaker, fundAddrs, fundValues, epochID-1)
 	if err != nil {
 		return nil, nil, err
 	}
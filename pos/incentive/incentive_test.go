@@ -28,7 +28,7 @@ func TestRun(t *testing.T) {
 
 	for i := 0; i < testTimes; i++ {
 		for m := 0; m < posconfig.SlotCount; m++ {
-			if !Run(&TestChainReader{}, statedb, uint64(i)) {
+			if _, ok := Run(&TestChainReader{}, statedb, uint64(i)); !ok {
 				t.FailNow()
 			}
 		}
@@ -55,7 +55,7 @@ func TestRun(t *testing.T) {
 }
 
 func TestRunFail(t *testing.T) {
-	if Run(nil, nil, 0) {
+	if _, ok := Run(nil, nil, 0); ok {
 		t.FailNow()
 	}
 }
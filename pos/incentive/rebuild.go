@@ -0,0 +1,149 @@
+package incentive
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/wanchain/go-wanchain/common"
+	"github.com/wanchain/go-wanchain/consensus"
+	"github.com/wanchain/go-wanchain/core/state"
+	"github.com/wanchain/go-wanchain/core/types"
+	"github.com/wanchain/go-wanchain/ethdb"
+	"github.com/wanchain/go-wanchain/log"
+	"github.com/wanchain/go-wanchain/pos/posconfig"
+	"github.com/wanchain/go-wanchain/pos/util"
+)
+
+// RunsAt returns whether the block of a slot runs the incentive of the epoch
+// IncentiveDelayEpochs before, unless an earlier block of the epoch did.
+func RunsAt(epochID, slotID uint64) bool {
+	return posconfig.FirstEpochId != 0 && epochID > posconfig.FirstEpochId+2 &&
+		epochID >= posconfig.IncentiveDelayEpochs && slotID > posconfig.IncentiveStartStage
+}
+
+// RebuildChain is the chain the incentive history is rebuilt from.
+type RebuildChain interface {
+	consensus.ChainReader
+	StateAt(root common.Hash) (*state.StateDB, error)
+}
+
+// StakersFn returns the stakers of an epoch, with their fee rates as of
+// feeRateState, the state the incentive of the epoch was paid into.
+type StakersFn func(epochID uint64, feeRateState *state.StateDB) (GetStakerInfoFn, error)
+
+// chainAt is a chain whose current header is head.
+type chainAt struct {
+	consensus.ChainReader
+	head *types.Header
+}
+
+func (c *chainAt) CurrentHeader() *types.Header { return c.head }
+
+// errStateMissing is returned by blockPayment for a block without state.
+var errStateMissing = errors.New("state missing")
+
+// Rebuild regenerates the incentive history of the canonical chain of
+// chain into db, and returns the number of epochs recorded. Each incentive
+// is computed again from the states of the block which paid it and of its
+// parent, so those states must be available, which a fast sync doesn't
+// download. The other blocks without state are skipped, as left by
+// gwan snapshot prune-state. The former history is dropped, along with the
+// records of the blocks reorganised away.
+func Rebuild(chain RebuildChain, db ethdb.Database, stakers StakersFn) (int, error) {
+	if getEpochLeaderInfo == nil {
+		return 0, errors.New("incentive not initialized")
+	}
+	for _, prefix := range [][]byte{historyRecordPrefix, historyEpochPrefix} {
+		if err := db.DeleteRange(ethdb.PrefixRange(prefix)); err != nil {
+			return 0, err
+		}
+	}
+	if err := db.Delete(historyHeadKey); err != nil {
+		return 0, err
+	}
+
+	head := chain.CurrentHeader()
+	headState, err := chain.StateAt(head.Root)
+	if err != nil {
+		return 0, fmt.Errorf("state of the head block missing: %v", err)
+	}
+	var (
+		count int
+		paid  = make(map[uint64]bool)
+	)
+	for number := util.FirstPosBlockNumber() + 1; number <= head.Number.Uint64(); number++ {
+		header := chain.GetHeaderByNumber(number)
+		if header == nil {
+			return count, fmt.Errorf("block %d missing", number)
+		}
+		if epochID, slotID := util.GetEpochSlotIDFromDifficulty(header.Difficulty); !RunsAt(epochID, slotID) || paid[epochID-posconfig.IncentiveDelayEpochs] {
+			continue
+		}
+		epochID, payment, err := blockPayment(chain, header, stakers)
+		if err == errStateMissing {
+			continue
+		}
+		if err != nil {
+			return count, err
+		}
+		if payment == nil {
+			continue
+		}
+		writeRecord(db, db, header.Hash(), number, epochID, payment)
+		writeHistoryHead(db, header.Hash())
+		paid[epochID] = true
+		count++
+		log.Info("Rebuilt incentive of epoch", "epochID", epochID, "number", number)
+	}
+	// An incentive paid by the chain but not found had its block skipped
+	headEpoch, _ := util.GetEpochSlotIDFromDifficulty(head.Difficulty)
+	for epochID := posconfig.FirstEpochId; epochID+posconfig.IncentiveDelayEpochs <= headEpoch; epochID++ {
+		if !paid[epochID] && isFinished(headState, epochID) {
+			return count, fmt.Errorf("incentive of epoch %d: state of the block which paid it missing, pruned or not synced", epochID)
+		}
+	}
+	return count, db.Delete(historyGapKey)
+}
+
+// blockPayment computes again the incentive paid by the block of header and
+// the epoch it belongs to, from the state of its parent. The payment is nil if
+// the block paid no incentive.
+func blockPayment(chain RebuildChain, header *types.Header, stakers StakersFn) (uint64, *EpochPayment, error) {
+	epochID, slotID := util.GetEpochSlotIDFromDifficulty(header.Difficulty)
+	if !RunsAt(epochID, slotID) {
+		return 0, nil, nil
+	}
+	epochID -= posconfig.IncentiveDelayEpochs
+	number := header.Number.Uint64()
+
+	blockState, err := chain.StateAt(header.Root)
+	if err != nil {
+		return 0, nil, errStateMissing
+	}
+	if !isFinished(blockState, epochID) {
+		// The run of the block failed, the next block retries it
+		return 0, nil, nil
+	}
+	parent := chain.GetHeader(header.ParentHash, number-1)
+	if parent == nil {
+		return 0, nil, fmt.Errorf("parent of block %d missing", number)
+	}
+	parentState, err := chain.StateAt(parent.Root)
+	if err != nil {
+		return 0, nil, fmt.Errorf("state of block %d missing: %v", number-1, err)
+	}
+	if isFinished(parentState, epochID) {
+		return 0, nil, nil
+	}
+
+	getStaker, err := stakers(epochID, parentState)
+	if err != nil {
+		return 0, nil, fmt.Errorf("stakers of epoch %d: %v", epochID, err)
+	}
+	act := collectActivity(&chainAt{chain, parent}, parentState, epochID)
+	payment, err := calculate(parentState, epochID, act, getStaker)
+	if err != nil {
+		return 0, nil, fmt.Errorf("incentive of epoch %d: %v", epochID, err)
+	}
+	return epochID, payment, nil
+}
@@ -0,0 +1,154 @@
+package incentive
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/wanchain/go-wanchain/common"
+	"github.com/wanchain/go-wanchain/consensus"
+	"github.com/wanchain/go-wanchain/core/state"
+	"github.com/wanchain/go-wanchain/core/vm"
+	"github.com/wanchain/go-wanchain/pos/posconfig"
+	"github.com/wanchain/go-wanchain/rlp"
+)
+
+// Delegation is a delegation added by a simulation.
+type Delegation struct {
+	Validator common.Address
+	Delegator common.Address
+	Amount    *big.Int
+}
+
+// Overrides are the changes a simulation makes to an epoch.
+type Overrides struct {
+	FeeRates    map[common.Address]uint64 // fee rates of validators, 10000 is 100%
+	Delegations []Delegation
+	Inactive    []common.Address // validators assumed to miss all their work in the epoch
+}
+
+// Simulate runs the incentive of an epoch against stateDb with the given
+// overrides and returns the payment it would make, without paying it or
+// recording anything. The overrides may be nil.
+func Simulate(chain consensus.ChainReader, stateDb *state.StateDB, epochID uint64, overrides *Overrides) (*EpochPayment, error) {
+	if chain == nil || stateDb == nil {
+		return nil, errors.New("incentive simulation needs a chain and a state")
+	}
+	if getStakerInfo == nil || getEpochLeaderInfo == nil {
+		return nil, errors.New("incentive not initialized")
+	}
+	if overrides == nil {
+		overrides = &Overrides{}
+	}
+	for addr, feeRate := range overrides.FeeRates {
+		if feeRate > 10000 {
+			return nil, fmt.Errorf("fee rate %d of %s above 10000", feeRate, addr.Hex())
+		}
+	}
+	for _, d := range overrides.Delegations {
+		if d.Amount == nil || d.Amount.Sign() <= 0 {
+			return nil, fmt.Errorf("delegation of %s to %s without amount", d.Delegator.Hex(), d.Validator.Hex())
+		}
+	}
+
+	act := collectActivity(chain, stateDb, epochID)
+	overrides.applyActivity(act)
+	return calculate(stateDb, epochID, act, overrides.stakerInfo(stateDb, getStakerInfo))
+}
+
+// applyActivity clears the work of the inactive validators. Their slots are
+// left empty, which lowers the slot leader activity of the epoch.
+func (o *Overrides) applyActivity(act *epochActivity) {
+	if len(o.Inactive) == 0 {
+		return
+	}
+	for i := range act.epAddrs {
+		if addressInclude(act.epAddrs[i], o.Inactive) {
+			act.epAct[i] = 0
+		}
+	}
+	for i := range act.rpAddrs {
+		if addressInclude(act.rpAddrs[i], o.Inactive) {
+			act.rpAct[i] = 0
+		}
+	}
+
+	slAddrs, slBlk := make([]common.Address, 0), make([]int, 0)
+	for i := range act.slAddrs {
+		if !addressInclude(act.slAddrs[i], o.Inactive) {
+			slAddrs, slBlk = append(slAddrs, act.slAddrs[i]), append(slBlk, act.slBlk[i])
+		}
+	}
+	epochBlockCnt := sumIntArray(slBlk) + act.ctrlCount
+	if epochBlockCnt > posconfig.SlotCount {
+		epochBlockCnt = posconfig.SlotCount
+	}
+	act.slAddrs, act.slBlk = slAddrs, slBlk
+	act.slAct = float64(epochBlockCnt) / float64(posconfig.SlotCount)
+}
+
+// stakerInfo returns get with the fee rates and delegations overridden. The
+// delegations added are weighted by the lock epochs of their validator in
+// stateDb.
+func (o *Overrides) stakerInfo(stateDb *state.StateDB, get GetStakerInfoFn) GetStakerInfoFn {
+	return func(epochID uint64, addr common.Address) (*vm.ValidatorInfo, error) {
+		validator, err := get(epochID, addr)
+		if err != nil {
+			return nil, err
+		}
+		info := *validator
+		info.Infos = make([]vm.ClientProbability, len(validator.Infos))
+		for i, client := range validator.Infos {
+			info.Infos[i] = client
+			info.Infos[i].Probability = new(big.Int).Set(client.Probability)
+		}
+		if validator.TotalProbability != nil {
+			info.TotalProbability = new(big.Int).Set(validator.TotalProbability)
+		}
+
+		if feeRate, ok := o.FeeRates[addr]; ok {
+			info.FeeRate = feeRate
+		}
+		weight := lockWeight(stateDb, addr)
+		for _, d := range o.Delegations {
+			if d.Validator != addr || len(info.Infos) == 0 {
+				continue
+			}
+			probability := new(big.Int).Mul(d.Amount, weight)
+			if info.TotalProbability != nil {
+				info.TotalProbability.Add(info.TotalProbability, probability)
+			}
+			found := false
+			for i := 1; i < len(info.Infos); i++ {
+				if info.Infos[i].WalletAddr == d.Delegator {
+					info.Infos[i].Probability.Add(info.Infos[i].Probability, probability)
+					found = true
+					break
+				}
+			}
+			if !found {
+				info.Infos = append(info.Infos, vm.ClientProbability{
+					ValidatorAddr: addr,
+					WalletAddr:    d.Delegator,
+					Probability:   probability,
+				})
+			}
+		}
+		return &info, nil
+	}
+}
+
+// lockWeight returns the weight of the stake locked with validator addr, from
+// its lock epochs in stateDb. An unknown validator gets the weight of the
+// minimum lock.
+func lockWeight(stateDb *state.StateDB, addr common.Address) *big.Int {
+	lockEpochs := uint64(vm.PSMinEpochNum)
+	data, err := vm.GetInfo(stateDb, vm.StakersInfoAddr, vm.GetStakeInKeyHash(addr))
+	if err == nil && len(data) > 0 {
+		var staker vm.StakerInfo
+		if err := rlp.DecodeBytes(data, &staker); err == nil {
+			lockEpochs = staker.LockEpochs
+		}
+	}
+	return big.NewInt(int64(vm.CalLocktimeWeight(lockEpochs)))
+}
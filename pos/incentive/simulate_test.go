@@ -0,0 +1,93 @@
+package incentive
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/wanchain/go-wanchain/common"
+	"github.com/wanchain/go-wanchain/core/vm"
+	"github.com/wanchain/go-wanchain/rlp"
+)
+
+// paidTo sums the incentive a simulation pays to a wallet.
+func paidTo(payment *EpochPayment, addr common.Address) *big.Int {
+	sum := big.NewInt(0)
+	for _, group := range payment.Incentives {
+		for _, c := range group {
+			if c.WalletAddr == addr {
+				sum.Add(sum, c.Incentive)
+			}
+		}
+	}
+	return sum
+}
+
+func TestSimulate(t *testing.T) {
+	TestSetActivityInterface(t)
+	TestSetStakerInterface(t)
+	epochID := uint64(5)
+	chain := &TestChainReader{}
+
+	balance := statedb.GetBalance(epAddrs[0])
+	base, err := Simulate(chain, statedb, epochID, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if isFinished(statedb, epochID) || statedb.GetBalance(epAddrs[0]).Cmp(balance) != 0 {
+		t.Fatal("simulation modified the state")
+	}
+	if sum := new(big.Int).Add(sumToPay(base.Incentives), base.Remain); sum.Cmp(base.Total) != 0 {
+		t.Fatalf("payout and remain %v do not add up to the total %v", sum, base.Total)
+	}
+
+	// A higher fee rate moves incentive from the delegators to the validator
+	delegator := delegateStakerMap[epAddrs[0]][1]
+	sim, err := Simulate(chain, statedb, epochID, &Overrides{FeeRates: map[common.Address]uint64{epAddrs[0]: 2000}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if paidTo(sim, epAddrs[0]).Cmp(paidTo(base, epAddrs[0])) <= 0 || paidTo(sim, delegator).Cmp(paidTo(base, delegator)) >= 0 {
+		t.Fatal("fee rate override not applied")
+	}
+
+	// An added delegation takes a part of the incentive of its validator
+	newDelegator := common.Address{0xde}
+	sim, err = Simulate(chain, statedb, epochID, &Overrides{Delegations: []Delegation{{epAddrs[1], newDelegator, big.NewInt(1000)}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if paidTo(sim, newDelegator).Sign() <= 0 || paidTo(sim, epAddrs[1]).Cmp(paidTo(base, epAddrs[1])) >= 0 {
+		t.Fatal("added delegation not applied")
+	}
+
+	// An inactive validator is paid nothing, its part stays in the pool
+	sim, err = Simulate(chain, statedb, epochID, &Overrides{Inactive: []common.Address{epAddrs[2]}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if paidTo(sim, epAddrs[2]).Sign() != 0 || sim.Remain.Cmp(base.Remain) <= 0 {
+		t.Fatal("activity miss not applied")
+	}
+
+	if _, err := Simulate(chain, statedb, epochID, &Overrides{FeeRates: map[common.Address]uint64{epAddrs[0]: 10001}}); err == nil {
+		t.Fatal("fee rate above 100% accepted")
+	}
+}
+
+func TestLockWeight(t *testing.T) {
+	TestSetStakerInterface(t)
+	validator := common.Address{0x10}
+	if have, want := lockWeight(statedb, validator).Uint64(), vm.CalLocktimeWeight(vm.PSMinEpochNum); have != want {
+		t.Fatalf("unknown validator weight mismatch: have %d, want %d", have, want)
+	}
+	data, err := rlp.EncodeToBytes(&vm.StakerInfo{Address: validator, Amount: big.NewInt(1), LockEpochs: 60})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := vm.StoreInfo(statedb, vm.StakersInfoAddr, vm.GetStakeInKeyHash(validator), data); err != nil {
+		t.Fatal(err)
+	}
+	if have, want := lockWeight(statedb, validator).Uint64(), vm.CalLocktimeWeight(60); have != want {
+		t.Fatalf("validator weight mismatch: have %d, want %d", have, want)
+	}
+}
@@ -1,6 +1,7 @@
 package posapi
 
 import (
+	"bytes"
 	"encoding/hex"
 	"fmt"
 	"sort"
@@ -13,6 +14,7 @@ import (
 
 	"github.com/wanchain/go-wanchain/params"
 
+	"github.com/wanchain/go-wanchain/common/hexutil"
 	"github.com/wanchain/go-wanchain/common/math"
 	"github.com/wanchain/go-wanchain/rlp"
 
@@ -27,13 +29,16 @@ import (
 
 	"encoding/binary"
 
+	"github.com/wanchain/go-wanchain/core"
 	"github.com/wanchain/go-wanchain/core/vm"
 	"github.com/wanchain/go-wanchain/crypto"
 	"github.com/wanchain/go-wanchain/internal/ethapi"
 	"github.com/wanchain/go-wanchain/pos/epochLeader"
 	"github.com/wanchain/go-wanchain/pos/posconfig"
 	"github.com/wanchain/go-wanchain/pos/posdb"
+	"github.com/wanchain/go-wanchain/pos/randombeacon"
 	"github.com/wanchain/go-wanchain/pos/slotleader"
+	"github.com/wanchain/go-wanchain/pos/staking"
 	"github.com/wanchain/go-wanchain/rpc"
 )
 
@@ -41,6 +46,9 @@ var (
 	maxUint64 = uint64(1<<64 - 1)
 )
 
+// maxReorgEventEpochs is the largest epoch range GetReorgEvents serves.
+const maxReorgEventEpochs = 1000
+
 type PosChainReader interface {
 	// Config retrieves the blockchain's chain configuration.
 	Config() *params.ChainConfig
@@ -269,6 +277,36 @@ func (a PosApi) GetRandom(epochId uint64, blockNr int64) (*big.Int, error) {
 	return r, nil
 }
 
+// GetRandomProof returns the random number of an epoch with the data of the
+// random proposers it was generated from, at the state of block blockNr.
+func (a PosApi) GetRandomProof(epochId uint64, blockNr int64) (*RandomProof, error) {
+	if !isPosStage() {
+		return nil, nil
+	}
+
+	if blockNr > a.chain.CurrentHeader().Number.Int64() {
+		blockNr = -1
+	}
+
+	epID, _ := util.CalEpSlbyTd(a.chain.CurrentHeader().Difficulty.Uint64())
+
+	if epochId > epID {
+		return nil, errors.New("wrong epochId (It hasn't arrived yet.):" + convert.Uint64ToString(epochId))
+	}
+
+	state, _, err := a.backend.StateAndHeaderByNumber(context.Background(), rpc.BlockNumber(blockNr))
+	if err != nil {
+		return nil, err
+	}
+
+	proof, err := vm.GetRandomProof(state, epochId)
+	if err != nil {
+		return nil, err
+	}
+
+	return newRandomProof(proof, uint64(posconfig.Cfg().PolymDegree)), nil
+}
+
 func (a PosApi) GetChainQuality(epochid uint64, slotid uint64) (uint64, error) {
 	if !isPosStage() {
 		return 1000, nil
@@ -302,6 +340,26 @@ func (a PosApi) GetReorgState(epochid uint64) ([]uint64, error) {
 	return []uint64{reOrgNum, reOrgLen}, nil
 }
 
+// GetReorgEvents returns the reorgs this node went through from epoch
+// fromEpoch to toEpoch included, oldest first.
+func (a PosApi) GetReorgEvents(fromEpoch uint64, toEpoch uint64) ([]*core.ReorgEvent, error) {
+	if toEpoch < fromEpoch {
+		return nil, fmt.Errorf("epoch range %d-%d is empty", fromEpoch, toEpoch)
+	}
+	if toEpoch-fromEpoch >= maxReorgEventEpochs {
+		return nil, fmt.Errorf("epoch range above the maximum of %d epochs", maxReorgEventEpochs)
+	}
+	events := make([]*core.ReorgEvent, 0)
+	reOrgDb := posdb.GetDbByName(posconfig.ReorgLocalDB)
+	if !isPosStage() || reOrgDb == nil {
+		return events, nil
+	}
+	for epochId := fromEpoch; epochId <= toEpoch; epochId++ {
+		events = append(events, core.ReadReorgEvents(reOrgDb, epochId)...)
+	}
+	return events, nil
+}
+
 func (a PosApi) GetRbSignatureCount(epochId uint64, blockNr int64) (int, error) {
 	if !isPosStage() {
 		return 0, nil
@@ -377,29 +435,47 @@ func (a PosApi) GetStakerInfo(targetBlkNum uint64) ([]*StakerJson, error) {
 			log.SyslogErr(err.Error())
 			return true
 		}
-		stakeJson := ToStakerJson(&staker)
-		// add NextFeeRate MaxFeeRate
-		keyFee := vm.GetStakeInKeyHash(staker.Address)
-		newFeeBytes, err := vm.GetInfo(stateDb, vm.StakersFeeAddr, keyFee)
-		if err == nil && newFeeBytes != nil {
-			var newFee vm.UpdateFeeRate
-			err = rlp.DecodeBytes(newFeeBytes, &newFee)
-			if err == nil {
-				stakeJson.MaxFeeRate = newFee.MaxFeeRate
-				stakeJson.FeeRateChangedEpoch = newFee.ChangedEpoch
-			} else {
-				stakeJson.MaxFeeRate = staker.FeeRate
-				stakeJson.FeeRateChangedEpoch = 0
-			}
+		stakers = append(stakers, toStakerJsonAt(stateDb, &staker))
+		return true
+	})
+	return stakers, nil
+}
+
+// toStakerJsonAt converts a validator, with its fee rates in stateDb.
+func toStakerJsonAt(stateDb vm.StateDB, staker *vm.StakerInfo) *StakerJson {
+	stakeJson := ToStakerJson(staker)
+	// add NextFeeRate MaxFeeRate
+	keyFee := vm.GetStakeInKeyHash(staker.Address)
+	newFeeBytes, err := vm.GetInfo(stateDb, vm.StakersFeeAddr, keyFee)
+	if err == nil && newFeeBytes != nil {
+		var newFee vm.UpdateFeeRate
+		err = rlp.DecodeBytes(newFeeBytes, &newFee)
+		if err == nil {
+			stakeJson.MaxFeeRate = newFee.MaxFeeRate
+			stakeJson.FeeRateChangedEpoch = newFee.ChangedEpoch
 		} else {
 			stakeJson.MaxFeeRate = staker.FeeRate
 			stakeJson.FeeRateChangedEpoch = 0
 		}
+	} else {
+		stakeJson.MaxFeeRate = staker.FeeRate
+		stakeJson.FeeRateChangedEpoch = 0
+	}
+	return stakeJson
+}
 
-		stakers = append(stakers, stakeJson)
-		return true
-	})
-	return stakers, nil
+// stakerJsonAt returns the validator addr in stateDb, or nil if it is not
+// registered.
+func stakerJsonAt(stateDb vm.StateDB, addr common.Address) (*StakerJson, error) {
+	stakerBytes, err := vm.GetInfo(stateDb, vm.StakersInfoAddr, vm.GetStakeInKeyHash(addr))
+	if err != nil || len(stakerBytes) == 0 {
+		return nil, err
+	}
+	var staker vm.StakerInfo
+	if err := rlp.DecodeBytes(stakerBytes, &staker); err != nil {
+		return nil, err
+	}
+	return toStakerJsonAt(stateDb, &staker), nil
 }
 
 func isPosStage() bool {
@@ -468,15 +544,26 @@ func biToString(value *big.Int, err error) (string, error) {
 	return value.String(), err
 }
 
+// incentiveHistory returns the incentive history of the canonical chain.
+func (a PosApi) incentiveHistory() *incentive.History {
+	return incentive.NewHistory(a.backend.ChainDb(), a.chain)
+}
+
 func (a PosApi) GetEpochIncentivePayDetail(epochID uint64) ([]ValidatorInfo, error) {
 	if !isPosStage() {
 		return nil, nil
 	}
-	c, err := incentive.GetEpochPayDetail(epochID)
+	c, err := a.incentiveHistory().GetEpochPayDetail(epochID)
 	if err != nil {
 		return []ValidatorInfo{}, nil
 	}
 
+	return toValidatorInfos(c), nil
+}
+
+// toValidatorInfos converts the payments of the validators of an epoch and of
+// their delegators.
+func toValidatorInfos(c [][]vm.ClientIncentive) []ValidatorInfo {
 	ret := make([]ValidatorInfo, len(c))
 	for i := 0; i < len(c); i++ {
 		if len(c[i]) == 0 {
@@ -500,21 +587,64 @@ func (a PosApi) GetEpochIncentivePayDetail(epochID uint64) ([]ValidatorInfo, err
 			Delegators:    delegators,
 		}
 	}
+	return ret
+}
 
-	return ret, nil
+// SimulateIncentive runs the incentive of a past epoch again with the given
+// overrides and returns what it would pay, without touching the chain. It lets
+// validators model a fee rate change, new delegations or a missed epoch.
+func (a PosApi) SimulateIncentive(epochID uint64, overrides *IncentiveOverrides) (*IncentiveSimulation, error) {
+	if !isPosStage() {
+		return nil, nil
+	}
+	if epochID >= a.GetEpochID() {
+		return nil, fmt.Errorf("epoch %d is not over", epochID)
+	}
+	s := slotleader.GetSlotLeaderSelection()
+	db, err := s.GetCurrentStateDb()
+	if err != nil {
+		return nil, err
+	}
+
+	var o *incentive.Overrides
+	if overrides != nil {
+		o = &incentive.Overrides{FeeRates: overrides.FeeRates, Inactive: overrides.Inactive}
+		for _, d := range overrides.Delegations {
+			o.Delegations = append(o.Delegations, incentive.Delegation{
+				Validator: d.Validator,
+				Delegator: d.Delegator,
+				Amount:    (*big.Int)(d.Amount),
+			})
+		}
+	}
+	payment, err := incentive.Simulate(s.GetChainReader(), db, epochID, o)
+	if err != nil {
+		return nil, err
+	}
+	return &IncentiveSimulation{
+		EpochID:               epochID,
+		Total:                 (*math.HexOrDecimal256)(payment.Total),
+		Foundation:            (*math.HexOrDecimal256)(payment.Foundation),
+		GasPool:               (*math.HexOrDecimal256)(payment.GasPool),
+		EpochLeaderSubsidy:    (*math.HexOrDecimal256)(payment.EpochLeaderSubsidy),
+		RandomProposerSubsidy: (*math.HexOrDecimal256)(payment.RandomProposerSubsidy),
+		SlotLeaderSubsidy:     (*math.HexOrDecimal256)(payment.SlotLeaderSubsidy),
+		Remain:                (*math.HexOrDecimal256)(payment.Remain),
+		Validators:            toValidatorInfos(payment.Incentives),
+	}, nil
 }
 
 func (a PosApi) GetTotalIncentive() (string, error) {
 	if !isPosStage() {
 		return "Not POS stage.", nil
 	}
-	return biToString(incentive.GetTotalIncentive())
+	return biToString(a.incentiveHistory().GetTotalIncentive())
 }
 func (a PosApi) GetEpochIncentiveBlockNumber(epochID uint64) (uint64, error) {
 	if !isPosStage() {
 		return 0, nil
 	}
-	number, err := incentive.GetEpochIncentiveBlockNumber(epochID)
+	number, err := a.incentiveHistory().GetEpochIncentiveBlockNumber(epochID)
 	if err == nil {
 		return number.Uint64(), nil
 	}
@@ -524,14 +654,14 @@ func (a PosApi) GetEpochIncentive(epochID uint64) (string, error) {
 	if !isPosStage() {
 		return "Not POS stage.", nil
 	}
-	return biToString(incentive.GetEpochIncentive(epochID))
+	return biToString(a.incentiveHistory().GetEpochIncentive(epochID))
 }
 
 func (a PosApi) GetEpochRemain(epochID uint64) (string, error) {
 	if !isPosStage() {
 		return "Not POS stage.", nil
 	}
-	return biToString(incentive.GetEpochRemain(epochID))
+	return biToString(a.incentiveHistory().GetEpochRemain(epochID))
 }
 
 func (a PosApi) GetWhiteListConfig() ([]vm.UpgradeWhiteEpochLeaderParam, error) {
@@ -574,14 +704,14 @@ func (a PosApi) GetTotalRemain() (string, error) {
 	if !isPosStage() {
 		return "Not POS stage.", nil
 	}
-	return biToString(incentive.GetTotalRemain())
+	return biToString(a.incentiveHistory().GetTotalRemain())
 }
 
 func (a PosApi) GetIncentiveRunTimes() (string, error) {
 	if !isPosStage() {
 		return "Not POS stage.", nil
 	}
-	return biToString(incentive.GetRunTimes())
+	return biToString(a.incentiveHistory().GetRunTimes())
 }
 
 func (a PosApi) GetEpochGasPool(epochID uint64) (string, error) {
@@ -917,11 +1047,240 @@ func (a PosApi) GetValidRBCnt(epochId uint64) ([]uint64, error) {
 	return cnts, err
 }
 
+// GetRbParticipation returns the work of the local node in the random beacon
+// of an epoch, nil if it was not a random proposer of the epoch.
+func (a PosApi) GetRbParticipation(epochId uint64) (*RbParticipation, error) {
+	if !isPosStage() {
+		return nil, nil
+	}
+	p, err := randombeacon.GetParticipation(epochId)
+	if err != nil || p == nil {
+		return nil, err
+	}
+	stateDb, _, err := a.backend.StateAndHeaderByNumber(context.Background(), rpc.BlockNumber(-1))
+	if err != nil {
+		return nil, err
+	}
+
+	ret := &RbParticipation{EpochId: p.EpochId, ProposerIds: p.ProposerIds, Stage: p.Stage, Tasks: make([]RbTask, len(p.Tasks))}
+	for i, t := range p.Tasks {
+		dkg1, dkg2, sig := vm.GetRBProgress(stateDb, epochId, t.ProposerId)
+		included := false
+		switch int(t.Stage) {
+		case vm.RbDkg1Stage:
+			included = dkg1
+		case vm.RbDkg2Stage:
+			included = dkg2
+		case vm.RbSignStage:
+			included = sig
+		}
+		ret.Tasks[i] = RbTask{
+			ProposerId:      t.ProposerId,
+			Stage:           t.Stage,
+			Attempts:        t.Attempts,
+			TxHashes:        t.TxHashes,
+			Included:        included,
+			Error:           t.Error,
+			ValidationError: t.ValidationError,
+			Time:            t.Time,
+		}
+	}
+	return ret, nil
+}
+
+// GetRbProposerStatus returns which random beacon transactions of each random
+// proposer of an epoch are in the current state.
+func (a PosApi) GetRbProposerStatus(epochId uint64) ([]RbProposerStatus, error) {
+	if !isPosStage() {
+		return nil, nil
+	}
+	selector := epochLeader.GetEpocher()
+	if selector == nil {
+		return nil, errors.New("epocher instance do not exist")
+	}
+	stateDb, _, err := a.backend.StateAndHeaderByNumber(context.Background(), rpc.BlockNumber(-1))
+	if err != nil {
+		return nil, err
+	}
+
+	leaders := selector.GetRBProposerGroup(epochId)
+	ret := make([]RbProposerStatus, len(leaders))
+	for i, leader := range leaders {
+		ret[i].ProposerId = uint32(i)
+		ret[i].Address = leader.SecAddr
+		ret[i].Dkg1, ret[i].Dkg2, ret[i].Sig = vm.GetRBProgress(stateDb, epochId, uint32(i))
+	}
+	return ret, nil
+}
+
 func (a PosApi) GetRbStage(slotId uint64) uint64 {
 	stage, _, _ := vm.GetRBStage(slotId)
 	return uint64(stage)
 }
 
+var (
+	rbStageNames = []string{"", "dkg1", "dkg1Confirm", "dkg2", "dkg2Confirm", "sign", "signConfirm"}
+	slStageNames = []string{"", "sma1", "sma1Confirm", "sma2", "sma2Confirm", "sma3", "sma3Confirm"}
+)
+
+// stageWindows splits the slots of an epoch starting at startTime into the
+// windows of the stages given by stageOf.
+func stageWindows(startTime uint64, stageOf func(slotId uint64) uint64, name func(stage uint64) string) []StageWindow {
+	windows := make([]StageWindow, 0)
+	for slotId := uint64(0); slotId < posconfig.SlotCount; slotId++ {
+		stage := stageOf(slotId)
+		if n := len(windows); n == 0 || windows[n-1].Stage != stage {
+			windows = append(windows, StageWindow{
+				Name:      name(stage),
+				Stage:     stage,
+				StartSlot: slotId,
+				StartTime: startTime + slotId*posconfig.SlotTime,
+			})
+		}
+		w := &windows[len(windows)-1]
+		w.EndSlot = slotId
+		w.EndTime = startTime + (slotId+1)*posconfig.SlotTime
+	}
+	return windows
+}
+
+func dutyWindow(name string, startTime, startSlot, endSlot uint64) StageWindow {
+	return StageWindow{
+		Name:      name,
+		StartSlot: startSlot,
+		EndSlot:   endSlot,
+		StartTime: startTime + startSlot*posconfig.SlotTime,
+		EndTime:   startTime + (endSlot+1)*posconfig.SlotTime,
+	}
+}
+
+// GetEpochSchedule returns the timetable of an epoch for an address: the
+// stage windows, the random beacon and slot leader selection duties of the
+// address and the slots it leads.
+func (a PosApi) GetEpochSchedule(epochID uint64, addr common.Address) (*EpochSchedule, error) {
+	if !isPosStage() {
+		return nil, nil
+	}
+	selector := epochLeader.GetEpocher()
+	if selector == nil {
+		return nil, errors.New("GetEpocherInst error")
+	}
+
+	startTime := epochID * posconfig.SlotCount * posconfig.SlotTime
+	sc := &EpochSchedule{
+		EpochId:           epochID,
+		Address:           addr,
+		StartTime:         startTime,
+		EndTime:           startTime + posconfig.SlotCount*posconfig.SlotTime,
+		SlotTime:          posconfig.SlotTime,
+		SlotCount:         posconfig.SlotCount,
+		RandomProposerIds: make([]uint32, 0),
+		Duties:            make([]StageWindow, 0),
+		LeaderSlots:       make([]LeaderSlot, 0),
+	}
+	sc.Stages = stageWindows(startTime, func(slotId uint64) uint64 {
+		return slotId/posconfig.Stage1K + 1
+	}, func(stage uint64) string {
+		return fmt.Sprintf("stage%dK", stage)
+	})
+	sc.RbStages = stageWindows(startTime, func(slotId uint64) uint64 {
+		stage, _, _ := vm.GetRBStage(slotId)
+		return uint64(stage)
+	}, func(stage uint64) string {
+		return rbStageNames[stage]
+	})
+	sc.SlStages = stageWindows(startTime, vm.GetSlStage, func(stage uint64) string {
+		return slStageNames[stage]
+	})
+
+	for _, pk := range selector.GetEpochLeaders(epochID) {
+		if pub := crypto.ToECDSAPub(pk); pub != nil && crypto.PubkeyToAddress(*pub) == addr {
+			sc.EpochLeader = true
+			break
+		}
+	}
+	if sc.EpochLeader {
+		sc.Duties = append(sc.Duties,
+			dutyWindow("sma1", startTime, posconfig.Sma1Start+1, posconfig.Sma1End-1),
+			dutyWindow("sma2", startTime, posconfig.Sma2Start+1, posconfig.Sma2End-1),
+			dutyWindow("sma3", startTime, posconfig.Sma3Start, posconfig.SlotCount-1))
+	}
+
+	for i, leader := range selector.GetRBProposerGroup(epochID) {
+		if leader.SecAddr == addr {
+			sc.RandomProposerIds = append(sc.RandomProposerIds, uint32(i))
+		}
+	}
+	if len(sc.RandomProposerIds) != 0 {
+		for _, w := range sc.RbStages {
+			switch int(w.Stage) {
+			case vm.RbDkg1Stage, vm.RbDkg2Stage, vm.RbSignStage:
+				sc.Duties = append(sc.Duties, dutyWindow(w.Name, startTime, w.StartSlot, w.EndSlot))
+			}
+		}
+	}
+
+	leaders, err := slotleader.GetSlotLeaderSelection().GetEpochSlotLeaders(epochID)
+	if err != nil {
+		sc.LeaderSlotsError = err.Error()
+		return sc, nil
+	}
+	for slotId, pk := range leaders {
+		if pk == nil || crypto.PubkeyToAddress(*pk) != addr {
+			continue
+		}
+		sc.LeaderSlots = append(sc.LeaderSlots, newLeaderSlot(epochID, uint64(slotId)))
+	}
+	return sc, nil
+}
+
+func newLeaderSlot(epochID, slotId uint64) LeaderSlot {
+	rbStage, _, _ := vm.GetRBStage(slotId)
+	return LeaderSlot{
+		EpochId: epochID,
+		SlotId:  slotId,
+		Time:    (epochID*posconfig.SlotCount + slotId) * posconfig.SlotTime,
+		RbStage: uint64(rbStage),
+		SlStage: vm.GetSlStage(slotId),
+	}
+}
+
+// GetNextLeaderSlot returns the next slot from now an address leads, in the
+// current epoch or in the next one once its slot leaders are known. It
+// returns nil if the address leads none of them.
+func (a PosApi) GetNextLeaderSlot(addr common.Address) (*LeaderSlot, error) {
+	if !isPosStage() {
+		return nil, nil
+	}
+
+	epochID, slotID := util.CalEpochSlotID(uint64(time.Now().Unix()))
+	leaders, err := slotleader.GetSlotLeaderSelection().GetEpochSlotLeaders(epochID)
+	if err != nil {
+		return nil, err
+	}
+	for ; slotID < posconfig.SlotCount; slotID++ {
+		if pk := leaders[slotID]; pk != nil && crypto.PubkeyToAddress(*pk) == addr {
+			slot := newLeaderSlot(epochID, slotID)
+			return &slot, nil
+		}
+	}
+
+	leaders, err = slotleader.GetSlotLeaderSelection().GetEpochSlotLeaders(epochID + 1)
+	if err == slotleader.ErrSlotLeadersNotReady {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	for slotID = 0; slotID < posconfig.SlotCount; slotID++ {
+		if pk := leaders[slotID]; pk != nil && crypto.PubkeyToAddress(*pk) == addr {
+			slot := newLeaderSlot(epochID+1, slotID)
+			return &slot, nil
+		}
+	}
+	return nil, nil
+}
+
 func (a PosApi) GetEpochIdByBlockNumber(blockNumber uint64) uint64 {
 	header := a.chain.GetHeaderByNumber(blockNumber)
 	if header != nil {
@@ -947,6 +1306,81 @@ func (a PosApi) GetEpochStakeOut(epochID uint64) ([]RefundInfo, error) {
 	return refundInfo, nil
 }
 
+// ValidateStakingTx runs all the validations of a call of the staking contract
+// from an account with a value, against the state of block blockNr, without
+// sending anything.
+func (a PosApi) ValidateStakingTx(data hexutil.Bytes, from common.Address, value *hexutil.Big, blockNr rpc.BlockNumber) (*StakingTxValidation, error) {
+	ctx := context.Background()
+	state, header, err := a.backend.StateAndHeaderByNumber(ctx, blockNr)
+	if err != nil {
+		return nil, err
+	}
+	if state == nil || header == nil {
+		return nil, errors.New("unknown block")
+	}
+	amount := new(big.Int)
+	if value != nil {
+		amount = value.ToInt()
+	}
+
+	res := &StakingTxValidation{Reasons: make([]StakingTxReason, 0)}
+	call := &staking.Call{Value: amount, Data: data}
+	if len(data) >= 4 {
+		for name, method := range vm.GetPosStakingAbi().Methods {
+			if bytes.Equal(method.Id(), data[:4]) {
+				call.Method = name
+			}
+		}
+	}
+	res.Method = call.Method
+	epochID, _ := util.CalEpochSlotID(header.Time.Uint64())
+	if err := call.ValidateAt(epochID); err != nil {
+		// the execution would fail on the same input
+		check := "input"
+		if lerr, ok := err.(*staking.LimitError); (ok && lerr.Field == "value") || err == staking.ErrNoValue || err == staking.ErrNotPayable {
+			check = "value"
+		}
+		res.Reasons = append(res.Reasons, stakingTxReason(check, err))
+		return res, nil
+	}
+	if balance := state.GetBalance(from); balance.Cmp(amount) < 0 {
+		res.Reasons = append(res.Reasons, StakingTxReason{Check: "balance", Field: "value", Limit: balance.String(), Message: fmt.Sprintf("insufficient balance %v for value %v", balance, amount)})
+	}
+	staker, err := stakerJsonAt(state, call.Validator())
+	if err != nil {
+		return nil, err
+	}
+	if err := call.CheckStaker(from, staker, epochID); err != nil {
+		res.Reasons = append(res.Reasons, stakingTxReason("state", err))
+	}
+
+	// Execute the call on the state, which is discarded afterwards
+	msg := types.NewMessage(from, &vm.WanCscPrecompileAddr, 0, amount, new(big.Int).SetUint64(maxUint64), new(big.Int), data, false)
+	evm, vmError, err := a.backend.GetEVM(ctx, msg, state, header, vm.Config{})
+	if err != nil {
+		return nil, err
+	}
+	contract := vm.NewContract(vm.AccountRef(from), vm.AccountRef(vm.WanCscPrecompileAddr), amount, maxUint64)
+	if _, err := new(vm.PosStaking).Run(data, contract, evm); err != nil {
+		res.Reasons = append(res.Reasons, StakingTxReason{Check: "state", Message: err.Error()})
+	}
+	if err := vmError(); err != nil {
+		return nil, err
+	}
+	res.Valid = len(res.Reasons) == 0
+	return res, nil
+}
+
+// stakingTxReason is the reason of a failed check of a staking call, with the
+// bound it breaks when the check tells it.
+func stakingTxReason(check string, err error) StakingTxReason {
+	reason := StakingTxReason{Check: check, Message: err.Error()}
+	if lerr, ok := err.(*staking.LimitError); ok {
+		reason.Field, reason.Limit = lerr.Field, lerr.Limit
+	}
+	return reason
+}
+
 // GetTps used to get tps value
 func (a PosApi) GetTps(fromNumber uint64, toNumber uint64) (string, error) {
 	sRet := fmt.Sprintf("Get tps from %d to %d, ", fromNumber, toNumber)
@@ -0,0 +1,41 @@
+package posapi
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/wanchain/go-wanchain/common"
+	"github.com/wanchain/go-wanchain/common/math"
+	"github.com/wanchain/go-wanchain/core/vm"
+	"github.com/wanchain/go-wanchain/params"
+	"github.com/wanchain/go-wanchain/pos/staking"
+)
+
+func TestStakingTxReason(t *testing.T) {
+	secPk := common.FromHex("0x04d7dffe5e06d2c7024d9bb93f675b8242e71901ee66a1bfe3fe5369324c0a75bf6f033dc4af65f5d0fe7072e98788fcfa670919b5bdc046f1ca91f28dff59db70")
+	bn256Pk := common.FromHex("0x150b2b3230d6d6c8d1c133ec42d82f84add5e096c57665ff50ad071f6345cf45191fd8015cea72c4591ab3fd2ade12287c28a092ac0abf9ea19c13eb65fd4910")
+	wan := func(n int64) *big.Int { return new(big.Int).Mul(big.NewInt(n), big.NewInt(params.Wan)) }
+
+	call, err := staking.StakeIn(secPk, bn256Pk, 30, 100, wan(vm.PSMinStakeholderStake))
+	if err != nil {
+		t.Fatal(err)
+	}
+	call.Value = wan(100)
+	reason := stakingTxReason("value", call.ValidateAt(0))
+	if reason.Field != "value" || reason.Limit != wan(vm.PSMinStakeholderStake).String() {
+		t.Errorf("value reason mismatch: %+v", reason)
+	}
+
+	owner := common.Address{1}
+	staker := &StakerJson{Address: common.Address{9}, Amount: (*math.HexOrDecimal256)(wan(50000)), From: owner, FeeRate: 1000, MaxFeeRate: 1050}
+	if call, err = staking.StakeUpdateFeeRate(staker.Address, 1100); err != nil {
+		t.Fatal(err)
+	}
+	reason = stakingTxReason("state", call.CheckStaker(owner, staker, 10))
+	if reason.Check != "state" || reason.Field != "feeRate" || reason.Limit != "1050" {
+		t.Errorf("fee rate reason mismatch: %+v", reason)
+	}
+	if reason := stakingTxReason("input", staking.ErrUnknownMethod); reason.Field != "" || reason.Message != staking.ErrUnknownMethod.Error() {
+		t.Errorf("plain reason mismatch: %+v", reason)
+	}
+}
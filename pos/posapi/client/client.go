@@ -0,0 +1,544 @@
+// Copyright 2018 Wanchain Foundation Ltd
+// This file is part of the go-wanchain library.
+//
+// The go-wanchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-wanchain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-wanchain library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package client provides a client for the pos RPC API.
+package client
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/wanchain/go-wanchain/common"
+	"github.com/wanchain/go-wanchain/common/hexutil"
+	"github.com/wanchain/go-wanchain/core"
+	"github.com/wanchain/go-wanchain/core/vm"
+	"github.com/wanchain/go-wanchain/pos/equivocation"
+	"github.com/wanchain/go-wanchain/pos/posapi"
+	"github.com/wanchain/go-wanchain/pos/stakingindex"
+	"github.com/wanchain/go-wanchain/pos/validatorhealth"
+	"github.com/wanchain/go-wanchain/rpc"
+)
+
+// PosClient defines typed wrappers for the pos RPC API. Results are decoded
+// into the posapi types the server encodes them from.
+type PosClient struct {
+	c *rpc.Client
+}
+
+// Dial connects a client to the given URL.
+func Dial(rawurl string) (*PosClient, error) {
+	c, err := rpc.Dial(rawurl)
+	if err != nil {
+		return nil, err
+	}
+	return NewClient(c), nil
+}
+
+// NewClient creates a client that uses the given RPC client.
+func NewClient(c *rpc.Client) *PosClient {
+	return &PosClient{c}
+}
+
+// Close closes the underlying RPC connection.
+func (pc *PosClient) Close() {
+	pc.c.Close()
+}
+
+// Version returns the version of the pos API.
+func (pc *PosClient) Version(ctx context.Context) (string, error) {
+	var result string
+	err := pc.c.CallContext(ctx, &result, "pos_version")
+	return result, err
+}
+
+// GetSlotLeaderByEpochIDAndSlotID returns the hex encoded public key of the leader of a slot, or the error text reported by the node.
+func (pc *PosClient) GetSlotLeaderByEpochIDAndSlotID(ctx context.Context, epochID, slotID uint64) (string, error) {
+	var result string
+	err := pc.c.CallContext(ctx, &result, "pos_getSlotLeaderByEpochIDAndSlotID", epochID, slotID)
+	return result, err
+}
+
+// GetEpochLeadersByEpochID returns the epoch leader public keys of an epoch.
+func (pc *PosClient) GetEpochLeadersByEpochID(ctx context.Context, epochID uint64) (map[string]string, error) {
+	var result map[string]string
+	err := pc.c.CallContext(ctx, &result, "pos_getEpochLeadersByEpochID", epochID)
+	return result, err
+}
+
+// GetEpochLeadersAddrByEpochID returns the epoch leader addresses of an epoch.
+func (pc *PosClient) GetEpochLeadersAddrByEpochID(ctx context.Context, epochID uint64) ([]common.Address, error) {
+	var result []common.Address
+	err := pc.c.CallContext(ctx, &result, "pos_getEpochLeadersAddrByEpochID", epochID)
+	return result, err
+}
+
+// GetLeaderGroupByEpochID returns the leader group of an epoch.
+func (pc *PosClient) GetLeaderGroupByEpochID(ctx context.Context, epochID uint64) ([]posapi.LeaderJson, error) {
+	var result []posapi.LeaderJson
+	err := pc.c.CallContext(ctx, &result, "pos_getLeaderGroupByEpochID", epochID)
+	return result, err
+}
+
+// GetLocalPK returns the hex encoded public key of the node's miner account.
+func (pc *PosClient) GetLocalPK(ctx context.Context) (string, error) {
+	var result string
+	err := pc.c.CallContext(ctx, &result, "pos_getLocalPK")
+	return result, err
+}
+
+// GetBootNodePK returns the hex encoded public key of the boot node.
+func (pc *PosClient) GetBootNodePK(ctx context.Context) (string, error) {
+	var result string
+	err := pc.c.CallContext(ctx, &result, "pos_getBootNodePK")
+	return result, err
+}
+
+// GetSlotScCallTimesByEpochID returns how many times the slot leader contract was called in an epoch.
+func (pc *PosClient) GetSlotScCallTimesByEpochID(ctx context.Context, epochID uint64) (uint64, error) {
+	var result uint64
+	err := pc.c.CallContext(ctx, &result, "pos_getSlotScCallTimesByEpochID", epochID)
+	return result, err
+}
+
+// GetSmaByEpochID returns the security message array of an epoch.
+func (pc *PosClient) GetSmaByEpochID(ctx context.Context, epochID uint64) (map[string]string, error) {
+	var result map[string]string
+	err := pc.c.CallContext(ctx, &result, "pos_getSmaByEpochID", epochID)
+	return result, err
+}
+
+// GetRandomProposersByEpochID returns the random proposer public keys of an epoch.
+func (pc *PosClient) GetRandomProposersByEpochID(ctx context.Context, epochID uint64) (map[string]string, error) {
+	var result map[string]string
+	err := pc.c.CallContext(ctx, &result, "pos_getRandomProposersByEpochID", epochID)
+	return result, err
+}
+
+// GetRandomProposersAddrByEpochID returns the random proposer addresses of an epoch.
+func (pc *PosClient) GetRandomProposersAddrByEpochID(ctx context.Context, epochID uint64) ([]common.Address, error) {
+	var result []common.Address
+	err := pc.c.CallContext(ctx, &result, "pos_getRandomProposersAddrByEpochID", epochID)
+	return result, err
+}
+
+// GetSlotCreateStatusByEpochID tells if the slot leaders of an epoch were created.
+func (pc *PosClient) GetSlotCreateStatusByEpochID(ctx context.Context, epochID uint64) (bool, error) {
+	var result bool
+	err := pc.c.CallContext(ctx, &result, "pos_getSlotCreateStatusByEpochID", epochID)
+	return result, err
+}
+
+// GetRandom returns the random beacon output of an epoch at the state of block blockNr, -1 meaning the latest block.
+func (pc *PosClient) GetRandom(ctx context.Context, epochID uint64, blockNr int64) (*big.Int, error) {
+	var result *big.Int
+	err := pc.c.CallContext(ctx, &result, "pos_getRandom", epochID, blockNr)
+	return result, err
+}
+
+// GetChainQuality returns the chain quality (multiplied by 1000) at a slot.
+func (pc *PosClient) GetChainQuality(ctx context.Context, epochID, slotID uint64) (uint64, error) {
+	var result uint64
+	err := pc.c.CallContext(ctx, &result, "pos_getChainQuality", epochID, slotID)
+	return result, err
+}
+
+// GetReorgState returns the reorg count and the length of the last reorg of an epoch.
+func (pc *PosClient) GetReorgState(ctx context.Context, epochID uint64) ([]uint64, error) {
+	var result []uint64
+	err := pc.c.CallContext(ctx, &result, "pos_getReorgState", epochID)
+	return result, err
+}
+
+// GetReorgEvents returns the reorgs the node went through from epoch fromEpoch to toEpoch included.
+func (pc *PosClient) GetReorgEvents(ctx context.Context, fromEpoch uint64, toEpoch uint64) ([]*core.ReorgEvent, error) {
+	var result []*core.ReorgEvent
+	err := pc.c.CallContext(ctx, &result, "pos_getReorgEvents", fromEpoch, toEpoch)
+	return result, err
+}
+
+// GetRbSignatureCount returns the number of random beacon signatures of an epoch at the state of block blockNr.
+func (pc *PosClient) GetRbSignatureCount(ctx context.Context, epochID uint64, blockNr int64) (int, error) {
+	var result int
+	err := pc.c.CallContext(ctx, &result, "pos_getRbSignatureCount", epochID, blockNr)
+	return result, err
+}
+
+// GetEpochStakerInfo returns the probabilities of a validator and its delegators in an epoch.
+func (pc *PosClient) GetEpochStakerInfo(ctx context.Context, epochID uint64, addr common.Address) (posapi.ApiStakerInfo, error) {
+	var result posapi.ApiStakerInfo
+	err := pc.c.CallContext(ctx, &result, "pos_getEpochStakerInfo", epochID, addr)
+	return result, err
+}
+
+// GetStakerInfo returns all stakers at the state of a block.
+func (pc *PosClient) GetStakerInfo(ctx context.Context, targetBlkNum uint64) ([]*posapi.StakerJson, error) {
+	var result []*posapi.StakerJson
+	err := pc.c.CallContext(ctx, &result, "pos_getStakerInfo", targetBlkNum)
+	return result, err
+}
+
+// GetPosInfo returns the first pos epoch and block number.
+func (pc *PosClient) GetPosInfo(ctx context.Context) (posapi.PosInfoJson, error) {
+	var result posapi.PosInfoJson
+	err := pc.c.CallContext(ctx, &result, "pos_getPosInfo")
+	return result, err
+}
+
+// GetEpochStakerInfoAll returns the probabilities of all validators in an epoch.
+func (pc *PosClient) GetEpochStakerInfoAll(ctx context.Context, epochID uint64) ([]posapi.ApiStakerInfo, error) {
+	var result []posapi.ApiStakerInfo
+	err := pc.c.CallContext(ctx, &result, "pos_getEpochStakerInfoAll", epochID)
+	return result, err
+}
+
+// GetEpochIncentivePayDetail returns the incentives paid to the validators and delegators of an epoch.
+func (pc *PosClient) GetEpochIncentivePayDetail(ctx context.Context, epochID uint64) ([]posapi.ValidatorInfo, error) {
+	var result []posapi.ValidatorInfo
+	err := pc.c.CallContext(ctx, &result, "pos_getEpochIncentivePayDetail", epochID)
+	return result, err
+}
+
+// SimulateIncentive runs the incentive of a past epoch again with the given overrides.
+func (pc *PosClient) SimulateIncentive(ctx context.Context, epochID uint64, overrides *posapi.IncentiveOverrides) (*posapi.IncentiveSimulation, error) {
+	var result *posapi.IncentiveSimulation
+	err := pc.c.CallContext(ctx, &result, "pos_simulateIncentive", epochID, overrides)
+	return result, err
+}
+
+// GetTotalIncentive returns the total incentive paid so far.
+func (pc *PosClient) GetTotalIncentive(ctx context.Context) (string, error) {
+	var result string
+	err := pc.c.CallContext(ctx, &result, "pos_getTotalIncentive")
+	return result, err
+}
+
+// GetEpochIncentiveBlockNumber returns the number of the block which paid the incentive of an epoch.
+func (pc *PosClient) GetEpochIncentiveBlockNumber(ctx context.Context, epochID uint64) (uint64, error) {
+	var result uint64
+	err := pc.c.CallContext(ctx, &result, "pos_getEpochIncentiveBlockNumber", epochID)
+	return result, err
+}
+
+// GetEpochIncentive returns the total incentive of an epoch.
+func (pc *PosClient) GetEpochIncentive(ctx context.Context, epochID uint64) (string, error) {
+	var result string
+	err := pc.c.CallContext(ctx, &result, "pos_getEpochIncentive", epochID)
+	return result, err
+}
+
+// GetEpochRemain returns the incentive left unpaid in an epoch.
+func (pc *PosClient) GetEpochRemain(ctx context.Context, epochID uint64) (string, error) {
+	var result string
+	err := pc.c.CallContext(ctx, &result, "pos_getEpochRemain", epochID)
+	return result, err
+}
+
+// GetWhiteListConfig returns the white list configuration.
+func (pc *PosClient) GetWhiteListConfig(ctx context.Context) ([]vm.UpgradeWhiteEpochLeaderParam, error) {
+	var result []vm.UpgradeWhiteEpochLeaderParam
+	err := pc.c.CallContext(ctx, &result, "pos_getWhiteListConfig")
+	return result, err
+}
+
+// GetWhiteListbyEpochID returns the white list of an epoch.
+func (pc *PosClient) GetWhiteListbyEpochID(ctx context.Context, epochID uint64) ([]string, error) {
+	var result []string
+	err := pc.c.CallContext(ctx, &result, "pos_getWhiteListbyEpochID", epochID)
+	return result, err
+}
+
+// GetTotalRemain returns the total incentive left unpaid.
+func (pc *PosClient) GetTotalRemain(ctx context.Context) (string, error) {
+	var result string
+	err := pc.c.CallContext(ctx, &result, "pos_getTotalRemain")
+	return result, err
+}
+
+// GetIncentiveRunTimes returns how many times the incentive was run.
+func (pc *PosClient) GetIncentiveRunTimes(ctx context.Context) (string, error) {
+	var result string
+	err := pc.c.CallContext(ctx, &result, "pos_getIncentiveRunTimes")
+	return result, err
+}
+
+// GetEpochGasPool returns the gas pool of an epoch.
+func (pc *PosClient) GetEpochGasPool(ctx context.Context, epochID uint64) (string, error) {
+	var result string
+	err := pc.c.CallContext(ctx, &result, "pos_getEpochGasPool", epochID)
+	return result, err
+}
+
+// GetRBAddress returns the random proposer addresses of an epoch.
+func (pc *PosClient) GetRBAddress(ctx context.Context, epochID uint64) ([]common.Address, error) {
+	var result []common.Address
+	err := pc.c.CallContext(ctx, &result, "pos_getRBAddress", epochID)
+	return result, err
+}
+
+// GetIncentivePool returns the incentive pool, remaining subsidy and gas fee of an epoch.
+func (pc *PosClient) GetIncentivePool(ctx context.Context, epochID uint64) ([]string, error) {
+	var result []string
+	err := pc.c.CallContext(ctx, &result, "pos_getIncentivePool", epochID)
+	return result, err
+}
+
+// GetActivity returns the activity of all leaders of an epoch.
+func (pc *PosClient) GetActivity(ctx context.Context, epochID uint64) (*posapi.Activity, error) {
+	var result *posapi.Activity
+	err := pc.c.CallContext(ctx, &result, "pos_getActivity", epochID)
+	return result, err
+}
+
+// GetEpRnpActivity returns the activity of the epoch leaders and random proposers of an epoch.
+func (pc *PosClient) GetEpRnpActivity(ctx context.Context, epochID uint64) (*posapi.EpRnpActivity, error) {
+	var result *posapi.EpRnpActivity
+	err := pc.c.CallContext(ctx, &result, "pos_getEpRnpActivity", epochID)
+	return result, err
+}
+
+// GetSlotActivity returns the activity of the slot leaders of an epoch.
+func (pc *PosClient) GetSlotActivity(ctx context.Context, epochID uint64) (*posapi.SlotActivity, error) {
+	var result *posapi.SlotActivity
+	err := pc.c.CallContext(ctx, &result, "pos_getSlotActivity", epochID)
+	return result, err
+}
+
+// GetValidatorActivity returns the activity of the validators of an epoch.
+func (pc *PosClient) GetValidatorActivity(ctx context.Context, epochID uint64) (*posapi.ValidatorActivity, error) {
+	var result *posapi.ValidatorActivity
+	err := pc.c.CallContext(ctx, &result, "pos_getValidatorActivity", epochID)
+	return result, err
+}
+
+// GetEpochID returns the current epoch id.
+func (pc *PosClient) GetEpochID(ctx context.Context) (uint64, error) {
+	var result uint64
+	err := pc.c.CallContext(ctx, &result, "pos_getEpochID")
+	return result, err
+}
+
+// GetSlotID returns the current slot id.
+func (pc *PosClient) GetSlotID(ctx context.Context) (uint64, error) {
+	var result uint64
+	err := pc.c.CallContext(ctx, &result, "pos_getSlotID")
+	return result, err
+}
+
+// GetSlotCount returns the number of slots in an epoch.
+func (pc *PosClient) GetSlotCount(ctx context.Context) (int, error) {
+	var result int
+	err := pc.c.CallContext(ctx, &result, "pos_getSlotCount")
+	return result, err
+}
+
+// GetSlotTime returns the length of a slot in seconds.
+func (pc *PosClient) GetSlotTime(ctx context.Context) (int, error) {
+	var result int
+	err := pc.c.CallContext(ctx, &result, "pos_getSlotTime")
+	return result, err
+}
+
+// GetMaxStableBlkNumber returns the number of the latest stable block.
+func (pc *PosClient) GetMaxStableBlkNumber(ctx context.Context) (uint64, error) {
+	var result uint64
+	err := pc.c.CallContext(ctx, &result, "pos_getMaxStableBlkNumber")
+	return result, err
+}
+
+// CalProbability returns the probability of a stake of amountCoin locked for lockTime epochs.
+func (pc *PosClient) CalProbability(ctx context.Context, amountCoin, lockTime uint64) (string, error) {
+	var result string
+	err := pc.c.CallContext(ctx, &result, "pos_calProbability", amountCoin, lockTime)
+	return result, err
+}
+
+// GetEpochIDByTime returns the epoch id of a unix time.
+func (pc *PosClient) GetEpochIDByTime(ctx context.Context, timeUnix uint64) (uint64, error) {
+	var result uint64
+	err := pc.c.CallContext(ctx, &result, "pos_getEpochIDByTime", timeUnix)
+	return result, err
+}
+
+// GetSlotIDByTime returns the slot id of a unix time.
+func (pc *PosClient) GetSlotIDByTime(ctx context.Context, timeUnix uint64) (uint64, error) {
+	var result uint64
+	err := pc.c.CallContext(ctx, &result, "pos_getSlotIDByTime", timeUnix)
+	return result, err
+}
+
+// GetTimeByEpochID returns the unix time an epoch starts at.
+func (pc *PosClient) GetTimeByEpochID(ctx context.Context, epochID uint64) (uint64, error) {
+	var result uint64
+	err := pc.c.CallContext(ctx, &result, "pos_getTimeByEpochID", epochID)
+	return result, err
+}
+
+// GetEpochBlkCnt returns the number of blocks in an epoch.
+func (pc *PosClient) GetEpochBlkCnt(ctx context.Context, epochID uint64) (uint64, error) {
+	var result uint64
+	err := pc.c.CallContext(ctx, &result, "pos_getEpochBlkCnt", epochID)
+	return result, err
+}
+
+// GetValidSMACnt returns the number of valid stage1 and stage2 txs of an epoch.
+func (pc *PosClient) GetValidSMACnt(ctx context.Context, epochID uint64) ([]uint64, error) {
+	var result []uint64
+	err := pc.c.CallContext(ctx, &result, "pos_getValidSMACnt", epochID)
+	return result, err
+}
+
+// GetSlStage returns the slot leader selection stage of a slot.
+func (pc *PosClient) GetSlStage(ctx context.Context, slotID uint64) (uint64, error) {
+	var result uint64
+	err := pc.c.CallContext(ctx, &result, "pos_getSlStage", slotID)
+	return result, err
+}
+
+// GetValidRBCnt returns the number of valid dkg1, dkg2 and signature txs of an epoch.
+func (pc *PosClient) GetValidRBCnt(ctx context.Context, epochID uint64) ([]uint64, error) {
+	var result []uint64
+	err := pc.c.CallContext(ctx, &result, "pos_getValidRBCnt", epochID)
+	return result, err
+}
+
+// GetRbParticipation returns the work of the node in the random beacon of an epoch, nil if it was not a random proposer of it.
+func (pc *PosClient) GetRbParticipation(ctx context.Context, epochID uint64) (*posapi.RbParticipation, error) {
+	var result *posapi.RbParticipation
+	err := pc.c.CallContext(ctx, &result, "pos_getRbParticipation", epochID)
+	return result, err
+}
+
+// GetRandomProof returns the random beacon output of an epoch with its proof at the state of block blockNr, -1 meaning the latest block.
+func (pc *PosClient) GetRandomProof(ctx context.Context, epochID uint64, blockNr int64) (*posapi.RandomProof, error) {
+	var result *posapi.RandomProof
+	err := pc.c.CallContext(ctx, &result, "pos_getRandomProof", epochID, blockNr)
+	return result, err
+}
+
+// GetRbProposerStatus returns which random beacon txs of each random proposer of an epoch are in the current state.
+func (pc *PosClient) GetRbProposerStatus(ctx context.Context, epochID uint64) ([]posapi.RbProposerStatus, error) {
+	var result []posapi.RbProposerStatus
+	err := pc.c.CallContext(ctx, &result, "pos_getRbProposerStatus", epochID)
+	return result, err
+}
+
+// GetEpochSchedule returns the stage windows of an epoch, the duties of an address and the slots it leads.
+func (pc *PosClient) GetEpochSchedule(ctx context.Context, epochID uint64, addr common.Address) (*posapi.EpochSchedule, error) {
+	var result *posapi.EpochSchedule
+	err := pc.c.CallContext(ctx, &result, "pos_getEpochSchedule", epochID, addr)
+	return result, err
+}
+
+// GetNextLeaderSlot returns the next slot an address leads, nil if it is not known to lead one.
+func (pc *PosClient) GetNextLeaderSlot(ctx context.Context, addr common.Address) (*posapi.LeaderSlot, error) {
+	var result *posapi.LeaderSlot
+	err := pc.c.CallContext(ctx, &result, "pos_getNextLeaderSlot", addr)
+	return result, err
+}
+
+// GetRbStage returns the random beacon stage of a slot.
+func (pc *PosClient) GetRbStage(ctx context.Context, slotID uint64) (uint64, error) {
+	var result uint64
+	err := pc.c.CallContext(ctx, &result, "pos_getRbStage", slotID)
+	return result, err
+}
+
+// GetEpochIdByBlockNumber returns the epoch id of a block.
+func (pc *PosClient) GetEpochIdByBlockNumber(ctx context.Context, blockNumber uint64) (uint64, error) {
+	var result uint64
+	err := pc.c.CallContext(ctx, &result, "pos_getEpochIdByBlockNumber", blockNumber)
+	return result, err
+}
+
+// GetEpochStakeOut returns the stakes refunded in an epoch.
+func (pc *PosClient) GetEpochStakeOut(ctx context.Context, epochID uint64) ([]posapi.RefundInfo, error) {
+	var result []posapi.RefundInfo
+	err := pc.c.CallContext(ctx, &result, "pos_getEpochStakeOut", epochID)
+	return result, err
+}
+
+// GetTps returns a description of the tps between two blocks.
+func (pc *PosClient) GetTps(ctx context.Context, fromNumber, toNumber uint64) (string, error) {
+	var result string
+	err := pc.c.CallContext(ctx, &result, "pos_getTps", fromNumber, toNumber)
+	return result, err
+}
+
+// ValidateStakingTx dry runs a call of the staking contract from an account with a value against the state of block blockNr, returning why it would fail.
+func (pc *PosClient) ValidateStakingTx(ctx context.Context, data hexutil.Bytes, from common.Address, value *hexutil.Big, blockNr rpc.BlockNumber) (*posapi.StakingTxValidation, error) {
+	var result *posapi.StakingTxValidation
+	err := pc.c.CallContext(ctx, &result, "pos_validateStakingTx", data, from, value, toBlockNumArg(blockNr))
+	return result, err
+}
+
+// GetStakingHistory returns a page of the staking events of an account between
+// two epochs. It needs a node running the staking indexer.
+func (pc *PosClient) GetStakingHistory(ctx context.Context, addr common.Address, fromEpoch, toEpoch, cursor, limit uint64) (*stakingindex.StakingHistory, error) {
+	var result *stakingindex.StakingHistory
+	err := pc.c.CallContext(ctx, &result, "pos_getStakingHistory", addr, fromEpoch, toEpoch, cursor, limit)
+	return result, err
+}
+
+// GetStakingIndexStatus returns the last block indexed by the staking indexer.
+func (pc *PosClient) GetStakingIndexStatus(ctx context.Context) (*stakingindex.StakingIndexStatus, error) {
+	var result *stakingindex.StakingIndexStatus
+	err := pc.c.CallContext(ctx, &result, "pos_getStakingIndexStatus")
+	return result, err
+}
+
+// GetValidatorHealth returns the progress of the validators monitored by the
+// node in the duties of the current epoch. It needs a node running the
+// validator health monitor.
+func (pc *PosClient) GetValidatorHealth(ctx context.Context) ([]*validatorhealth.ValidatorStatus, error) {
+	var result []*validatorhealth.ValidatorStatus
+	err := pc.c.CallContext(ctx, &result, "pos_getValidatorHealth")
+	return result, err
+}
+
+// GetHealthAlerts returns the last alerts raised by the validator health monitor.
+func (pc *PosClient) GetHealthAlerts(ctx context.Context) ([]*validatorhealth.Alert, error) {
+	var result []*validatorhealth.Alert
+	err := pc.c.CallContext(ctx, &result, "pos_getHealthAlerts")
+	return result, err
+}
+
+// SubscribeHealthAlerts subscribes to the alerts raised by the validator health monitor.
+func (pc *PosClient) SubscribeHealthAlerts(ctx context.Context, ch chan<- validatorhealth.Alert) (*rpc.ClientSubscription, error) {
+	return pc.c.Subscribe(ctx, "pos", ch, "healthAlerts")
+}
+
+// GetEquivocationEvidence returns the evidence of the slot leaders which signed
+// two blocks of a slot in an epoch.
+func (pc *PosClient) GetEquivocationEvidence(ctx context.Context, epochID uint64) ([]*equivocation.RPCEvidence, error) {
+	var result []*equivocation.RPCEvidence
+	err := pc.c.CallContext(ctx, &result, "pos_getEquivocationEvidence", epochID)
+	return result, err
+}
+
+// toBlockNumArg encodes a block number the way rpc.BlockNumber decodes it.
+func toBlockNumArg(number rpc.BlockNumber) string {
+	switch number {
+	case rpc.LatestBlockNumber:
+		return "latest"
+	case rpc.PendingBlockNumber:
+		return "pending"
+	case rpc.EarliestBlockNumber:
+		return "earliest"
+	case rpc.StableBlockNumber:
+		return "stable"
+	}
+	return hexutil.EncodeUint64(uint64(number))
+}
@@ -0,0 +1,87 @@
+package client
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/wanchain/go-wanchain/pos/posapi"
+	"github.com/wanchain/go-wanchain/pos/posconfig"
+	"github.com/wanchain/go-wanchain/pos/util"
+	"github.com/wanchain/go-wanchain/rpc"
+)
+
+var (
+	contextType = reflect.TypeOf((*context.Context)(nil)).Elem()
+	errorType   = reflect.TypeOf((*error)(nil)).Elem()
+)
+
+// TestClientCoversApi makes sure every pos API method has a client wrapper
+// with the same parameters and result type.
+func TestClientCoversApi(t *testing.T) {
+	api := reflect.TypeOf(&posapi.PosApi{})
+	client := reflect.TypeOf(&PosClient{})
+
+	for i := 0; i < api.NumMethod(); i++ {
+		method := api.Method(i)
+		wrapper, ok := client.MethodByName(method.Name)
+		if !ok {
+			t.Errorf("no client method for pos API method %s", method.Name)
+			continue
+		}
+		// skip the receivers and the leading context of the wrapper
+		in, win := method.Type.NumIn()-1, wrapper.Type.NumIn()-2
+		if win != in || wrapper.Type.In(1) != contextType {
+			t.Errorf("%s: parameter count mismatch, have %d want %d", method.Name, win, in)
+			continue
+		}
+		for j := 0; j < in; j++ {
+			if have, want := wrapper.Type.In(j+2), method.Type.In(j+1); have != want {
+				t.Errorf("%s: parameter %d type mismatch, have %v want %v", method.Name, j, have, want)
+			}
+		}
+		if wrapper.Type.NumOut() != 2 || wrapper.Type.Out(1) != errorType {
+			t.Errorf("%s: wrapper must return a result and an error", method.Name)
+			continue
+		}
+		if have, want := wrapper.Type.Out(0), method.Type.Out(0); have != want {
+			t.Errorf("%s: result type mismatch, have %v want %v", method.Name, have, want)
+		}
+	}
+}
+
+func TestClientCalls(t *testing.T) {
+	server := rpc.NewServer()
+	defer server.Stop()
+	for _, api := range posapi.APIs(nil, nil) {
+		if err := server.RegisterName(api.Namespace, api.Service); err != nil {
+			t.Fatal(err)
+		}
+	}
+	client := NewClient(rpc.DialInProc(server))
+	defer client.Close()
+	ctx := context.Background()
+
+	version, err := client.Version(ctx)
+	if err != nil || version != "1.0" {
+		t.Fatalf("version mismatch: have %q (%v)", version, err)
+	}
+	count, err := client.GetSlotCount(ctx)
+	if err != nil || count != posconfig.SlotCount {
+		t.Fatalf("slot count mismatch: have %d (%v), want %d", count, err, posconfig.SlotCount)
+	}
+	timeUnix := uint64(1600000000)
+	wantEpoch, wantSlot := util.CalEpochSlotID(timeUnix)
+	epochID, err := client.GetEpochIDByTime(ctx, timeUnix)
+	if err != nil || epochID != wantEpoch {
+		t.Fatalf("epoch id mismatch: have %d (%v), want %d", epochID, err, wantEpoch)
+	}
+	slotID, err := client.GetSlotIDByTime(ctx, timeUnix)
+	if err != nil || slotID != wantSlot {
+		t.Fatalf("slot id mismatch: have %d (%v), want %d", slotID, err, wantSlot)
+	}
+	info, err := client.GetPosInfo(ctx)
+	if err != nil || info.FirstEpochId != posconfig.FirstEpochId {
+		t.Fatalf("pos info mismatch: have %+v (%v)", info, err)
+	}
+}
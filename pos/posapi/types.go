@@ -1,11 +1,18 @@
 package posapi
 
 import (
+	"errors"
+	"fmt"
+	"math/big"
+
 	"github.com/wanchain/go-wanchain/common"
 	"github.com/wanchain/go-wanchain/common/hexutil"
 	"github.com/wanchain/go-wanchain/common/math"
 	"github.com/wanchain/go-wanchain/core/vm"
+	"github.com/wanchain/go-wanchain/crypto/bn256/cloudflare"
 	"github.com/wanchain/go-wanchain/pos/epochLeader"
+	"github.com/wanchain/go-wanchain/pos/rbselection"
+	"github.com/wanchain/go-wanchain/pos/staking"
 )
 
 type ValidatorActivity struct {
@@ -53,6 +60,33 @@ type DelegatorInfo struct {
 	Type      string                `json:"type"`
 }
 
+// IncentiveOverrides are the changes SimulateIncentive makes to an epoch.
+type IncentiveOverrides struct {
+	FeeRates    map[common.Address]uint64 `json:"feeRates"` // fee rates of validators, 10000 is 100%
+	Delegations []DelegationOverride      `json:"delegations"`
+	Inactive    []common.Address          `json:"inactive"` // validators assumed to miss all their work
+}
+
+// DelegationOverride is a delegation added by SimulateIncentive.
+type DelegationOverride struct {
+	Validator common.Address        `json:"validator"`
+	Delegator common.Address        `json:"delegator"`
+	Amount    *math.HexOrDecimal256 `json:"amount"`
+}
+
+// IncentiveSimulation is the payment SimulateIncentive computed for an epoch.
+type IncentiveSimulation struct {
+	EpochID               uint64                `json:"epochId"`
+	Total                 *math.HexOrDecimal256 `json:"total"`
+	Foundation            *math.HexOrDecimal256 `json:"foundation"`
+	GasPool               *math.HexOrDecimal256 `json:"gasPool"`
+	EpochLeaderSubsidy    *math.HexOrDecimal256 `json:"epochLeaderSubsidy"`
+	RandomProposerSubsidy *math.HexOrDecimal256 `json:"randomProposerSubsidy"`
+	SlotLeaderSubsidy     *math.HexOrDecimal256 `json:"slotLeaderSubsidy"`
+	Remain                *math.HexOrDecimal256 `json:"remain"`
+	Validators            []ValidatorInfo       `json:"validators"`
+}
+
 type ApiClientProbability struct {
 	Addr        common.Address
 	Probability *math.HexOrDecimal256
@@ -65,40 +99,13 @@ type ApiStakerInfo struct {
 	TotalProbability *math.HexOrDecimal256
 }
 
-type ClientInfo struct {
-	Address     common.Address        `json:"address"`
-	Amount      *math.HexOrDecimal256 `json:"amount"`
-	StakeAmount *math.HexOrDecimal256 `json:"votingPower"`
-	QuitEpoch   uint64                `json:"quitEpoch"`
-}
-type PartnerInfo struct {
-	Address      common.Address        `json:"address"`
-	Amount       *math.HexOrDecimal256 `json:"amount"`
-	StakeAmount  *math.HexOrDecimal256 `json:"votingPower"`
-	Renewal      bool                  `json:"renewal"`
-	LockEpochs   uint64                `json:"lockEpochs"`
-	StakingEpoch uint64                `json:"stakingEpoch"`
-}
-type StakerJson struct {
-	Address   common.Address `json:"address"`
-	PubSec256 string         `json:"pubSec256"` //stakeholder’s wan public key
-	PubBn256  string         `json:"pubBn256"`  //stakeholder’s bn256 public key
-
-	Amount         *math.HexOrDecimal256 `json:"amount"`
-	StakeAmount    *math.HexOrDecimal256 `json:"votingPower"`
-	LockEpochs     uint64                `json:"lockEpochs"`     //lock time which is input by user. 0 means unexpired.
-	NextLockEpochs uint64                `json:"nextLockEpochs"` //lock time which is input by user. 0 means unexpired.
-	From           common.Address        `json:"from"`
-
-	StakingEpoch uint64 `json:"stakingEpoch"` //the user’s staking time
-	FeeRate      uint64 `json:"feeRate"`
-	//NextFeeRate  uint64
-	Clients  []ClientInfo  `json:"clients"`
-	Partners []PartnerInfo `json:"partners"`
-
-	MaxFeeRate          uint64 `json:"maxFeeRate"`
-	FeeRateChangedEpoch uint64 `json:"feeRateChangedEpoch"`
-}
+// The validators returned by pos_getStakerInfo are defined by the staking
+// package, which checks the calls of the staking contract against them.
+type (
+	ClientInfo  = staking.ClientInfo
+	PartnerInfo = staking.PartnerInfo
+	StakerJson  = staking.StakerJson
+)
 
 type RefundInfo struct {
 	Addr   common.Address        `json:"address"`
@@ -178,3 +185,200 @@ func ToStakerJson(staker *vm.StakerInfo) *StakerJson {
 
 	return &stakeJson
 }
+
+// StakingTxValidation is the result of the dry run of a staking contract call.
+type StakingTxValidation struct {
+	Method  string            `json:"method"`
+	Valid   bool              `json:"valid"`
+	Reasons []StakingTxReason `json:"reasons"`
+}
+
+// StakingTxReason is a reason for a staking contract call to fail. Check is
+// "input" for the checks of the transaction pool, "value" for the bounds of the
+// value sent, "balance" for the balance of the sender and "state" for the
+// checks against the validator and the error of the contract execution. Field
+// and Limit name the argument and the bound it breaks, when known.
+type StakingTxReason struct {
+	Check   string `json:"check"`
+	Field   string `json:"field,omitempty"`
+	Limit   string `json:"limit,omitempty"`
+	Message string `json:"message"`
+}
+
+// RbParticipation is the work of the local node in the random beacon of an
+// epoch.
+type RbParticipation struct {
+	EpochId     uint64   `json:"epochId"`
+	ProposerIds []uint32 `json:"proposerIds"`
+	Stage       uint64   `json:"stage"` // last stage reached, see pos_getRbStage
+	Tasks       []RbTask `json:"tasks"`
+}
+
+// RbTask is the work of the local node for one of its proposer ids in a stage.
+// Included tells if the transaction of the stage is in the current state.
+type RbTask struct {
+	ProposerId      uint32        `json:"proposerId"`
+	Stage           uint64        `json:"stage"`
+	Attempts        uint64        `json:"attempts"`
+	TxHashes        []common.Hash `json:"txHashes"`
+	Included        bool          `json:"included"`
+	Error           string        `json:"error"`
+	ValidationError string        `json:"validationError"`
+	Time            uint64        `json:"time"`
+}
+
+// RbProposerStatus tells which random beacon transactions of a proposer are in
+// the current state.
+type RbProposerStatus struct {
+	ProposerId uint32         `json:"proposerId"`
+	Address    common.Address `json:"address"`
+	Dkg1       bool           `json:"dkg1"`
+	Dkg2       bool           `json:"dkg2"`
+	Sig        bool           `json:"sig"`
+}
+
+// RandomProof is the evidence of the random number of an epoch, see
+// rbselection.RandomProof. Points are in the marshalled form of bn256.
+type RandomProof struct {
+	EpochId        uint64                `json:"epochId"`
+	Random         *math.HexOrDecimal256 `json:"random"`
+	M              *math.HexOrDecimal256 `json:"m"`
+	Degree         uint64                `json:"degree"`
+	Signature      hexutil.Bytes         `json:"signature"`
+	GroupPublicKey hexutil.Bytes         `json:"groupPublicKey"`
+	Proposers      []RandomProofProposer `json:"proposers"`
+}
+
+// RandomProofProposer is the data of a random proposer in a RandomProof.
+// Commits is empty if the proposer did not complete dkg2, SignShare if it did
+// not sign.
+type RandomProofProposer struct {
+	ProposerId uint32          `json:"proposerId"`
+	PubKey     hexutil.Bytes   `json:"pubKey"`
+	Commits    []hexutil.Bytes `json:"commits"`
+	SignShare  hexutil.Bytes   `json:"signShare"`
+}
+
+func newRandomProof(p *rbselection.RandomProof, degree uint64) *RandomProof {
+	proof := &RandomProof{
+		EpochId:        p.EpochId,
+		Random:         (*math.HexOrDecimal256)(p.Random),
+		M:              (*math.HexOrDecimal256)(p.M),
+		Degree:         degree,
+		Signature:      p.Signature.Marshal(),
+		GroupPublicKey: p.GroupPublicKey.Marshal(),
+		Proposers:      make([]RandomProofProposer, len(p.ProposerPks)),
+	}
+	for i := range p.ProposerPks {
+		proposer := &proof.Proposers[i]
+		proposer.ProposerId = uint32(i)
+		proposer.PubKey = p.ProposerPks[i].Marshal()
+		for _, c := range p.Commits[i] {
+			proposer.Commits = append(proposer.Commits, c.Marshal())
+		}
+		if p.Shares[i] != nil {
+			proposer.SignShare = p.Shares[i].Marshal()
+		}
+	}
+	return proof
+}
+
+// Proof decodes the points of the proof, for rbselection.RandomProof.Verify.
+func (p *RandomProof) Proof() (*rbselection.RandomProof, error) {
+	if p.Random == nil || p.M == nil {
+		return nil, errors.New("incomplete random proof")
+	}
+	proof := &rbselection.RandomProof{
+		EpochId:        p.EpochId,
+		Random:         (*big.Int)(p.Random),
+		M:              (*big.Int)(p.M),
+		Signature:      new(bn256.G1),
+		GroupPublicKey: new(bn256.G2),
+		ProposerPks:    make([]bn256.G1, len(p.Proposers)),
+		Commits:        make([][]*bn256.G2, len(p.Proposers)),
+		Shares:         make([]*bn256.G1, len(p.Proposers)),
+	}
+	if _, err := proof.Signature.Unmarshal(p.Signature); err != nil {
+		return nil, fmt.Errorf("signature: %v", err)
+	}
+	if _, err := proof.GroupPublicKey.Unmarshal(p.GroupPublicKey); err != nil {
+		return nil, fmt.Errorf("group public key: %v", err)
+	}
+	for i, proposer := range p.Proposers {
+		if proposer.ProposerId != uint32(i) {
+			return nil, fmt.Errorf("proposer %d out of order", proposer.ProposerId)
+		}
+		if _, err := proof.ProposerPks[i].Unmarshal(proposer.PubKey); err != nil {
+			return nil, fmt.Errorf("public key of proposer %d: %v", i, err)
+		}
+		if len(proposer.Commits) != 0 {
+			proof.Commits[i] = make([]*bn256.G2, len(proposer.Commits))
+			for j, c := range proposer.Commits {
+				proof.Commits[i][j] = new(bn256.G2)
+				if _, err := proof.Commits[i][j].Unmarshal(c); err != nil {
+					return nil, fmt.Errorf("commit %d of proposer %d: %v", j, i, err)
+				}
+			}
+		}
+		if len(proposer.SignShare) != 0 {
+			proof.Shares[i] = new(bn256.G1)
+			if _, err := proof.Shares[i].Unmarshal(proposer.SignShare); err != nil {
+				return nil, fmt.Errorf("sign share of proposer %d: %v", i, err)
+			}
+		}
+	}
+	return proof, nil
+}
+
+// Verify checks the proof, see rbselection.RandomProof.Verify. The degree is
+// the one reported by the node, consumers not trusting it should check it
+// against the configuration of the network.
+func (p *RandomProof) Verify() error {
+	proof, err := p.Proof()
+	if err != nil {
+		return err
+	}
+	return proof.Verify(int(p.Degree))
+}
+
+// StageWindow is a range of slots of an epoch, with the unix times of the
+// start of its first slot and of the end of its last one.
+type StageWindow struct {
+	Name      string `json:"name"`
+	Stage     uint64 `json:"stage"`
+	StartSlot uint64 `json:"startSlot"`
+	EndSlot   uint64 `json:"endSlot"`
+	StartTime uint64 `json:"startTime"`
+	EndTime   uint64 `json:"endTime"`
+}
+
+// LeaderSlot is a slot an address leads, with the random beacon and slot
+// leader selection stages of the slot.
+type LeaderSlot struct {
+	EpochId uint64 `json:"epochId"`
+	SlotId  uint64 `json:"slotId"`
+	Time    uint64 `json:"time"`
+	RbStage uint64 `json:"rbStage"`
+	SlStage uint64 `json:"slStage"`
+}
+
+// EpochSchedule is the timetable of an epoch for an address. Duties are the
+// windows the address has to send its random beacon and slot leader
+// selection transactions in. LeaderSlotsError tells why the leader slots are
+// unknown, they are only computed by the epoch leaders of the previous epoch.
+type EpochSchedule struct {
+	EpochId           uint64         `json:"epochId"`
+	Address           common.Address `json:"address"`
+	StartTime         uint64         `json:"startTime"`
+	EndTime           uint64         `json:"endTime"`
+	SlotTime          uint64         `json:"slotTime"`
+	SlotCount         uint64         `json:"slotCount"`
+	Stages            []StageWindow  `json:"stages"`
+	RbStages          []StageWindow  `json:"rbStages"`
+	SlStages          []StageWindow  `json:"slStages"`
+	EpochLeader       bool           `json:"epochLeader"`
+	RandomProposerIds []uint32       `json:"randomProposerIds"`
+	Duties            []StageWindow  `json:"duties"`
+	LeaderSlots       []LeaderSlot   `json:"leaderSlots"`
+	LeaderSlotsError  string         `json:"leaderSlotsError,omitempty"`
+}
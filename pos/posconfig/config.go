@@ -31,7 +31,6 @@ const (
 	EpLocalDB        = "eplocaldb"
 	StakerLocalDB    = "stlocaldb"
 	PosLocalDB       = "pos"
-	IncentiveLocalDB = "incentive"
 	ReorgLocalDB     = "forkdb"
 	ApolloEpochID     = 18104
 	AugustEpochID     = 18116  //TODO change it as mainnet 8.8
@@ -54,17 +53,10 @@ const (
 	StakeOutEpochKey  = "StakeOutEpochKey"
 )
 const (
-	// SlotTime is the time span of a slot in second, So it's 1 hours for a epoch
-	SlotTime = 5
-
 	//Incentive should perform delay some epochs.
 	IncentiveDelayEpochs = 1
 	IncentiveStartStage  = Stage2K
 
-	// K count of each epoch
-	KCount = 12
-	K      = 1440
-
 	// SlotCount is slot count in an epoch
 	SlotCount = K * KCount
 
@@ -123,6 +115,7 @@ type Config struct {
 	EpochInterval uint64
 	PosStartTime  int64
 	MinerKey      *keystore.Key
+	MinerSigner   MinerSigner // set instead of MinerKey when the keys are held by a remote signer
 	Dbpath        string
 	NodeCfg       *node.Config
 	Dkg1End       uint64
@@ -136,6 +129,8 @@ type Config struct {
 	DefaultGasPrice	 *big.Int
 
 	SyncTargetBlokcNum uint64
+
+	EpochRetention uint64 // epochs before the current one the pos databases keep, 0 keeping them all
 }
 
 var DefaultConfig = Config{
@@ -145,6 +140,7 @@ var DefaultConfig = Config{
 	0,
 	0,
 	nil,
+	nil,
 	"",
 	nil,
 	Stage2K - 1,
@@ -158,6 +154,8 @@ var DefaultConfig = Config{
 	nil,
 
 	0,
+
+	0,
 }
 
 func Cfg() *Config {
@@ -165,6 +163,9 @@ func Cfg() *Config {
 }
 
 func (c *Config) GetMinerAddr() common.Address {
+	if c.MinerSigner != nil {
+		return c.MinerSigner.Address()
+	}
 	if c.MinerKey == nil {
 		return common.Address{}
 	}
@@ -173,6 +174,9 @@ func (c *Config) GetMinerAddr() common.Address {
 }
 
 func (c *Config) GetMinerBn256PK() *bn256.G1 {
+	if c.MinerSigner != nil {
+		return c.MinerSigner.Bn256PublicKey()
+	}
 	D3 := GenerateD3byKey2(c.MinerKey.PrivateKey2)
 	return new(bn256.G1).ScalarBaseMult(D3)
 }
@@ -0,0 +1,67 @@
+package posconfig
+
+import (
+	"crypto/ecdsa"
+	"errors"
+	"math/big"
+
+	"github.com/wanchain/go-wanchain/common"
+	bn256 "github.com/wanchain/go-wanchain/crypto/bn256/cloudflare"
+)
+
+var ErrNoMinerKey = errors.New("miner key is not set")
+
+// MinerSigner performs the private key operations of a validator: the secret
+// message array and the slot leader proof of the slot leader selection, done
+// with the secp256k1 account key, and the signature share of the random
+// beacon, done with the bn256 key derived from the second private key.
+//
+// Block seals are signed through the accounts.Wallet of the miner, so a wallet
+// that also implements MinerSigner lets the node validate without ever holding
+// the private keys.
+type MinerSigner interface {
+	// Address returns the account address of the miner.
+	Address() common.Address
+
+	// PublicKey returns the secp256k1 public key of the miner.
+	PublicKey() *ecdsa.PublicKey
+
+	// Bn256PublicKey returns the bn256 public key used by the random beacon.
+	Bn256PublicKey() *bn256.G1
+
+	// GenerateSMA multiplies the received security pieces by the inverse of the
+	// private key.
+	GenerateSMA(pieces []*ecdsa.PublicKey) ([]*ecdsa.PublicKey, error)
+
+	// SlotLeaderProof proves that the miner is the leader of the given slot.
+	SlotLeaderProof(sma, epochLeaders []*ecdsa.PublicKey, rb []byte, slotID, epochID uint64) ([]*ecdsa.PublicKey, []*big.Int, error)
+
+	// Bn256SignShare computes the signature share m*sk^-1*(ens[0]+...+ens[n-1])
+	// from the encrypted DKG shares sent to the miner.
+	Bn256SignShare(ens []*bn256.G1, m *big.Int) (*bn256.G1, error)
+}
+
+// Bn256SignShare computes the random beacon signature share of a proposer from
+// its bn256 private key, the encrypted DKG shares it received and the message.
+func Bn256SignShare(sk *big.Int, ens []*bn256.G1, m *big.Int) *bn256.G1 {
+	gskshare := new(bn256.G1).ScalarBaseMult(big.NewInt(0))
+
+	// gskshare = (sk^-1)*(enshare[1]+...+enshare[Nr])
+	skinver := new(big.Int).ModInverse(sk, bn256.Order)
+	for _, en := range ens {
+		gskshare.Add(gskshare, new(bn256.G1).ScalarMult(en, skinver))
+	}
+	return new(bn256.G1).ScalarMult(gskshare, m)
+}
+
+// MinerBn256SignShare computes the random beacon signature share of the miner,
+// with the miner signer if there is one or with the local miner key otherwise.
+func (c *Config) MinerBn256SignShare(ens []*bn256.G1, m *big.Int) (*bn256.G1, error) {
+	if c.MinerSigner != nil {
+		return c.MinerSigner.Bn256SignShare(ens, m)
+	}
+	if c.MinerKey == nil {
+		return nil, ErrNoMinerKey
+	}
+	return Bn256SignShare(c.GetMinerBn256SK(), ens, m), nil
+}
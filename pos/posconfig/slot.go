@@ -0,0 +1,13 @@
+//go:build !devnet
+// +build !devnet
+
+package posconfig
+
+const (
+	// SlotTime is the time span of a slot in second, So it's 1 hours for a epoch
+	SlotTime = 5
+
+	// K count of each epoch
+	KCount = 12
+	K      = 1440
+)
@@ -0,0 +1,15 @@
+//go:build devnet
+// +build devnet
+
+package posconfig
+
+// The devnet build shortens the epochs to a few minutes, so that a local
+// network goes through the SMA, random beacon and incentive stages quickly.
+const (
+	// SlotTime is the time span of a slot in second, So it's 288 seconds for a epoch
+	SlotTime = 2
+
+	// K count of each epoch
+	KCount = 12
+	K      = 12
+)
@@ -6,6 +6,8 @@ import (
 	"os"
 	"path"
 	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
 
@@ -111,30 +113,35 @@ func (s *Db) DbInit(dbPath string) {
 	if err != nil {
 		panic("failed to create wanpos_tmpdb database: " + dbPath + "_" + err.Error())
 	}
-}
-
-func (s *Db) put(epochID uint64, index uint64, key string, value []byte, saveKey bool) ([]byte, error) {
-	newKey := s.getUniqueKeyBytes(epochID, index, key)
-
-	has, err := s.db.Has(newKey)
-	if err != nil {
-		return nil, err
+	if err := s.dropLegacyKeyLists(); err != nil {
+		log.Warn("Failed to drop the legacy pos key lists", "db", dbPath, "err", err)
 	}
+}
 
-	if has {
-		saveKey = false
-	}
+// legacyKeyName and legacyKeyCountName prefix the names of the key lists the
+// values of an epoch were enumerated with, stored with epoch 0 by the former
+// versions.
+const (
+	legacyKeyName      = "key_"
+	legacyKeyCountName = "keyCount_"
+)
 
-	s.db.Put(newKey, value)
-	if saveKey {
-		err = s.saveKey(newKey, epochID)
+// dropLegacyKeyLists deletes the key lists of the former versions, which
+// would be taken for values of epoch 0 otherwise. It finds nothing to delete
+// once done.
+func (s *Db) dropLegacyKeyLists() error {
+	for _, name := range []string{legacyKeyName, legacyKeyCountName} {
+		if err := s.db.DeleteRange(ethdb.PrefixRange(s.getUniqueKeyBytes(0, 0, name))); err != nil {
+			return err
+		}
 	}
-	return newKey, err
+	return nil
 }
 
 //PutWithIndex use to set a key-value store with a given epochID and Index
 func (s *Db) PutWithIndex(epochID uint64, index uint64, key string, value []byte) ([]byte, error) {
-	return s.put(epochID, index, key, value, true)
+	newKey := s.getUniqueKeyBytes(epochID, index, key)
+	return newKey, s.db.Put(newKey, value)
 }
 
 //GetWithIndex use to get a key-value store with a given epochID and Index
@@ -158,62 +165,44 @@ func (s *Db) Get(epochID uint64, key string) ([]byte, error) {
 	return s.GetWithIndex(epochID, 0, key)
 }
 
-func (s *Db) saveKey(key []byte, epochID uint64) error {
-	keyCount := s.getKeyCount(epochID)
-
-	keyName := s.getKeyName(epochID, keyCount)
-
-	_, err := s.putNoCount(0, keyName, key)
-	if err != nil {
-		return err
-	}
-
-	keyCount++
-	_, err = s.putNoCount(0, s.getKeyCountName(epochID), convert.Uint64ToBytes(keyCount))
-	if err != nil {
-		return err
-	}
-
-	return nil
-}
-
-func (s *Db) getKeyCount(epochID uint64) uint64 {
-	ret, err := s.Get(0, s.getKeyCountName(epochID))
-	if err != nil {
-		return 0
-	}
-	return convert.BytesToUint64(ret)
+// epochValue is a value stored with an epoch.
+type epochValue struct {
+	index uint64
+	key   string
+	value []byte
 }
 
-func (s *Db) getAllKeys(epochID uint64) []string {
-	keyCount := s.getKeyCount(epochID)
-
-	keys := make([]string, keyCount)
-	var i uint64
-	for i = 0; i < keyCount; i++ {
-		keyName := s.getKeyName(epochID, i)
-		ret, err := s.Get(0, keyName)
+// epochValues returns the values stored with epochID, ordered by index and key.
+func (s *Db) epochValues(epochID uint64) ([]epochValue, error) {
+	prefix := s.getEpochPrefix(epochID)
+	it := s.db.NewIteratorWithPrefix(prefix)
+	defer it.Release()
+
+	var values []epochValue
+	for it.Next() {
+		rest := string(it.Key()[len(prefix):])
+		sep := strings.IndexByte(rest, '_')
+		if sep < 0 {
+			continue
+		}
+		index, err := strconv.ParseUint(rest[:sep], 10, 64)
 		if err != nil {
-			log.Warn(err.Error())
 			continue
 		}
-
-		keys[i] = string(ret)
+		values = append(values, epochValue{index, rest[sep+1:], common.CopyBytes(it.Value())})
 	}
-
-	return keys
-}
-
-func (s *Db) getKeyName(epochID uint64, keyIndex uint64) string {
-	return "key_" + convert.Uint64ToString(epochID) + "_" + convert.Uint64ToString(keyIndex)
-}
-
-func (s *Db) getKeyCountName(epochID uint64) string {
-	return "keyCount_" + convert.Uint64ToString(epochID)
+	sort.Slice(values, func(i, j int) bool {
+		if values[i].index != values[j].index {
+			return values[i].index < values[j].index
+		}
+		return values[i].key < values[j].key
+	})
+	return values, it.Error()
 }
 
-func (s *Db) putNoCount(epochID uint64, key string, value []byte) ([]byte, error) {
-	return s.put(epochID, 0, key, value, false)
+// DeleteEpoch removes all the values stored with epochID.
+func (s *Db) DeleteEpoch(epochID uint64) error {
+	return s.db.DeleteRange(ethdb.PrefixRange(s.getEpochPrefix(epochID)))
 }
 
 //DbClose use to close db file
@@ -221,25 +210,23 @@ func (s *Db) DbClose() {
 	s.db.Close()
 }
 
-// GetStorageByteArray : cb is callback function. cb return true indicating like to continue, return false indicating stop
+// GetStorageByteArray returns the values stored with epochID, ordered by index.
 func (s *Db) GetStorageByteArray(epochID uint64) [][]byte {
-
-	keys := s.getAllKeys(epochID)
-
-	arrays := make([][]byte, len(keys))
-
-	for i := 0; i < len(keys); i++ {
-		ret, err := s.db.Get([]byte(keys[i]))
-		if err != nil {
-			log.Warn(err.Error())
-			continue
-		}
-		arrays[i] = ret
+	values, err := s.epochValues(epochID)
+	if err != nil {
+		log.Warn(err.Error())
+	}
+	arrays := make([][]byte, len(values))
+	for i, v := range values {
+		arrays[i] = v.value
 	}
-
 	return arrays
 }
 
+func (s *Db) getEpochPrefix(epochID uint64) []byte {
+	return []byte(convert.Uint64ToString(epochID) + "_")
+}
+
 func (s *Db) getUniqueKey(epochID uint64, index uint64, key string) string {
 	uskey := convert.Uint64ToString(epochID) + "_" + convert.Uint64ToString(index) + "_" + key
 	return uskey
@@ -295,6 +282,18 @@ func GetStakerInfoBytes(epochId uint64, addr common.Address) []byte {
 	return stakerBytes
 }
 
+// PutStakerInfoBytes saves the staker info of addr, as the leaders of epochId
+// are selected from it.
+func PutStakerInfoBytes(epochId uint64, addr common.Address, stakerBytes []byte) error {
+	db := NewDb(posconfig.StakerLocalDB)
+	if db == nil {
+		log.SyslogErr("PutStakerInfo create db error")
+		return nil
+	}
+	_, err := db.PutWithIndex(epochId, 0, common.ToHex(addr[:]), stakerBytes)
+	return err
+}
+
 func GetEpochLeaderGroup(epochId uint64) [][]byte {
 	db := NewDb(posconfig.EpLocalDB)
 	if db == nil {
@@ -1,39 +1,39 @@
 package posdb
 
 import (
-	"encoding/hex"
+	"bytes"
 	"fmt"
+	"io/ioutil"
 	"os"
 	"testing"
 
 	"github.com/wanchain/go-wanchain/common"
 
 	"github.com/wanchain/go-wanchain/crypto"
+	"github.com/wanchain/go-wanchain/ethdb"
 	"github.com/wanchain/go-wanchain/pos/posconfig"
 	"github.com/wanchain/go-wanchain/pos/util"
 )
 
 func TestDbInitAll(t *testing.T) {
-	os.RemoveAll("/tmp/gwan")
-	DbInitAll("/tmp")
-	db := NewDb(posconfig.PosLocalDB)
-	if db == nil {
-		t.Fail()
-	}
-
-	db = NewDb(posconfig.RbLocalDB)
-	if db == nil {
-		t.Fail()
+	dir, err := ioutil.TempDir("", "posdb")
+	if err != nil {
+		t.Fatal(err)
 	}
-
-	db = NewDb(posconfig.EpLocalDB)
-	if db == nil {
-		t.Fail()
+	defer os.RemoveAll(dir)
+	defer func(dbpath string) { posconfig.Cfg().Dbpath = dbpath }(posconfig.Cfg().Dbpath)
+	DbInitAll(dir)
+
+	names := []string{posconfig.PosLocalDB, posconfig.RbLocalDB, posconfig.EpLocalDB}
+	for _, name := range names {
+		if NewDb(name) == nil {
+			t.Fatalf("db %s not created", name)
+		}
 	}
 
 	testCount := 1000
 	keys := make([][]byte, testCount)
-	for i := 0; i < 1000; i++ {
+	for i := 0; i < testCount; i++ {
 		key, _ := crypto.GenerateKey()
 		keys[i] = crypto.FromECDSAPub(&key.PublicKey)
 		if !util.PkEqual(&key.PublicKey, &key.PublicKey) {
@@ -41,102 +41,53 @@ func TestDbInitAll(t *testing.T) {
 		}
 	}
 
-	allQuit := make(chan struct{}, 3)
-
-	go func() {
-		for i := 0; i < testCount; i++ {
-			db := NewDb(posconfig.PosLocalDB)
-			db.PutWithIndex(0, uint64(i), "", keys[i])
-		}
-
-		for i := 0; i < testCount; i++ {
-			value, err := db.GetWithIndex(0, uint64(i), "")
-			if hex.EncodeToString(value) != hex.EncodeToString(keys[i]) || err != nil {
-				t.Fail()
-			}
-		}
-
-		bufs := db.GetStorageByteArray(0)
-		for i := 0; i < testCount; i++ {
-			if hex.EncodeToString(bufs[i]) != hex.EncodeToString(keys[i]) {
-				t.Fail()
-			}
-		}
-
-		allQuit <- struct{}{}
-	}()
-
-	go func() {
-		for i := 0; i < testCount; i++ {
-			db := NewDb(posconfig.RbLocalDB)
-			db.PutWithIndex(0, uint64(i), "", keys[i])
-		}
-
-		for i := 0; i < testCount; i++ {
-			value, err := db.GetWithIndex(0, uint64(i), "")
-			if hex.EncodeToString(value) != hex.EncodeToString(keys[i]) || err != nil {
-				t.Fail()
+	// Each goroutine fills and reads back its own db
+	errs := make(chan error, len(names))
+	for _, name := range names {
+		go func(db *Db) {
+			for i := 0; i < testCount; i++ {
+				db.PutWithIndex(0, uint64(i), "", keys[i])
 			}
-		}
-
-		bufs := db.GetStorageByteArray(0)
-		for i := 0; i < testCount; i++ {
-			if hex.EncodeToString(bufs[i]) != hex.EncodeToString(keys[i]) {
-				t.Fail()
+			for i := 0; i < testCount; i++ {
+				value, err := db.GetWithIndex(0, uint64(i), "")
+				if err != nil || !bytes.Equal(value, keys[i]) {
+					errs <- fmt.Errorf("value %d mismatch: %x %v", i, value, err)
+					return
+				}
 			}
-		}
-
-		allQuit <- struct{}{}
-	}()
-
-	go func() {
-		for i := 0; i < testCount; i++ {
-			db := NewDb(posconfig.EpLocalDB)
-			db.PutWithIndex(0, uint64(i), "", keys[i])
-		}
-
-		for i := 0; i < testCount; i++ {
-			value, err := db.GetWithIndex(0, uint64(i), "")
-			if hex.EncodeToString(value) != hex.EncodeToString(keys[i]) || err != nil {
-				t.Fail()
+			bufs := db.GetStorageByteArray(0)
+			if len(bufs) != testCount {
+				errs <- fmt.Errorf("value count mismatch: have %d, want %d", len(bufs), testCount)
+				return
 			}
-		}
-
-		bufs := db.GetStorageByteArray(0)
-		for i := 0; i < testCount; i++ {
-			if hex.EncodeToString(bufs[i]) != hex.EncodeToString(keys[i]) {
-				t.Fail()
+			for i := 0; i < testCount; i++ {
+				if !bytes.Equal(bufs[i], keys[i]) {
+					errs <- fmt.Errorf("stored value %d mismatch: %x", i, bufs[i])
+					return
+				}
 			}
-		}
-
-		allQuit <- struct{}{}
-	}()
-
-	select {
-	case <-allQuit:
-	}
-	select {
-	case <-allQuit:
+			errs <- nil
+		}(NewDb(name))
 	}
-	select {
-	case <-allQuit:
+	for range names {
+		if err := <-errs; err != nil {
+			t.Error(err)
+		}
 	}
 
-	db = NewDb("test")
+	db := NewDb("test")
 	db.Put(0, "hello", []byte{1, 2, 3})
 	buf, err := db.Get(0, "hello")
-	if buf[0] != 1 || buf[1] != 2 || buf[2] != 3 || err != nil {
+	if err != nil || !bytes.Equal(buf, []byte{1, 2, 3}) {
 		t.Fail()
 	}
 
 	db = GetDb()
 	db.Put(0, "hello", []byte{3, 4, 5})
 	buf, err = db.Get(0, "hello")
-	if buf[0] != 3 || buf[1] != 4 || buf[2] != 5 || err != nil {
+	if err != nil || !bytes.Equal(buf, []byte{3, 4, 5}) {
 		t.Fail()
 	}
-
-	db.DbClose()
 }
 
 func TestInfomationGet(t *testing.T) {
@@ -147,3 +98,91 @@ func TestInfomationGet(t *testing.T) {
 	buf4 := GetEpochLeaderGroup(0)
 	fmt.Println(buf4)
 }
+
+func TestEpochValues(t *testing.T) {
+	dir, err := ioutil.TempDir("", "posdb")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	db := &Db{}
+	if db.db, err = ethdb.NewLDBDatabase(dir, 0, 0); err != nil {
+		t.Fatal(err)
+	}
+	defer db.DbClose()
+
+	for _, index := range []uint64{10, 2, 0} {
+		db.PutWithIndex(5, index, "", []byte{byte(index)})
+	}
+	db.PutWithIndex(5, 2, "b", []byte{3})
+	db.PutWithIndex(55, 0, "", []byte{55})
+	db.Put(0, "slot", []byte{1})
+	// Key list of epoch 5 stored by the former versions
+	db.Put(0, legacyKeyName+"5_0", db.getUniqueKeyBytes(5, 0, ""))
+	db.Put(0, legacyKeyCountName+"5", []byte{1})
+	if err := db.dropLegacyKeyLists(); err != nil {
+		t.Fatal(err)
+	}
+
+	if values := db.GetStorageByteArray(5); fmt.Sprint(values) != "[[0] [2] [3] [10]]" {
+		t.Fatalf("epoch values %v", values)
+	}
+	if values := db.GetStorageByteArray(0); fmt.Sprint(values) != "[[1]]" {
+		t.Fatalf("epoch 0 values %v", values)
+	}
+
+	if err := db.DeleteEpoch(5); err != nil {
+		t.Fatal(err)
+	}
+	if values := db.GetStorageByteArray(5); len(values) != 0 {
+		t.Fatalf("epoch values %v left", values)
+	}
+	if values := db.GetStorageByteArray(55); fmt.Sprint(values) != "[[55]]" {
+		t.Fatalf("epoch 55 values %v", values)
+	}
+	if _, err := db.Get(0, legacyKeyCountName+"5"); err == nil {
+		t.Fatal("key count of epoch 5 left")
+	}
+	if _, err := db.Get(0, legacyKeyName+"5_0"); err == nil {
+		t.Fatal("key list of epoch 5 left")
+	}
+	if _, err := db.Get(0, "slot"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCollectGarbage(t *testing.T) {
+	defer func(retention uint64) { posconfig.Cfg().EpochRetention = retention }(posconfig.Cfg().EpochRetention)
+	defer func(first uint64) { posconfig.FirstEpochId = first }(posconfig.FirstEpochId)
+	posconfig.FirstEpochId = 0
+
+	// Without retention, everything is kept
+	if _, _, ok := garbageEpochs(10); ok {
+		t.Fatal("epochs collected without retention")
+	}
+
+	posconfig.Cfg().EpochRetention = 3
+	start, end, ok := garbageEpochs(10)
+	if !ok || start != 1 || end != 7 {
+		t.Fatalf("collected epochs mismatch: have %d-%d %v, want 1-7", start, end, ok)
+	}
+	// The collected epochs are not collected again
+	if _, _, ok := garbageEpochs(9); ok {
+		t.Fatal("epochs collected twice")
+	}
+	if start, end, ok := garbageEpochs(12); !ok || start != 7 || end != 9 {
+		t.Fatalf("next collected epochs mismatch: have %d-%d %v, want 7-9", start, end, ok)
+	}
+
+	db := NewDb(posconfig.StakerLocalDB)
+	for epochID := uint64(0); epochID < 10; epochID++ {
+		db.Put(epochID, "gc", []byte{byte(epochID)})
+	}
+	collectEpochs(1, 7)
+	for epochID := uint64(0); epochID < 10; epochID++ {
+		_, err := db.Get(epochID, "gc")
+		if kept := epochID == 0 || epochID >= 7; kept != (err == nil) {
+			t.Errorf("epoch %d kept %v, want %v", epochID, err == nil, kept)
+		}
+	}
+}
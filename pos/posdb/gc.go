@@ -0,0 +1,66 @@
+package posdb
+
+import (
+	"sync"
+
+	"github.com/wanchain/go-wanchain/log"
+	"github.com/wanchain/go-wanchain/pos/posconfig"
+)
+
+// epochDbs are the pos databases whose values are stored by epoch.
+var epochDbs = []string{posconfig.PosLocalDB, posconfig.RbLocalDB, posconfig.EpLocalDB, posconfig.StakerLocalDB}
+
+var (
+	gcMu    sync.Mutex
+	gcEpoch uint64 // the epochs before are collected
+)
+
+// CollectGarbage deletes in the background the values the pos databases store
+// with the epochs more than posconfig.Cfg().EpochRetention epochs before
+// epochID, once per epoch. Nothing is deleted if the retention is 0, nor the
+// values of epoch 0, some of them being stored with it for good.
+func CollectGarbage(epochID uint64) {
+	if start, end, ok := garbageEpochs(epochID); ok {
+		go collectEpochs(start, end)
+	}
+}
+
+// garbageEpochs returns the epochs to collect once epochID is reached, from
+// start to end excluded, and marks them collected.
+func garbageEpochs(epochID uint64) (uint64, uint64, bool) {
+	retention := posconfig.Cfg().EpochRetention
+	if retention == 0 || epochID <= retention {
+		return 0, 0, false
+	}
+	end := epochID - retention
+
+	gcMu.Lock()
+	defer gcMu.Unlock()
+	if end <= gcEpoch {
+		return 0, 0, false
+	}
+	start := gcEpoch
+	if start < posconfig.FirstEpochId {
+		start = posconfig.FirstEpochId
+	}
+	if start == 0 {
+		start = 1
+	}
+	gcEpoch = end
+	return start, end, true
+}
+
+// collectEpochs deletes the values stored with the epochs from start to end,
+// excluded.
+func collectEpochs(start, end uint64) {
+	for _, name := range epochDbs {
+		db := NewDb(name)
+		for epochID := start; epochID < end; epochID++ {
+			if err := db.DeleteEpoch(epochID); err != nil {
+				log.Warn("Failed to collect pos epoch data", "db", name, "epochID", epochID, "err", err)
+				return
+			}
+		}
+	}
+	log.Debug("Collected pos epoch data", "from", start, "to", end)
+}
@@ -0,0 +1,174 @@
+package possigner
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/wanchain/go-wanchain/accounts/keystore"
+	"github.com/wanchain/go-wanchain/accounts/remotesigner"
+	"github.com/wanchain/go-wanchain/common"
+	"github.com/wanchain/go-wanchain/common/hexutil"
+	"github.com/wanchain/go-wanchain/core/types"
+	"github.com/wanchain/go-wanchain/crypto"
+	"github.com/wanchain/go-wanchain/rlp"
+)
+
+// SignerAPI is the reference implementation of the remote signer protocol of
+// accounts/remotesigner. It keeps the decrypted keys in memory and is meant to
+// be served by a standalone signer process, so that the node never sees them.
+type SignerAPI struct {
+	mu    sync.RWMutex
+	keys  map[common.Address]*keystore.Key
+	addrs []common.Address // in insertion order
+}
+
+// NewSignerAPI creates a signer service for the given keys.
+func NewSignerAPI(keys ...*keystore.Key) *SignerAPI {
+	api := &SignerAPI{keys: make(map[common.Address]*keystore.Key)}
+	for _, key := range keys {
+		api.Add(key)
+	}
+	return api
+}
+
+// Add makes a key available to the clients of the signer.
+func (api *SignerAPI) Add(key *keystore.Key) {
+	api.mu.Lock()
+	defer api.mu.Unlock()
+
+	if _, ok := api.keys[key.Address]; !ok {
+		api.addrs = append(api.addrs, key.Address)
+	}
+	api.keys[key.Address] = key
+}
+
+func (api *SignerAPI) key(addr common.Address) (*keystore.Key, error) {
+	api.mu.RLock()
+	defer api.mu.RUnlock()
+
+	key, ok := api.keys[addr]
+	if !ok {
+		return nil, fmt.Errorf("unknown account %x", addr)
+	}
+	return key, nil
+}
+
+// Accounts lists the accounts of the signer with their public keys.
+func (api *SignerAPI) Accounts() []remotesigner.AccountInfo {
+	api.mu.RLock()
+	defer api.mu.RUnlock()
+
+	infos := make([]remotesigner.AccountInfo, 0, len(api.addrs))
+	for _, addr := range api.addrs {
+		key := api.keys[addr]
+		signer := NewKeySigner(key)
+		info := remotesigner.AccountInfo{
+			Address:   addr,
+			PublicKey: crypto.FromECDSAPub(signer.PublicKey()),
+			WAddress:  key.WAddress[:],
+		}
+		if pk := signer.Bn256PublicKey(); pk != nil {
+			info.Bn256PublicKey = pk.Marshal()
+		}
+		infos = append(infos, info)
+	}
+	return infos
+}
+
+// SignHash signs a hash with the key of an account.
+func (api *SignerAPI) SignHash(addr common.Address, hash hexutil.Bytes) (hexutil.Bytes, error) {
+	key, err := api.key(addr)
+	if err != nil {
+		return nil, err
+	}
+	return crypto.Sign(hash, key.PrivateKey)
+}
+
+// SignTx signs an RLP encoded transaction with the key of an account, with the
+// EIP155 signer of chainId if given or the homestead signer otherwise.
+func (api *SignerAPI) SignTx(addr common.Address, txRlp hexutil.Bytes, chainId *hexutil.Big) (hexutil.Bytes, error) {
+	key, err := api.key(addr)
+	if err != nil {
+		return nil, err
+	}
+	tx := new(types.Transaction)
+	if err := rlp.DecodeBytes(txRlp, tx); err != nil {
+		return nil, err
+	}
+	var signer types.Signer = types.HomesteadSigner{}
+	if chainId != nil {
+		signer = types.NewEIP155Signer(chainId.ToInt())
+	}
+	signed, err := types.SignTx(tx, signer, key.PrivateKey)
+	if err != nil {
+		return nil, err
+	}
+	return rlp.EncodeToBytes(signed)
+}
+
+// GenerateSMA computes the secret message array of an account from its
+// security pieces.
+func (api *SignerAPI) GenerateSMA(addr common.Address, pieces []hexutil.Bytes) ([]hexutil.Bytes, error) {
+	key, err := api.key(addr)
+	if err != nil {
+		return nil, err
+	}
+	pks, err := remotesigner.DecodePublicKeys(pieces)
+	if err != nil {
+		return nil, err
+	}
+	sma, err := NewKeySigner(key).GenerateSMA(pks)
+	if err != nil {
+		return nil, err
+	}
+	return remotesigner.EncodePublicKeys(sma), nil
+}
+
+// SlotLeaderProof generates the slot leader proof of an account.
+func (api *SignerAPI) SlotLeaderProof(addr common.Address, args remotesigner.SlotLeaderProofArgs) (*remotesigner.SlotLeaderProof, error) {
+	key, err := api.key(addr)
+	if err != nil {
+		return nil, err
+	}
+	sma, err := remotesigner.DecodePublicKeys(args.SMA)
+	if err != nil {
+		return nil, err
+	}
+	leaders, err := remotesigner.DecodePublicKeys(args.EpochLeaders)
+	if err != nil {
+		return nil, err
+	}
+	proofMeg, proof, err := NewKeySigner(key).SlotLeaderProof(sma, leaders, args.RB, uint64(args.SlotID), uint64(args.EpochID))
+	if err != nil {
+		return nil, err
+	}
+	res := &remotesigner.SlotLeaderProof{
+		ProofMeg: remotesigner.EncodePublicKeys(proofMeg),
+		Proof:    make([]*hexutil.Big, len(proof)),
+	}
+	for i, p := range proof {
+		res.Proof[i] = (*hexutil.Big)(p)
+	}
+	return res, nil
+}
+
+// Bn256SignShare computes the random beacon signature share of an account.
+func (api *SignerAPI) Bn256SignShare(addr common.Address, ens []hexutil.Bytes, m *hexutil.Big) (hexutil.Bytes, error) {
+	key, err := api.key(addr)
+	if err != nil {
+		return nil, err
+	}
+	if m == nil {
+		return nil, errors.New("missing message")
+	}
+	points, err := remotesigner.DecodeG1s(ens)
+	if err != nil {
+		return nil, err
+	}
+	share, err := NewKeySigner(key).Bn256SignShare(points, m.ToInt())
+	if err != nil {
+		return nil, err
+	}
+	return share.Marshal(), nil
+}
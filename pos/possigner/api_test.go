@@ -0,0 +1,202 @@
+package possigner
+
+import (
+	"crypto/ecdsa"
+	"io/ioutil"
+	"math/big"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/wanchain/go-wanchain/accounts"
+	"github.com/wanchain/go-wanchain/accounts/keystore"
+	"github.com/wanchain/go-wanchain/accounts/remotesigner"
+	"github.com/wanchain/go-wanchain/common"
+	"github.com/wanchain/go-wanchain/core/types"
+	"github.com/wanchain/go-wanchain/crypto"
+	bn256 "github.com/wanchain/go-wanchain/crypto/bn256/cloudflare"
+	"github.com/wanchain/go-wanchain/pos/posconfig"
+	"github.com/wanchain/go-wanchain/pos/uleaderselection"
+	"github.com/wanchain/go-wanchain/rpc"
+)
+
+func newTestKey(t *testing.T) *keystore.Key {
+	sk1, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sk2, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &keystore.Key{
+		Address:     crypto.PubkeyToAddress(sk1.PublicKey),
+		PrivateKey:  sk1,
+		PrivateKey2: sk2,
+		WAddress:    *keystore.GenerateWaddressFromPK(&sk1.PublicKey, &sk2.PublicKey),
+	}
+}
+
+func TestRemoteSigner(t *testing.T) {
+	dir, err := ioutil.TempDir("", "wansigner-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	key := newTestKey(t)
+	local := NewKeySigner(key)
+
+	// Serve the key over IPC and connect a backend to it
+	srv := rpc.NewServer()
+	if err := srv.RegisterName(remotesigner.Namespace, NewSignerAPI(key)); err != nil {
+		t.Fatal(err)
+	}
+	endpoint := filepath.Join(dir, "signer.ipc")
+	listener, err := rpc.CreateIPCListener(endpoint)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer srv.Stop()
+	go srv.ServeListener(listener)
+
+	backend, err := remotesigner.NewBackend(endpoint)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wallets := backend.Wallets()
+	if len(wallets) != 1 {
+		t.Fatalf("wallet count mismatch: have %d, want 1", len(wallets))
+	}
+	wallet := wallets[0]
+	account := accounts.Account{Address: key.Address}
+	if !wallet.Contains(account) {
+		t.Fatalf("wallet doesn't contain %x", key.Address)
+	}
+	if waddr, err := wallet.GetWanAddress(account); err != nil || waddr != key.WAddress {
+		t.Fatalf("wan address mismatch: have %x, %v, want %x", waddr, err, key.WAddress)
+	}
+
+	// Seals and transactions are signed remotely
+	hash := crypto.Keccak256([]byte("header"))
+	sig, err := wallet.SignHash(account, hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pub, err := crypto.SigToPub(hash, sig); err != nil || crypto.PubkeyToAddress(*pub) != key.Address {
+		t.Fatalf("hash signed by the wrong key: %v", err)
+	}
+	chainID := big.NewInt(6)
+	tx := types.NewTransaction(1, common.Address{1}, big.NewInt(1), big.NewInt(21000), big.NewInt(1), nil)
+	signed, err := wallet.SignTx(account, tx, chainID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if from, err := types.Sender(types.NewEIP155Signer(chainID), signed); err != nil || from != key.Address {
+		t.Fatalf("transaction sender mismatch: have %x, %v", from, err)
+	}
+
+	// The wallet is the miner signer and agrees with the local one
+	signer, err := FromWallet(wallet, key.Address)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := FromWallet(wallet, common.Address{1}); err == nil {
+		t.Fatal("remote signer used for another account")
+	}
+	if signer.Address() != key.Address || !reflect.DeepEqual(crypto.FromECDSAPub(signer.PublicKey()), crypto.FromECDSAPub(local.PublicKey())) {
+		t.Fatal("public key mismatch")
+	}
+	if signer.Bn256PublicKey().String() != local.Bn256PublicKey().String() {
+		t.Fatal("bn256 public key mismatch")
+	}
+
+	pieces := make([]*ecdsa.PublicKey, 3)
+	for i := range pieces {
+		sk, _ := crypto.GenerateKey()
+		pieces[i] = &sk.PublicKey
+	}
+	remoteSMA, err := signer.GenerateSMA(pieces)
+	if err != nil {
+		t.Fatal(err)
+	}
+	localSMA, _ := local.GenerateSMA(pieces)
+	if !reflect.DeepEqual(remotesigner.EncodePublicKeys(remoteSMA), remotesigner.EncodePublicKeys(localSMA)) {
+		t.Fatal("SMA mismatch")
+	}
+
+	leaders := []*ecdsa.PublicKey{local.PublicKey()}
+	rb := crypto.Keccak256([]byte("rb"))
+	proofMeg, proof, err := signer.SlotLeaderProof(remoteSMA, leaders, rb, 5, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !uleaderselection.VerifySlotLeaderProof(proof, proofMeg, leaders, rb) {
+		t.Fatal("invalid slot leader proof")
+	}
+
+	ens := make([]*bn256.G1, 3)
+	for i := range ens {
+		ens[i] = new(bn256.G1).ScalarBaseMult(big.NewInt(int64(i + 7)))
+	}
+	m := big.NewInt(12345)
+	share, err := signer.Bn256SignShare(ens, m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := posconfig.Bn256SignShare(posconfig.GenerateD3byKey2(key.PrivateKey2), ens, m)
+	if share.String() != want.String() {
+		t.Fatal("signature share mismatch")
+	}
+}
+
+func TestRemoteSignerToken(t *testing.T) {
+	key := newTestKey(t)
+	srv := rpc.NewServer()
+	if err := srv.RegisterName(remotesigner.Namespace, NewSignerAPI(key)); err != nil {
+		t.Fatal(err)
+	}
+	defer srv.Stop()
+	handler := rpc.NewVirtualHostHandler([]string{"localhost"}, rpc.NewHTTPServer(nil, srv).Handler)
+	server := httptest.NewServer(remotesigner.NewTokenHandler("secret", handler))
+	defer server.Close()
+
+	// Only the backends sending the token see the accounts
+	for _, token := range []string{"", "wrong"} {
+		backend, err := remotesigner.NewBackendWithToken(server.URL, token)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if wallets := backend.Wallets(); len(wallets) != 0 {
+			t.Errorf("token %q: wallet count mismatch: have %d, want 0", token, len(wallets))
+		}
+	}
+	backend, err := remotesigner.NewBackendWithToken(server.URL, "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if wallets := backend.Wallets(); len(wallets) != 1 {
+		t.Fatalf("wallet count mismatch: have %d, want 1", len(wallets))
+	}
+
+	// Requests for unknown virtual hosts are refused
+	backend, err = remotesigner.NewBackendWithToken(strings.Replace(server.URL, "127.0.0.1", "localhost", 1), "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if wallets := backend.Wallets(); len(wallets) != 1 {
+		t.Errorf("wallet count mismatch for localhost: have %d, want 1", len(wallets))
+	}
+	other := httptest.NewServer(remotesigner.NewTokenHandler("secret", rpc.NewVirtualHostHandler([]string{"signer.example"}, rpc.NewHTTPServer(nil, srv).Handler)))
+	defer other.Close()
+	backend, err = remotesigner.NewBackendWithToken(strings.Replace(other.URL, "127.0.0.1", "localhost", 1), "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if wallets := backend.Wallets(); len(wallets) != 0 {
+		t.Errorf("wallet count mismatch for an unknown host: have %d, want 0", len(wallets))
+	}
+}
@@ -0,0 +1,86 @@
+// Package possigner provides the miner signers of the PoS protocol: the local
+// signer over an unlocked keystore key and the reference remote signer service.
+package possigner
+
+import (
+	"crypto/ecdsa"
+	"errors"
+	"math/big"
+
+	"github.com/wanchain/go-wanchain/accounts"
+	"github.com/wanchain/go-wanchain/accounts/keystore"
+	"github.com/wanchain/go-wanchain/common"
+	bn256 "github.com/wanchain/go-wanchain/crypto/bn256/cloudflare"
+	"github.com/wanchain/go-wanchain/pos/posconfig"
+	"github.com/wanchain/go-wanchain/pos/uleaderselection"
+)
+
+var (
+	errNoBn256Key     = errors.New("key has no second private key")
+	errNoMinerSigner  = errors.New("wallet can't sign for the miner")
+	errSignerMismatch = errors.New("wallet signs for another account")
+)
+
+// KeySigner is a posconfig.MinerSigner over a key held in memory.
+type KeySigner struct {
+	key *keystore.Key
+}
+
+// NewKeySigner creates a miner signer using the given key.
+func NewKeySigner(key *keystore.Key) *KeySigner {
+	return &KeySigner{key: key}
+}
+
+// FromWallet returns the miner signer of an account: a KeySigner over its
+// unlocked key for keystore wallets, or the wallet itself for wallets doing the
+// operations remotely.
+func FromWallet(wallet accounts.Wallet, addr common.Address) (posconfig.MinerSigner, error) {
+	type getKey interface {
+		GetUnlockedKey(address common.Address) (*keystore.Key, error)
+	}
+	if w, ok := wallet.(getKey); ok {
+		key, err := w.GetUnlockedKey(addr)
+		if err != nil {
+			return nil, err
+		}
+		return NewKeySigner(key), nil
+	}
+	signer, ok := wallet.(posconfig.MinerSigner)
+	if !ok {
+		return nil, errNoMinerSigner
+	}
+	if signer.Address() != addr {
+		return nil, errSignerMismatch
+	}
+	return signer, nil
+}
+
+func (s *KeySigner) Address() common.Address {
+	return s.key.Address
+}
+
+func (s *KeySigner) PublicKey() *ecdsa.PublicKey {
+	return &s.key.PrivateKey.PublicKey
+}
+
+func (s *KeySigner) Bn256PublicKey() *bn256.G1 {
+	if s.key.PrivateKey2 == nil {
+		return nil
+	}
+	return new(bn256.G1).ScalarBaseMult(posconfig.GenerateD3byKey2(s.key.PrivateKey2))
+}
+
+func (s *KeySigner) GenerateSMA(pieces []*ecdsa.PublicKey) ([]*ecdsa.PublicKey, error) {
+	return uleaderselection.GenerateSMA(s.key.PrivateKey, pieces)
+}
+
+func (s *KeySigner) SlotLeaderProof(sma, epochLeaders []*ecdsa.PublicKey, rb []byte, slotID, epochID uint64) ([]*ecdsa.PublicKey, []*big.Int, error) {
+	return uleaderselection.GenerateSlotLeaderProof(s.key.PrivateKey, sma, epochLeaders, rb, slotID, epochID)
+}
+
+func (s *KeySigner) Bn256SignShare(ens []*bn256.G1, m *big.Int) (*bn256.G1, error) {
+	if s.key.PrivateKey2 == nil {
+		return nil, errNoBn256Key
+	}
+	return posconfig.Bn256SignShare(posconfig.GenerateD3byKey2(s.key.PrivateKey2), ens, m), nil
+}
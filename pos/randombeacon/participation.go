@@ -0,0 +1,211 @@
+package randombeacon
+
+import (
+	"sync"
+	"time"
+
+	gometrics "github.com/rcrowley/go-metrics"
+	"github.com/wanchain/go-wanchain/common"
+	"github.com/wanchain/go-wanchain/core/vm"
+	"github.com/wanchain/go-wanchain/log"
+	"github.com/wanchain/go-wanchain/metrics"
+	"github.com/wanchain/go-wanchain/pos/posconfig"
+	"github.com/wanchain/go-wanchain/pos/posdb"
+	"github.com/wanchain/go-wanchain/rlp"
+)
+
+// TaskRecord is the work of the local node for one of its proposer ids in a
+// stage.
+type TaskRecord struct {
+	ProposerId      uint32
+	Stage           uint64 // vm.RbDkg1Stage, vm.RbDkg2Stage or vm.RbSignStage
+	Attempts        uint64
+	TxHashes        []common.Hash
+	Error           string // last error building or sending the transaction
+	ValidationError string // last error of the checks of the contract on the transaction
+	Time            uint64 // unix time of the last attempt
+}
+
+// Participation is the work of the local node in the random beacon of an
+// epoch. It is only recorded in the epochs the node is a random proposer of.
+type Participation struct {
+	EpochId     uint64
+	ProposerIds []uint32
+	Stage       uint64 // last stage reached
+	Tasks       []*TaskRecord
+}
+
+var (
+	rbParticipation = "RB_PARTICIPATION"
+
+	// participationMu guards the records, updated from the loop and from the
+	// routines sending the transactions.
+	participationMu sync.Mutex
+)
+
+type stageMeters struct {
+	sent    gometrics.Meter
+	failed  gometrics.Meter
+	invalid gometrics.Meter
+}
+
+func newStageMeters(stage string) *stageMeters {
+	return &stageMeters{
+		sent:    metrics.NewMeter("pos/rb/" + stage + "/sent"),
+		failed:  metrics.NewMeter("pos/rb/" + stage + "/failed"),
+		invalid: metrics.NewMeter("pos/rb/" + stage + "/invalid"),
+	}
+}
+
+var (
+	taskMeters = map[int]*stageMeters{
+		vm.RbDkg1Stage: newStageMeters("dkg1"),
+		vm.RbDkg2Stage: newStageMeters("dkg2"),
+		vm.RbSignStage: newStageMeters("sig"),
+	}
+
+	// Number of proposers whose transactions of the current epoch are in the
+	// state of the chain
+	chainDkg1Gauge = metrics.NewGauge("pos/rb/chain/dkg1")
+	chainDkg2Gauge = metrics.NewGauge("pos/rb/chain/dkg2")
+	chainSigGauge  = metrics.NewGauge("pos/rb/chain/sig")
+)
+
+// GetParticipation returns the work of the local node in the random beacon of
+// an epoch, nil if it was not a random proposer of the epoch.
+func GetParticipation(epochId uint64) (*Participation, error) {
+	participationMu.Lock()
+	defer participationMu.Unlock()
+
+	return loadParticipation(epochId)
+}
+
+func loadParticipation(epochId uint64) (*Participation, error) {
+	b, err := posdb.GetDb().Get(epochId, rbParticipation)
+	if err != nil || len(b) == 0 {
+		return nil, nil
+	}
+
+	p := new(Participation)
+	if err := rlp.DecodeBytes(b, p); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func storeParticipation(p *Participation) {
+	b, err := rlp.EncodeToBytes(p)
+	if err != nil {
+		log.SyslogErr("random beacon store participation fail", "err", err)
+		return
+	}
+
+	if _, err = posdb.GetDb().Put(p.EpochId, rbParticipation, b); err != nil {
+		log.SyslogErr("random beacon store participation fail", "err", err)
+	}
+}
+
+// updateParticipation applies update to the participation of the local node
+// in an epoch. It starts a record for the proposer ids if there is none.
+func updateParticipation(epochId uint64, proposerIds []uint32, update func(p *Participation)) {
+	participationMu.Lock()
+	defer participationMu.Unlock()
+
+	p, err := loadParticipation(epochId)
+	if err != nil {
+		log.SyslogErr("random beacon load participation fail", "err", err)
+	}
+	if p == nil {
+		p = &Participation{EpochId: epochId, ProposerIds: proposerIds, Stage: uint64(vm.RbDkg1Stage)}
+	}
+
+	update(p)
+	storeParticipation(p)
+}
+
+// task returns the record of a proposer id in a stage, adding it if missing.
+func (p *Participation) task(stage uint64, proposerId uint32) *TaskRecord {
+	for _, t := range p.Tasks {
+		if t.Stage == stage && t.ProposerId == proposerId {
+			return t
+		}
+	}
+
+	t := &TaskRecord{ProposerId: proposerId, Stage: stage}
+	p.Tasks = append(p.Tasks, t)
+	return t
+}
+
+func (rb *RandomBeacon) recordStage(stage int) {
+	if len(rb.myPropserIds) == 0 {
+		return
+	}
+
+	updateParticipation(rb.epochId, rb.myPropserIds, func(p *Participation) {
+		p.Stage = uint64(stage)
+	})
+}
+
+// recordAttempt records an attempt of a task, failed with err if not nil.
+func (rb *RandomBeacon) recordAttempt(stage int, proposerId uint32, err error) {
+	updateParticipation(rb.epochId, rb.myPropserIds, func(p *Participation) {
+		t := p.task(uint64(stage), proposerId)
+		t.Attempts++
+		t.Time = uint64(time.Now().Unix())
+		if err != nil {
+			t.Error = err.Error()
+			taskMeters[stage].failed.Mark(1)
+		}
+	})
+}
+
+// recordValidation records the outcome of the checks of the contract on the
+// transaction of a task.
+func (rb *RandomBeacon) recordValidation(stage int, proposerId uint32, err error) {
+	updateParticipation(rb.epochId, rb.myPropserIds, func(p *Participation) {
+		t := p.task(uint64(stage), proposerId)
+		t.ValidationError = ""
+		if err != nil {
+			t.ValidationError = err.Error()
+			taskMeters[stage].invalid.Mark(1)
+		}
+	})
+}
+
+// recordSent records the transaction of a task sent in an epoch, or the error
+// sending it.
+func recordSent(epochId uint64, proposerIds []uint32, stage int, proposerId uint32, txHash common.Hash, err error) {
+	updateParticipation(epochId, proposerIds, func(p *Participation) {
+		t := p.task(uint64(stage), proposerId)
+		if err != nil {
+			t.Error = err.Error()
+			taskMeters[stage].failed.Mark(1)
+			return
+		}
+		t.Error = ""
+		t.TxHashes = append(t.TxHashes, txHash)
+		taskMeters[stage].sent.Mark(1)
+	})
+}
+
+// updateChainGauges reports the number of proposers whose transactions of an
+// epoch are in the state.
+func updateChainGauges(statedb vm.StateDB, epochId uint64) {
+	var dkg1Cnt, dkg2Cnt, sigCnt int64
+	for i := 0; i < posconfig.RandomProperCount; i++ {
+		dkg1, dkg2, sig := vm.GetRBProgress(statedb, epochId, uint32(i))
+		if dkg1 {
+			dkg1Cnt++
+		}
+		if dkg2 {
+			dkg2Cnt++
+		}
+		if sig {
+			sigCnt++
+		}
+	}
+
+	chainDkg1Gauge.Update(dkg1Cnt)
+	chainDkg2Gauge.Update(dkg2Cnt)
+	chainSigGauge.Update(sigCnt)
+}
@@ -0,0 +1,46 @@
+package randombeacon
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/wanchain/go-wanchain/common"
+	"github.com/wanchain/go-wanchain/core/vm"
+)
+
+func TestParticipation(t *testing.T) {
+	const epochId = 123456789
+	rb := &RandomBeacon{epochId: epochId, myPropserIds: []uint32{3, 7}}
+
+	if p, err := GetParticipation(epochId); p != nil || err != nil {
+		t.Fatalf("participation of an unknown epoch: %v %v", p, err)
+	}
+
+	rb.recordAttempt(vm.RbDkg1Stage, 3, nil)
+	rb.recordValidation(vm.RbDkg1Stage, 3, errors.New("invalid rb stage"))
+	recordSent(epochId, rb.myPropserIds, vm.RbDkg1Stage, 3, common.Hash{1}, nil)
+	rb.recordAttempt(vm.RbDkg1Stage, 7, errors.New("dkg1, get rand fail"))
+	rb.recordStage(vm.RbDkg2Stage)
+
+	p, err := GetParticipation(epochId)
+	if err != nil || p == nil {
+		t.Fatalf("participation not recorded: %v", err)
+	}
+	if p.EpochId != epochId || len(p.ProposerIds) != 2 || p.Stage != uint64(vm.RbDkg2Stage) || len(p.Tasks) != 2 {
+		t.Fatalf("participation mismatch: %+v", p)
+	}
+	if task := p.Tasks[0]; task.ProposerId != 3 || task.Attempts != 1 || len(task.TxHashes) != 1 ||
+		task.TxHashes[0] != (common.Hash{1}) || task.Error != "" || task.ValidationError != "invalid rb stage" {
+		t.Fatalf("task of proposer 3 mismatch: %+v", task)
+	}
+	if task := p.Tasks[1]; task.ProposerId != 7 || task.Attempts != 1 || len(task.TxHashes) != 0 || task.Error != "dkg1, get rand fail" {
+		t.Fatalf("task of proposer 7 mismatch: %+v", task)
+	}
+
+	// A failed send keeps the hashes of the earlier ones
+	recordSent(epochId, rb.myPropserIds, vm.RbDkg1Stage, 3, common.Hash{}, errors.New("rc is not ready"))
+	p, _ = GetParticipation(epochId)
+	if task := p.Tasks[0]; len(task.TxHashes) != 1 || task.Error != "rc is not ready" {
+		t.Fatalf("task of proposer 3 after failed send mismatch: %+v", task)
+	}
+}
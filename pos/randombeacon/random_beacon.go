@@ -265,6 +265,7 @@ func (rb *RandomBeacon) updateEpochId(epochId uint64) {
 func (rb *RandomBeacon) updateStage(stage int) {
 	rb.epochStage = stage
 	rb.taskTags = nil
+	rb.recordStage(stage)
 }
 
 func (rb *RandomBeacon) doLoop(statedb vm.StateDB, rc *rpc.Client, epochId uint64, slotId uint64) error {
@@ -281,6 +282,10 @@ func (rb *RandomBeacon) doLoop(statedb vm.StateDB, rc *rpc.Client, epochId uint6
 		rb.updateEpochId(epochId)
 	}
 
+	if statedb != nil {
+		updateChainGauges(statedb, epochId)
+	}
+
 	// rb.epochId == epochId
 	if len(rb.myPropserIds) == 0 {
 		return nil
@@ -434,11 +439,12 @@ func (rb *RandomBeacon) doDKG1s() error {
 func (rb *RandomBeacon) doDKG1(proposerId uint32) error {
 	log.SyslogInfo("begin do dkg1", "proposerId", proposerId)
 	txPayload, err := rb.generateDKG1(proposerId)
-	if err != nil {
-		return err
+	if err == nil {
+		err = rb.sendDKG1(txPayload)
 	}
 
-	return rb.sendDKG1(txPayload)
+	rb.recordAttempt(vm.RbDkg1Stage, proposerId, err)
+	return err
 }
 
 func (rb *RandomBeacon) generateDKG1(proposerId uint32) (*vm.RbDKG1FlatTxPayload, error) {
@@ -518,11 +524,12 @@ func (rb *RandomBeacon) doDKG2s() error {
 func (rb *RandomBeacon) doDKG2(proposerId uint32) error {
 	log.SyslogInfo("begin do dkg2", "proposerId", proposerId)
 	txPayload, err := rb.generateDKG2(proposerId)
-	if err != nil {
-		return err
+	if err == nil {
+		err = rb.sendDKG2(txPayload)
 	}
 
-	return rb.sendDKG2(txPayload)
+	rb.recordAttempt(vm.RbDkg2Stage, proposerId, err)
+	return err
 }
 
 func (rb *RandomBeacon) generateDKG2(proposerId uint32) (*vm.RbDKG2FlatTxPayload, error) {
@@ -608,15 +615,15 @@ func (rb *RandomBeacon) doSIGs() error {
 func (rb *RandomBeacon) doSIG(proposerId uint32) error {
 	log.SyslogInfo("do sig begin", "proposerId", proposerId)
 	sig, err := rb.generateSIG(proposerId)
-	if err != nil {
-		return err
+	if err == nil {
+		err = rb.sendSIG(sig)
 	}
 
-	return rb.sendSIG(sig)
+	rb.recordAttempt(vm.RbSignStage, proposerId, err)
+	return err
 }
 
 func (rb *RandomBeacon) generateSIG(proposerId uint32) (*vm.RbSIGTxPayload, error) {
-	prikey := posconfig.Cfg().GetMinerBn256SK()
 	datas := make([]RbEnsDataCollector, 0)
 
 	for id, pk := range rb.proposerPks {
@@ -636,17 +643,10 @@ func (rb *RandomBeacon) generateSIG(proposerId uint32) (*vm.RbSIGTxPayload, erro
 
 	// Compute Group Secret Key Share
 	// Random proposers get information from the blockchain and compute its group secret share.
-
-	//set zero
-	gskshare := new(bn256.G1).ScalarBaseMult(big.NewInt(int64(0)))
-
-	// sk^-1
-	skinver := new(big.Int).ModInverse(prikey, bn256.Order)
+	// gskshare[i] = (sk^-1)*(enshare[1][i]+...+enshare[Nr][i])
+	ens := make([]*bn256.G1, dkgCount)
 	for i := 0; i < dkgCount; i++ {
-		temp := new(bn256.G1).ScalarMult(datas[i].ens[proposerId], skinver)
-
-		// gskshare[i] = (sk^-1)*(enshare[1][i]+...+enshare[Nr][i])
-		gskshare.Add(gskshare, temp)
+		ens[i] = datas[i].ens[proposerId]
 	}
 
 	// Signing Stage
@@ -658,8 +658,11 @@ func (rb *RandomBeacon) generateSIG(proposerId uint32) (*vm.RbSIGTxPayload, erro
 
 	m := new(big.Int).SetBytes(mBuf)
 
-	// Compute signature share
-	gsigshare := new(bn256.G1).ScalarMult(gskshare, m)
+	// Compute signature share, the miner key may be held by a remote signer
+	gsigshare, err := posconfig.Cfg().MinerBn256SignShare(ens, m)
+	if err != nil {
+		return nil, err
+	}
 	return &vm.RbSIGTxPayload{EpochId:rb.epochId, ProposerId:proposerId, GSignShare:gsigshare}, nil
 }
 
@@ -670,7 +673,7 @@ func (rb *RandomBeacon) sendDKG1(payloadObj *vm.RbDKG1FlatTxPayload) error {
 		return err
 	}
 
-	return rb.doSendRBTx(payload)
+	return rb.doSendRBTx(vm.RbDkg1Stage, payloadObj.ProposerId, payload)
 }
 
 func (rb *RandomBeacon) sendDKG2(payloadObj *vm.RbDKG2FlatTxPayload) error {
@@ -680,7 +683,7 @@ func (rb *RandomBeacon) sendDKG2(payloadObj *vm.RbDKG2FlatTxPayload) error {
 		return err
 	}
 
-	return rb.doSendRBTx(payload)
+	return rb.doSendRBTx(vm.RbDkg2Stage, payloadObj.ProposerId, payload)
 }
 
 func (rb *RandomBeacon) sendSIG(payloadObj *vm.RbSIGTxPayload) error {
@@ -690,10 +693,10 @@ func (rb *RandomBeacon) sendSIG(payloadObj *vm.RbSIGTxPayload) error {
 		return err
 	}
 
-	return rb.doSendRBTx(payload)
+	return rb.doSendRBTx(vm.RbSignStage, payloadObj.ProposerId, payload)
 }
 
-func (rb *RandomBeacon) doSendRBTx(payload []byte) error {
+func (rb *RandomBeacon) doSendRBTx(stage int, proposerId uint32, payload []byte) error {
 	to := vm.GetRBAddress()
 	data := hexutil.Bytes(payload)
 	gas := core.IntrinsicGas(data, &to, true)
@@ -707,8 +710,17 @@ func (rb *RandomBeacon) doSendRBTx(payload []byte) error {
 	arg["data"] = data
 
 
+	// The transaction pool runs the same checks, record why it would refuse it
+	if rb.statedb != nil {
+		rb.recordValidation(stage, proposerId, vm.ValidPosRBTx(rb.statedb, rb.getTxFrom(), payload))
+	}
+
 	log.SyslogInfo("do send rb tx", "payload len", len(payload))
-	go util.SendPosTx(rb.rpcClient, arg)
+	epochId, proposerIds, rc := rb.epochId, rb.myPropserIds, rb.rpcClient
+	go func() {
+		txHash, err := util.SendDelayedPosTx(rc, arg)
+		recordSent(epochId, proposerIds, stage, proposerId, txHash, err)
+	}()
 	return nil
 }
 
@@ -0,0 +1,133 @@
+package rbselection
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/wanchain/go-wanchain/crypto"
+	"github.com/wanchain/go-wanchain/crypto/bn256/cloudflare"
+)
+
+// RandomProof is the evidence of the random number of an epoch. The random
+// is the hash of the group signature of the random proposers of the previous
+// epoch over the message M, which verifies against their group public key.
+type RandomProof struct {
+	EpochId        uint64
+	Random         *big.Int
+	M              *big.Int
+	Signature      *bn256.G1
+	GroupPublicKey *bn256.G2
+
+	// The random proposers of the previous epoch, by proposer id. Commits[i] is
+	// nil if proposer i did not complete dkg2, Shares[i] if it did not sign.
+	ProposerPks []bn256.G1
+	Commits     [][]*bn256.G2
+	Shares      []*bn256.G1
+}
+
+// PolynomialX returns the evaluation point of the proposer of id with public
+// key pk.
+func PolynomialX(pk *bn256.G1, id uint32) *big.Int {
+	x := new(big.Int).SetBytes(crypto.Keccak256(pk.Marshal(), big.NewInt(int64(id)).Bytes()))
+	return x.Mod(x, bn256.Order)
+}
+
+// GroupPublicKey computes the group public key of the proposers from the dkg1
+// commitments of those which completed dkg2.
+func GroupPublicKey(pks []bn256.G1, commits [][]*bn256.G2, degree int) (*bn256.G2, error) {
+	nr := len(pks)
+	if nr < degree+1 {
+		return nil, errors.New("insufficient proposer")
+	}
+	if len(commits) != nr {
+		return nil, errors.New("commits and proposers have different length")
+	}
+
+	c := make([]bn256.G2, nr)
+	x := make([]big.Int, nr)
+	for i := 0; i < nr; i++ {
+		c[i].ScalarBaseMult(big.NewInt(0))
+		x[i].Set(PolynomialX(&pks[i], uint32(i)))
+	}
+	for j := range commits {
+		if commits[j] == nil {
+			continue
+		}
+		if len(commits[j]) != nr {
+			return nil, fmt.Errorf("commits of proposer %d have invalid length", j)
+		}
+		for i := 0; i < nr; i++ {
+			c[i].Add(&c[i], commits[j][i])
+		}
+	}
+
+	gpk := LagrangePub(c, x, degree)
+	return &gpk, nil
+}
+
+// GroupSignature interpolates the group signature from the signature shares
+// of the proposers which signed.
+func GroupSignature(pks []bn256.G1, shares []*bn256.G1, degree int) (*bn256.G1, error) {
+	if len(shares) != len(pks) {
+		return nil, errors.New("shares and proposers have different length")
+	}
+
+	sigs := make([]bn256.G1, 0, len(shares))
+	x := make([]big.Int, 0, len(shares))
+	for i, share := range shares {
+		if share == nil {
+			continue
+		}
+		sigs = append(sigs, *share)
+		x = append(x, *PolynomialX(&pks[i], uint32(i)))
+	}
+	if len(sigs) < degree+1 {
+		return nil, errors.New("insufficient sign proposer")
+	}
+
+	sig := LagrangeSig(sigs, x, degree)
+	return &sig, nil
+}
+
+// VerifyRandom checks that random is the hash of sig, and that sig is the
+// signature of m by the group of public key gpk: e(sig, h) == e(m*g, gpk).
+func VerifyRandom(random, m *big.Int, sig *bn256.G1, gpk *bn256.G2) error {
+	if random == nil || m == nil || sig == nil || gpk == nil {
+		return errors.New("incomplete random proof")
+	}
+	if new(big.Int).SetBytes(crypto.Keccak256(sig.Marshal())).Cmp(random) != 0 {
+		return errors.New("random is not the hash of the group signature")
+	}
+
+	mG := new(bn256.G1).ScalarBaseMult(m)
+	if bn256.Pair(sig, Hbase).String() != bn256.Pair(mG, gpk).String() {
+		return errors.New("group signature pairing check failed")
+	}
+	return nil
+}
+
+// Verify checks the proof from the data of the proposers: the group public
+// key and signature are computed again before checking the random against
+// them. degree is the degree of the polynomials of the random beacon.
+func (p *RandomProof) Verify(degree int) error {
+	if p.Signature == nil || p.GroupPublicKey == nil {
+		return errors.New("incomplete random proof")
+	}
+	gpk, err := GroupPublicKey(p.ProposerPks, p.Commits, degree)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(gpk.Marshal(), p.GroupPublicKey.Marshal()) {
+		return errors.New("group public key does not match the commitments")
+	}
+	sig, err := GroupSignature(p.ProposerPks, p.Shares, degree)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(sig.Marshal(), p.Signature.Marshal()) {
+		return errors.New("group signature does not match the shares")
+	}
+	return VerifyRandom(p.Random, p.M, p.Signature, p.GroupPublicKey)
+}
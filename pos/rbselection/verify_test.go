@@ -0,0 +1,99 @@
+package rbselection
+
+import (
+	"crypto/rand"
+	"math/big"
+	"testing"
+
+	"github.com/wanchain/go-wanchain/crypto"
+	"github.com/wanchain/go-wanchain/crypto/bn256/cloudflare"
+)
+
+// testRandomProof runs the random beacon of nr proposers, of which the last
+// one does not complete dkg2 and the first one does not sign.
+func testRandomProof(t *testing.T, nr, degree int) *RandomProof {
+	pks := make([]bn256.G1, nr)
+	x := make([]big.Int, nr)
+	for i := 0; i < nr; i++ {
+		_, pk, err := bn256.RandomG1(rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pks[i] = *pk
+		x[i] = *PolynomialX(pk, uint32(i))
+	}
+
+	commits := make([][]*bn256.G2, nr)
+	gsk := make([]big.Int, nr)
+	for j := 0; j < nr-1; j++ {
+		s, _ := rand.Int(rand.Reader, bn256.Order)
+		poly, _ := RandPoly(degree, *s)
+		commits[j] = make([]*bn256.G2, nr)
+		for i := 0; i < nr; i++ {
+			share, _ := EvaluatePoly(poly, &x[i], degree)
+			commits[j][i] = new(bn256.G2).ScalarBaseMult(&share)
+			gsk[i].Add(&gsk[i], &share)
+		}
+	}
+
+	m := new(big.Int).SetBytes(crypto.Keccak256([]byte("wanchain")))
+	mG := new(bn256.G1).ScalarBaseMult(m)
+	shares := make([]*bn256.G1, nr)
+	for i := 1; i < nr; i++ {
+		shares[i] = new(bn256.G1).ScalarMult(mG, gsk[i].Mod(&gsk[i], bn256.Order))
+	}
+
+	p := &RandomProof{EpochId: 2, M: m, ProposerPks: pks, Commits: commits, Shares: shares}
+	var err error
+	if p.GroupPublicKey, err = GroupPublicKey(pks, commits, degree); err != nil {
+		t.Fatal(err)
+	}
+	if p.Signature, err = GroupSignature(pks, shares, degree); err != nil {
+		t.Fatal(err)
+	}
+	p.Random = new(big.Int).SetBytes(crypto.Keccak256(p.Signature.Marshal()))
+	return p
+}
+
+func TestVerifyRandom(t *testing.T) {
+	const nr, degree = 5, 2
+	p := testRandomProof(t, nr, degree)
+	if err := p.Verify(degree); err != nil {
+		t.Fatalf("valid proof rejected: %v", err)
+	}
+
+	random := p.Random
+	p.Random = new(big.Int).Add(random, big.NewInt(1))
+	if err := p.Verify(degree); err == nil {
+		t.Fatal("proof of a wrong random accepted")
+	}
+	p.Random = random
+
+	m := p.M
+	p.M = new(big.Int).Add(m, big.NewInt(1))
+	if err := p.Verify(degree); err == nil {
+		t.Fatal("proof of a wrong message accepted")
+	}
+	p.M = m
+
+	share := p.Shares[1]
+	p.Shares[1] = new(bn256.G1).Add(share, share)
+	if err := p.Verify(degree); err == nil {
+		t.Fatal("proof with a wrong sign share accepted")
+	}
+	p.Shares[1] = share
+
+	commit := p.Commits[0][0]
+	p.Commits[0][0] = new(bn256.G2).Add(commit, commit)
+	if err := p.Verify(degree); err == nil {
+		t.Fatal("proof with a wrong commit accepted")
+	}
+	p.Commits[0][0] = commit
+
+	for i := 1; i < nr-degree; i++ {
+		p.Shares[i] = nil
+	}
+	if err := p.Verify(degree); err == nil {
+		t.Fatal("proof with insufficient sign shares accepted")
+	}
+}
@@ -8,6 +8,7 @@ import (
 
 	"github.com/wanchain/go-wanchain/pos/util"
 
+	"github.com/wanchain/go-wanchain/core/state"
 	"github.com/wanchain/go-wanchain/core/types"
 	"github.com/wanchain/go-wanchain/core/vm"
 
@@ -48,9 +49,23 @@ func (s *SLS) VerifySlotProof(block *types.Block, epochID uint64, slotID uint64,
 		return false
 	}
 
+	stateDb, err := s.getCurrentStateDb()
+	if err != nil {
+		log.SyslogErr(err.Error())
+		return s.verifySlotProofByGenesis(epochID, slotID, Proof, ProofMeg)
+	}
+	return s.verifySlotProofWith(epochLeadersPtrPre, epochID, slotID, Proof, ProofMeg, rbPtr, stateDb)
+}
+
+// verifySlotProofWith verifies a slot leader proof against the leaders of the
+// previous epoch, the random of the epoch and the stage two transactions of
+// the previous epoch recorded in stateDb.
+func (s *SLS) verifySlotProofWith(epochLeadersPtrPre []*ecdsa.PublicKey, epochID uint64, slotID uint64,
+	Proof []*big.Int, ProofMeg []*ecdsa.PublicKey, rbPtr *big.Int, stateDb *state.StateDB) bool {
+
 	rbBytes := rbPtr.Bytes()
 	// stage two info from trans
-	validEpochLeadersIndex, stageTwoAlphaPKi, err := s.getStageTwoFromTrans(epochID)
+	validEpochLeadersIndex, stageTwoAlphaPKi, err := s.getStageTwoFromTrans(epochID, stateDb)
 	if err != nil {
 		log.SyslogErr(err.Error())
 		// no stage2 trans on the block chain.
@@ -126,8 +141,8 @@ func (s *SLS) VerifySlotProof(block *types.Block, epochID uint64, slotID uint64,
 	return uleaderselection.VerifySlotLeaderProof(Proof[:], ProofMeg[:], epochLeadersPtrPre[:], rbBytes[:])
 }
 
-func (s *SLS) PackSlotProof(epochID uint64, slotID uint64, prvKey *ecdsa.PrivateKey) ([]byte, error) {
-	proofMeg, proof, err := s.getSlotLeaderProof(prvKey, epochID, slotID)
+func (s *SLS) PackSlotProof(epochID uint64, slotID uint64, signer posconfig.MinerSigner) ([]byte, error) {
+	proofMeg, proof, err := s.getSlotLeaderProof(signer, epochID, slotID)
 	if err != nil {
 		return nil, err
 	}
@@ -140,6 +155,12 @@ func (s *SLS) PackSlotProof(epochID uint64, slotID uint64, prvKey *ecdsa.Private
 }
 
 func (s *SLS) GetInfoFromHeadExtra(epochID uint64, input []byte) ([]*big.Int, []*ecdsa.PublicKey, error) {
+	return UnpackSlotProof(epochID, input)
+}
+
+// UnpackSlotProof decodes and sanity checks the slot leader proof packed into
+// a header's extra data (without the trailing seal).
+func UnpackSlotProof(epochID uint64, input []byte) ([]*big.Int, []*ecdsa.PublicKey, error) {
 	var info Pack
 	err := rlp.DecodeBytes(input, &info)
 	if err != nil {
@@ -175,7 +196,7 @@ func (s *SLS) GetInfoFromHeadExtra(epochID uint64, input []byte) ([]*big.Int, []
 	return proof, proofMeg, nil
 }
 
-func (s *SLS) getSlotLeaderProofByGenesis(PrivateKey *ecdsa.PrivateKey, epochID uint64,
+func (s *SLS) getSlotLeaderProofByGenesis(signer posconfig.MinerSigner, epochID uint64,
 	slotID uint64) ([]*ecdsa.PublicKey, []*big.Int, error) {
 
 	//1. SMA PRE
@@ -186,26 +207,25 @@ func (s *SLS) getSlotLeaderProofByGenesis(PrivateKey *ecdsa.PrivateKey, epochID
 	log.Debug("getSlotLeaderProofByGenesis", "epochID", epochID, "slotID", slotID)
 	log.Debug("getSlotLeaderProofByGenesis", "epochID", epochID, "slotID", slotID, "slotLeaderRb",
 		hex.EncodeToString(rbBytes[:]))
-	profMeg, proof, err := uleaderselection.GenerateSlotLeaderProof(PrivateKey, smaPiecesPtr[:],
-		epochLeadersPtrPre[:], rbBytes[:], slotID, epochID)
+	profMeg, proof, err := signer.SlotLeaderProof(smaPiecesPtr[:], epochLeadersPtrPre[:], rbBytes[:], slotID, epochID)
 	return profMeg, proof, err
 }
 
-func (s *SLS) getSlotLeaderProof(PrivateKey *ecdsa.PrivateKey, epochID uint64,
+func (s *SLS) getSlotLeaderProof(signer posconfig.MinerSigner, epochID uint64,
 	slotID uint64) ([]*ecdsa.PublicKey, []*big.Int, error) {
 	if epochID <= posconfig.FirstEpochId+2 {
-		return s.getSlotLeaderProofByGenesis(PrivateKey, 0, slotID)
+		return s.getSlotLeaderProofByGenesis(signer, 0, slotID)
 	}
 	epochLeadersPtrPre, isDefault := s.GetPreEpochLeadersPK(epochID)
 	if isDefault {
 		log.Warn("getSlotLeaderProof", "isDefault", isDefault)
-		return s.getSlotLeaderProofByGenesis(PrivateKey, 0, slotID)
+		return s.getSlotLeaderProofByGenesis(signer, 0, slotID)
 	}
 
 	//SMA PRE
 	smaPiecesPtr, isGenesis, _ := s.getSMAPieces(epochID)
 	if isGenesis {
-		return s.getSlotLeaderProofByGenesis(PrivateKey, 0, slotID)
+		return s.getSlotLeaderProofByGenesis(signer, 0, slotID)
 	}
 
 	//RB PRE
@@ -228,8 +248,7 @@ func (s *SLS) getSlotLeaderProof(PrivateKey *ecdsa.PrivateKey, epochID uint64,
 	}
 	log.Debug("getSlotLeaderProof", "epochID", epochID, "slotID", slotID, "smaPiecesHexStr", smaPiecesHexStr)
 
-	profMeg, proof, err := uleaderselection.GenerateSlotLeaderProof(PrivateKey, smaPiecesPtr, epochLeadersPtrPre,
-		rbBytes[:], slotID, epochID)
+	profMeg, proof, err := signer.SlotLeaderProof(smaPiecesPtr, epochLeadersPtrPre, rbBytes[:], slotID, epochID)
 
 	return profMeg, proof, err
 }
@@ -284,6 +303,11 @@ func (s *SLS) verifySlotProofByGenesis(epochID uint64, slotID uint64, Proof []*b
 
 func (s *SLS) getSkGtFromTrans(epochLeadersPtrPre []*ecdsa.PublicKey, epochID uint64, slotID uint64, rbBytes []byte,
 	smaPieces []*ecdsa.PublicKey) (skGtRet *ecdsa.PublicKey) {
+	return computeSkGt(epochLeadersPtrPre, epochID, slotID, rbBytes, smaPieces)
+}
+
+func computeSkGt(epochLeadersPtrPre []*ecdsa.PublicKey, epochID uint64, slotID uint64, rbBytes []byte,
+	smaPieces []*ecdsa.PublicKey) (skGtRet *ecdsa.PublicKey) {
 
 	var buffer bytes.Buffer
 	buffer.Write(rbBytes[:])
@@ -313,14 +337,14 @@ func (s *SLS) getSkGtFromTrans(epochLeadersPtrPre []*ecdsa.PublicKey, epochID ui
 	return skGt
 }
 
-func (s *SLS) getStageTwoFromTrans(epochID uint64) (validEpochLeadersIndex [posconfig.EpochLeaderCount]bool,
+func (s *SLS) getStageTwoFromTrans(epochID uint64, statedb *state.StateDB) (validEpochLeadersIndex [posconfig.EpochLeaderCount]bool,
 	stageTwoAlphaPKi [posconfig.EpochLeaderCount][posconfig.EpochLeaderCount]*ecdsa.PublicKey, err error) {
 
 	for i := 0; i < posconfig.EpochLeaderCount; i++ {
 		validEpochLeadersIndex[i] = true
 	}
 
-	indexesSentTran, err := s.getSlotLeaderStage2TxIndexes(epochID - 1)
+	indexesSentTran, err := stage2TxIndexes(statedb, epochID-1)
 	log.Debug("VerifySlotProof", "indexesSentTran", indexesSentTran)
 	if err != nil {
 		log.SyslogErr("getStageTwoFromTrans", "indexesSentTran error", err.Error())
@@ -343,7 +367,6 @@ func (s *SLS) getStageTwoFromTrans(epochID uint64) (validEpochLeadersIndex [posc
 		alphaPkiCached, ok := APkiCache.Get(ckey)
 		if !ok {
 			var err error
-			statedb, _ := s.getCurrentStateDb()
 			alphaPki, _, err = vm.GetStage2TxAlphaPki(statedb, epochID-1, uint64(i))
 			if err != nil {
 				log.Debug("VerifySlotProof:GetStage2TxAlphaPki", "index", i, "error", err.Error())
@@ -0,0 +1,121 @@
+package slotleader
+
+import (
+	"crypto/ecdsa"
+	"math/big"
+
+	"github.com/wanchain/go-wanchain/crypto"
+	"github.com/wanchain/go-wanchain/log"
+	"github.com/wanchain/go-wanchain/pos/posconfig"
+	"github.com/wanchain/go-wanchain/pos/uleaderselection"
+)
+
+// ProofData is the consensus data a slot leader proof of one epoch is checked
+// against. A full node reads it from posdb and its own state, a light client
+// assembles it from data retrieved (and proven) over the network.
+type ProofData struct {
+	// Genesis marks data built from the default epoch leaders, which are
+	// verified the same way as the first epochs after the pos upgrade.
+	Genesis bool
+	// EpochLeaders is the epoch leader group of the previous epoch.
+	EpochLeaders []*ecdsa.PublicKey
+	// Random is the random beacon output used by the epoch.
+	Random *big.Int
+	// ValidIndexes marks the epoch leaders whose stage2 tx is on chain.
+	ValidIndexes [posconfig.EpochLeaderCount]bool
+	// AlphaPki is the alpha*Pk array published by each stage2 tx.
+	AlphaPki [posconfig.EpochLeaderCount][posconfig.EpochLeaderCount]*ecdsa.PublicKey
+	// Sma is the security message array, only filled for genesis data.
+	Sma [posconfig.EpochLeaderCount]*ecdsa.PublicKey
+}
+
+// NewGenesisProofData builds the proof data of the default epoch leaders the
+// same way SLS.initSma does.
+func NewGenesisProofData(defaultLeaders []*ecdsa.PublicKey) *ProofData {
+	data := &ProofData{
+		Genesis:      true,
+		EpochLeaders: make([]*ecdsa.PublicKey, posconfig.EpochLeaderCount),
+		Random:       posconfig.GetRandomGenesis(),
+	}
+	if len(defaultLeaders) == 0 {
+		return data
+	}
+	for i := 0; i < posconfig.EpochLeaderCount; i++ {
+		data.EpochLeaders[i] = defaultLeaders[i%len(defaultLeaders)]
+	}
+
+	alphas := make([]*big.Int, 0, posconfig.EpochLeaderCount)
+	for _, value := range data.EpochLeaders {
+		alphas = append(alphas, new(big.Int).SetBytes(crypto.Keccak256(crypto.FromECDSAPub(value))))
+	}
+
+	for i := 0; i < posconfig.EpochLeaderCount; i++ {
+		data.ValidIndexes[i] = true
+
+		smaPiece := new(ecdsa.PublicKey)
+		smaPiece.Curve = crypto.S256()
+		smaPiece.X, smaPiece.Y = crypto.S256().ScalarBaseMult(alphas[i].Bytes())
+		data.Sma[i] = smaPiece
+
+		for j := 0; j < posconfig.EpochLeaderCount; j++ {
+			alphaIPkj := new(ecdsa.PublicKey)
+			alphaIPkj.Curve = crypto.S256()
+			alphaIPkj.X, alphaIPkj.Y = crypto.S256().ScalarMult(data.EpochLeaders[j].X,
+				data.EpochLeaders[j].Y, alphas[i].Bytes())
+			data.AlphaPki[i][j] = alphaIPkj
+		}
+	}
+	return data
+}
+
+// VerifySlotProofWithData checks a slot leader proof against the given proof
+// data only, without touching posdb or any local state. It follows the same
+// steps as SLS.VerifySlotProof.
+func VerifySlotProofWithData(data *ProofData, epochID uint64, slotID uint64, Proof []*big.Int,
+	ProofMeg []*ecdsa.PublicKey) bool {
+	if data == nil || data.Random == nil || len(data.EpochLeaders) == 0 {
+		return false
+	}
+	if len(Proof) != LenProof || len(ProofMeg) != LenProofMeg {
+		return false
+	}
+
+	// genesis proofs are always built on epoch 0
+	skGtEpochID := epochID
+	if data.Genesis {
+		skGtEpochID = 0
+	}
+	rbBytes := data.Random.Bytes()
+
+	publicKeyIndexes := make([]int, 0)
+	for index, value := range data.EpochLeaders {
+		if uleaderselection.PublicKeyEqual(ProofMeg[0], value) {
+			publicKeyIndexes = append(publicKeyIndexes, index)
+		}
+	}
+
+	skGtValid := false
+	for _, index := range publicKeyIndexes {
+		smaPieces := make([]*ecdsa.PublicKey, 0)
+		for i := 0; i < posconfig.EpochLeaderCount; i++ {
+			if data.ValidIndexes[i] && data.AlphaPki[i][index] != nil {
+				smaPieces = append(smaPieces, data.AlphaPki[i][index])
+			}
+		}
+		if len(smaPieces) == 0 {
+			continue
+		}
+
+		skGt := computeSkGt(data.EpochLeaders, skGtEpochID, slotID, rbBytes, smaPieces)
+		if uleaderselection.PublicKeyEqual(skGt, ProofMeg[2]) {
+			skGtValid = true
+			break
+		}
+	}
+	if !skGtValid {
+		log.Warn("VerifySlotProofWithData Fail skGt is not valid", "epochID", epochID, "slotID", slotID)
+		return false
+	}
+
+	return uleaderselection.VerifySlotLeaderProof(Proof, ProofMeg, data.EpochLeaders, rbBytes)
+}
@@ -0,0 +1,82 @@
+package slotleader
+
+import (
+	"crypto/ecdsa"
+	"math/big"
+	"testing"
+
+	"github.com/wanchain/go-wanchain/crypto"
+	"github.com/wanchain/go-wanchain/pos/posconfig"
+	"github.com/wanchain/go-wanchain/pos/uleaderselection"
+)
+
+func TestNewGenesisProofData(t *testing.T) {
+	prvKey, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := NewGenesisProofData([]*ecdsa.PublicKey{&prvKey.PublicKey})
+	if !data.Genesis || len(data.EpochLeaders) != posconfig.EpochLeaderCount {
+		t.Fatal("genesis proof data not filled")
+	}
+	for i := 0; i < posconfig.EpochLeaderCount; i++ {
+		if !data.ValidIndexes[i] || data.Sma[i] == nil || data.AlphaPki[i][0] == nil {
+			t.Fatalf("index %d not filled", i)
+		}
+	}
+
+	empty := NewGenesisProofData(nil)
+	if empty.EpochLeaders[0] != nil {
+		t.Fatal("expect no epoch leaders without default leaders")
+	}
+}
+
+func TestVerifySlotProofWithData(t *testing.T) {
+	keys := make(map[string]*ecdsa.PrivateKey)
+	leaders := make([]*ecdsa.PublicKey, 0)
+	for i := 0; i < 3; i++ {
+		key, err := crypto.GenerateKey()
+		if err != nil {
+			t.Fatal(err)
+		}
+		keys[string(crypto.FromECDSAPub(&key.PublicKey))] = key
+		leaders = append(leaders, &key.PublicKey)
+	}
+	data := NewGenesisProofData(leaders)
+
+	slotLeaders, _, _, err := uleaderselection.GenerateSlotLeaderSeqAndIndex(data.Sma[:], data.EpochLeaders,
+		data.Random.Bytes(), 16, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	slotID := uint64(3)
+	prvKey := keys[string(crypto.FromECDSAPub(slotLeaders[slotID]))]
+	otherSlot := uint64(0)
+	for i, leader := range slotLeaders {
+		if !uleaderselection.PublicKeyEqual(leader, slotLeaders[slotID]) {
+			otherSlot = uint64(i)
+			break
+		}
+	}
+
+	profMeg, proof, err := uleaderselection.GenerateSlotLeaderProof(prvKey, data.Sma[:], data.EpochLeaders,
+		data.Random.Bytes(), slotID, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !VerifySlotProofWithData(data, 10, slotID, proof, profMeg) {
+		t.Fatal("valid genesis proof rejected")
+	}
+	if VerifySlotProofWithData(data, 10, otherSlot, proof, profMeg) {
+		t.Fatal("proof accepted for a slot led by another leader")
+	}
+
+	broken := []*big.Int{proof[0], new(big.Int).Add(proof[1], big.NewInt(1))}
+	if VerifySlotProofWithData(data, 10, slotID, broken, profMeg) {
+		t.Fatal("tampered proof accepted")
+	}
+	if VerifySlotProofWithData(nil, 10, slotID, proof, profMeg) {
+		t.Fatal("proof accepted without data")
+	}
+}
@@ -0,0 +1,88 @@
+package slotleader
+
+import (
+	"crypto/ecdsa"
+	"errors"
+
+	"github.com/wanchain/go-wanchain/core/vm"
+	"github.com/wanchain/go-wanchain/pos/posconfig"
+	"github.com/wanchain/go-wanchain/pos/posdb"
+	"github.com/wanchain/go-wanchain/pos/uleaderselection"
+	"github.com/wanchain/go-wanchain/pos/util"
+)
+
+// ErrSlotLeadersNotReady is returned for an epoch whose random number or
+// security message is not generated yet.
+var ErrSlotLeadersNotReady = errors.New("slot leaders of the epoch are not generated yet")
+
+// GetEpochSlotLeaders returns the slot leaders of all the slots of an epoch,
+// the same GetSlotLeader returns slot by slot. The leaders of an epoch after
+// the first ones are only known by the epoch leaders of the previous epoch,
+// once the random number and the security message of the epoch are generated.
+func (s *SLS) GetEpochSlotLeaders(epochID uint64) ([]*ecdsa.PublicKey, error) {
+	if leaders, ok := SlotLeadersCache.Get(epochID); ok {
+		return leaders.([]*ecdsa.PublicKey), nil
+	}
+
+	curEpochID, _ := util.GetEpochSlotID()
+	if epochID > curEpochID+1 {
+		return nil, ErrSlotLeadersNotReady
+	}
+
+	if epochID <= posconfig.FirstEpochId+2 {
+		return s.defaultSlotLeaders(), nil
+	}
+
+	if epochID > curEpochID {
+		// The chain still uses the defaults for an epoch not started yet
+		if _, err := posdb.GetDb().Get(epochID, SecurityMsg); err != nil {
+			return nil, ErrSlotLeadersNotReady
+		}
+		db, err := s.getCurrentStateDb()
+		if err != nil {
+			return nil, err
+		}
+		if vm.GetR(db, epochID) == nil {
+			return nil, ErrSlotLeadersNotReady
+		}
+	}
+
+	if _, isGenesis, _ := s.getSMAPieces(epochID); isGenesis {
+		return s.defaultSlotLeaders(), nil
+	}
+
+	epochIDGet := epochID
+	epochLeadersPtrArray, isDefault := s.GetPreEpochLeadersPK(epochIDGet)
+	if isDefault {
+		epochIDGet = 0
+	}
+	if !s.IsLocalPkInEpochLeaders(epochLeadersPtrArray) {
+		return nil, uleaderselection.ErrNoInPreEPLS
+	}
+	if len(epochLeadersPtrArray) != posconfig.EpochLeaderCount {
+		return nil, errors.New("fail to get epoch leaders")
+	}
+
+	piecesPtr, _, _ := s.getSMAPieces(epochIDGet)
+	random, err := s.getRandom(nil, epochIDGet)
+	if err != nil {
+		return nil, vm.ErrInvalidRandom
+	}
+
+	leaders, _, _, err := uleaderselection.GenerateSlotLeaderSeqAndIndex(piecesPtr[:],
+		epochLeadersPtrArray[:], random.Bytes(), posconfig.SlotCount, epochID)
+	if err != nil {
+		return nil, err
+	}
+
+	SlotLeadersCache.Add(epochID, leaders)
+	return leaders, nil
+}
+
+func (s *SLS) defaultSlotLeaders() []*ecdsa.PublicKey {
+	leaders := make([]*ecdsa.PublicKey, posconfig.SlotCount)
+	for i := range leaders {
+		leaders[i] = s.getDefaultSlotLeader(uint64(i))
+	}
+	return leaders
+}
@@ -23,12 +23,17 @@ func (s *SLS) sendSlotTx(payload []byte, posSender SendTxFn) error {
 		return errRCNotReady
 	}
 
+	signer := s.minerSigner()
+	if signer == nil {
+		return vm.ErrInvalidLocalPublicKey
+	}
+
 	to := vm.GetSlotLeaderSCAddress()
 	data := hexutil.Bytes(payload)
 	gas := core.IntrinsicGas(data, &to, true)
 
 	arg := map[string]interface{}{}
-	arg["from"] = s.key.Address
+	arg["from"] = signer.Address()
 	arg["to"] = vm.GetSlotLeaderSCAddress()
 	arg["value"] = (*hexutil.Big)(big.NewInt(0))
 	arg["gas"] = (*hexutil.Big)(gas)
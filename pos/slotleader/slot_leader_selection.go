@@ -23,6 +23,7 @@ import (
 	"github.com/wanchain/go-wanchain/log"
 	"github.com/wanchain/go-wanchain/pos/posconfig"
 	"github.com/wanchain/go-wanchain/pos/posdb"
+	"github.com/wanchain/go-wanchain/pos/possigner"
 	"github.com/wanchain/go-wanchain/pos/util/convert"
 
 	lru "github.com/hashicorp/golang-lru"
@@ -61,6 +62,7 @@ type SLS struct {
 	workStage      int
 	rc             *rpc.Client
 	key            *keystore.Key
+	signer         posconfig.MinerSigner // set by Loop, used when there is no local key
 	stateDbTest    *state.StateDB
 
 	epochLeadersArray []string            // len(pki)=65 hex.EncodeToString
@@ -97,6 +99,9 @@ var APkiCache *lru.ARCCache
 
 var RndCache *lru.ARCCache
 
+// SlotLeadersCache holds the slot leaders of whole epochs, see GetEpochSlotLeaders.
+var SlotLeadersCache *lru.ARCCache
+
 type Pack struct {
 	Proof    [][]byte
 	ProofMeg [][]byte
@@ -227,6 +232,11 @@ func SlsInit() {
 		log.SyslogErr("RndCache failed")
 	}
 
+	SlotLeadersCache, err = lru.NewARC(4)
+	if err != nil || SlotLeadersCache == nil {
+		log.SyslogErr("SlotLeadersCache failed")
+	}
+
 	slotLeaderSelection = &SLS{}
 	slotLeaderSelection.epochLeadersMap = make(map[string][]uint64)
 	slotLeaderSelection.epochLeadersArray = make([]string, 0)
@@ -240,7 +250,13 @@ func (s *SLS) getSlotLeaderStage2TxIndexes(epochID uint64) (indexesSentTran []bo
 	if err != nil {
 		return ret[:], err
 	}
+	return stage2TxIndexes(stateDb, epochID)
+}
 
+// stage2TxIndexes returns which epoch leaders of epochID sent their stage two
+// transaction, as recorded in stateDb.
+func stage2TxIndexes(stateDb *state.StateDB, epochID uint64) ([]bool, error) {
+	var ret [posconfig.EpochLeaderCount]bool
 	slotLeaderPrecompileAddr := vm.GetSlotLeaderSCAddress()
 
 	keyHash := vm.GetSlotLeaderStage2IndexesKeyHash(convert.Uint64ToBytes(epochID))
@@ -254,7 +270,7 @@ func (s *SLS) getSlotLeaderStage2TxIndexes(epochID uint64) (indexesSentTran []bo
 		return ret[:], vm.ErrNoTx2TransInDB
 	}
 
-	err = rlp.DecodeBytes(data, &ret)
+	err := rlp.DecodeBytes(data, &ret)
 	if err != nil {
 		return ret[:], vm.ErrNoTx2TransInDB
 	}
@@ -275,12 +291,21 @@ func (s *SLS) getAlpha(epochID uint64, selfIndex uint64) (*big.Int, error) {
 	return alpha, nil
 }
 
+// minerSigner returns the signer of the local miner, or nil if there is none.
+func (s *SLS) minerSigner() posconfig.MinerSigner {
+	if s.key != nil && s.key.PrivateKey != nil {
+		return possigner.NewKeySigner(s.key)
+	}
+	return s.signer
+}
+
 func (s *SLS) getLocalPublicKey() (*ecdsa.PublicKey, error) {
-	if s.key == nil || s.key.PrivateKey == nil {
+	signer := s.minerSigner()
+	if signer == nil {
 		log.SyslogErr("SLS", "getLocalPublicKey", vm.ErrInvalidLocalPublicKey.Error())
 		return nil, vm.ErrInvalidLocalPublicKey
 	}
-	return &s.key.PrivateKey.PublicKey, nil
+	return signer.PublicKey(), nil
 }
 
 func (s *SLS) getLocalPrivateKey() (*ecdsa.PrivateKey, error) {
@@ -743,10 +768,14 @@ func (s *SLS) collectStagesData(epochID uint64) (err error) {
 	return nil
 }
 
-func (s *SLS) generateSecurityMsg(epochID uint64, PrivateKey *ecdsa.PrivateKey) error {
+func (s *SLS) generateSecurityMsg(epochID uint64) error {
+	signer := s.minerSigner()
+	if signer == nil {
+		return vm.ErrInvalidLocalPublicKey
+	}
 	if !s.isLocalPkInCurrentEpochLeaders() {
 		log.Debug("generateSecurityMsg", "input public key",
-			hex.EncodeToString(crypto.FromECDSAPub(&PrivateKey.PublicKey)))
+			hex.EncodeToString(crypto.FromECDSAPub(signer.PublicKey())))
 		return vm.ErrPkNotInCurrentEpochLeadersGroup
 	}
 	// collect data
@@ -765,7 +794,7 @@ func (s *SLS) generateSecurityMsg(epochID uint64, PrivateKey *ecdsa.PrivateKey)
 	smasPtr := make([]*ecdsa.PublicKey, 0)
 	var smasBytes bytes.Buffer
 
-	smasPtr, err = uleaderselection.GenerateSMA(PrivateKey, ArrayPiece)
+	smasPtr, err = signer.GenerateSMA(ArrayPiece)
 	if err != nil {
 		log.Error("generateSecurityMsg:GenerateSMA", "error", err.Error())
 		return err
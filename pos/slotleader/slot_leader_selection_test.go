@@ -18,6 +18,7 @@ import (
 	"github.com/wanchain/go-wanchain/ethdb"
 	"github.com/wanchain/go-wanchain/pos/posconfig"
 	"github.com/wanchain/go-wanchain/pos/uleaderselection"
+	"github.com/wanchain/go-wanchain/pos/util"
 	"github.com/wanchain/go-wanchain/pos/util/convert"
 
 	"github.com/btcsuite/btcd/btcec"
@@ -829,7 +830,7 @@ func TestGenerateSecurityMsg(t *testing.T) {
 	// build security pieces
 	//pieces,_:= s.buildSecurityPieces(epochID)
 	// create SMA
-	err = s.generateSecurityMsg(epochID, key)
+	err = s.generateSecurityMsg(epochID)
 	if err != nil {
 		t.Logf("generate security message error. err:%v \n", err.Error())
 		t.Fail()
@@ -847,3 +848,34 @@ func TestGenerateSecurityMsg(t *testing.T) {
 	RmDB("epochGendb")
 	os.RemoveAll(path.Join(dir, "sl_leader_test"))
 }
+
+func TestGetEpochSlotLeaders(t *testing.T) {
+	dir, _ := filepath.Abs(filepath.Dir(os.Args[0]))
+	os.RemoveAll(path.Join(dir, "sl_leader_test"))
+	posdb.GetDb().DbInit(path.Join(dir, "sl_leader_test"))
+	defer os.RemoveAll(path.Join(dir, "sl_leader_test"))
+
+	SlsInit()
+	s := GetSlotLeaderSelection()
+
+	for i := 0; i < posconfig.SlotCount; i++ {
+		prvKey, _ := crypto.GenerateKey()
+		s.defaultSlotLeadersPtrArray[i] = &prvKey.PublicKey
+	}
+
+	leaders, err := s.GetEpochSlotLeaders(0)
+	if err != nil || len(leaders) != posconfig.SlotCount {
+		t.Fatalf("slot leaders of the first epoch: %d %v", len(leaders), err)
+	}
+	for _, slotID := range []uint64{0, 1, posconfig.SlotCount - 1} {
+		leader, err := s.GetSlotLeader(0, slotID)
+		if err != nil || leader != leaders[slotID] {
+			t.Fatalf("slot leader of slot %d mismatch: %v", slotID, err)
+		}
+	}
+
+	curEpochID, _ := util.GetEpochSlotID()
+	if _, err := s.GetEpochSlotLeaders(curEpochID + 2); err != ErrSlotLeadersNotReady {
+		t.Fatalf("slot leaders of a future epoch: %v", err)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"github.com/wanchain/go-wanchain/core/state"
 	"github.com/wanchain/go-wanchain/core/types"
+	"github.com/wanchain/go-wanchain/core/vm"
 	"github.com/wanchain/go-wanchain/crypto"
 	"github.com/wanchain/go-wanchain/log"
 	"github.com/wanchain/go-wanchain/pos/posconfig"
@@ -51,6 +52,44 @@ func (s *SLS) ValidateBody(block *types.Block) error {
 	return nil
 }
 
+// ValidateHeaderAt validates the slot leader proof of a header like
+// ValidateBody, for a header whose parent state is not available. The random
+// and the stage two transactions of its epoch are read from stateDb, the state
+// of the last block of the epoch before, which may be nil for the epochs led
+// by the genesis leaders.
+func (s *SLS) ValidateHeaderAt(header *types.Header, stateDb *state.StateDB) error {
+	extraSeal := 65
+	blkTd := header.Difficulty.Uint64()
+	epochID := (blkTd >> 32)
+	slotID := ((blkTd & 0xffffffff) >> 8)
+
+	if len(header.Extra) < extraSeal {
+		return errors.New("Can not GetInfoFromHeadExtra, verify failed")
+	}
+	proof, proofMeg, err := s.GetInfoFromHeadExtra(epochID, header.Extra[:len(header.Extra)-extraSeal])
+	if err != nil {
+		return errors.New("Can not GetInfoFromHeadExtra, verify failed")
+	}
+
+	valid := false
+	if epochID <= posconfig.FirstEpochId+2 {
+		valid = s.verifySlotProofByGenesis(epochID, slotID, proof, proofMeg)
+	} else if epochLeadersPtrPre, isDefault := s.GetPreEpochLeadersPK(epochID); isDefault {
+		valid = s.verifySlotProofByGenesis(epochID, slotID, proof, proofMeg)
+	} else if stateDb != nil {
+		rb := vm.GetR(stateDb, epochID)
+		if rb == nil {
+			rb = posconfig.GetRandomGenesis()
+		}
+		valid = s.verifySlotProofWith(epochLeadersPtrPre, epochID, slotID, proof, proofMeg, rb, stateDb)
+	}
+	if !valid {
+		log.Error("VerifyPackedSlotProof failed", "number", header.Number, "epochID", epochID, "slotID", slotID)
+		return errors.New("VerifyPackedSlotProof failed")
+	}
+	return nil
+}
+
 func (s *SLS) ValidateState(block, parent *types.Block, state *state.StateDB, receipts types.Receipts, usedGas *big.Int) error {
 	return nil
 }
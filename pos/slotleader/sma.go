@@ -0,0 +1,100 @@
+package slotleader
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"fmt"
+
+	"github.com/wanchain/go-wanchain/core"
+	"github.com/wanchain/go-wanchain/core/state"
+	"github.com/wanchain/go-wanchain/core/vm"
+	"github.com/wanchain/go-wanchain/crypto"
+	"github.com/wanchain/go-wanchain/pos/epochLeader"
+	"github.com/wanchain/go-wanchain/pos/posconfig"
+	"github.com/wanchain/go-wanchain/pos/posdb"
+	"github.com/wanchain/go-wanchain/pos/util"
+)
+
+// SecurityMsgAt computes again the security message signer generated as an
+// epoch leader of epochID, which is stored for epochID+1. It is built from the
+// stage two transactions in stateDb, a state after the stage two of the epoch.
+// leaders are the epoch leaders of epochID.
+func SecurityMsgAt(stateDb *state.StateDB, epochID uint64, leaders [][]byte, signer posconfig.MinerSigner) ([]byte, error) {
+	selfPk := crypto.FromECDSAPub(signer.PublicKey())
+	selfIndex := -1
+	for i, pk := range leaders {
+		if bytes.Equal(pk, selfPk) {
+			selfIndex = i
+			break
+		}
+	}
+	if selfIndex < 0 {
+		return nil, vm.ErrPkNotInCurrentEpochLeadersGroup
+	}
+
+	indexesSentTran, err := stage2TxIndexes(stateDb, epochID)
+	if err != nil {
+		return nil, err
+	}
+	pieces := make([]*ecdsa.PublicKey, 0)
+	for i := 0; i < posconfig.EpochLeaderCount; i++ {
+		if !indexesSentTran[i] {
+			continue
+		}
+		alphaPki, proof, err := vm.GetStage2TxAlphaPki(stateDb, epochID, uint64(i))
+		if err != nil || len(alphaPki) != posconfig.EpochLeaderCount || len(proof) != StageTwoProofCount {
+			continue
+		}
+		if alphaPki[selfIndex] != nil {
+			pieces = append(pieces, alphaPki[selfIndex])
+		}
+	}
+
+	if len(pieces) == 0 {
+		return nil, vm.ErrNoTx2TransInDB
+	}
+
+	smas, err := signer.GenerateSMA(pieces)
+	if err != nil {
+		return nil, err
+	}
+	var smasBytes bytes.Buffer
+	for _, value := range smas {
+		smasBytes.Write(crypto.FromECDSAPub(value))
+	}
+	return smasBytes.Bytes(), nil
+}
+
+// SecurityMsgOf computes again the security message signer generated in
+// epochID, from the state of the last block of the epoch. It returns nil if
+// the signer generated none.
+func SecurityMsgOf(chain *core.BlockChain, epocher *epochLeader.Epocher, epochID uint64, signer posconfig.MinerSigner) ([]byte, error) {
+	number := util.GetEpochBlock(epochID)
+	header := chain.GetHeaderByNumber(number)
+	if header == nil {
+		return nil, fmt.Errorf("block %d missing", number)
+	}
+	stateDb, err := chain.StateAt(header.Root)
+	if err != nil {
+		return nil, fmt.Errorf("state of block %d missing: %v", number, err)
+	}
+	msg, err := SecurityMsgAt(stateDb, epochID, epocher.GetEpochLeaders(epochID), signer)
+	if err == vm.ErrPkNotInCurrentEpochLeadersGroup || err == vm.ErrNoTx2TransInDB {
+		return nil, nil
+	}
+	return msg, err
+}
+
+// RebuildSecurityMsg stores again the security message signer generated in
+// epochID, for epochID+1, the way the slot leader workflow does. It returns
+// false if the signer generated none.
+func RebuildSecurityMsg(chain *core.BlockChain, epocher *epochLeader.Epocher, epochID uint64, signer posconfig.MinerSigner) (bool, error) {
+	msg, err := SecurityMsgOf(chain, epocher, epochID, signer)
+	if err != nil || msg == nil {
+		return false, err
+	}
+	if _, err := posdb.GetDb().Put(epochID+1, SecurityMsg, msg); err != nil {
+		return false, err
+	}
+	return true, nil
+}
@@ -124,9 +124,10 @@ func (s *SLS) initSma() {
 //Loop check work every Slot time. Called by backend loop.
 //It's all slotLeaderSelection's main workflow loop.
 //It does not loop at all, it is loop called by the backend.
-func (s *SLS) Loop(rc *rpc.Client, key *keystore.Key, epochID uint64, slotID uint64) {
+func (s *SLS) Loop(rc *rpc.Client, signer posconfig.MinerSigner, epochID uint64, slotID uint64) {
 	s.rc = rc
-	s.key = key
+	s.key = nil
+	s.signer = signer
 
 	log.Info("Now epchoID and slotID:", "epochID", convert.Uint64ToString(epochID), "slotID",
 		convert.Uint64ToString(slotID))
@@ -192,7 +193,7 @@ func (s *SLS) Loop(rc *rpc.Client, key *keystore.Key, epochID uint64, slotID uin
 			break
 		}
 
-		err := s.generateSecurityMsg(epochID, s.key.PrivateKey)
+		err := s.generateSecurityMsg(epochID)
 		if err != nil {
 			log.Warn(err.Error())
 		} else {
@@ -19,6 +19,7 @@ import (
 	"github.com/wanchain/go-wanchain/core/vm"
 	"github.com/wanchain/go-wanchain/crypto"
 	"github.com/wanchain/go-wanchain/pos/posdb"
+	"github.com/wanchain/go-wanchain/pos/possigner"
 	"github.com/wanchain/go-wanchain/rlp"
 	"github.com/wanchain/go-wanchain/rpc"
 )
@@ -81,11 +82,11 @@ func TestLoop(t *testing.T) {
 	epochIDStart := time.Now().Second()
 
 	for i := 0; i < posconfig.SlotCount; i++ {
-		s.Loop(&rpc.Client{}, key, uint64(epochIDStart+0), uint64(i))
+		s.Loop(&rpc.Client{}, possigner.NewKeySigner(key), uint64(epochIDStart+0), uint64(i))
 	}
 
 	for i := 0; i < posconfig.SlotCount; i++ {
-		s.Loop(&rpc.Client{}, key, uint64(epochIDStart+1), uint64(i))
+		s.Loop(&rpc.Client{}, possigner.NewKeySigner(key), uint64(epochIDStart+1), uint64(i))
 	}
 	RmDB("test")
 	posconfig.SelfTestMode = false
@@ -0,0 +1,256 @@
+// Package staking builds, validates and signs the calls of the pos staking
+// contract, so that validators and delegators don't need to pack its abi by
+// hand.
+package staking
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/wanchain/go-wanchain/common"
+	"github.com/wanchain/go-wanchain/core/types"
+	"github.com/wanchain/go-wanchain/core/vm"
+	"github.com/wanchain/go-wanchain/crypto"
+	"github.com/wanchain/go-wanchain/params"
+	"github.com/wanchain/go-wanchain/pos/util"
+)
+
+// Methods of the pos staking contract.
+const (
+	MethodStakeIn            = "stakeIn"
+	MethodStakeRegister      = "stakeRegister"
+	MethodStakeAppend        = "stakeAppend"
+	MethodStakeUpdate        = "stakeUpdate"
+	MethodStakeUpdateFeeRate = "stakeUpdateFeeRate"
+	MethodPartnerIn          = "partnerIn"
+	MethodDelegateIn         = "delegateIn"
+	MethodDelegateOut        = "delegateOut"
+)
+
+// DefaultGasLimit is enough for any call of the staking contract.
+const DefaultGasLimit = 200000
+
+var (
+	wan = big.NewInt(params.Wan)
+
+	MinStakeholderStake = new(big.Int).Mul(big.NewInt(vm.PSMinStakeholderStake), wan)
+	MinValidatorStake   = new(big.Int).Mul(big.NewInt(vm.PSMinValidatorStake), wan)
+	MinDelegatorStake   = new(big.Int).Mul(big.NewInt(vm.PSMinDelegatorStake), wan)
+	MinPartnerIn        = new(big.Int).Mul(big.NewInt(vm.PSMinPartnerIn), wan)
+	MaxTotalStake       = new(big.Int).Mul(big.NewInt(vm.PSMaxStake), wan)
+)
+
+var (
+	ErrUnknownMethod = errors.New("not a call of the pos staking contract")
+	ErrNotPayable    = errors.New("method doesn't accept value")
+	ErrNoValue       = errors.New("method needs a value")
+)
+
+// LimitError is the error of a check of a call against a bound of the staking
+// contract. Field is "value" or the name of the argument out of the bound, and
+// Limit the bound.
+type LimitError struct {
+	Field string
+	Limit string
+	msg   string
+}
+
+func (e *LimitError) Error() string {
+	return e.msg
+}
+
+func limitError(field string, limit interface{}, format string, args ...interface{}) error {
+	return &LimitError{Field: field, Limit: fmt.Sprint(limit), msg: fmt.Sprintf(format, args...)}
+}
+
+// Call is a call of the pos staking contract.
+type Call struct {
+	Method string
+	Value  *big.Int
+	Data   []byte
+}
+
+func newCall(method string, value *big.Int, args ...interface{}) (*Call, error) {
+	data, err := vm.GetPosStakingAbi().Pack(method, args...)
+	if err != nil {
+		return nil, err
+	}
+	if value == nil {
+		value = new(big.Int)
+	}
+	call := &Call{Method: method, Value: value, Data: data}
+	if err := call.Validate(); err != nil {
+		return nil, err
+	}
+	return call, nil
+}
+
+// StakeIn registers a validator with its secp256k1 and bn256 public keys.
+func StakeIn(secPk, bn256Pk []byte, lockEpochs, feeRate uint64, value *big.Int) (*Call, error) {
+	return newCall(MethodStakeIn, value, secPk, bn256Pk, new(big.Int).SetUint64(lockEpochs), new(big.Int).SetUint64(feeRate))
+}
+
+// StakeRegister registers a validator like StakeIn, with a cap on the fee rate
+// it may later set.
+func StakeRegister(secPk, bn256Pk []byte, lockEpochs, feeRate, maxFeeRate uint64, value *big.Int) (*Call, error) {
+	return newCall(MethodStakeRegister, value, secPk, bn256Pk, new(big.Int).SetUint64(lockEpochs), new(big.Int).SetUint64(feeRate), new(big.Int).SetUint64(maxFeeRate))
+}
+
+// StakeAppend adds value to the stake of a validator.
+func StakeAppend(validator common.Address, value *big.Int) (*Call, error) {
+	return newCall(MethodStakeAppend, value, validator)
+}
+
+// StakeUpdate sets the lock epochs of the next staking period of a validator,
+// 0 to quit at the end of the current one.
+func StakeUpdate(validator common.Address, lockEpochs uint64) (*Call, error) {
+	return newCall(MethodStakeUpdate, nil, validator, new(big.Int).SetUint64(lockEpochs))
+}
+
+// StakeUpdateFeeRate changes the fee rate of a validator.
+func StakeUpdateFeeRate(validator common.Address, feeRate uint64) (*Call, error) {
+	return newCall(MethodStakeUpdateFeeRate, nil, validator, new(big.Int).SetUint64(feeRate))
+}
+
+// PartnerIn joins or adds value to the partners of a validator.
+func PartnerIn(validator common.Address, renewal bool, value *big.Int) (*Call, error) {
+	return newCall(MethodPartnerIn, value, validator, renewal)
+}
+
+// DelegateIn delegates value to a validator.
+func DelegateIn(validator common.Address, value *big.Int) (*Call, error) {
+	return newCall(MethodDelegateIn, value, validator)
+}
+
+// DelegateOut withdraws the delegation to a validator.
+func DelegateOut(validator common.Address) (*Call, error) {
+	return newCall(MethodDelegateOut, nil, validator)
+}
+
+// Decode returns the staking contract call of a transaction.
+func Decode(tx *types.Transaction) (*Call, error) {
+	if tx.To() == nil || *tx.To() != vm.WanCscPrecompileAddr {
+		return nil, ErrUnknownMethod
+	}
+	data := tx.Data()
+	if len(data) < 4 {
+		return nil, ErrUnknownMethod
+	}
+	for name, method := range vm.GetPosStakingAbi().Methods {
+		if bytes.Equal(method.Id(), data[:4]) {
+			return &Call{Method: name, Value: tx.Value(), Data: data}, nil
+		}
+	}
+	return nil, ErrUnknownMethod
+}
+
+// Validator returns the address of the validator targeted by the call.
+func (c *Call) Validator() common.Address {
+	switch c.Method {
+	case MethodStakeIn, MethodStakeRegister:
+		var (
+			info vm.StakeRegisterParam
+			err  error
+		)
+		if c.Method == MethodStakeIn {
+			err = vm.GetPosStakingAbi().UnpackInput(&info.StakeInParam, c.Method, c.Data[4:])
+		} else {
+			err = vm.GetPosStakingAbi().UnpackInput(&info, c.Method, c.Data[4:])
+		}
+		if err != nil {
+			return common.Address{}
+		}
+		pub := crypto.ToECDSAPub(info.SecPk)
+		if pub == nil {
+			return common.Address{}
+		}
+		return crypto.PubkeyToAddress(*pub)
+	}
+	// The other methods take the validator as first argument
+	if len(c.Data) < 36 {
+		return common.Address{}
+	}
+	return common.BytesToAddress(c.Data[4:36])
+}
+
+// Validate checks the call offline. It runs PosStaking.ValidTx, which the
+// transaction pool applies to staking calls, and checks the limits on the value
+// that don't depend on the chain state. See CheckStaker for the others.
+func (c *Call) Validate() error {
+	epochID, _ := util.CalEpochSlotID(uint64(time.Now().Unix()))
+	return c.ValidateAt(epochID)
+}
+
+// ValidateAt is Validate for a call included in epoch epochID.
+func (c *Call) ValidateAt(epochID uint64) error {
+	if err := new(vm.PosStaking).ValidInput(c.Data, epochID); err != nil {
+		return err
+	}
+	switch c.Method {
+	case MethodStakeIn, MethodStakeRegister:
+		if c.Value.Cmp(MinStakeholderStake) < 0 {
+			return limitError("value", MinStakeholderStake, "stake %s is below the minimum of %d wan", FormatAmount(c.Value), vm.PSMinStakeholderStake)
+		}
+		if c.Value.Cmp(MaxTotalStake) > 0 {
+			return limitError("value", MaxTotalStake, "stake %s is above the maximum of %d wan", FormatAmount(c.Value), vm.PSMaxStake)
+		}
+	case MethodStakeAppend, MethodPartnerIn, MethodDelegateIn:
+		if c.Value.Sign() <= 0 {
+			return ErrNoValue
+		}
+	case MethodStakeUpdate, MethodStakeUpdateFeeRate, MethodDelegateOut:
+		if c.Value.Sign() != 0 {
+			return ErrNotPayable
+		}
+	default:
+		return ErrUnknownMethod
+	}
+	return nil
+}
+
+// Tx returns the unsigned transaction of the call. Nil gas values are left 0.
+func (c *Call) Tx(nonce uint64, gasLimit, gasPrice *big.Int) *types.Transaction {
+	if gasLimit == nil {
+		gasLimit = new(big.Int)
+	}
+	if gasPrice == nil {
+		gasPrice = new(big.Int)
+	}
+	return types.NewTransaction(nonce, vm.WanCscPrecompileAddr, c.Value, gasLimit, gasPrice, c.Data)
+}
+
+// SignTx signs a staking transaction offline with the EIP155 signer of chainID.
+func SignTx(tx *types.Transaction, chainID *big.Int, key *ecdsa.PrivateKey) (*types.Transaction, error) {
+	return types.SignTx(tx, types.NewEIP155Signer(chainID), key)
+}
+
+// ParseAmount parses an amount of wan with up to 18 decimals, e.g. "10000.5".
+func ParseAmount(s string) (*big.Int, error) {
+	parts := strings.SplitN(strings.TrimSpace(s), ".", 2)
+	if len(parts) == 2 && (len(parts[1]) > 18 || len(parts[1]) == 0) || parts[0] == "" {
+		return nil, fmt.Errorf("invalid amount %q", s)
+	}
+	frac := ""
+	if len(parts) == 2 {
+		frac = parts[1]
+	}
+	amount, ok := new(big.Int).SetString(parts[0]+frac+strings.Repeat("0", 18-len(frac)), 10)
+	if !ok || amount.Sign() < 0 {
+		return nil, fmt.Errorf("invalid amount %q", s)
+	}
+	return amount, nil
+}
+
+// FormatAmount formats an amount of wei in wan.
+func FormatAmount(amount *big.Int) string {
+	whole, frac := new(big.Int).QuoRem(amount, wan, new(big.Int))
+	if frac.Sign() == 0 {
+		return whole.String() + " wan"
+	}
+	return fmt.Sprintf("%s.%s wan", whole, strings.TrimRight(fmt.Sprintf("%018s", frac), "0"))
+}
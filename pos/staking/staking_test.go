@@ -0,0 +1,175 @@
+package staking
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/wanchain/go-wanchain/common"
+	"github.com/wanchain/go-wanchain/common/math"
+	"github.com/wanchain/go-wanchain/core/types"
+	"github.com/wanchain/go-wanchain/core/vm"
+	"github.com/wanchain/go-wanchain/crypto"
+	"github.com/wanchain/go-wanchain/crypto/bn256"
+)
+
+func wans(n int64) *big.Int {
+	return new(big.Int).Mul(big.NewInt(n), wan)
+}
+
+func TestBuildAndValidate(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	secPk := crypto.FromECDSAPub(&key.PublicKey)
+	bn256Pk := new(bn256.G1).ScalarBaseMult(big.NewInt(7)).Marshal()
+	validator := crypto.PubkeyToAddress(key.PublicKey)
+
+	valid := []func() (*Call, error){
+		func() (*Call, error) { return StakeIn(secPk, bn256Pk, 30, 1500, wans(50000)) },
+		func() (*Call, error) { return StakeRegister(secPk, bn256Pk, 30, 1500, 2000, wans(50000)) },
+		func() (*Call, error) { return StakeAppend(validator, wans(1)) },
+		func() (*Call, error) { return StakeUpdate(validator, 0) },
+		func() (*Call, error) { return StakeUpdate(validator, 90) },
+		func() (*Call, error) { return StakeUpdateFeeRate(validator, 1000) },
+		func() (*Call, error) { return PartnerIn(validator, true, wans(10000)) },
+		func() (*Call, error) { return DelegateIn(validator, wans(100)) },
+		func() (*Call, error) { return DelegateOut(validator) },
+	}
+	for i, build := range valid {
+		call, err := build()
+		if err != nil {
+			t.Fatalf("call %d: %v", i, err)
+		}
+		decoded, err := Decode(call.Tx(1, nil, nil))
+		if err != nil || decoded.Method != call.Method {
+			t.Fatalf("call %d: decode mismatch: %v", i, err)
+		}
+		if decoded.Validator() != validator {
+			t.Fatalf("call %d: validator mismatch: have %x, want %x", i, decoded.Validator(), validator)
+		}
+	}
+
+	invalid := []func() (*Call, error){
+		func() (*Call, error) { return StakeIn(secPk, bn256Pk, 30, 1500, wans(9999)) },
+		func() (*Call, error) { return StakeIn(secPk, bn256Pk, 6, 1500, wans(50000)) },
+		func() (*Call, error) { return StakeIn(secPk, bn256Pk, 91, 1500, wans(50000)) },
+		func() (*Call, error) { return StakeIn(secPk, bn256Pk, 30, 10001, wans(50000)) },
+		func() (*Call, error) { return StakeIn(secPk[1:], bn256Pk, 30, 1500, wans(50000)) },
+		func() (*Call, error) { return StakeIn(secPk, bn256Pk[1:], 30, 1500, wans(50000)) },
+		func() (*Call, error) { return StakeAppend(validator, nil) },
+		func() (*Call, error) { return StakeUpdate(validator, 5) },
+		func() (*Call, error) { return StakeUpdateFeeRate(validator, 10001) },
+		func() (*Call, error) { return DelegateIn(validator, new(big.Int)) },
+	}
+	for i, build := range invalid {
+		if _, err := build(); err == nil {
+			t.Errorf("invalid call %d accepted", i)
+		}
+	}
+	call, _ := DelegateOut(validator)
+	call.Value = big.NewInt(1)
+	if err := call.Validate(); err != ErrNotPayable {
+		t.Errorf("value sent to delegateOut: have %v, want %v", err, ErrNotPayable)
+	}
+	if _, err := Decode(types.NewTransaction(0, common.Address{1}, nil, nil, nil, nil)); err != ErrUnknownMethod {
+		t.Errorf("decoded a call to another contract: %v", err)
+	}
+}
+
+func TestSignTx(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	call, err := DelegateIn(common.Address{1}, wans(100))
+	if err != nil {
+		t.Fatal(err)
+	}
+	chainID := big.NewInt(3)
+	signed, err := SignTx(call.Tx(5, big.NewInt(DefaultGasLimit), big.NewInt(180e9)), chainID, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if from, err := types.Sender(types.NewEIP155Signer(chainID), signed); err != nil || from != crypto.PubkeyToAddress(key.PublicKey) {
+		t.Fatalf("sender mismatch: %x, %v", from, err)
+	}
+}
+
+func TestAmount(t *testing.T) {
+	for s, want := range map[string]string{
+		"1":                    "1000000000000000000",
+		"10000.5":              "10000500000000000000000",
+		"0.000000000000000001": "1",
+	} {
+		have, err := ParseAmount(s)
+		if err != nil || have.String() != want {
+			t.Errorf("ParseAmount(%q) = %v, %v, want %s", s, have, err, want)
+		}
+	}
+	for _, s := range []string{"", "1.", ".5", "-1", "1.0000000000000000001", "1e18"} {
+		if _, err := ParseAmount(s); err == nil {
+			t.Errorf("ParseAmount(%q) accepted", s)
+		}
+	}
+	if have := FormatAmount(big.NewInt(1500000000000000000)); have != "1.5 wan" {
+		t.Errorf("FormatAmount = %s", have)
+	}
+}
+
+func TestCheckStaker(t *testing.T) {
+	owner, other := common.Address{1}, common.Address{2}
+	staker := &StakerJson{
+		Address:      common.Address{9},
+		Amount:       (*math.HexOrDecimal256)(wans(50000)),
+		LockEpochs:   30,
+		From:         owner,
+		StakingEpoch: 100,
+		FeeRate:      1000,
+		MaxFeeRate:   2000,
+		Clients: []ClientInfo{
+			{Address: other, Amount: (*math.HexOrDecimal256)(wans(100)), QuitEpoch: 0},
+		},
+	}
+	const epoch = 110
+
+	check := func(call *Call, err error) func(from common.Address, s *StakerJson) error {
+		if err != nil {
+			t.Fatal(err)
+		}
+		return func(from common.Address, s *StakerJson) error {
+			return call.CheckStaker(from, s, epoch)
+		}
+	}
+	tests := []struct {
+		check func(common.Address, *StakerJson) error
+		from  common.Address
+		ok    bool
+	}{
+		{check(StakeAppend(staker.Address, wans(1))), owner, true},
+		{check(StakeAppend(staker.Address, wans(1))), other, false},
+		{check(StakeAppend(staker.Address, wans(vm.PSMaxStake))), owner, false},
+		{check(StakeUpdate(staker.Address, 0)), owner, true},
+		{check(StakeUpdateFeeRate(staker.Address, 1100)), owner, true},
+		{check(StakeUpdateFeeRate(staker.Address, 1101)), owner, false},
+		{check(StakeUpdateFeeRate(staker.Address, 1000)), owner, false},
+		{check(StakeUpdateFeeRate(staker.Address, 1100)), other, false},
+		{check(DelegateIn(staker.Address, wans(100))), common.Address{3}, true},
+		{check(DelegateIn(staker.Address, wans(99))), common.Address{3}, false},
+		{check(DelegateIn(staker.Address, wans(1))), other, true},
+		{check(DelegateIn(staker.Address, wans(500000))), common.Address{3}, false},
+		{check(DelegateOut(staker.Address)), other, true},
+		{check(DelegateOut(staker.Address)), owner, false},
+	}
+	for i, tt := range tests {
+		if err := tt.check(tt.from, staker); (err == nil) != tt.ok {
+			t.Errorf("test %d: have %v, want ok %v", i, err, tt.ok)
+		}
+	}
+
+	key, _ := crypto.GenerateKey()
+	stakeIn := check(StakeIn(crypto.FromECDSAPub(&key.PublicKey), new(bn256.G1).ScalarBaseMult(big.NewInt(7)).Marshal(), 30, 1000, wans(20000)))
+	if err := stakeIn(owner, nil); err != nil {
+		t.Errorf("new validator rejected: %v", err)
+	}
+	if err := stakeIn(owner, staker); err != ErrValidatorExists {
+		t.Errorf("registered validator: have %v, want %v", err, ErrValidatorExists)
+	}
+	if err := check(DelegateOut(staker.Address))(other, nil); err != ErrUnknownValidator {
+		t.Errorf("unknown validator: have %v, want %v", err, ErrUnknownValidator)
+	}
+}
@@ -0,0 +1,209 @@
+package staking
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/wanchain/go-wanchain/common"
+	"github.com/wanchain/go-wanchain/common/math"
+	"github.com/wanchain/go-wanchain/core/vm"
+	"github.com/wanchain/go-wanchain/pos/posconfig"
+)
+
+var (
+	ErrUnknownValidator = errors.New("validator is not registered")
+	ErrValidatorExists  = errors.New("validator is already registered")
+	ErrNotStakeOwner    = errors.New("sender didn't stake the validator")
+)
+
+// ClientInfo is a delegation to a validator.
+type ClientInfo struct {
+	Address     common.Address        `json:"address"`
+	Amount      *math.HexOrDecimal256 `json:"amount"`
+	StakeAmount *math.HexOrDecimal256 `json:"votingPower"`
+	QuitEpoch   uint64                `json:"quitEpoch"`
+}
+
+// PartnerInfo is a partner of a validator.
+type PartnerInfo struct {
+	Address      common.Address        `json:"address"`
+	Amount       *math.HexOrDecimal256 `json:"amount"`
+	StakeAmount  *math.HexOrDecimal256 `json:"votingPower"`
+	Renewal      bool                  `json:"renewal"`
+	LockEpochs   uint64                `json:"lockEpochs"`
+	StakingEpoch uint64                `json:"stakingEpoch"`
+}
+
+// StakerJson is a validator as returned by pos_getStakerInfo.
+type StakerJson struct {
+	Address   common.Address `json:"address"`
+	PubSec256 string         `json:"pubSec256"` //stakeholder’s wan public key
+	PubBn256  string         `json:"pubBn256"`  //stakeholder’s bn256 public key
+
+	Amount         *math.HexOrDecimal256 `json:"amount"`
+	StakeAmount    *math.HexOrDecimal256 `json:"votingPower"`
+	LockEpochs     uint64                `json:"lockEpochs"`     //lock time which is input by user. 0 means unexpired.
+	NextLockEpochs uint64                `json:"nextLockEpochs"` //lock time which is input by user. 0 means unexpired.
+	From           common.Address        `json:"from"`
+
+	StakingEpoch uint64 `json:"stakingEpoch"` //the user’s staking time
+	FeeRate      uint64 `json:"feeRate"`
+	//NextFeeRate  uint64
+	Clients  []ClientInfo  `json:"clients"`
+	Partners []PartnerInfo `json:"partners"`
+
+	MaxFeeRate          uint64 `json:"maxFeeRate"`
+	FeeRateChangedEpoch uint64 `json:"feeRateChangedEpoch"`
+}
+
+// CheckStaker checks the call against the state of the validator it targets,
+// with the rules the staking contract applies when executing it: ownership,
+// the fee rate step, lock epochs, partner and delegation limits. staker is the
+// validator as returned by pos_getStakerInfo, or nil if it is not registered.
+// The checks may still pass for a call that fails later if the state changes
+// before it is mined.
+func (c *Call) CheckStaker(from common.Address, staker *StakerJson, epochID uint64) error {
+	if c.Method == MethodStakeIn || c.Method == MethodStakeRegister {
+		if staker != nil {
+			return ErrValidatorExists
+		}
+		return nil
+	}
+	if staker == nil {
+		return ErrUnknownValidator
+	}
+
+	self := amount(staker.Amount)
+	partners := new(big.Int)
+	for _, p := range staker.Partners {
+		partners.Add(partners, amount(p.Amount))
+	}
+	clients := new(big.Int)
+	for _, cl := range staker.Clients {
+		clients.Add(clients, amount(cl.Amount))
+	}
+	total := new(big.Int).Add(self, partners)
+	total.Add(total, clients)
+	total.Add(total, c.Value)
+	// room is the most value the call may send without passing the maximum
+	room := new(big.Int).Sub(MaxTotalStake, total)
+	room.Add(room, c.Value)
+
+	switch c.Method {
+	case MethodStakeAppend:
+		if from != staker.From {
+			return ErrNotStakeOwner
+		}
+		if err := checkLockEpochs(staker, epochID); err != nil {
+			return err
+		}
+		if total.Cmp(MaxTotalStake) > 0 {
+			return limitError("value", room, "too much stake")
+		}
+
+	case MethodStakeUpdate:
+		if from != staker.From {
+			return ErrNotStakeOwner
+		}
+		if epochID > staker.StakingEpoch+staker.LockEpochs-vm.UpdateDelay {
+			return fmt.Errorf("cannot change in the last %d epochs", vm.UpdateDelay)
+		}
+
+	case MethodStakeUpdateFeeRate:
+		feeRate := new(big.Int).SetBytes(c.Data[len(c.Data)-32:]).Uint64()
+		switch {
+		case from != staker.From:
+			return ErrNotStakeOwner
+		case staker.FeeRate == vm.PSMaxFeeRate || feeRate == vm.PSMaxFeeRate:
+			return fmt.Errorf("fee rate %d can't be changed", vm.PSMaxFeeRate)
+		case staker.FeeRate == feeRate:
+			return errors.New("fee rate already set")
+		case staker.FeeRateChangedEpoch == epochID:
+			return errors.New("fee rate can only change once per epoch")
+		case feeRate > staker.MaxFeeRate:
+			return limitError("feeRate", staker.MaxFeeRate, "fee rate above the maximum of %d", staker.MaxFeeRate)
+		case feeRate > staker.FeeRate+vm.PSFeeRateStep:
+			return limitError("feeRate", staker.FeeRate+vm.PSFeeRateStep, "fee rate can rise by at most %d per change", vm.PSFeeRateStep)
+		}
+
+	case MethodPartnerIn:
+		if err := checkLockEpochs(staker, epochID); err != nil {
+			return err
+		}
+		if total.Cmp(MaxTotalStake) > 0 {
+			return limitError("value", room, "too much stake")
+		}
+		found := false
+		for _, p := range staker.Partners {
+			found = found || p.Address == from
+		}
+		minPartner := epochID >= posconfig.ApolloEpochID && (!found || epochID < posconfig.AugustEpochID)
+		if minPartner && c.Value.Cmp(MinPartnerIn) < 0 {
+			return limitError("value", MinPartnerIn, "partner amount below the minimum of %d wan", vm.PSMinPartnerIn)
+		}
+		if !found && len(staker.Partners) >= vm.PSMaxPartners {
+			return errors.New("too many partners")
+		}
+
+	case MethodDelegateIn:
+		if staker.FeeRate == vm.PSNodeleFeeRate {
+			return errors.New("validator doesn't accept delegation")
+		}
+		selfTotal := new(big.Int).Add(self, partners)
+		if selfTotal.Cmp(MinValidatorStake) < 0 {
+			return fmt.Errorf("validator stake below the minimum of %d wan", vm.PSMinValidatorStake)
+		}
+		found := false
+		for _, cl := range staker.Clients {
+			if cl.Address == from {
+				if cl.QuitEpoch != 0 {
+					return errors.New("delegation is quitting")
+				}
+				found = true
+			}
+		}
+		if total.Cmp(MaxTotalStake) > 0 {
+			return limitError("value", room, "too much stake")
+		}
+		delegated := new(big.Int).Add(clients, c.Value)
+		if delegated.Cmp(new(big.Int).Mul(selfTotal, big.NewInt(vm.MaxTimeDelegate))) > 0 {
+			return limitError("value", new(big.Int).Sub(new(big.Int).Mul(selfTotal, big.NewInt(vm.MaxTimeDelegate)), clients), "delegations above %d times the validator stake", vm.MaxTimeDelegate)
+		}
+		if !found && c.Value.Cmp(MinDelegatorStake) < 0 {
+			return limitError("value", MinDelegatorStake, "delegation below the minimum of %d wan", vm.PSMinDelegatorStake)
+		}
+
+	case MethodDelegateOut:
+		for _, cl := range staker.Clients {
+			if cl.Address == from {
+				if cl.QuitEpoch != 0 {
+					return errors.New("delegation is already quitting")
+				}
+				return nil
+			}
+		}
+		return errors.New("no delegation to the validator")
+	}
+	return nil
+}
+
+// checkLockEpochs checks the lock epochs remaining to a validator, as done for
+// stakeAppend and partnerIn.
+func checkLockEpochs(staker *StakerJson, epochID uint64) error {
+	if staker.StakingEpoch == 0 {
+		return nil
+	}
+	remain := int64(staker.LockEpochs - (epochID + vm.JoinDelay - staker.StakingEpoch))
+	if remain < 0 || remain > vm.PSMaxEpochNum {
+		return errors.New("wrong lock epochs")
+	}
+	return nil
+}
+
+func amount(v *math.HexOrDecimal256) *big.Int {
+	if v == nil {
+		return new(big.Int)
+	}
+	return (*big.Int)(v)
+}
@@ -0,0 +1,123 @@
+package stakingindex
+
+import (
+	"fmt"
+
+	"github.com/wanchain/go-wanchain/common"
+	"github.com/wanchain/go-wanchain/common/math"
+	"github.com/wanchain/go-wanchain/rpc"
+)
+
+const (
+	// DefaultPageSize is the number of events returned when no limit is given.
+	DefaultPageSize = 100
+
+	// MaxPageSize is the maximum number of events returned by one call.
+	MaxPageSize = 1000
+)
+
+// StakingEventJson is the JSON representation of a timeline event.
+type StakingEventJson struct {
+	Type        string                `json:"type"`
+	Epoch       uint64                `json:"epochId"`
+	BlockNumber uint64                `json:"blockNumber"`
+	TxHash      common.Hash           `json:"txHash"`
+	LogIndex    uint64                `json:"logIndex"`
+	From        common.Address        `json:"from"`
+	Validator   common.Address        `json:"validator"`
+	Amount      *math.HexOrDecimal256 `json:"amount"`
+	FeeRate     uint64                `json:"feeRate"`
+	LockEpochs  uint64                `json:"lockEpochs"`
+	MaxFeeRate  uint64                `json:"maxFeeRate"`
+	Renewal     bool                  `json:"renewal"`
+}
+
+func newStakingEventJson(e *Event) StakingEventJson {
+	return StakingEventJson{
+		Type:        e.Type,
+		Epoch:       e.Epoch,
+		BlockNumber: e.BlockNumber,
+		TxHash:      e.TxHash,
+		LogIndex:    e.LogIndex,
+		From:        e.From,
+		Validator:   e.Validator,
+		Amount:      (*math.HexOrDecimal256)(e.Amount),
+		FeeRate:     e.FeeRate,
+		LockEpochs:  e.LockEpochs,
+		MaxFeeRate:  e.MaxFeeRate,
+		Renewal:     e.Renewal,
+	}
+}
+
+// StakingHistory is a page of the timeline of an account. Next is the cursor
+// of the following page, nil on the last one.
+type StakingHistory struct {
+	Address common.Address     `json:"address"`
+	Total   uint64             `json:"total"`
+	Events  []StakingEventJson `json:"events"`
+	Next    *uint64            `json:"next"`
+}
+
+// StakingIndexStatus is the last block indexed.
+type StakingIndexStatus struct {
+	Number uint64      `json:"blockNumber"`
+	Hash   common.Hash `json:"blockHash"`
+}
+
+// APIs returns the RPC services of the staking indexer. They extend the pos
+// namespace.
+func APIs(idx *Indexer) []rpc.API {
+	return []rpc.API{{
+		Namespace: "pos",
+		Version:   "1.0",
+		Service:   &PublicStakingIndexAPI{idx},
+		Public:    true,
+	}}
+}
+
+// PublicStakingIndexAPI exposes the staking timelines.
+type PublicStakingIndexAPI struct {
+	idx *Indexer
+}
+
+// GetStakingHistory returns the staking events of an account between two
+// epochs, oldest first. toEpoch 0 means no upper bound. The first page is
+// requested with cursor 0, the following ones with the next cursor of the
+// previous page. limit 0 returns DefaultPageSize events.
+func (api *PublicStakingIndexAPI) GetStakingHistory(addr common.Address, fromEpoch, toEpoch, cursor, limit uint64) (*StakingHistory, error) {
+	if limit == 0 {
+		limit = DefaultPageSize
+	}
+	if limit > MaxPageSize {
+		return nil, fmt.Errorf("limit above the maximum page size %d", MaxPageSize)
+	}
+	if toEpoch != 0 && toEpoch < fromEpoch {
+		return nil, fmt.Errorf("epoch range %d-%d is empty", fromEpoch, toEpoch)
+	}
+	start := api.idx.SearchEpoch(addr, fromEpoch)
+	if cursor > start {
+		start = cursor
+	}
+	// Fetch one more event to tell if there is a next page
+	events, total := api.idx.Timeline(addr, start, limit+1)
+
+	history := &StakingHistory{Address: addr, Total: total, Events: make([]StakingEventJson, 0, len(events))}
+	for i, e := range events {
+		if toEpoch != 0 && e.Epoch > toEpoch {
+			break
+		}
+		if uint64(i) == limit {
+			next := start + limit
+			history.Next = &next
+			break
+		}
+		history.Events = append(history.Events, newStakingEventJson(e))
+	}
+	return history, nil
+}
+
+// GetStakingIndexStatus returns the last block indexed.
+func (api *PublicStakingIndexAPI) GetStakingIndexStatus() *StakingIndexStatus {
+	number, hash := api.idx.Head()
+	return &StakingIndexStatus{number, hash}
+}
@@ -0,0 +1,134 @@
+package stakingindex
+
+import (
+	"encoding/binary"
+
+	"github.com/wanchain/go-wanchain/common"
+	"github.com/wanchain/go-wanchain/ethdb"
+	"github.com/wanchain/go-wanchain/log"
+	"github.com/wanchain/go-wanchain/rlp"
+)
+
+var (
+	headKey       = []byte("stakeidx-head") // headKey -> rlp(indexHead)
+	countPrefix   = []byte("stakeidx-c")    // countPrefix + address -> number of events (uint64 big endian)
+	eventPrefix   = []byte("stakeidx-e")    // eventPrefix + address + index (uint64 big endian) -> rlp(Event)
+	journalPrefix = []byte("stakeidx-j")    // journalPrefix + block number (uint64 big endian) -> rlp(journal)
+)
+
+// indexHead is the last block indexed.
+type indexHead struct {
+	Number uint64
+	Hash   common.Hash
+}
+
+// journal records the timelines a block added events to, in order, so that
+// they can be undone if the block gets reorged.
+type journal struct {
+	Hash  common.Hash
+	Addrs []common.Address
+}
+
+func encodeUint64(n uint64) []byte {
+	enc := make([]byte, 8)
+	binary.BigEndian.PutUint64(enc, n)
+	return enc
+}
+
+func countKey(addr common.Address) []byte {
+	return append(append([]byte{}, countPrefix...), addr[:]...)
+}
+
+func eventKey(addr common.Address, index uint64) []byte {
+	key := append(append([]byte{}, eventPrefix...), addr[:]...)
+	return append(key, encodeUint64(index)...)
+}
+
+func journalKey(number uint64) []byte {
+	return append(append([]byte{}, journalPrefix...), encodeUint64(number)...)
+}
+
+func readHead(db ethdb.Database) (*indexHead, bool) {
+	data, _ := db.Get(headKey)
+	if len(data) == 0 {
+		return nil, false
+	}
+	head := new(indexHead)
+	if err := rlp.DecodeBytes(data, head); err != nil {
+		log.Error("Invalid staking index head", "err", err)
+		return nil, false
+	}
+	return head, true
+}
+
+func writeHead(db ethdb.Putter, head *indexHead) {
+	data, err := rlp.EncodeToBytes(head)
+	if err != nil {
+		log.Crit("Failed to RLP encode staking index head", "err", err)
+	}
+	if err := db.Put(headKey, data); err != nil {
+		log.Crit("Failed to store staking index head", "err", err)
+	}
+}
+
+// readCount returns the number of events in the timeline of an account.
+func readCount(db ethdb.Database, addr common.Address) uint64 {
+	data, _ := db.Get(countKey(addr))
+	if len(data) != 8 {
+		return 0
+	}
+	return binary.BigEndian.Uint64(data)
+}
+
+func writeCount(db ethdb.Putter, addr common.Address, count uint64) {
+	if err := db.Put(countKey(addr), encodeUint64(count)); err != nil {
+		log.Crit("Failed to store staking timeline length", "err", err)
+	}
+}
+
+// readEvent returns the index-th event of the timeline of an account.
+func readEvent(db ethdb.Database, addr common.Address, index uint64) *Event {
+	data, _ := db.Get(eventKey(addr, index))
+	if len(data) == 0 {
+		return nil
+	}
+	e := new(Event)
+	if err := rlp.DecodeBytes(data, e); err != nil {
+		log.Error("Invalid staking timeline event", "account", addr, "index", index, "err", err)
+		return nil
+	}
+	return e
+}
+
+func writeEvent(db ethdb.Putter, addr common.Address, index uint64, e *Event) {
+	data, err := rlp.EncodeToBytes(e)
+	if err != nil {
+		log.Crit("Failed to RLP encode staking event", "err", err)
+	}
+	if err := db.Put(eventKey(addr, index), data); err != nil {
+		log.Crit("Failed to store staking event", "err", err)
+	}
+}
+
+func readJournal(db ethdb.Database, number uint64) *journal {
+	data, _ := db.Get(journalKey(number))
+	if len(data) == 0 {
+		return nil
+	}
+	j := new(journal)
+	if err := rlp.DecodeBytes(data, j); err != nil {
+		log.Error("Invalid staking index journal", "number", number, "err", err)
+		return nil
+	}
+	return j
+}
+
+func writeJournal(db ethdb.Putter, number uint64, j *journal) {
+	data, err := rlp.EncodeToBytes(j)
+	if err != nil {
+		log.Crit("Failed to RLP encode staking index journal", "err", err)
+	}
+	if err := db.Put(journalKey(number), data); err != nil {
+		log.Crit("Failed to store staking index journal", "err", err)
+	}
+}
@@ -0,0 +1,177 @@
+package stakingindex
+
+import (
+	"math/big"
+
+	"github.com/wanchain/go-wanchain/common"
+	"github.com/wanchain/go-wanchain/core/types"
+	"github.com/wanchain/go-wanchain/core/vm"
+	"github.com/wanchain/go-wanchain/crypto"
+	"github.com/wanchain/go-wanchain/pos/staking"
+)
+
+// EventStakeOut is the type of the refunds paid back by the stake out of an
+// epoch, which has no log of its own.
+const EventStakeOut = "stakeOut"
+
+// Event is a change of the stake of an account. The types of the events
+// logged by the staking contract are the names of the contract methods.
+type Event struct {
+	Type        string
+	Epoch       uint64
+	BlockNumber uint64
+	TxHash      common.Hash // zero for stake outs
+	LogIndex    uint64
+
+	From       common.Address // sender of the call, or receiver of a refund
+	Validator  common.Address // zero for stake outs
+	Amount     *big.Int
+	FeeRate    uint64
+	LockEpochs uint64
+	MaxFeeRate uint64
+	Renewal    bool
+}
+
+// addresses returns the accounts whose timelines the event belongs to.
+func (e *Event) addresses() []common.Address {
+	if e.Validator == (common.Address{}) || e.Validator == e.From {
+		return []common.Address{e.From}
+	}
+	return []common.Address{e.From, e.Validator}
+}
+
+// logDecoder fills an event from the topics and data of a log, topic 0 left
+// out. It returns false if the log is malformed.
+type logDecoder func(e *Event, topics []common.Hash, data []byte) bool
+
+// word returns the i-th 32 bytes word of data as a number.
+func word(data []byte, i int) (*big.Int, bool) {
+	if len(data) < (i+1)*32 {
+		return nil, false
+	}
+	return new(big.Int).SetBytes(data[i*32 : (i+1)*32]), true
+}
+
+func topicAddress(h common.Hash) common.Address { return common.BytesToAddress(h[:]) }
+func topicBig(h common.Hash) *big.Int           { return h.Big() }
+func topicUint(h common.Hash) uint64            { return h.Big().Uint64() }
+
+// decoders maps topic 0 of the staking logs to their decoders. The logs of
+// the blocks before the Apollo epoch have the method signature as topic 0 and
+// all their fields as topics, the later ones follow the events of the abi.
+var decoders = make(map[common.Hash]decoder)
+
+type decoder struct {
+	typ    string
+	decode logDecoder
+}
+
+func init() {
+	cscAbi := vm.GetPosStakingAbi()
+	method := func(name string, n int, decode func(e *Event, topics []common.Hash)) {
+		id := crypto.Keccak256Hash([]byte(cscAbi.Methods[name].Sig()))
+		decoders[id] = decoder{name, func(e *Event, topics []common.Hash, data []byte) bool {
+			if len(topics) != n {
+				return false
+			}
+			decode(e, topics)
+			return true
+		}}
+	}
+	event := func(name string, n int, decode logDecoder) {
+		decoders[cscAbi.Events[name].Id()] = decoder{name, func(e *Event, topics []common.Hash, data []byte) bool {
+			if len(topics) != n {
+				return false
+			}
+			e.From, e.Validator = topicAddress(topics[0]), topicAddress(topics[1])
+			return decode(e, topics, data)
+		}}
+	}
+
+	// Logs before the Apollo epoch
+	method(staking.MethodStakeIn, 5, func(e *Event, topics []common.Hash) {
+		e.From, e.Amount = topicAddress(topics[0]), topicBig(topics[1])
+		e.FeeRate, e.LockEpochs = topicUint(topics[2]), topicUint(topics[3])
+		e.Validator = topicAddress(topics[4])
+	})
+	method(staking.MethodStakeAppend, 3, func(e *Event, topics []common.Hash) {
+		e.From, e.Amount, e.Validator = topicAddress(topics[0]), topicBig(topics[1]), topicAddress(topics[2])
+	})
+	method(staking.MethodStakeUpdate, 3, func(e *Event, topics []common.Hash) {
+		e.From, e.LockEpochs, e.Validator = topicAddress(topics[0]), topicUint(topics[1]), topicAddress(topics[2])
+	})
+	method(staking.MethodDelegateIn, 3, func(e *Event, topics []common.Hash) {
+		e.From, e.Amount, e.Validator = topicAddress(topics[0]), topicBig(topics[1]), topicAddress(topics[2])
+	})
+	method(staking.MethodDelegateOut, 2, func(e *Event, topics []common.Hash) {
+		e.From, e.Validator = topicAddress(topics[0]), topicAddress(topics[1])
+	})
+
+	// Logs from the Apollo epoch on
+	event(staking.MethodStakeRegister, 3, func(e *Event, topics []common.Hash, data []byte) bool {
+		e.Amount = topicBig(topics[2])
+		feeRate, ok1 := word(data, 0)
+		lockEpochs, ok2 := word(data, 1)
+		maxFeeRate, ok3 := word(data, 2)
+		if !ok1 || !ok2 || !ok3 {
+			return false
+		}
+		e.FeeRate, e.LockEpochs, e.MaxFeeRate = feeRate.Uint64(), lockEpochs.Uint64(), maxFeeRate.Uint64()
+		return true
+	})
+	event(staking.MethodStakeIn, 3, func(e *Event, topics []common.Hash, data []byte) bool {
+		e.Amount = topicBig(topics[2])
+		feeRate, ok1 := word(data, 0)
+		lockEpochs, ok2 := word(data, 1)
+		if !ok1 || !ok2 {
+			return false
+		}
+		e.FeeRate, e.LockEpochs = feeRate.Uint64(), lockEpochs.Uint64()
+		return true
+	})
+	event(staking.MethodStakeAppend, 3, func(e *Event, topics []common.Hash, data []byte) bool {
+		e.Amount = topicBig(topics[2])
+		return true
+	})
+	event(staking.MethodStakeUpdate, 3, func(e *Event, topics []common.Hash, data []byte) bool {
+		e.LockEpochs = topicUint(topics[2])
+		return true
+	})
+	event(staking.MethodDelegateIn, 3, func(e *Event, topics []common.Hash, data []byte) bool {
+		e.Amount = topicBig(topics[2])
+		return true
+	})
+	event(staking.MethodDelegateOut, 2, func(e *Event, topics []common.Hash, data []byte) bool {
+		return true
+	})
+	event(staking.MethodStakeUpdateFeeRate, 3, func(e *Event, topics []common.Hash, data []byte) bool {
+		e.FeeRate = topicUint(topics[2])
+		return true
+	})
+	event(staking.MethodPartnerIn, 3, func(e *Event, topics []common.Hash, data []byte) bool {
+		e.Amount = topicBig(topics[2])
+		renewal, ok := word(data, 0)
+		if !ok {
+			return false
+		}
+		e.Renewal = renewal.Sign() != 0
+		return true
+	})
+}
+
+// decodeLog turns a log of the staking contract into an event. It returns nil
+// for other logs.
+func decodeLog(l *types.Log) *Event {
+	if l.Address != vm.WanCscPrecompileAddr || len(l.Topics) == 0 {
+		return nil
+	}
+	d, ok := decoders[l.Topics[0]]
+	if !ok {
+		return nil
+	}
+	e := &Event{Type: d.typ, Amount: new(big.Int)}
+	if !d.decode(e, l.Topics[1:], l.Data) {
+		return nil
+	}
+	return e
+}
@@ -0,0 +1,325 @@
+// Package stakingindex implements an optional background service which keeps
+// the history of the stake of each account: the calls of the pos staking
+// contract sent by it or to its validator, and the refunds paid back to it by
+// the stake outs. The pos API only has point-in-time snapshots of the stakers,
+// the index makes the changes across epochs available without replaying the
+// chain.
+package stakingindex
+
+import (
+	"sync"
+
+	"github.com/wanchain/go-wanchain/common"
+	"github.com/wanchain/go-wanchain/core"
+	"github.com/wanchain/go-wanchain/core/types"
+	"github.com/wanchain/go-wanchain/ethdb"
+	"github.com/wanchain/go-wanchain/event"
+	"github.com/wanchain/go-wanchain/log"
+	"github.com/wanchain/go-wanchain/pos/epochLeader"
+	"github.com/wanchain/go-wanchain/pos/posconfig"
+	"github.com/wanchain/go-wanchain/pos/posdb"
+	"github.com/wanchain/go-wanchain/pos/util"
+	"github.com/wanchain/go-wanchain/rlp"
+)
+
+// indexBatchBlocks is the maximum number of blocks indexed in one round.
+const indexBatchBlocks = 4096
+
+// blockChain is the part of core.BlockChain the indexer relies on.
+type blockChain interface {
+	CurrentHeader() *types.Header
+	GetHeaderByNumber(number uint64) *types.Header
+	SubscribeChainHeadEvent(ch chan<- core.ChainHeadEvent) event.Subscription
+}
+
+// Indexer follows the canonical chain and appends the staking events of each
+// block to the timelines of the accounts involved. The index is kept in the
+// chain database; blocks dropped by a reorg are undone before the new ones
+// are indexed.
+type Indexer struct {
+	chain blockChain
+	db    ethdb.Database
+
+	// stakeOuts returns the refunds of the stake out of an epoch
+	stakeOuts func(epochID uint64) []epochLeader.RefundInfo
+
+	lock sync.RWMutex // Protects the index against concurrent rounds and API reads
+
+	quit chan struct{}
+	wg   sync.WaitGroup
+}
+
+// New creates a staking indexer storing its index in the chain database db.
+func New(chain blockChain, db ethdb.Database) *Indexer {
+	return &Indexer{
+		chain:     chain,
+		db:        db,
+		stakeOuts: readStakeOuts,
+		quit:      make(chan struct{}),
+	}
+}
+
+// readStakeOuts returns the refunds the stake out of an epoch recorded in the
+// local pos database.
+func readStakeOuts(epochID uint64) []epochLeader.RefundInfo {
+	data, err := posdb.GetDb().Get(epochID, posconfig.StakeOutEpochKey)
+	if err != nil {
+		return nil
+	}
+	var refunds []epochLeader.RefundInfo
+	if err := rlp.DecodeBytes(data, &refunds); err != nil {
+		log.Error("Invalid stake out record", "epochID", epochID, "err", err)
+		return nil
+	}
+	return refunds
+}
+
+// Start launches the background indexing.
+func (idx *Indexer) Start() {
+	idx.wg.Add(1)
+	go idx.loop()
+	log.Info("Staking indexer started")
+}
+
+// Stop terminates the background indexing.
+func (idx *Indexer) Stop() {
+	close(idx.quit)
+	idx.wg.Wait()
+	log.Info("Staking indexer stopped")
+}
+
+func (idx *Indexer) loop() {
+	defer idx.wg.Done()
+
+	headCh := make(chan core.ChainHeadEvent, 10)
+	sub := idx.chain.SubscribeChainHeadEvent(headCh)
+	defer sub.Unsubscribe()
+
+	for {
+		// Keep indexing while behind, checking for quit in between
+		for idx.index() {
+			select {
+			case <-idx.quit:
+				return
+			default:
+			}
+		}
+		select {
+		case <-headCh:
+		case <-sub.Err():
+			return
+		case <-idx.quit:
+			return
+		}
+	}
+}
+
+// index runs one indexing round and tells if there are blocks left to index.
+func (idx *Indexer) index() bool {
+	idx.lock.Lock()
+	defer idx.lock.Unlock()
+
+	target := idx.chain.CurrentHeader().Number.Uint64()
+
+	from := uint64(0)
+	if head, ok := readHead(idx.db); ok {
+		from = idx.rollback(head) + 1
+	}
+	if from > target {
+		return false
+	}
+	to := target
+	if to-from >= indexBatchBlocks {
+		to = from + indexBatchBlocks - 1
+	}
+
+	w := &timelineWriter{db: idx.db, batch: idx.db.NewBatch(), counts: make(map[common.Address]uint64)}
+	var last *types.Header
+	for number := from; number <= to; number++ {
+		header := idx.chain.GetHeaderByNumber(number)
+		if header == nil {
+			break
+		}
+		idx.indexBlock(w, header)
+		last = header
+	}
+	if last == nil {
+		return false
+	}
+	writeHead(w.batch, &indexHead{last.Number.Uint64(), last.Hash()})
+	if err := w.batch.Write(); err != nil {
+		log.Crit("Failed to store staking index", "err", err)
+	}
+	log.Debug("Staking index round done", "from", from, "to", last.Number)
+	return last.Number.Uint64() < target
+}
+
+// indexBlock appends the staking events of a block to the timelines.
+func (idx *Indexer) indexBlock(w *timelineWriter, header *types.Header) {
+	var (
+		number = header.Number.Uint64()
+		hash   = header.Hash()
+		j      = &journal{Hash: hash}
+	)
+	epochID, _ := util.CalEpochSlotID(header.Time.Uint64())
+	for _, receipt := range core.GetBlockReceipts(idx.db, hash, number) {
+		for _, l := range receipt.Logs {
+			e := decodeLog(l)
+			if e == nil {
+				continue
+			}
+			e.Epoch, e.BlockNumber, e.TxHash, e.LogIndex = epochID, number, receipt.TxHash, uint64(l.Index)
+			j.Addrs = append(j.Addrs, w.append(e)...)
+		}
+	}
+	if epochID, ok := idx.stakeOutEpoch(header); ok {
+		for i, refund := range idx.stakeOuts(epochID) {
+			e := &Event{
+				Type:        EventStakeOut,
+				Epoch:       epochID,
+				BlockNumber: number,
+				LogIndex:    uint64(i),
+				From:        refund.Addr,
+				Amount:      refund.Amount,
+			}
+			j.Addrs = append(j.Addrs, w.append(e)...)
+		}
+	}
+	if len(j.Addrs) > 0 {
+		writeJournal(w.batch, number, j)
+	}
+}
+
+// stakeOutEpoch tells if the pos engine ran the stake out of an epoch when
+// finalizing a block, which it does in the first block past the incentive
+// start stage of each epoch.
+func (idx *Indexer) stakeOutEpoch(header *types.Header) (uint64, bool) {
+	number := header.Number.Uint64()
+	if !util.IsPosBlock(number) || number == 0 {
+		return 0, false
+	}
+	epochID, ok := stakeOutStage(header)
+	if !ok {
+		return 0, false
+	}
+	if parent := idx.chain.GetHeaderByNumber(number - 1); parent != nil && util.IsPosBlock(number-1) {
+		if parentEpochID, ok := stakeOutStage(parent); ok && parentEpochID == epochID {
+			return 0, false
+		}
+	}
+	return epochID, true
+}
+
+// stakeOutStage returns the epoch of a pos block if the stake out runs in its
+// slot, mirroring the conditions of pluto.Finalize.
+func stakeOutStage(header *types.Header) (uint64, bool) {
+	epochID, slotID := util.GetEpochSlotIDFromDifficulty(header.Difficulty)
+	if posconfig.FirstEpochId == 0 || epochID <= posconfig.FirstEpochId+2 ||
+		epochID < posconfig.IncentiveDelayEpochs || slotID <= posconfig.IncentiveStartStage {
+		return 0, false
+	}
+	return epochID, true
+}
+
+// rollback undoes the blocks indexed on a chain which is not canonical anymore
+// and returns the number of the last indexed block left.
+func (idx *Indexer) rollback(head *indexHead) uint64 {
+	number, hash := head.Number, head.Hash
+	for core.GetCanonicalHash(idx.db, number) != hash {
+		if j := readJournal(idx.db, number); j != nil {
+			idx.undo(number, j)
+		}
+		header := core.GetHeader(idx.db, hash, number)
+		if header == nil || number == 0 {
+			log.Error("Staking index rollback past unknown block", "number", number, "hash", hash)
+			break
+		}
+		number, hash = number-1, header.ParentHash
+	}
+	if number != head.Number {
+		log.Warn("Staking index rolled back reorged blocks", "from", head.Number, "to", number)
+		writeHead(idx.db, &indexHead{number, hash})
+	}
+	return number
+}
+
+// undo removes the events a block added, newest first.
+func (idx *Indexer) undo(number uint64, j *journal) {
+	for i := len(j.Addrs) - 1; i >= 0; i-- {
+		addr := j.Addrs[i]
+		count := readCount(idx.db, addr)
+		if count == 0 {
+			continue
+		}
+		writeCount(idx.db, addr, count-1)
+		idx.db.Delete(eventKey(addr, count-1))
+	}
+	idx.db.Delete(journalKey(number))
+}
+
+// timelineWriter appends events to the timelines through a batch.
+type timelineWriter struct {
+	db     ethdb.Database
+	batch  ethdb.Batch
+	counts map[common.Address]uint64 // timeline lengths including the batch
+}
+
+// append adds an event to the timelines of its accounts and returns them.
+func (w *timelineWriter) append(e *Event) []common.Address {
+	addrs := e.addresses()
+	for _, addr := range addrs {
+		count, ok := w.counts[addr]
+		if !ok {
+			count = readCount(w.db, addr)
+		}
+		writeEvent(w.batch, addr, count, e)
+		writeCount(w.batch, addr, count+1)
+		w.counts[addr] = count + 1
+	}
+	return addrs
+}
+
+// Timeline returns up to limit events of an account, from the start-th on, and
+// the length of its timeline.
+func (idx *Indexer) Timeline(addr common.Address, start, limit uint64) ([]*Event, uint64) {
+	idx.lock.RLock()
+	defer idx.lock.RUnlock()
+
+	count := readCount(idx.db, addr)
+	var events []*Event
+	for i := start; i < count && uint64(len(events)) < limit; i++ {
+		if e := readEvent(idx.db, addr, i); e != nil {
+			events = append(events, e)
+		}
+	}
+	return events, count
+}
+
+// SearchEpoch returns the position of the first event of an account at or
+// after an epoch, or the length of its timeline if there is none.
+func (idx *Indexer) SearchEpoch(addr common.Address, epochID uint64) uint64 {
+	idx.lock.RLock()
+	defer idx.lock.RUnlock()
+
+	lo, hi := uint64(0), readCount(idx.db, addr)
+	for lo < hi {
+		mid := lo + (hi-lo)/2
+		if e := readEvent(idx.db, addr, mid); e != nil && e.Epoch < epochID {
+			lo = mid + 1
+		} else {
+			hi = mid
+		}
+	}
+	return lo
+}
+
+// Head returns the last block indexed.
+func (idx *Indexer) Head() (uint64, common.Hash) {
+	idx.lock.RLock()
+	defer idx.lock.RUnlock()
+
+	if head, ok := readHead(idx.db); ok {
+		return head.Number, head.Hash
+	}
+	return 0, common.Hash{}
+}
@@ -0,0 +1,211 @@
+package stakingindex
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/wanchain/go-wanchain/common"
+	"github.com/wanchain/go-wanchain/core"
+	"github.com/wanchain/go-wanchain/core/types"
+	"github.com/wanchain/go-wanchain/core/vm"
+	"github.com/wanchain/go-wanchain/crypto"
+	"github.com/wanchain/go-wanchain/ethdb"
+	"github.com/wanchain/go-wanchain/event"
+	"github.com/wanchain/go-wanchain/pos/epochLeader"
+	"github.com/wanchain/go-wanchain/pos/posconfig"
+)
+
+var (
+	validator = common.HexToAddress("0x1000000000000000000000000000000000000001")
+	delegator = common.HexToAddress("0x2000000000000000000000000000000000000002")
+	owner     = common.HexToAddress("0x3000000000000000000000000000000000000003")
+)
+
+type testChain struct {
+	db   ethdb.Database
+	head *types.Header
+	feed event.Feed
+}
+
+func (c *testChain) CurrentHeader() *types.Header { return c.head }
+
+func (c *testChain) GetHeaderByNumber(number uint64) *types.Header {
+	return core.GetHeader(c.db, core.GetCanonicalHash(c.db, number), number)
+}
+
+func (c *testChain) SubscribeChainHeadEvent(ch chan<- core.ChainHeadEvent) event.Subscription {
+	return c.feed.Subscribe(ch)
+}
+
+// insert makes a block with the given logs the canonical block of its number.
+func (c *testChain) insert(t *testing.T, parent *types.Header, epochID, slotID uint64, logs ...*types.Log) *types.Header {
+	header := &types.Header{
+		Number:     big.NewInt(0),
+		Difficulty: new(big.Int).SetUint64(epochID<<32 | slotID<<8),
+		Time:       new(big.Int).SetUint64((epochID*posconfig.SlotCount + slotID) * posconfig.SlotTime),
+		Extra:      []byte{byte(len(logs))},
+	}
+	if parent != nil {
+		header.Number.Add(parent.Number, big.NewInt(1))
+		header.ParentHash = parent.Hash()
+	}
+	receipt := &types.Receipt{CumulativeGasUsed: new(big.Int), GasUsed: new(big.Int), Logs: logs}
+	receipt.TxHash = crypto.Keccak256Hash(header.Number.Bytes())
+	for i, l := range logs {
+		l.Index = uint(i)
+	}
+	number := header.Number.Uint64()
+	if err := core.WriteHeader(c.db, header); err != nil {
+		t.Fatal(err)
+	}
+	if err := core.WriteCanonicalHash(c.db, header.Hash(), number); err != nil {
+		t.Fatal(err)
+	}
+	if err := core.WriteBlockReceipts(c.db, header.Hash(), number, types.Receipts{receipt}); err != nil {
+		t.Fatal(err)
+	}
+	c.head = header
+	return header
+}
+
+func eventLog(name string, topics []common.Hash, data ...*big.Int) *types.Log {
+	l := &types.Log{
+		Address: vm.WanCscPrecompileAddr,
+		Topics:  append([]common.Hash{vm.GetPosStakingAbi().Events[name].Id()}, topics...),
+	}
+	for _, word := range data {
+		l.Data = append(l.Data, common.BigToHash(word).Bytes()...)
+	}
+	return l
+}
+
+func stakeInLog(from, validator common.Address, value int64, feeRate, lockEpochs uint64) *types.Log {
+	return eventLog("stakeIn", []common.Hash{from.Hash(), validator.Hash(), common.BigToHash(big.NewInt(value))},
+		new(big.Int).SetUint64(feeRate), new(big.Int).SetUint64(lockEpochs))
+}
+
+func delegateInLog(from, validator common.Address, value int64) *types.Log {
+	return eventLog("delegateIn", []common.Hash{from.Hash(), validator.Hash(), common.BigToHash(big.NewInt(value))})
+}
+
+func TestDecodeLog(t *testing.T) {
+	// Logs before the Apollo epoch carry the method signature
+	sig := crypto.Keccak256Hash([]byte(vm.GetPosStakingAbi().Methods["delegateIn"].Sig()))
+	old := &types.Log{
+		Address: vm.WanCscPrecompileAddr,
+		Topics:  []common.Hash{sig, delegator.Hash(), common.BigToHash(big.NewInt(500)), validator.Hash()},
+	}
+	e := decodeLog(old)
+	if e == nil || e.Type != "delegateIn" || e.From != delegator || e.Validator != validator || e.Amount.Int64() != 500 {
+		t.Fatalf("pre Apollo log decoded wrongly: %+v", e)
+	}
+
+	e = decodeLog(stakeInLog(owner, validator, 1000, 1500, 30))
+	if e == nil || e.Type != "stakeIn" || e.From != owner || e.Validator != validator ||
+		e.Amount.Int64() != 1000 || e.FeeRate != 1500 || e.LockEpochs != 30 {
+		t.Fatalf("stakeIn log decoded wrongly: %+v", e)
+	}
+
+	partner := eventLog("partnerIn", []common.Hash{owner.Hash(), validator.Hash(), common.BigToHash(big.NewInt(10))}, big.NewInt(1))
+	if e = decodeLog(partner); e == nil || !e.Renewal {
+		t.Fatalf("partnerIn log decoded wrongly: %+v", e)
+	}
+
+	// Malformed and foreign logs are skipped
+	partner.Data = nil
+	if e = decodeLog(partner); e != nil {
+		t.Fatalf("partnerIn log without data decoded: %+v", e)
+	}
+	foreign := stakeInLog(owner, validator, 1000, 1500, 30)
+	foreign.Address = common.Address{1}
+	if e = decodeLog(foreign); e != nil {
+		t.Fatalf("log of another contract decoded: %+v", e)
+	}
+}
+
+func TestIndexer(t *testing.T) {
+	db, _ := ethdb.NewMemDatabase()
+	chain := &testChain{db: db}
+	idx := New(chain, db)
+	api := &PublicStakingIndexAPI{idx}
+
+	genesis := chain.insert(t, nil, 0, 0)
+	b1 := chain.insert(t, genesis, 10, 1, stakeInLog(owner, validator, 1000, 1500, 30))
+	b2 := chain.insert(t, b1, 11, 1, delegateInLog(delegator, validator, 200), delegateInLog(delegator, validator, 300))
+	chain.insert(t, b2, 12, 1, delegateInLog(delegator, validator, 400))
+	for idx.index() {
+	}
+
+	history, err := api.GetStakingHistory(validator, 0, 0, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if history.Total != 4 || len(history.Events) != 4 || history.Next != nil {
+		t.Fatalf("validator timeline mismatch: %+v", history)
+	}
+	if e := history.Events[0]; e.Type != "stakeIn" || e.Epoch != 10 || e.BlockNumber != 1 || e.From != owner {
+		t.Fatalf("first event mismatch: %+v", e)
+	}
+	if history, _ = api.GetStakingHistory(delegator, 0, 0, 0, 0); history.Total != 3 {
+		t.Fatalf("delegator timeline length mismatch: have %d, want 3", history.Total)
+	}
+	if history, _ = api.GetStakingHistory(owner, 0, 0, 0, 0); history.Total != 1 {
+		t.Fatalf("owner timeline length mismatch: have %d, want 1", history.Total)
+	}
+
+	// Pages and epoch ranges
+	page, _ := api.GetStakingHistory(validator, 0, 0, 0, 3)
+	if len(page.Events) != 3 || page.Next == nil || *page.Next != 3 {
+		t.Fatalf("first page mismatch: %+v", page)
+	}
+	page, _ = api.GetStakingHistory(validator, 0, 0, *page.Next, 3)
+	if len(page.Events) != 1 || page.Next != nil || (*big.Int)(page.Events[0].Amount).Int64() != 400 {
+		t.Fatalf("last page mismatch: %+v", page)
+	}
+	page, _ = api.GetStakingHistory(validator, 11, 11, 0, 0)
+	if len(page.Events) != 2 || page.Events[0].Epoch != 11 || page.Events[1].Epoch != 11 {
+		t.Fatalf("epoch range mismatch: %+v", page)
+	}
+	if _, err := api.GetStakingHistory(validator, 0, 0, 0, MaxPageSize+1); err == nil {
+		t.Fatal("oversized page accepted")
+	}
+
+	// A reorg replaces the last block
+	b3 := chain.insert(t, b2, 12, 2, delegateInLog(owner, validator, 700))
+	for idx.index() {
+	}
+	if status := api.GetStakingIndexStatus(); status.Number != 3 || status.Hash != b3.Hash() {
+		t.Fatalf("index head mismatch: %+v", status)
+	}
+	if history, _ = api.GetStakingHistory(delegator, 0, 0, 0, 0); history.Total != 2 {
+		t.Fatalf("reorged event kept: have %d events, want 2", history.Total)
+	}
+	history, _ = api.GetStakingHistory(validator, 0, 0, 0, 0)
+	if history.Total != 4 || history.Events[3].From != owner || (*big.Int)(history.Events[3].Amount).Int64() != 700 {
+		t.Fatalf("validator timeline after reorg mismatch: %+v", history)
+	}
+}
+
+func TestIndexStakeOut(t *testing.T) {
+	defer func(first uint64) { posconfig.FirstEpochId = first }(posconfig.FirstEpochId)
+	posconfig.FirstEpochId = 1
+
+	db, _ := ethdb.NewMemDatabase()
+	chain := &testChain{db: db}
+	idx := New(chain, db)
+	idx.stakeOuts = func(epochID uint64) []epochLeader.RefundInfo {
+		return []epochLeader.RefundInfo{{Addr: delegator, Amount: new(big.Int).SetUint64(epochID)}}
+	}
+
+	// Only the first block past the incentive start stage runs the stake out
+	header := chain.insert(t, nil, 0, 0)
+	for _, slot := range []uint64{1, posconfig.IncentiveStartStage + 1, posconfig.IncentiveStartStage + 2} {
+		header = chain.insert(t, header, 10, slot)
+	}
+	for idx.index() {
+	}
+	events, total := idx.Timeline(delegator, 0, MaxPageSize)
+	if total != 1 || events[0].Type != EventStakeOut || events[0].BlockNumber != 2 || events[0].Amount.Uint64() != 10 {
+		t.Fatalf("stake out mismatch: %d events %+v", total, events)
+	}
+}
@@ -43,6 +43,11 @@ func SendTx(rc *rpc.Client, tx map[string]interface{}) (common.Hash, error) {
 }
 
 func SendPosTx(rc *rpc.Client, tx map[string]interface{})  {
+	SendDelayedPosTx(rc, tx)
+}
+
+// SendDelayedPosTx is SendPosTx returning the hash of the transaction.
+func SendDelayedPosTx(rc *rpc.Client, tx map[string]interface{}) (common.Hash, error) {
 	if posconfig.TxDelay != 0 {
 		delay := rand.Intn(posconfig.TxDelay)
 		time.Sleep(time.Duration(delay)*time.Second)
@@ -51,5 +56,5 @@ func SendPosTx(rc *rpc.Client, tx map[string]interface{})  {
 
 
 
-	SendTx(rc, tx)
+	return SendTx(rc, tx)
 }
\ No newline at end of file
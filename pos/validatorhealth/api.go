@@ -0,0 +1,63 @@
+package validatorhealth
+
+import (
+	"context"
+
+	"github.com/wanchain/go-wanchain/rpc"
+)
+
+// APIs returns the RPC services of the validator health monitor. They extend
+// the pos namespace.
+func APIs(m *Monitor) []rpc.API {
+	return []rpc.API{{
+		Namespace: "pos",
+		Version:   "1.0",
+		Service:   &PublicValidatorHealthAPI{m},
+		Public:    true,
+	}}
+}
+
+// PublicValidatorHealthAPI exposes the health of the monitored validators.
+type PublicValidatorHealthAPI struct {
+	m *Monitor
+}
+
+// GetValidatorHealth returns the progress of the monitored validators in the
+// duties of the current epoch.
+func (api *PublicValidatorHealthAPI) GetValidatorHealth() []*ValidatorStatus {
+	return api.m.Status()
+}
+
+// GetHealthAlerts returns the last alerts raised, oldest first.
+func (api *PublicValidatorHealthAPI) GetHealthAlerts() []*Alert {
+	return api.m.Alerts()
+}
+
+// HealthAlerts sends a notification for each alert raised.
+func (api *PublicValidatorHealthAPI) HealthAlerts(ctx context.Context) (*rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+
+	rpcSub := notifier.CreateSubscription()
+
+	go func() {
+		alerts := make(chan Alert)
+		sub := api.m.SubscribeAlerts(alerts)
+		defer sub.Unsubscribe()
+
+		for {
+			select {
+			case a := <-alerts:
+				notifier.Notify(rpcSub.ID, a)
+			case <-rpcSub.Err():
+				return
+			case <-notifier.Closed():
+				return
+			}
+		}
+	}()
+
+	return rpcSub, nil
+}
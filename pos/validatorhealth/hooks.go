@@ -0,0 +1,82 @@
+package validatorhealth
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/wanchain/go-wanchain/log"
+)
+
+// hookTimeout bounds the time a webhook call or a command may take.
+const hookTimeout = 30 * time.Second
+
+// runHooks posts an alert to the webhook and runs the command of config.
+func runHooks(config Config, a *Alert) {
+	if config.Webhook != "" {
+		if err := postWebhook(config.Webhook, a); err != nil {
+			log.Error("Validator health webhook failed", "url", config.Webhook, "err", err)
+		}
+	}
+	if config.Exec != "" {
+		if err := runExec(config.Exec, a); err != nil {
+			log.Error("Validator health command failed", "cmd", config.Exec, "err", err)
+		}
+	}
+}
+
+// postWebhook posts an alert as JSON to url.
+func postWebhook(url string, a *Alert) error {
+	body, err := json.Marshal(a)
+	if err != nil {
+		return err
+	}
+	client := &http.Client{Timeout: hookTimeout}
+	resp, err := client.Post(url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned %s", resp.Status)
+	}
+	return nil
+}
+
+// runExec runs command with the alert as JSON on its standard input. The
+// fields of the alert are also passed in the WAN_ALERT_* environment
+// variables.
+func runExec(command string, a *Alert) error {
+	args := strings.Fields(command)
+	if len(args) == 0 {
+		return nil
+	}
+	body, err := json.Marshal(a)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), hookTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Stdin = bytes.NewReader(body)
+	cmd.Env = append(os.Environ(),
+		"WAN_ALERT_KIND="+a.Kind,
+		"WAN_ALERT_ADDRESS="+a.Address.Hex(),
+		fmt.Sprintf("WAN_ALERT_EPOCH=%d", a.EpochId),
+		fmt.Sprintf("WAN_ALERT_SLOT=%d", a.SlotId),
+		fmt.Sprintf("WAN_ALERT_INDEX=%d", a.Index),
+		fmt.Sprintf("WAN_ALERT_COUNT=%d", a.Count),
+		"WAN_ALERT_MESSAGE="+a.Message,
+	)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("%v: %s", err, out)
+	}
+	return nil
+}
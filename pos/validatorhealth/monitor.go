@@ -0,0 +1,431 @@
+// Package validatorhealth implements an optional service which follows the
+// duties of a set of validators in the current epoch as the chain grows: the
+// slots they lead against the blocks they produced, the slot leader
+// selection (SMA) transactions of their epoch leader indexes and the random
+// beacon transactions of their proposer ids. An alert is raised, and the
+// configured hooks run, as soon as a duty is missed so the operators can fail
+// over before the incentive of the epoch is lost.
+package validatorhealth
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/wanchain/go-wanchain/common"
+	"github.com/wanchain/go-wanchain/core"
+	"github.com/wanchain/go-wanchain/core/state"
+	"github.com/wanchain/go-wanchain/core/types"
+	"github.com/wanchain/go-wanchain/core/vm"
+	"github.com/wanchain/go-wanchain/crypto"
+	"github.com/wanchain/go-wanchain/event"
+	"github.com/wanchain/go-wanchain/log"
+	"github.com/wanchain/go-wanchain/pos/epochLeader"
+	"github.com/wanchain/go-wanchain/pos/posconfig"
+	"github.com/wanchain/go-wanchain/pos/slotleader"
+	"github.com/wanchain/go-wanchain/pos/util"
+)
+
+// maxAlerts is the number of recent alerts kept for GetHealthAlerts.
+const maxAlerts = 100
+
+// Config are the settings of the validator health service.
+type Config struct {
+	// Validators monitored, the service is disabled without any
+	Addresses []common.Address `toml:",omitempty"`
+
+	// Number of slots missed in an epoch which raises an alert
+	MissedSlots uint64
+
+	// URL the alerts are posted to as JSON
+	Webhook string `toml:",omitempty"`
+
+	// Command run on each alert, with the alert as JSON on its standard input
+	Exec string `toml:",omitempty"`
+}
+
+// DefaultConfig alerts from the first missed slot.
+var DefaultConfig = Config{
+	MissedSlots: 1,
+}
+
+// Alert kinds
+const (
+	AlertMissedSlots      = "missedSlots"
+	AlertSmaStage1Missing = "smaStage1Missing"
+	AlertSmaStage2Missing = "smaStage2Missing"
+	AlertRbDkg1Missing    = "rbDkg1Missing"
+	AlertRbDkg2Missing    = "rbDkg2Missing"
+	AlertRbSigMissing     = "rbSigMissing"
+)
+
+// Alert is a duty of a validator missed in an epoch. Index is the epoch
+// leader index or the random proposer id the duty belongs to, Count the
+// number of slots missed for AlertMissedSlots.
+type Alert struct {
+	Kind    string         `json:"kind"`
+	Address common.Address `json:"address"`
+	EpochId uint64         `json:"epochId"`
+	SlotId  uint64         `json:"slotId"`
+	Index   uint64         `json:"index"`
+	Count   uint64         `json:"count"`
+	Message string         `json:"message"`
+	Time    uint64         `json:"time"`
+}
+
+// ValidatorStatus is the progress of a validator in its duties of the epoch
+// of the chain head. The slots are only known on the epoch leaders of the
+// previous epoch, SlotLeadersKnown tells if they are.
+type ValidatorStatus struct {
+	Address            common.Address `json:"address"`
+	EpochId            uint64         `json:"epochId"`
+	SlotId             uint64         `json:"slotId"`
+	SlotLeadersKnown   bool           `json:"slotLeadersKnown"`
+	AssignedSlots      uint64         `json:"assignedSlots"`
+	PassedSlots        uint64         `json:"passedSlots"`
+	ProducedSlots      uint64         `json:"producedSlots"`
+	MissedSlotIds      []uint64       `json:"missedSlotIds"`
+	EpochLeaderIndexes []uint64       `json:"epochLeaderIndexes"`
+	SmaStage1          []bool         `json:"smaStage1"`
+	SmaStage2          []bool         `json:"smaStage2"`
+	RbProposerIds      []uint32       `json:"rbProposerIds"`
+	RbDkg1             []bool         `json:"rbDkg1"`
+	RbDkg2             []bool         `json:"rbDkg2"`
+	RbSig              []bool         `json:"rbSig"`
+	Time               uint64         `json:"time"`
+}
+
+// blockChain is the part of core.BlockChain the monitor relies on.
+type blockChain interface {
+	CurrentHeader() *types.Header
+	GetHeader(hash common.Hash, number uint64) *types.Header
+	StateAt(root common.Hash) (*state.StateDB, error)
+	SubscribeChainHeadEvent(ch chan<- core.ChainHeadEvent) event.Subscription
+}
+
+// Monitor follows the chain head and checks the duties of the configured
+// validators in its epoch.
+type Monitor struct {
+	config Config
+	chain  blockChain
+
+	// Who has to do what in an epoch, replaced in tests
+	epochLeaders func(epochID uint64) []common.Address
+	rbProposers  func(epochID uint64) []common.Address
+	slotLeaders  func(epochID uint64) ([]common.Address, error)
+
+	lock      sync.RWMutex
+	epochID   uint64
+	epochLdrs []common.Address
+	proposers []common.Address
+	slotLdrs  []common.Address          // nil until known
+	produced  map[uint64]common.Address // signer of the block of each slot of the epoch
+	lastHash  common.Hash
+	status    map[common.Address]*ValidatorStatus
+	raised    map[string]bool // alerts raised in the epoch
+	alerts    []*Alert
+	alertFeed event.Feed
+	scope     event.SubscriptionScope
+
+	quit chan struct{}
+	wg   sync.WaitGroup
+}
+
+// New creates a monitor of the validators of config.
+func New(chain blockChain, config Config) *Monitor {
+	return &Monitor{
+		config:       config,
+		chain:        chain,
+		epochLeaders: readEpochLeaders,
+		rbProposers:  readRBProposers,
+		slotLeaders:  readSlotLeaders,
+		status:       make(map[common.Address]*ValidatorStatus),
+		quit:         make(chan struct{}),
+	}
+}
+
+func readEpochLeaders(epochID uint64) []common.Address {
+	selector := epochLeader.GetEpocher()
+	if selector == nil {
+		return nil
+	}
+	pks := selector.GetEpochLeaders(epochID)
+	addrs := make([]common.Address, len(pks))
+	for i := range pks {
+		if pub := crypto.ToECDSAPub(pks[i]); pub != nil {
+			addrs[i] = crypto.PubkeyToAddress(*pub)
+		}
+	}
+	return addrs
+}
+
+func readRBProposers(epochID uint64) []common.Address {
+	selector := epochLeader.GetEpocher()
+	if selector == nil {
+		return nil
+	}
+	leaders := selector.GetRBProposerGroup(epochID)
+	addrs := make([]common.Address, len(leaders))
+	for i := range leaders {
+		addrs[i] = leaders[i].SecAddr
+	}
+	return addrs
+}
+
+func readSlotLeaders(epochID uint64) ([]common.Address, error) {
+	sls := slotleader.GetSlotLeaderSelection()
+	if sls == nil {
+		return nil, slotleader.ErrSlotLeadersNotReady
+	}
+	pks, err := sls.GetEpochSlotLeaders(epochID)
+	if err != nil {
+		return nil, err
+	}
+	addrs := make([]common.Address, len(pks))
+	for i := range pks {
+		if pks[i] != nil {
+			addrs[i] = crypto.PubkeyToAddress(*pks[i])
+		}
+	}
+	return addrs, nil
+}
+
+// Start launches the monitoring.
+func (m *Monitor) Start() {
+	m.wg.Add(1)
+	go m.loop()
+	log.Info("Validator health monitor started", "validators", len(m.config.Addresses))
+}
+
+// Stop terminates the monitoring.
+func (m *Monitor) Stop() {
+	close(m.quit)
+	m.wg.Wait()
+	m.scope.Close()
+	log.Info("Validator health monitor stopped")
+}
+
+func (m *Monitor) loop() {
+	defer m.wg.Done()
+
+	headCh := make(chan core.ChainHeadEvent, 10)
+	sub := m.chain.SubscribeChainHeadEvent(headCh)
+	defer sub.Unsubscribe()
+
+	m.update(m.chain.CurrentHeader())
+	for {
+		select {
+		case ev := <-headCh:
+			m.update(ev.Block.Header())
+		case <-sub.Err():
+			return
+		case <-m.quit:
+			return
+		}
+	}
+}
+
+// update checks the duties of the validators as of a new chain head.
+func (m *Monitor) update(head *types.Header) {
+	if head == nil || posconfig.FirstEpochId == 0 || !util.IsPosBlock(head.Number.Uint64()) {
+		return
+	}
+	epochID, slotID := util.GetEpochSlotIDFromDifficulty(head.Difficulty)
+
+	m.lock.Lock()
+	alerts := make([]*Alert, 0)
+	if epochID != m.epochID || m.produced == nil {
+		m.epochID = epochID
+		m.epochLdrs = m.epochLeaders(epochID)
+		m.proposers = m.rbProposers(epochID)
+		m.slotLdrs = nil
+		m.raised = make(map[string]bool)
+		m.scan(head)
+	} else if head.ParentHash == m.lastHash {
+		m.produced[slotID] = head.Coinbase
+	} else {
+		// Reorg within the epoch
+		m.scan(head)
+	}
+	m.lastHash = head.Hash()
+	if m.slotLdrs == nil {
+		if leaders, err := m.slotLeaders(epochID); err == nil {
+			m.slotLdrs = leaders
+		}
+	}
+
+	statedb, err := m.chain.StateAt(head.Root)
+	if err != nil {
+		m.lock.Unlock()
+		log.Warn("Validator health state unavailable", "number", head.Number, "err", err)
+		return
+	}
+	for _, addr := range m.config.Addresses {
+		status := m.validatorStatus(statedb, addr, slotID)
+		m.status[addr] = status
+		alerts = append(alerts, m.check(status)...)
+	}
+	m.alerts = append(m.alerts, alerts...)
+	if len(m.alerts) > maxAlerts {
+		m.alerts = m.alerts[len(m.alerts)-maxAlerts:]
+	}
+	m.lock.Unlock()
+
+	for _, a := range alerts {
+		log.Warn("Validator health alert", "kind", a.Kind, "address", a.Address, "epochID", a.EpochId, "slotID", a.SlotId, "msg", a.Message)
+		m.alertFeed.Send(*a)
+		go runHooks(m.config, a)
+	}
+}
+
+// scan collects the signers of the blocks of the epoch of head.
+func (m *Monitor) scan(head *types.Header) {
+	m.produced = make(map[uint64]common.Address)
+	for h := head; h != nil && util.IsPosBlock(h.Number.Uint64()); {
+		epochID, slotID := util.GetEpochSlotIDFromDifficulty(h.Difficulty)
+		if epochID != m.epochID {
+			break
+		}
+		m.produced[slotID] = h.Coinbase
+		if h.Number.Sign() == 0 {
+			break
+		}
+		h = m.chain.GetHeader(h.ParentHash, h.Number.Uint64()-1)
+	}
+}
+
+func (m *Monitor) validatorStatus(statedb *state.StateDB, addr common.Address, slotID uint64) *ValidatorStatus {
+	s := &ValidatorStatus{
+		Address:            addr,
+		EpochId:            m.epochID,
+		SlotId:             slotID,
+		SlotLeadersKnown:   m.slotLdrs != nil,
+		MissedSlotIds:      make([]uint64, 0),
+		EpochLeaderIndexes: make([]uint64, 0),
+		SmaStage1:          make([]bool, 0),
+		SmaStage2:          make([]bool, 0),
+		RbProposerIds:      make([]uint32, 0),
+		RbDkg1:             make([]bool, 0),
+		RbDkg2:             make([]bool, 0),
+		RbSig:              make([]bool, 0),
+		Time:               uint64(time.Now().Unix()),
+	}
+
+	for _, signer := range m.produced {
+		if signer == addr {
+			s.ProducedSlots++
+		}
+	}
+	for slot, leader := range m.slotLdrs {
+		if leader != addr {
+			continue
+		}
+		s.AssignedSlots++
+		if uint64(slot) > slotID {
+			continue
+		}
+		s.PassedSlots++
+		if signer, ok := m.produced[uint64(slot)]; (!ok || signer != addr) && uint64(slot) < slotID {
+			s.MissedSlotIds = append(s.MissedSlotIds, uint64(slot))
+		}
+	}
+
+	for i, leader := range m.epochLdrs {
+		if leader != addr {
+			continue
+		}
+		stage1, stage2 := vm.GetSMAProgress(statedb, m.epochID, uint64(i))
+		s.EpochLeaderIndexes = append(s.EpochLeaderIndexes, uint64(i))
+		s.SmaStage1 = append(s.SmaStage1, stage1)
+		s.SmaStage2 = append(s.SmaStage2, stage2)
+	}
+
+	for i, proposer := range m.proposers {
+		if proposer != addr {
+			continue
+		}
+		dkg1, dkg2, sig := vm.GetRBProgress(statedb, m.epochID, uint32(i))
+		s.RbProposerIds = append(s.RbProposerIds, uint32(i))
+		s.RbDkg1 = append(s.RbDkg1, dkg1)
+		s.RbDkg2 = append(s.RbDkg2, dkg2)
+		s.RbSig = append(s.RbSig, sig)
+	}
+	return s
+}
+
+// check returns the alerts a status raises, once per epoch each.
+func (m *Monitor) check(s *ValidatorStatus) []*Alert {
+	alerts := make([]*Alert, 0)
+	raise := func(kind string, index, count uint64, format string, args ...interface{}) {
+		key := fmt.Sprintf("%s/%x/%d", kind, s.Address, index)
+		if m.raised[key] {
+			return
+		}
+		m.raised[key] = true
+		alerts = append(alerts, &Alert{
+			Kind:    kind,
+			Address: s.Address,
+			EpochId: s.EpochId,
+			SlotId:  s.SlotId,
+			Index:   index,
+			Count:   count,
+			Message: fmt.Sprintf(format, args...),
+			Time:    s.Time,
+		})
+	}
+
+	if missed := uint64(len(s.MissedSlotIds)); m.config.MissedSlots != 0 && missed >= m.config.MissedSlots {
+		raise(AlertMissedSlots, 0, missed, "missed %d of %d slots led so far", missed, s.PassedSlots)
+	}
+
+	for i, index := range s.EpochLeaderIndexes {
+		if s.SlotId > posconfig.Sma1End && !s.SmaStage1[i] {
+			raise(AlertSmaStage1Missing, index, 0, "no SMA stage 1 transaction of epoch leader %d", index)
+		}
+		if s.SlotId > posconfig.Sma2End && !s.SmaStage2[i] {
+			raise(AlertSmaStage2Missing, index, 0, "no SMA stage 2 transaction of epoch leader %d", index)
+		}
+	}
+
+	cfg := posconfig.Cfg()
+	for i, id := range s.RbProposerIds {
+		if s.SlotId > cfg.Dkg1End && !s.RbDkg1[i] {
+			raise(AlertRbDkg1Missing, uint64(id), 0, "no random beacon dkg1 transaction of proposer %d", id)
+		}
+		if s.SlotId > cfg.Dkg2End && !s.RbDkg2[i] {
+			raise(AlertRbDkg2Missing, uint64(id), 0, "no random beacon dkg2 transaction of proposer %d", id)
+		}
+		if s.SlotId > cfg.SignEnd && !s.RbSig[i] {
+			raise(AlertRbSigMissing, uint64(id), 0, "no random beacon sign transaction of proposer %d", id)
+		}
+	}
+	return alerts
+}
+
+// Status returns the status of the validators as of the last head, in the
+// order of the configuration.
+func (m *Monitor) Status() []*ValidatorStatus {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
+	statuses := make([]*ValidatorStatus, 0, len(m.config.Addresses))
+	for _, addr := range m.config.Addresses {
+		if s, ok := m.status[addr]; ok {
+			statuses = append(statuses, s)
+		}
+	}
+	return statuses
+}
+
+// Alerts returns the last alerts raised, oldest first.
+func (m *Monitor) Alerts() []*Alert {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
+	return append([]*Alert{}, m.alerts...)
+}
+
+// SubscribeAlerts registers a subscription of the alerts raised.
+func (m *Monitor) SubscribeAlerts(ch chan<- Alert) event.Subscription {
+	return m.scope.Track(m.alertFeed.Subscribe(ch))
+}
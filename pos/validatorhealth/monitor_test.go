@@ -0,0 +1,167 @@
+package validatorhealth
+
+import (
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/wanchain/go-wanchain/common"
+	"github.com/wanchain/go-wanchain/core"
+	"github.com/wanchain/go-wanchain/core/state"
+	"github.com/wanchain/go-wanchain/core/types"
+	"github.com/wanchain/go-wanchain/core/vm"
+	"github.com/wanchain/go-wanchain/ethdb"
+	"github.com/wanchain/go-wanchain/event"
+	"github.com/wanchain/go-wanchain/pos/posconfig"
+	"github.com/wanchain/go-wanchain/pos/util/convert"
+)
+
+type testChain struct {
+	headers map[common.Hash]*types.Header
+	head    *types.Header
+	state   *state.StateDB
+	feed    event.Feed
+}
+
+func (c *testChain) CurrentHeader() *types.Header { return c.head }
+
+func (c *testChain) GetHeader(hash common.Hash, number uint64) *types.Header {
+	return c.headers[hash]
+}
+
+func (c *testChain) StateAt(root common.Hash) (*state.StateDB, error) { return c.state, nil }
+
+func (c *testChain) SubscribeChainHeadEvent(ch chan<- core.ChainHeadEvent) event.Subscription {
+	return c.feed.Subscribe(ch)
+}
+
+// add makes a block of a slot signed by signer the head.
+func (c *testChain) add(parent *types.Header, epochID, slotID uint64, signer common.Address) *types.Header {
+	h := &types.Header{
+		Number:     big.NewInt(1),
+		Difficulty: new(big.Int).SetUint64(epochID<<32 | slotID<<8 | 1),
+		Coinbase:   signer,
+	}
+	if parent != nil {
+		h.ParentHash = parent.Hash()
+		h.Number = new(big.Int).Add(parent.Number, big.NewInt(1))
+	}
+	c.headers[h.Hash()] = h
+	c.head = h
+	return h
+}
+
+func TestMonitor(t *testing.T) {
+	defer func(first, upgrade uint64) {
+		posconfig.FirstEpochId, posconfig.Pow2PosUpgradeBlockNumber = first, upgrade
+	}(posconfig.FirstEpochId, posconfig.Pow2PosUpgradeBlockNumber)
+	posconfig.FirstEpochId, posconfig.Pow2PosUpgradeBlockNumber = 1, 1
+
+	const epochID = 10
+	var (
+		a = common.Address{0xa}
+		b = common.Address{0xb}
+	)
+
+	// The webhook collects the alerts posted
+	posted := make(chan Alert, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var alert Alert
+		if err := json.NewDecoder(r.Body).Decode(&alert); err != nil {
+			t.Errorf("invalid webhook body: %v", err)
+		}
+		posted <- alert
+	}))
+	defer server.Close()
+
+	db, _ := ethdb.NewMemDatabase()
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(db))
+	// Epoch leader index 2 sent its stage 1 transaction only
+	statedb.SetStateByteArray(vm.GetSlotLeaderSCAddress(),
+		vm.GetSlotLeaderStage1KeyHash(convert.Uint64ToBytes(epochID), convert.Uint64ToBytes(2)), []byte{1})
+
+	chain := &testChain{headers: make(map[common.Hash]*types.Header), state: statedb}
+	m := New(chain, Config{Addresses: []common.Address{a}, MissedSlots: 1, Webhook: server.URL})
+	m.epochLeaders = func(uint64) []common.Address { return []common.Address{b, b, a} }
+	m.rbProposers = func(uint64) []common.Address { return []common.Address{a, b} }
+	m.slotLeaders = func(uint64) ([]common.Address, error) {
+		leaders := make([]common.Address, posconfig.SlotCount)
+		for i := range leaders {
+			leaders[i] = b
+		}
+		leaders[1], leaders[3], leaders[12000] = a, a, a
+		return leaders, nil
+	}
+
+	alerts := make(chan Alert, 10)
+	sub := m.SubscribeAlerts(alerts)
+	defer sub.Unsubscribe()
+
+	// a produced slot 1 and missed slot 3
+	h := chain.add(nil, epochID, 1, a)
+	h = chain.add(h, epochID, 4, b)
+	m.update(h)
+	h = chain.add(h, epochID, posconfig.Sma2End+1, b)
+	m.update(h)
+
+	status := m.Status()
+	if len(status) != 1 {
+		t.Fatalf("status count mismatch: have %d, want 1", len(status))
+	}
+	s := status[0]
+	if s.AssignedSlots != 3 || s.PassedSlots != 2 || s.ProducedSlots != 1 || len(s.MissedSlotIds) != 1 || s.MissedSlotIds[0] != 3 {
+		t.Fatalf("slot status mismatch: %+v", s)
+	}
+	if len(s.EpochLeaderIndexes) != 1 || s.EpochLeaderIndexes[0] != 2 || !s.SmaStage1[0] || s.SmaStage2[0] {
+		t.Fatalf("SMA status mismatch: %+v", s)
+	}
+	if len(s.RbProposerIds) != 1 || s.RbProposerIds[0] != 0 || s.RbDkg1[0] {
+		t.Fatalf("random beacon status mismatch: %+v", s)
+	}
+
+	want := map[string]bool{
+		AlertMissedSlots:      true,
+		AlertSmaStage2Missing: true,
+		AlertRbDkg1Missing:    true,
+		AlertRbDkg2Missing:    true,
+	}
+	if raised := m.Alerts(); len(raised) != len(want) {
+		t.Fatalf("alert count mismatch: have %d, want %d", len(raised), len(want))
+	}
+	for range want {
+		select {
+		case alert := <-alerts:
+			if !want[alert.Kind] || alert.Address != a || alert.EpochId != epochID {
+				t.Fatalf("unexpected alert: %+v", alert)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("alert not sent")
+		}
+		select {
+		case alert := <-posted:
+			if !want[alert.Kind] {
+				t.Fatalf("unexpected alert posted: %+v", alert)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("alert not posted")
+		}
+	}
+
+	// The alerts are raised once per epoch
+	h = chain.add(h, epochID, posconfig.Sma2End+2, b)
+	m.update(h)
+	if raised := m.Alerts(); len(raised) != len(want) {
+		t.Fatalf("alerts raised again: %d", len(raised))
+	}
+
+	// A reorg replacing the block of slot 1 counts it as missed
+	h = chain.add(nil, epochID, 2, b)
+	h = chain.add(h, epochID, posconfig.Sma2End+3, b)
+	m.update(h)
+	if s := m.Status()[0]; s.ProducedSlots != 0 || len(s.MissedSlotIds) != 2 {
+		t.Fatalf("slot status after reorg mismatch: %+v", s)
+	}
+}
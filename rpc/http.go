@@ -25,6 +25,7 @@ import (
 	"io/ioutil"
 	"net"
 	"net/http"
+	"strings"
 	"sync"
 	"time"
 
@@ -64,10 +65,19 @@ func (hc *httpConn) Close() error {
 
 // DialHTTP creates a new RPC clients that connection to an RPC server over HTTP.
 func DialHTTP(endpoint string) (*Client, error) {
+	return DialHTTPWithHeader(endpoint, nil)
+}
+
+// DialHTTPWithHeader creates a new RPC client over HTTP, sending the given
+// header fields, e.g. credentials, along with every request.
+func DialHTTPWithHeader(endpoint string, header http.Header) (*Client, error) {
 	req, err := http.NewRequest("POST", endpoint, nil)
 	if err != nil {
 		return nil, err
 	}
+	for key, values := range header {
+		req.Header[key] = values
+	}
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Accept", "application/json")
 
@@ -175,3 +185,37 @@ func newCorsHandler(srv *Server, allowedOrigins []string) http.Handler {
 	})
 	return c.Handler(srv)
 }
+
+// NewVirtualHostHandler wraps an HTTP handler, refusing the requests whose Host
+// header isn't in vhosts, so that DNS rebinding can't reach a server bound to
+// a local address. "*" accepts any host, requests by IP address are accepted.
+func NewVirtualHostHandler(vhosts []string, next http.Handler) http.Handler {
+	allowed := make(map[string]struct{})
+	for _, host := range vhosts {
+		allowed[strings.ToLower(host)] = struct{}{}
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Host == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		host, _, err := net.SplitHostPort(r.Host)
+		if err != nil {
+			// The host has no port
+			host = r.Host
+		}
+		if net.ParseIP(host) != nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if _, ok := allowed["*"]; ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if _, ok := allowed[strings.ToLower(host)]; ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+		http.Error(w, "invalid host specified", http.StatusForbidden)
+	})
+}